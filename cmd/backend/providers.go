@@ -1,8 +1,8 @@
 package main
 
 import (
-	"time"
 	"trx-project/internal/api/handler/backendHandler"
+	"trx-project/internal/api/middleware"
 	"trx-project/internal/api/router"
 	"trx-project/internal/service"
 	"trx-project/pkg/cache"
@@ -10,6 +10,9 @@ import (
 	"trx-project/pkg/database"
 	"trx-project/pkg/jwt"
 	"trx-project/pkg/logger"
+	"trx-project/pkg/metrics"
+	"trx-project/pkg/passhash"
+	"trx-project/pkg/permission"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -33,32 +36,114 @@ func provideDB(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error) {
 	return database.InitMySQL(&cfg.Database.MySQL, logger)
 }
 
+// provideMetrics 创建 Prometheus 指标，每个进程只能创建一次
+func provideMetrics() *metrics.Metrics {
+	return metrics.NewMetrics("trx")
+}
+
 func provideRedis(cfg *config.Config, logger *zap.Logger) (*redis.Client, error) {
 	return cache.InitRedis(&cfg.Redis, logger)
 }
 
-func provideAdminJWTConfig(cfg *config.Config) jwt.Config {
-	return jwt.Config{
-		Secret:     cfg.JWT.Secret,
-		Issuer:     cfg.JWT.Issuer,
-		ExpireTime: time.Duration(cfg.JWT.AdminExpireHours) * time.Hour,
+// jwtKeyFiles 将配置的签名密钥转换为 jwt.KeyFile
+func jwtKeyFiles(keys []config.JWTKeyConfig) []jwt.KeyFile {
+	files := make([]jwt.KeyFile, 0, len(keys))
+	for _, key := range keys {
+		files = append(files, jwt.KeyFile{
+			ID:             key.ID,
+			Algorithm:      key.Algorithm,
+			PrivateKeyFile: key.PrivateKeyFile,
+			PublicKeyFile:  key.PublicKeyFile,
+			ActiveFrom:     key.ActiveFrom,
+		})
 	}
+	return files
+}
+
+func provideAdminJWTConfig(cfg *config.Config) (jwt.Config, error) {
+	keys, err := jwt.LoadKeySet(cfg.JWT.Secret, jwtKeyFiles(cfg.JWT.Keys), cfg.JWT.RotationOverlap())
+	if err != nil {
+		return jwt.Config{}, err
+	}
+
+	return jwt.Config{
+		Secret:            cfg.JWT.Secret,
+		Issuer:            cfg.JWT.Issuer,
+		ExpireTime:        cfg.JWT.AdminAccessExpire(),
+		RefreshExpireTime: cfg.JWT.AdminRefreshExpire(),
+		Keys:              keys,
+	}, nil
+}
+
+// provideHasher 根据 auth.password_hash 配置创建密码哈希器
+func provideHasher(cfg *config.Config) (*passhash.Hasher, error) {
+	c := cfg.Auth.PasswordHash
+	return passhash.NewHasher(passhash.Params{
+		Algorithm:         c.Algorithm,
+		BcryptCost:        c.BcryptCost,
+		Argon2MemoryKiB:   c.Argon2MemoryKiB,
+		Argon2Iterations:  c.Argon2Iterations,
+		Argon2Parallelism: c.Argon2Parallelism,
+	})
+}
+
+// provideMatcher 根据 rbac.implied_permissions 配置创建权限匹配器
+func provideMatcher(cfg *config.Config) (*permission.Matcher, error) {
+	return permission.NewMatcher(cfg.RBAC.ImpliedPermissions)
+}
+
+// provideSessionCookies 创建后台浏览器 Cookie 会话
+func provideSessionCookies(cfg *config.Config) *middleware.SessionCookies {
+	return middleware.NewSessionCookies(cfg.Auth.BackendCookie, "trx_admin")
 }
 
 func provideBackendRouter(
+	adminAuthHandler *backendHandler.AdminAuthHandler,
 	adminUserHandler *backendHandler.AdminUserHandler,
 	rbacHandler *backendHandler.RBACHandler,
+	adminMFAHandler *backendHandler.AdminMFAHandler,
+	adminLockoutHandler *backendHandler.AdminLockoutHandler,
+	adminSessionHandler *backendHandler.AdminSessionHandler,
+	adminAPIKeyHandler *backendHandler.AdminAPIKeyHandler,
+	serviceAccountHandler *backendHandler.ServiceAccountHandler,
+	impersonationHandler *backendHandler.ImpersonationHandler,
+	adminPasskeyHandler *backendHandler.AdminPasskeyHandler,
+	adminSSOHandler *backendHandler.AdminSSOHandler,
+	tenantHandler *backendHandler.TenantHandler,
 	rbacService service.RBACService,
+	authzService service.AuthorizationService,
+	userService service.UserService,
+	tenantService service.TenantService,
+	tokenService service.TokenService,
+	apiKeyService service.APIKeyService,
+	cookies *middleware.SessionCookies,
 	redisClient *redis.Client,
+	m *metrics.Metrics,
 	logger *zap.Logger,
 	cfg *config.Config,
 ) *gin.Engine {
 	return router.SetupBackend(
+		adminAuthHandler,
 		adminUserHandler,
 		rbacHandler,
+		adminMFAHandler,
+		adminLockoutHandler,
+		adminSessionHandler,
+		adminAPIKeyHandler,
+		serviceAccountHandler,
+		impersonationHandler,
+		adminPasskeyHandler,
+		adminSSOHandler,
+		tenantHandler,
 		rbacService,
-		cfg.JWT.Secret,
+		authzService,
+		userService,
+		tenantService,
+		tokenService,
+		apiKeyService,
+		cookies,
 		redisClient,
+		m,
 		cfg,
 		logger,
 		cfg.Server.Mode,
@@ -9,6 +9,7 @@ import (
 	"trx-project/internal/service"
 	"trx-project/pkg/cache"
 	"trx-project/pkg/config"
+	"trx-project/pkg/notifier"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
@@ -26,23 +27,87 @@ func initBackendApp(cfg *config.Config) (*gin.Engine, func(), error) {
 		// Redis
 		provideRedis,
 
+		// Metrics
+		provideMetrics,
+
 		// RBAC Cache
 		cache.NewRBACCache,
 
+		// Permission Matcher
+		provideMatcher,
+
 		// JWT Config
 		provideAdminJWTConfig,
 
+		// Session Cookie
+		provideSessionCookies,
+
+		// Password Hasher
+		provideHasher,
+
+		// Token Store
+		cache.NewRefreshTokenStore,
+		cache.NewTokenRevocationStore,
+		cache.NewTokenVersionCache,
+		cache.NewUserStatusCache,
+		cache.NewMFAChallengeStore,
+		cache.NewLoginAttemptStore,
+		cache.NewWebAuthnChallengeStore,
+		cache.NewOIDCStateStore,
+
+		// Notifier
+		notifier.NewNotifier,
+
 		// Repository
 		repository.NewUserRepository,
 		repository.NewRBACRepository,
+		repository.NewMFARepository,
+		repository.NewAuditRepository,
+		repository.NewPasswordResetRepository,
+		repository.NewPasswordHistoryRepository,
+		repository.NewSessionRepository,
+		repository.NewAPIKeyRepository,
+		repository.NewWebAuthnRepository,
+		repository.NewIdentityRepository,
+		repository.NewTenantRepository,
 
 		// Service
+		service.NewUserStatusService,
+		service.NewTokenService,
+		service.NewLocalAuthenticator,
+		service.NewLDAPAuthenticator,
+		service.NewAuthenticator,
+		service.NewPasswordPolicyService,
 		service.NewUserService,
 		service.NewRBACService,
+		service.NewAuthorizationService,
+		service.NewAuditService,
+		service.NewMFAService,
+		service.NewPasswordService,
+		service.NewEmailVerificationService,
+		service.NewLoginProtectionService,
+		service.NewSessionService,
+		service.NewAPIKeyService,
+		service.NewServiceAccountService,
+		service.NewImpersonationService,
+		service.NewPasskeyService,
+		service.NewOIDCService,
+		service.NewAdminAuthService,
+		service.NewTenantService,
 
 		// Handler
+		backendHandler.NewAdminAuthHandler,
 		backendHandler.NewAdminUserHandler,
 		backendHandler.NewRBACHandler,
+		backendHandler.NewAdminMFAHandler,
+		backendHandler.NewAdminLockoutHandler,
+		backendHandler.NewAdminSessionHandler,
+		backendHandler.NewAdminAPIKeyHandler,
+		backendHandler.NewServiceAccountHandler,
+		backendHandler.NewImpersonationHandler,
+		backendHandler.NewAdminPasskeyHandler,
+		backendHandler.NewAdminSSOHandler,
+		backendHandler.NewTenantHandler,
 
 		// Backend Router
 		provideBackendRouter,
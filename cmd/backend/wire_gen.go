@@ -12,6 +12,7 @@ import (
 	"trx-project/internal/service"
 	"trx-project/pkg/cache"
 	"trx-project/pkg/config"
+	"trx-project/pkg/notifier"
 
 	"github.com/gin-gonic/gin"
 
@@ -35,14 +36,90 @@ func initBackendApp(cfg *config.Config) (*gin.Engine, func(), error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	jwtConfig := provideAdminJWTConfig(cfg)
-	userService := service.NewUserService(userRepository, client, logger, jwtConfig)
-	adminUserHandler := backendHandler.NewAdminUserHandler(userService, logger)
+	sessionRepository := repository.NewSessionRepository(db)
+	refreshTokenStore := cache.NewRefreshTokenStore(client, logger)
+	tokenRevocationStore := cache.NewTokenRevocationStore(client, logger)
+	tokenVersionCache := cache.NewTokenVersionCache(client, logger)
+	userStatusCache := cache.NewUserStatusCache(client, logger)
+	userStatusService := service.NewUserStatusService(userRepository, userStatusCache, logger, cfg)
 	rbacRepository := repository.NewRBACRepository(db)
 	rbacCache := cache.NewRBACCache(client, logger)
-	rbacService := service.NewRBACService(rbacRepository, rbacCache, logger)
+	matcher, err := provideMatcher(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	rbacService := service.NewRBACService(rbacRepository, rbacCache, matcher, logger)
+	apiKeyRepository := repository.NewAPIKeyRepository(db)
+	auditRepository := repository.NewAuditRepository(db)
+	auditService := service.NewAuditService(auditRepository, logger)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepository, userRepository, rbacService, auditService, logger, cfg)
+	jwtConfig, err := provideAdminJWTConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokenService := service.NewTokenService(userRepository, sessionRepository, refreshTokenStore, tokenRevocationStore, tokenVersionCache, userStatusService, rbacService, apiKeyService, logger, jwtConfig, cfg)
+	mfaRepository := repository.NewMFARepository(db)
+	mfaChallengeStore := cache.NewMFAChallengeStore(client, logger)
+	mfaService := service.NewMFAService(mfaRepository, userRepository, rbacService, auditService, mfaChallengeStore, logger, cfg)
+	notifierNotifier, err := notifier.NewNotifier(cfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	emailVerificationService, err := service.NewEmailVerificationService(userRepository, notifierNotifier, logger, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	loginAttemptStore := cache.NewLoginAttemptStore(client, logger)
+	metrics := provideMetrics()
+	loginProtectionService := service.NewLoginProtectionService(loginAttemptStore, auditService, metrics, logger, cfg)
+	hasher, err := provideHasher(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	localAuthenticator := service.NewLocalAuthenticator(userRepository, hasher, logger)
+	identityRepository := repository.NewIdentityRepository(db)
+	ldapAuthenticator := service.NewLDAPAuthenticator(identityRepository, userRepository, rbacService, auditService, logger, cfg)
+	authenticator, err := service.NewAuthenticator(localAuthenticator, ldapAuthenticator, logger, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	passwordHistoryRepository := repository.NewPasswordHistoryRepository(db)
+	passwordPolicyService, err := service.NewPasswordPolicyService(passwordHistoryRepository, hasher, logger, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	userService := service.NewUserService(userRepository, client, logger, tokenService, userStatusService, mfaService, emailVerificationService, loginProtectionService, authenticator, hasher, passwordPolicyService)
+	webAuthnRepository := repository.NewWebAuthnRepository(db)
+	webAuthnChallengeStore := cache.NewWebAuthnChallengeStore(client, logger)
+	passkeyService, err := service.NewPasskeyService(webAuthnRepository, userRepository, rbacService, webAuthnChallengeStore, auditService, logger, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	oidcStateStore := cache.NewOIDCStateStore(client, logger)
+	oidcService := service.NewOIDCService(identityRepository, userRepository, rbacService, oidcStateStore, auditService, logger, cfg)
+	adminAuthService := service.NewAdminAuthService(userService, rbacService, tokenService, mfaService, passkeyService, oidcService, loginProtectionService, logger)
+	sessionCookies := provideSessionCookies(cfg)
+	adminAuthHandler := backendHandler.NewAdminAuthHandler(adminAuthService, sessionCookies, logger)
+	passwordResetRepository := repository.NewPasswordResetRepository(db)
+	passwordService := service.NewPasswordService(userRepository, passwordResetRepository, userService, tokenService, rbacService, auditService, notifierNotifier, hasher, passwordPolicyService, logger, cfg)
+	authorizationService := service.NewAuthorizationService(rbacRepository, userRepository, matcher, logger)
+	adminUserHandler := backendHandler.NewAdminUserHandler(userService, passwordService, authorizationService, logger)
 	rbacHandler := backendHandler.NewRBACHandler(rbacService, logger)
-	engine := provideBackendRouter(adminUserHandler, rbacHandler, rbacService, client, logger, cfg)
+	adminMFAHandler := backendHandler.NewAdminMFAHandler(mfaService, logger)
+	adminLockoutHandler := backendHandler.NewAdminLockoutHandler(loginProtectionService, logger)
+	sessionService := service.NewSessionService(sessionRepository, tokenService, auditService, logger)
+	adminSessionHandler := backendHandler.NewAdminSessionHandler(sessionService, logger)
+	adminAPIKeyHandler := backendHandler.NewAdminAPIKeyHandler(apiKeyService, logger)
+	serviceAccountService := service.NewServiceAccountService(userRepository, apiKeyService, userStatusService, auditService, logger)
+	serviceAccountHandler := backendHandler.NewServiceAccountHandler(serviceAccountService, logger)
+	impersonationService := service.NewImpersonationService(userRepository, rbacService, tokenService, auditService, logger, cfg)
+	impersonationHandler := backendHandler.NewImpersonationHandler(impersonationService, logger)
+	adminPasskeyHandler := backendHandler.NewAdminPasskeyHandler(passkeyService, adminAuthService, sessionCookies, logger)
+	adminSSOHandler := backendHandler.NewAdminSSOHandler(oidcService, adminAuthService, sessionCookies, logger)
+	tenantRepository := repository.NewTenantRepository(db)
+	tenantService := service.NewTenantService(tenantRepository, userRepository, rbacService, tokenService, auditService, logger)
+	tenantHandler := backendHandler.NewTenantHandler(tenantService, logger)
+	engine := provideBackendRouter(adminAuthHandler, adminUserHandler, rbacHandler, adminMFAHandler, adminLockoutHandler, adminSessionHandler, adminAPIKeyHandler, serviceAccountHandler, impersonationHandler, adminPasskeyHandler, adminSSOHandler, tenantHandler, rbacService, authorizationService, userService, tenantService, tokenService, apiKeyService, sessionCookies, client, metrics, logger, cfg)
 	return engine, func() {
 	}, nil
 }
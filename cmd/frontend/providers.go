@@ -1,14 +1,18 @@
 package main
 
 import (
-	"time"
 	"trx-project/internal/api/handler/frontendHandler"
+	"trx-project/internal/api/middleware"
 	"trx-project/internal/api/router"
+	"trx-project/internal/service"
 	"trx-project/pkg/cache"
 	"trx-project/pkg/config"
 	"trx-project/pkg/database"
 	"trx-project/pkg/jwt"
 	"trx-project/pkg/logger"
+	"trx-project/pkg/metrics"
+	"trx-project/pkg/passhash"
+	"trx-project/pkg/permission"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -32,28 +36,100 @@ func provideDB(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error) {
 	return database.InitMySQL(&cfg.Database.MySQL, logger)
 }
 
+// provideMetrics 创建 Prometheus 指标，每个进程只能创建一次
+func provideMetrics() *metrics.Metrics {
+	return metrics.NewMetrics("trx")
+}
+
 func provideRedis(cfg *config.Config, logger *zap.Logger) (*redis.Client, error) {
 	return cache.InitRedis(&cfg.Redis, logger)
 }
 
-func provideJWTConfig(cfg *config.Config) jwt.Config {
-	return jwt.Config{
-		Secret:     cfg.JWT.Secret,
-		Issuer:     cfg.JWT.Issuer,
-		ExpireTime: time.Duration(cfg.JWT.ExpireHours) * time.Hour,
+// jwtKeyFiles 将配置的签名密钥转换为 jwt.KeyFile
+func jwtKeyFiles(keys []config.JWTKeyConfig) []jwt.KeyFile {
+	files := make([]jwt.KeyFile, 0, len(keys))
+	for _, key := range keys {
+		files = append(files, jwt.KeyFile{
+			ID:             key.ID,
+			Algorithm:      key.Algorithm,
+			PrivateKeyFile: key.PrivateKeyFile,
+			PublicKeyFile:  key.PublicKeyFile,
+			ActiveFrom:     key.ActiveFrom,
+		})
 	}
+	return files
+}
+
+func provideJWTConfig(cfg *config.Config) (jwt.Config, error) {
+	keys, err := jwt.LoadKeySet(cfg.JWT.Secret, jwtKeyFiles(cfg.JWT.Keys), cfg.JWT.RotationOverlap())
+	if err != nil {
+		return jwt.Config{}, err
+	}
+
+	return jwt.Config{
+		Secret:            cfg.JWT.Secret,
+		Issuer:            cfg.JWT.Issuer,
+		ExpireTime:        cfg.JWT.AccessExpire(),
+		RefreshExpireTime: cfg.JWT.RefreshExpire(),
+		Keys:              keys,
+	}, nil
+}
+
+// provideHasher 根据 auth.password_hash 配置创建密码哈希器
+func provideHasher(cfg *config.Config) (*passhash.Hasher, error) {
+	c := cfg.Auth.PasswordHash
+	return passhash.NewHasher(passhash.Params{
+		Algorithm:         c.Algorithm,
+		BcryptCost:        c.BcryptCost,
+		Argon2MemoryKiB:   c.Argon2MemoryKiB,
+		Argon2Iterations:  c.Argon2Iterations,
+		Argon2Parallelism: c.Argon2Parallelism,
+	})
+}
+
+// provideMatcher 根据 rbac.implied_permissions 配置创建权限匹配器
+func provideMatcher(cfg *config.Config) (*permission.Matcher, error) {
+	return permission.NewMatcher(cfg.RBAC.ImpliedPermissions)
+}
+
+// provideSessionCookies 创建前台浏览器 Cookie 会话
+func provideSessionCookies(cfg *config.Config) *middleware.SessionCookies {
+	return middleware.NewSessionCookies(cfg.Auth.FrontendCookie, "trx")
 }
 
 func provideFrontendRouter(
 	userHandler *frontendHandler.UserHandler,
+	jwksHandler *frontendHandler.JWKSHandler,
+	mfaHandler *frontendHandler.MFAHandler,
+	passwordHandler *frontendHandler.PasswordHandler,
+	emailVerificationHandler *frontendHandler.EmailVerificationHandler,
+	sessionHandler *frontendHandler.SessionHandler,
+	apiKeyHandler *frontendHandler.APIKeyHandler,
+	magicLinkHandler *frontendHandler.MagicLinkHandler,
+	tokenService service.TokenService,
+	apiKeyService service.APIKeyService,
+	rbacService service.RBACService,
+	cookies *middleware.SessionCookies,
 	redisClient *redis.Client,
+	m *metrics.Metrics,
 	logger *zap.Logger,
 	cfg *config.Config,
 ) *gin.Engine {
 	return router.SetupFrontend(
 		userHandler,
-		cfg.JWT.Secret,
+		jwksHandler,
+		mfaHandler,
+		passwordHandler,
+		emailVerificationHandler,
+		sessionHandler,
+		apiKeyHandler,
+		magicLinkHandler,
+		tokenService,
+		apiKeyService,
+		rbacService,
+		cookies,
 		redisClient,
+		m,
 		cfg,
 		logger,
 		cfg.Server.Mode,
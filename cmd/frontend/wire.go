@@ -4,10 +4,12 @@
 package main
 
 import (
-	"trx-project/internal/api/handler/frontendhandler"
+	"trx-project/internal/api/handler/frontendHandler"
 	"trx-project/internal/repository"
 	"trx-project/internal/service"
+	"trx-project/pkg/cache"
 	"trx-project/pkg/config"
+	"trx-project/pkg/notifier"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
@@ -25,17 +27,74 @@ func initFrontendApp(cfg *config.Config) (*gin.Engine, func(), error) {
 		// Redis
 		provideRedis,
 
+		// Metrics
+		provideMetrics,
+
+		// RBAC Cache
+		cache.NewRBACCache,
+
+		// Permission Matcher
+		provideMatcher,
+
 		// JWT Config
 		provideJWTConfig,
 
+		// Session Cookie
+		provideSessionCookies,
+
+		// Password Hasher
+		provideHasher,
+
+		// Token Store
+		cache.NewRefreshTokenStore,
+		cache.NewTokenRevocationStore,
+		cache.NewTokenVersionCache,
+		cache.NewUserStatusCache,
+		cache.NewMFAChallengeStore,
+		cache.NewLoginAttemptStore,
+
+		// Notifier
+		notifier.NewNotifier,
+
 		// Repository
 		repository.NewUserRepository,
+		repository.NewRBACRepository,
+		repository.NewMFARepository,
+		repository.NewAuditRepository,
+		repository.NewPasswordResetRepository,
+		repository.NewPasswordHistoryRepository,
+		repository.NewSessionRepository,
+		repository.NewAPIKeyRepository,
+		repository.NewMagicLinkRepository,
+		repository.NewIdentityRepository,
 
 		// Service
+		service.NewUserStatusService,
+		service.NewTokenService,
+		service.NewLocalAuthenticator,
+		service.NewLDAPAuthenticator,
+		service.NewAuthenticator,
+		service.NewPasswordPolicyService,
 		service.NewUserService,
+		service.NewRBACService,
+		service.NewAuditService,
+		service.NewMFAService,
+		service.NewPasswordService,
+		service.NewEmailVerificationService,
+		service.NewLoginProtectionService,
+		service.NewSessionService,
+		service.NewAPIKeyService,
+		service.NewMagicLinkService,
 
 		// Handler
-		frontendhandler.NewUserHandler,
+		frontendHandler.NewUserHandler,
+		frontendHandler.NewJWKSHandler,
+		frontendHandler.NewMFAHandler,
+		frontendHandler.NewPasswordHandler,
+		frontendHandler.NewEmailVerificationHandler,
+		frontendHandler.NewSessionHandler,
+		frontendHandler.NewAPIKeyHandler,
+		frontendHandler.NewMagicLinkHandler,
 
 		// Frontend Router
 		provideFrontendRouter,
@@ -10,7 +10,9 @@ import (
 	"trx-project/internal/api/handler/frontendHandler"
 	"trx-project/internal/repository"
 	"trx-project/internal/service"
+	"trx-project/pkg/cache"
 	"trx-project/pkg/config"
+	"trx-project/pkg/notifier"
 
 	"github.com/gin-gonic/gin"
 
@@ -34,10 +36,77 @@ func initFrontendApp(cfg *config.Config) (*gin.Engine, func(), error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	jwtConfig := provideJWTConfig(cfg)
-	userService := service.NewUserService(userRepository, client, logger, jwtConfig)
-	userHandler := frontendHandler.NewUserHandler(userService, logger)
-	engine := provideFrontendRouter(userHandler, client, logger, cfg)
+	sessionRepository := repository.NewSessionRepository(db)
+	refreshTokenStore := cache.NewRefreshTokenStore(client, logger)
+	tokenRevocationStore := cache.NewTokenRevocationStore(client, logger)
+	tokenVersionCache := cache.NewTokenVersionCache(client, logger)
+	userStatusCache := cache.NewUserStatusCache(client, logger)
+	userStatusService := service.NewUserStatusService(userRepository, userStatusCache, logger, cfg)
+	rbacRepository := repository.NewRBACRepository(db)
+	rbacCache := cache.NewRBACCache(client, logger)
+	matcher, err := provideMatcher(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	rbacService := service.NewRBACService(rbacRepository, rbacCache, matcher, logger)
+	apiKeyRepository := repository.NewAPIKeyRepository(db)
+	auditRepository := repository.NewAuditRepository(db)
+	auditService := service.NewAuditService(auditRepository, logger)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepository, userRepository, rbacService, auditService, logger, cfg)
+	jwtConfig, err := provideJWTConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokenService := service.NewTokenService(userRepository, sessionRepository, refreshTokenStore, tokenRevocationStore, tokenVersionCache, userStatusService, rbacService, apiKeyService, logger, jwtConfig, cfg)
+	mfaRepository := repository.NewMFARepository(db)
+	mfaChallengeStore := cache.NewMFAChallengeStore(client, logger)
+	mfaService := service.NewMFAService(mfaRepository, userRepository, rbacService, auditService, mfaChallengeStore, logger, cfg)
+	notifierNotifier, err := notifier.NewNotifier(cfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	emailVerificationService, err := service.NewEmailVerificationService(userRepository, notifierNotifier, logger, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	loginAttemptStore := cache.NewLoginAttemptStore(client, logger)
+	metrics := provideMetrics()
+	loginProtectionService := service.NewLoginProtectionService(loginAttemptStore, auditService, metrics, logger, cfg)
+	hasher, err := provideHasher(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	localAuthenticator := service.NewLocalAuthenticator(userRepository, hasher, logger)
+	identityRepository := repository.NewIdentityRepository(db)
+	ldapAuthenticator := service.NewLDAPAuthenticator(identityRepository, userRepository, rbacService, auditService, logger, cfg)
+	authenticator, err := service.NewAuthenticator(localAuthenticator, ldapAuthenticator, logger, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	passwordHistoryRepository := repository.NewPasswordHistoryRepository(db)
+	passwordPolicyService, err := service.NewPasswordPolicyService(passwordHistoryRepository, hasher, logger, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	userService := service.NewUserService(userRepository, client, logger, tokenService, userStatusService, mfaService, emailVerificationService, loginProtectionService, authenticator, hasher, passwordPolicyService)
+	sessionCookies := provideSessionCookies(cfg)
+	userHandler := frontendHandler.NewUserHandler(userService, sessionCookies, logger)
+	jwksHandler := frontendHandler.NewJWKSHandler(jwtConfig)
+	mfaHandler := frontendHandler.NewMFAHandler(mfaService, logger)
+	passwordResetRepository := repository.NewPasswordResetRepository(db)
+	passwordService := service.NewPasswordService(userRepository, passwordResetRepository, userService, tokenService, rbacService, auditService, notifierNotifier, hasher, passwordPolicyService, logger, cfg)
+	passwordHandler := frontendHandler.NewPasswordHandler(passwordService, sessionCookies, logger)
+	emailVerificationHandler := frontendHandler.NewEmailVerificationHandler(emailVerificationService, logger)
+	sessionService := service.NewSessionService(sessionRepository, tokenService, auditService, logger)
+	sessionHandler := frontendHandler.NewSessionHandler(sessionService, logger)
+	apiKeyHandler := frontendHandler.NewAPIKeyHandler(apiKeyService, logger)
+	magicLinkRepository := repository.NewMagicLinkRepository(db)
+	magicLinkService, err := service.NewMagicLinkService(magicLinkRepository, userRepository, mfaService, tokenService, notifierNotifier, logger, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	magicLinkHandler := frontendHandler.NewMagicLinkHandler(magicLinkService, sessionCookies, logger)
+	engine := provideFrontendRouter(userHandler, jwksHandler, mfaHandler, passwordHandler, emailVerificationHandler, sessionHandler, apiKeyHandler, magicLinkHandler, tokenService, apiKeyService, rbacService, sessionCookies, client, metrics, logger, cfg)
 	return engine, func() {
 	}, nil
 }
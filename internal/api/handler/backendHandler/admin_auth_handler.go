@@ -0,0 +1,333 @@
+package backendHandler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminAuthHandler 管理员认证处理器
+type AdminAuthHandler struct {
+	service service.AdminAuthService
+	cookies *middleware.SessionCookies
+	logger  *zap.Logger
+}
+
+// NewAdminAuthHandler 创建管理员认证处理器
+func NewAdminAuthHandler(service service.AdminAuthService, cookies *middleware.SessionCookies, logger *zap.Logger) *AdminAuthHandler {
+	return &AdminAuthHandler{
+		service: service,
+		cookies: cookies,
+		logger:  logger,
+	}
+}
+
+// AdminLoginRequest 管理员登录请求
+type AdminLoginRequest struct {
+	Username string `json:"username" binding:"required" example:"admin"`       // 用户名
+	Password string `json:"password" binding:"required" example:"password123"` // 密码
+}
+
+// AdminMFALoginRequest 管理员两步验证登录请求
+type AdminMFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`             // 登录接口返回的 mfa_token
+	Code     string `json:"code" binding:"required" example:"123456"` // 6 位验证码或恢复码
+}
+
+// AdminMFASetupRequest 管理员登录时绑定两步验证请求
+type AdminMFASetupRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"` // 登录接口返回的 mfa_token
+}
+
+// AdminRefreshTokenRequest 刷新管理员 Token 请求
+type AdminRefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"` // Refresh Token，使用 Cookie 会话时可以省略
+}
+
+// AdminLogoutRequest 管理员退出登录请求
+type AdminLogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // Refresh Token（可选）
+}
+
+// Login 管理员登录
+//
+//	@Summary		管理员登录
+//	@Description	使用用户名和密码登录后台，Token 角色由用户已分配的 RBAC 角色决定；启用了两步验证或角色要求两步验证时返回 mfa_required 和 mfa_token，需要调用 /admin/auth/login/mfa 完成登录
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		AdminLoginRequest								true	"登录信息"
+//	@Success		200		{object}	response.Response{data=map[string]interface{}}	"登录成功，返回管理员信息、角色和 Token"
+//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		401		{object}	response.Response								"用户名或密码错误"
+//	@Failure		403		{object}	response.Response								"没有后台角色"
+//	@Failure		429		{object}	response.Response								"登录失败次数过多，暂时锁定（业务码 20011，Retry-After 为剩余秒数）"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/auth/login [post]
+func (h *AdminAuthHandler) Login(c *gin.Context) {
+	var req AdminLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	result, err := h.service.Login(c.Request.Context(), req.Username, req.Password, c.ClientIP())
+	if err != nil {
+		h.logger.Warn("Admin login failed",
+			zap.String("username", req.Username),
+			zap.Error(err))
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, response.CodeUserLoginLocked, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrAdminRoleRequired) {
+			response.Forbidden(c, "Admin access required")
+			return
+		}
+		if err.Error() == "invalid username or password" {
+			response.Unauthorized(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrUserInactive) {
+			response.BusinessError(c, response.CodeUserDisabled, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			response.BusinessError(c, response.CodeUserEmailNotVerified, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to login")
+		return
+	}
+
+	if result.MFA != nil {
+		response.SuccessWithMsg(c, "MFA verification required", gin.H{
+			"mfa_required":        true,
+			"mfa_token":           result.MFA.Token,
+			"expires_in":          result.MFA.ExpiresIn,
+			"enrollment_required": result.MFA.EnrollmentRequired,
+		})
+		return
+	}
+
+	h.cookies.SetTokens(c, result.Tokens)
+	response.SuccessWithMsg(c, "Login successful", adminLoginResponse(result))
+}
+
+// SetupLoginMFA 管理员登录时绑定两步验证
+//
+//	@Summary		管理员登录时绑定两步验证
+//	@Description	角色要求两步验证但管理员尚未绑定时（enrollment_required=true），使用 mfa_token 获取 TOTP 密钥，再调用 /admin/auth/login/mfa 提交验证码完成绑定和登录
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		AdminMFASetupRequest							true	"mfa_token"
+//	@Success		200		{object}	response.Response{data=service.MFAEnrollment}	"返回密钥和 otpauth 链接"
+//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		401		{object}	response.Response								"mfa_token 无效或已过期"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/auth/login/mfa/setup [post]
+func (h *AdminAuthHandler) SetupLoginMFA(c *gin.Context) {
+	var req AdminMFASetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	enrollment, err := h.service.BeginMFAEnrollment(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		h.logger.Warn("Failed to begin admin mfa enrollment", zap.Error(err))
+		respondMFAError(c, err, "Failed to begin mfa enrollment")
+		return
+	}
+
+	response.Success(c, enrollment)
+}
+
+// LoginMFA 管理员完成两步验证登录
+//
+//	@Summary		管理员完成两步验证登录
+//	@Description	提交登录接口返回的 mfa_token 和验证码（或恢复码），成功后返回管理员信息、角色和 Token；每个 mfa_token 最多尝试 5 次
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		AdminMFALoginRequest							true	"mfa_token 和验证码"
+//	@Success		200		{object}	response.Response{data=map[string]interface{}}	"登录成功，返回管理员信息、角色和 Token"
+//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		401		{object}	response.Response								"mfa_token 无效或已过期"
+//	@Failure		403		{object}	response.Response								"没有后台角色"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/auth/login/mfa [post]
+func (h *AdminAuthHandler) LoginMFA(c *gin.Context) {
+	var req AdminMFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	result, err := h.service.CompleteMFALogin(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		h.logger.Warn("Admin mfa login failed", zap.Error(err))
+		if errors.Is(err, service.ErrAdminRoleRequired) {
+			response.Forbidden(c, "Admin access required")
+			return
+		}
+		respondMFAError(c, err, "Failed to login")
+		return
+	}
+
+	h.cookies.SetTokens(c, result.Tokens)
+	response.SuccessWithMsg(c, "Login successful", adminLoginResponse(result))
+}
+
+// adminLoginResponse 管理员登录成功的响应数据
+func adminLoginResponse(result *service.LoginResult) gin.H {
+	data := gin.H{
+		"user":               result.User,
+		"roles":              result.Roles,
+		"token":              result.Tokens.AccessToken,
+		"refresh_token":      result.Tokens.RefreshToken,
+		"expires_in":         result.Tokens.ExpiresIn,
+		"refresh_expires_in": result.Tokens.RefreshExpiresIn,
+	}
+	// 登录过程中完成绑定时返回恢复码，只展示这一次
+	if len(result.RecoveryCodes) > 0 {
+		data["recovery_codes"] = result.RecoveryCodes
+	}
+	return data
+}
+
+// RefreshToken 刷新管理员 Token
+//
+//	@Summary		刷新管理员 Token
+//	@Description	使用 Refresh Token 换取新的 Token 对，角色会根据当前 user_roles 重新推导；重复使用已失效的 Refresh Token 会吊销整个登录会话。使用 Cookie 会话时请求体可以为空，从 Cookie 中读取 Refresh Token 并下发新的 Cookie
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		AdminRefreshTokenRequest					false	"Refresh Token"
+//	@Success		200		{object}	response.Response{data=service.TokenPair}	"刷新成功，返回新的 Token 对"
+//	@Failure		400		{object}	response.Response							"请求参数错误"
+//	@Failure		401		{object}	response.Response							"Refresh Token 无效"
+//	@Failure		500		{object}	response.Response							"服务器内部错误"
+//	@Router			/admin/auth/refresh [post]
+func (h *AdminAuthHandler) RefreshToken(c *gin.Context) {
+	// 使用 Cookie 会话时请求体可以为空
+	var req AdminRefreshTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidateError(c, err.Error())
+			return
+		}
+	}
+	if req.RefreshToken == "" {
+		req.RefreshToken = h.cookies.RefreshToken(c)
+	}
+	if req.RefreshToken == "" {
+		response.ValidateError(c, "refresh_token is required")
+		return
+	}
+
+	tokens, err := h.service.RefreshToken(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		h.logger.Warn("Failed to refresh admin token", zap.Error(err))
+		if errors.Is(err, service.ErrRefreshTokenInvalid) || errors.Is(err, service.ErrRefreshTokenReused) {
+			h.cookies.Clear(c)
+			response.Unauthorized(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to refresh token")
+		return
+	}
+
+	h.cookies.SetTokens(c, tokens)
+	response.SuccessWithMsg(c, "Token refreshed successfully", tokens)
+}
+
+// Logout 管理员退出登录
+//
+//	@Summary		管理员退出登录
+//	@Description	吊销当前 Access Token，请求体携带 Refresh Token 时一并吊销该登录会话；吊销对所有实例立即生效
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		AdminLogoutRequest	false	"Refresh Token（可选）"
+//	@Success		200		{object}	response.Response	"退出成功"
+//	@Failure		400		{object}	response.Response	"请求参数错误"
+//	@Failure		401		{object}	response.Response	"未授权"
+//	@Failure		500		{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/auth/logout [post]
+func (h *AdminAuthHandler) Logout(c *gin.Context) {
+	claims, exists := middleware.GetClaims(c)
+	if !exists {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	// 请求体可选
+	var req AdminLogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidateError(c, err.Error())
+			return
+		}
+	}
+
+	if err := h.service.Logout(c.Request.Context(), claims, req.RefreshToken); err != nil {
+		h.logger.Error("Admin logout failed", zap.Uint("admin_id", claims.UserID), zap.Error(err))
+		response.InternalError(c, "Failed to logout")
+		return
+	}
+
+	h.cookies.Clear(c)
+	response.SuccessWithMsg(c, "Logout successful", nil)
+}
+
+// Me 获取当前管理员信息
+//
+//	@Summary		获取当前管理员信息
+//	@Description	获取当前登录管理员的用户信息、角色和权限
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=map[string]interface{}}	"成功获取管理员信息"
+//	@Failure		401	{object}	response.Response								"未授权"
+//	@Failure		404	{object}	response.Response								"用户不存在"
+//	@Failure		500	{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/auth/me [get]
+func (h *AdminAuthHandler) Me(c *gin.Context) {
+	adminID, exists := middleware.GetAdminID(c)
+	if !exists {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	user, roles, permissions, err := h.service.GetProfile(c.Request.Context(), adminID)
+	if err != nil {
+		h.logger.Error("Failed to get admin profile", zap.Uint("admin_id", adminID), zap.Error(err))
+		if err.Error() == "user not found" {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to get admin profile")
+		return
+	}
+
+	role, _ := middleware.GetAdminRole(c)
+	response.Success(c, gin.H{
+		"user":        user,
+		"role":        role,
+		"roles":       roles,
+		"permissions": permissions,
+	})
+}
@@ -0,0 +1,81 @@
+package backendHandler
+
+import (
+	"errors"
+	"strconv"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ImpersonationHandler 管理员模拟用户处理器
+type ImpersonationHandler struct {
+	service service.ImpersonationService
+	logger  *zap.Logger
+}
+
+// NewImpersonationHandler 创建管理员模拟用户处理器
+func NewImpersonationHandler(service service.ImpersonationService, logger *zap.Logger) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ImpersonateRequest 模拟用户请求
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,max=500" example:"复现工单 #1024 中的下单失败问题"` // 模拟原因，写入审计日志
+}
+
+// Impersonate 模拟用户
+//
+//	@Summary		模拟用户
+//	@Description	签发一个以该用户身份访问前台的短期 Token（有效期 auth.impersonation_ttl_minutes，不可刷新）。Token 中带有 act 声明记录发起的管理员，模拟期间不能修改密码、两步验证、会话、API Key 和个人资料
+//	@Tags			用户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int													true	"用户 ID"
+//	@Param			request	body		ImpersonateRequest									true	"模拟原因"
+//	@Success		200		{object}	response.Response{data=service.ImpersonationToken}	"签发成功"
+//	@Failure		400		{object}	response.Response									"请求参数错误"
+//	@Failure		401		{object}	response.Response									"未授权"
+//	@Failure		403		{object}	response.Response									"无权限或不能模拟该用户"
+//	@Failure		404		{object}	response.Response									"用户不存在"
+//	@Failure		500		{object}	response.Response									"服务器内部错误"
+//	@Router			/admin/users/{id}/impersonate [post]
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	token, err := h.service.Impersonate(c.Request.Context(), adminID, uint(id), req.Reason, c.ClientIP())
+	if err != nil {
+		if err.Error() == "user not found" {
+			response.NotFound(c, "User not found")
+			return
+		}
+		if errors.Is(err, service.ErrImpersonationNotAllowed) {
+			response.Forbidden(c, "Impersonation of this user is not allowed")
+			return
+		}
+		h.logger.Error("Failed to impersonate user", zap.Uint("admin_id", adminID), zap.Uint64("user_id", id), zap.Error(err))
+		response.InternalError(c, "Failed to impersonate user")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Impersonation token issued", token)
+}
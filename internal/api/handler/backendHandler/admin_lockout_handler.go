@@ -0,0 +1,114 @@
+package backendHandler
+
+import (
+	"errors"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	_ "trx-project/pkg/cache" // 用于 Swagger 文档生成
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminLockoutHandler 登录锁定管理处理器
+type AdminLockoutHandler struct {
+	service service.LoginProtectionService
+	logger  *zap.Logger
+}
+
+// NewAdminLockoutHandler 创建登录锁定管理处理器
+func NewAdminLockoutHandler(service service.LoginProtectionService, logger *zap.Logger) *AdminLockoutHandler {
+	return &AdminLockoutHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListLockouts 查看登录锁定
+//
+//	@Summary		查看登录锁定
+//	@Description	不带参数时列出当前被锁定的所有用户名和 IP；指定 scope 和 value 时返回该用户名或 IP 的失败次数和剩余锁定时间。锁定不属于任何租户，只有平台管理员可以访问
+//	@Tags			用户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			scope	query		string											false	"锁定维度：username 或 ip"
+//	@Param			value	query		string											false	"用户名或 IP"
+//	@Success		200		{object}	response.Response{data=[]cache.LoginAttemptState}	"成功获取锁定列表"
+//	@Failure		400		{object}	response.Response								"无效的锁定维度"
+//	@Failure		401		{object}	response.Response								"未授权"
+//	@Failure		403		{object}	response.Response								"无权限或不是平台管理员"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/lockouts [get]
+func (h *AdminLockoutHandler) ListLockouts(c *gin.Context) {
+	scope := c.Query("scope")
+	value := c.Query("value")
+
+	if scope == "" && value == "" {
+		states, err := h.service.ListLockouts(c.Request.Context())
+		if err != nil {
+			h.logger.Error("Failed to list login lockouts", zap.Error(err))
+			response.InternalError(c, "Failed to list login lockouts")
+			return
+		}
+		response.Success(c, states)
+		return
+	}
+
+	if value == "" {
+		response.BadRequest(c, "value is required")
+		return
+	}
+
+	state, err := h.service.GetLockout(c.Request.Context(), scope, value)
+	if err != nil {
+		if errors.Is(err, service.ErrLoginLockoutScopeInvalid) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to get login lockout", zap.Error(err))
+		response.InternalError(c, "Failed to get login lockout")
+		return
+	}
+
+	response.Success(c, state)
+}
+
+// ClearLockout 解除登录锁定
+//
+//	@Summary		解除登录锁定
+//	@Description	清除用户名或 IP 的登录失败次数和锁定，操作会记录审计日志，只有平台管理员可以访问
+//	@Tags			用户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			scope	query		string				true	"锁定维度：username 或 ip"
+//	@Param			value	query		string				true	"用户名或 IP"
+//	@Success		200		{object}	response.Response	"解除成功"
+//	@Failure		400		{object}	response.Response	"请求参数错误"
+//	@Failure		401		{object}	response.Response	"未授权"
+//	@Failure		403		{object}	response.Response	"无权限或不是平台管理员"
+//	@Failure		500		{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/lockouts [delete]
+func (h *AdminLockoutHandler) ClearLockout(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	value := c.Query("value")
+	if value == "" {
+		response.BadRequest(c, "value is required")
+		return
+	}
+
+	if err := h.service.ClearLockout(c.Request.Context(), adminID, c.Query("scope"), value, c.ClientIP()); err != nil {
+		if errors.Is(err, service.ErrLoginLockoutScopeInvalid) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to clear login lockout", zap.Uint("admin_id", adminID), zap.Error(err))
+		response.InternalError(c, "Failed to clear login lockout")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Login lockout cleared successfully", nil)
+}
@@ -0,0 +1,329 @@
+package backendHandler
+
+import (
+	"errors"
+	"strconv"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminMFAHandler 管理员两步验证处理器
+type AdminMFAHandler struct {
+	service service.MFAService
+	logger  *zap.Logger
+}
+
+// NewAdminMFAHandler 创建管理员两步验证处理器
+func NewAdminMFAHandler(service service.MFAService, logger *zap.Logger) *AdminMFAHandler {
+	return &AdminMFAHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// AdminMFACodeRequest 两步验证码请求
+type AdminMFACodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"` // 验证器 App 中的 6 位验证码或恢复码
+}
+
+// RoleMFARequest 设置角色两步验证要求请求
+type RoleMFARequest struct {
+	Required *bool `json:"required" binding:"required" example:"true"` // 拥有该角色的用户是否必须启用两步验证
+}
+
+// GetStatus 获取当前管理员两步验证状态
+//
+//	@Summary		获取两步验证状态
+//	@Description	获取当前管理员是否已启用两步验证、角色是否要求启用以及剩余恢复码数量
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=service.MFAStatus}	"成功获取状态"
+//	@Failure		401	{object}	response.Response							"未授权"
+//	@Failure		500	{object}	response.Response							"服务器内部错误"
+//	@Router			/admin/auth/mfa [get]
+func (h *AdminMFAHandler) GetStatus(c *gin.Context) {
+	adminID, exists := middleware.GetAdminID(c)
+	if !exists {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	status, err := h.service.GetStatus(c.Request.Context(), adminID)
+	if err != nil {
+		h.logger.Error("Failed to get mfa status", zap.Uint("admin_id", adminID), zap.Error(err))
+		response.InternalError(c, "Failed to get mfa status")
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// BeginEnrollment 开始绑定两步验证
+//
+//	@Summary		开始绑定两步验证
+//	@Description	生成新的 TOTP 密钥，返回 otpauth 链接用于生成二维码；调用确认接口前不会生效
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=service.MFAEnrollment}	"返回密钥和 otpauth 链接"
+//	@Failure		400	{object}	response.Response								"已启用两步验证"
+//	@Failure		401	{object}	response.Response								"未授权"
+//	@Failure		500	{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/auth/mfa/enroll [post]
+func (h *AdminMFAHandler) BeginEnrollment(c *gin.Context) {
+	adminID, exists := middleware.GetAdminID(c)
+	if !exists {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	enrollment, err := h.service.BeginEnrollment(c.Request.Context(), adminID)
+	if err != nil {
+		h.logger.Warn("Failed to begin mfa enrollment", zap.Uint("admin_id", adminID), zap.Error(err))
+		respondMFAError(c, err, "Failed to begin mfa enrollment")
+		return
+	}
+
+	response.Success(c, enrollment)
+}
+
+// ConfirmEnrollment 确认绑定两步验证
+//
+//	@Summary		确认绑定两步验证
+//	@Description	校验验证器 App 中的验证码后启用两步验证，返回的恢复码只展示这一次
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		AdminMFACodeRequest								true	"验证码"
+//	@Success		200		{object}	response.Response{data=map[string]interface{}}	"启用成功，返回恢复码"
+//	@Failure		400		{object}	response.Response								"请求参数错误或尚未开始绑定"
+//	@Failure		401		{object}	response.Response								"未授权"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/auth/mfa/confirm [post]
+func (h *AdminMFAHandler) ConfirmEnrollment(c *gin.Context) {
+	adminID, exists := middleware.GetAdminID(c)
+	if !exists {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req AdminMFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	codes, err := h.service.ConfirmEnrollment(c.Request.Context(), adminID, req.Code)
+	if err != nil {
+		h.logger.Warn("Failed to confirm mfa enrollment", zap.Uint("admin_id", adminID), zap.Error(err))
+		respondMFAError(c, err, "Failed to confirm mfa enrollment")
+		return
+	}
+
+	response.SuccessWithMsg(c, "MFA enabled successfully", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// Disable 关闭两步验证
+//
+//	@Summary		关闭两步验证
+//	@Description	校验验证码或恢复码后关闭两步验证；角色要求两步验证时不能关闭
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		AdminMFACodeRequest	true	"验证码或恢复码"
+//	@Success		200		{object}	response.Response	"关闭成功"
+//	@Failure		400		{object}	response.Response	"请求参数错误或未启用两步验证"
+//	@Failure		401		{object}	response.Response	"未授权"
+//	@Failure		500		{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/auth/mfa/disable [post]
+func (h *AdminMFAHandler) Disable(c *gin.Context) {
+	adminID, exists := middleware.GetAdminID(c)
+	if !exists {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req AdminMFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	if err := h.service.Disable(c.Request.Context(), adminID, req.Code); err != nil {
+		h.logger.Warn("Failed to disable mfa", zap.Uint("admin_id", adminID), zap.Error(err))
+		respondMFAError(c, err, "Failed to disable mfa")
+		return
+	}
+
+	response.SuccessWithMsg(c, "MFA disabled successfully", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+//
+//	@Summary		重新生成恢复码
+//	@Description	校验验证码或恢复码后生成新的恢复码，原有恢复码全部失效
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		AdminMFACodeRequest								true	"验证码或恢复码"
+//	@Success		200		{object}	response.Response{data=map[string]interface{}}	"返回新的恢复码"
+//	@Failure		400		{object}	response.Response								"请求参数错误或未启用两步验证"
+//	@Failure		401		{object}	response.Response								"未授权"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/auth/mfa/recovery-codes [post]
+func (h *AdminMFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	adminID, exists := middleware.GetAdminID(c)
+	if !exists {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req AdminMFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), adminID, req.Code)
+	if err != nil {
+		h.logger.Warn("Failed to regenerate recovery codes", zap.Uint("admin_id", adminID), zap.Error(err))
+		respondMFAError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Recovery codes regenerated successfully", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// ResetUserMFA 重置用户两步验证
+//
+//	@Summary		重置用户两步验证
+//	@Description	清除用户的两步验证密钥和恢复码（用户丢失设备和恢复码时使用），操作会记录审计日志；目标用户拥有后台角色时只有拥有其全部权限的超级管理员可以重置
+//	@Tags			用户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int					true	"用户ID"
+//	@Success		200	{object}	response.Response	"重置成功"
+//	@Failure		400	{object}	response.Response	"无效的用户ID"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		403	{object}	response.Response	"无权限或目标用户权限更高"
+//	@Failure		404	{object}	response.Response	"用户不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/users/{id}/mfa [delete]
+func (h *AdminMFAHandler) ResetUserMFA(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.service.Reset(c.Request.Context(), adminID, uint(id), c.ClientIP()); err != nil {
+		h.logger.Error("Failed to reset user mfa",
+			zap.Uint("admin_id", adminID),
+			zap.Uint64("user_id", id),
+			zap.Error(err))
+		if err.Error() == "user not found" {
+			response.NotFound(c, "User not found")
+			return
+		}
+		if errors.Is(err, service.ErrTargetPrivileged) {
+			response.Forbidden(c, "Cannot reset mfa of a more privileged user")
+			return
+		}
+		response.InternalError(c, "Failed to reset mfa")
+		return
+	}
+
+	h.logger.Info("Admin reset user mfa",
+		zap.Uint("admin_id", adminID),
+		zap.Uint64("user_id", id))
+
+	response.SuccessWithMsg(c, "MFA reset successfully", nil)
+}
+
+// SetRoleMFARequirement 设置角色两步验证要求
+//
+//	@Summary		设置角色两步验证要求
+//	@Description	设置拥有该角色的用户是否必须启用两步验证，尚未绑定的用户会在下次登录时被要求绑定；操作会记录审计日志
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int									true	"角色ID"
+//	@Param			request	body		RoleMFARequest						true	"是否要求两步验证"
+//	@Success		200		{object}	response.Response{data=model.Role}	"设置成功"
+//	@Failure		400		{object}	response.Response					"请求参数错误"
+//	@Failure		401		{object}	response.Response					"未授权"
+//	@Failure		403		{object}	response.Response					"无权限"
+//	@Failure		404		{object}	response.Response					"角色不存在"
+//	@Failure		500		{object}	response.Response					"服务器内部错误"
+//	@Router			/admin/rbac/roles/{id}/mfa [put]
+func (h *AdminMFAHandler) SetRoleMFARequirement(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid role ID")
+		return
+	}
+
+	var req RoleMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	role, err := h.service.SetRoleRequirement(c.Request.Context(), adminID, uint(id), *req.Required, c.ClientIP())
+	if err != nil {
+		h.logger.Error("Failed to set role mfa requirement",
+			zap.Uint("admin_id", adminID),
+			zap.Uint64("role_id", id),
+			zap.Error(err))
+		if err.Error() == "role not found" {
+			response.NotFound(c, "Role not found")
+			return
+		}
+		response.InternalError(c, "Failed to update role")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Role updated successfully", role)
+}
+
+// respondMFAError 根据两步验证错误类型返回响应
+func respondMFAError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrMFAInvalidCode):
+		response.BusinessError(c, response.CodeUserMFAInvalid, err.Error())
+	case errors.Is(err, service.ErrMFARequiredByRole):
+		response.BusinessError(c, response.CodeUserMFARequired, err.Error())
+	case errors.Is(err, service.ErrMFAChallengeInvalid):
+		response.Unauthorized(c, err.Error())
+	case errors.Is(err, service.ErrMFAAlreadyEnabled),
+		errors.Is(err, service.ErrMFANotEnabled),
+		errors.Is(err, service.ErrMFAEnrollmentNotStarted):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrUserInactive):
+		response.BusinessError(c, response.CodeUserDisabled, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
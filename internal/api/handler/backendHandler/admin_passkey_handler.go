@@ -0,0 +1,339 @@
+package backendHandler
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminPasskeyHandler 管理员通行密钥处理器
+type AdminPasskeyHandler struct {
+	service     service.PasskeyService
+	authService service.AdminAuthService
+	cookies     *middleware.SessionCookies
+	logger      *zap.Logger
+}
+
+// NewAdminPasskeyHandler 创建管理员通行密钥处理器
+func NewAdminPasskeyHandler(service service.PasskeyService, authService service.AdminAuthService, cookies *middleware.SessionCookies, logger *zap.Logger) *AdminPasskeyHandler {
+	return &AdminPasskeyHandler{
+		service:     service,
+		authService: authService,
+		cookies:     cookies,
+		logger:      logger,
+	}
+}
+
+// PasskeyRegisterRequest 完成通行密钥注册请求
+type PasskeyRegisterRequest struct {
+	ChallengeID string          `json:"challenge_id" binding:"required"`                    // 发起注册时返回的 challenge_id
+	Name        string          `json:"name" binding:"max=100" example:"MacBook Touch ID"`  // 名称，便于区分设备
+	Credential  json.RawMessage `json:"credential" binding:"required" swaggertype:"object"` // navigator.credentials.create 返回的 PublicKeyCredential（JSON）
+}
+
+// PasskeyLoginRequest 通行密钥登录请求
+type PasskeyLoginRequest struct {
+	ChallengeID string          `json:"challenge_id" binding:"required"`                    // 发起登录时返回的 challenge_id
+	Credential  json.RawMessage `json:"credential" binding:"required" swaggertype:"object"` // navigator.credentials.get 返回的 PublicKeyCredential（JSON）
+}
+
+// BeginLogin 发起通行密钥登录
+//
+//	@Summary		发起通行密钥登录
+//	@Description	返回 challenge_id 和 options，options.publicKey 传给浏览器的 navigator.credentials.get；不需要用户名，由验证器选择通行密钥
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	response.Response{data=service.PasskeyCeremony}	"返回挑战"
+//	@Failure		404	{object}	response.Response								"未配置通行密钥登录"
+//	@Failure		500	{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/auth/login/passkey/begin [post]
+func (h *AdminPasskeyHandler) BeginLogin(c *gin.Context) {
+	ceremony, err := h.service.BeginLogin(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrPasskeyDisabled) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to begin passkey login")
+		return
+	}
+
+	response.Success(c, ceremony)
+}
+
+// Login 使用通行密钥登录
+//
+//	@Summary		使用通行密钥登录
+//	@Description	提交验证器返回的签名，成功后返回管理员信息、角色和 Token；通行密钥要求用户验证（PIN 或生物识别），视为已完成两步验证。每个 challenge_id 只能使用一次
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		PasskeyLoginRequest								true	"challenge_id 和验证器返回的凭证"
+//	@Success		200		{object}	response.Response{data=map[string]interface{}}	"登录成功，返回管理员信息、角色和 Token"
+//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		401		{object}	response.Response								"挑战无效或签名校验失败"
+//	@Failure		403		{object}	response.Response								"没有后台角色"
+//	@Failure		404		{object}	response.Response								"未配置通行密钥登录"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/auth/login/passkey [post]
+func (h *AdminPasskeyHandler) Login(c *gin.Context) {
+	var req PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	result, err := h.authService.LoginWithPasskey(c.Request.Context(), req.ChallengeID, req.Credential)
+	if err != nil {
+		h.logger.Warn("Admin passkey login failed", zap.Error(err))
+		switch {
+		case errors.Is(err, service.ErrPasskeyDisabled):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, service.ErrPasskeyChallengeInvalid), errors.Is(err, service.ErrPasskeyVerificationFailed):
+			response.Unauthorized(c, err.Error())
+		case errors.Is(err, service.ErrAdminRoleRequired):
+			response.Forbidden(c, "Admin access required")
+		case errors.Is(err, service.ErrUserInactive):
+			response.BusinessError(c, response.CodeUserDisabled, err.Error())
+		default:
+			response.InternalError(c, "Failed to login")
+		}
+		return
+	}
+
+	h.cookies.SetTokens(c, result.Tokens)
+	response.SuccessWithMsg(c, "Login successful", adminLoginResponse(result))
+}
+
+// ListMyPasskeys 获取当前管理员的通行密钥
+//
+//	@Summary		获取当前管理员的通行密钥
+//	@Description	列出当前管理员注册的通行密钥
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=[]model.WebAuthnCredential}	"成功获取通行密钥列表"
+//	@Failure		401	{object}	response.Response									"未授权"
+//	@Failure		500	{object}	response.Response									"服务器内部错误"
+//	@Router			/admin/auth/passkeys [get]
+func (h *AdminPasskeyHandler) ListMyPasskeys(c *gin.Context) {
+	adminID, exists := middleware.GetAdminID(c)
+	if !exists {
+		response.Unauthorized(c, "Admin not authenticated")
+		return
+	}
+
+	passkeys, err := h.service.ListPasskeys(c.Request.Context(), adminID)
+	if err != nil {
+		response.InternalError(c, "Failed to list passkeys")
+		return
+	}
+
+	response.Success(c, passkeys)
+}
+
+// BeginRegistration 发起通行密钥注册
+//
+//	@Summary		发起通行密钥注册
+//	@Description	返回 challenge_id 和 options，options.publicKey 传给浏览器的 navigator.credentials.create；已注册的通行密钥会被排除
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=service.PasskeyCeremony}	"返回挑战"
+//	@Failure		401	{object}	response.Response								"未授权"
+//	@Failure		404	{object}	response.Response								"未配置通行密钥登录"
+//	@Failure		500	{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/auth/passkeys/register/begin [post]
+func (h *AdminPasskeyHandler) BeginRegistration(c *gin.Context) {
+	adminID, exists := middleware.GetAdminID(c)
+	if !exists {
+		response.Unauthorized(c, "Admin not authenticated")
+		return
+	}
+
+	ceremony, err := h.service.BeginRegistration(c.Request.Context(), adminID)
+	if err != nil {
+		if errors.Is(err, service.ErrPasskeyDisabled) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to begin passkey registration")
+		return
+	}
+
+	response.Success(c, ceremony)
+}
+
+// FinishRegistration 完成通行密钥注册
+//
+//	@Summary		完成通行密钥注册
+//	@Description	提交验证器返回的注册数据，校验通过后保存通行密钥；每个 challenge_id 只能使用一次
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		PasskeyRegisterRequest								true	"challenge_id、名称和验证器返回的凭证"
+//	@Success		201		{object}	response.Response{data=model.WebAuthnCredential}	"注册成功"
+//	@Failure		400		{object}	response.Response									"请求参数错误、挑战无效或校验失败"
+//	@Failure		401		{object}	response.Response									"未授权"
+//	@Failure		404		{object}	response.Response									"未配置通行密钥登录"
+//	@Failure		500		{object}	response.Response									"服务器内部错误"
+//	@Router			/admin/auth/passkeys/register [post]
+func (h *AdminPasskeyHandler) FinishRegistration(c *gin.Context) {
+	adminID, exists := middleware.GetAdminID(c)
+	if !exists {
+		response.Unauthorized(c, "Admin not authenticated")
+		return
+	}
+
+	var req PasskeyRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	passkey, err := h.service.FinishRegistration(c.Request.Context(), adminID, req.ChallengeID, req.Name, req.Credential, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPasskeyDisabled):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, service.ErrPasskeyChallengeInvalid), errors.Is(err, service.ErrPasskeyVerificationFailed):
+			response.BadRequest(c, err.Error())
+		case errors.Is(err, service.ErrPasskeyAlreadyRegistered):
+			response.BusinessError(c, response.CodeRecordExists, err.Error())
+		default:
+			h.logger.Error("Failed to register passkey", zap.Uint("admin_id", adminID), zap.Error(err))
+			response.InternalError(c, "Failed to register passkey")
+		}
+		return
+	}
+
+	response.CreatedWithMsg(c, "Passkey registered successfully", passkey)
+}
+
+// DeleteMyPasskey 删除当前管理员的通行密钥
+//
+//	@Summary		删除当前管理员的通行密钥
+//	@Description	删除后该通行密钥不能再用于登录，操作会记录审计日志
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int					true	"通行密钥ID"
+//	@Success		200	{object}	response.Response	"删除成功"
+//	@Failure		400	{object}	response.Response	"无效的通行密钥ID"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		404	{object}	response.Response	"通行密钥不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/auth/passkeys/{id} [delete]
+func (h *AdminPasskeyHandler) DeleteMyPasskey(c *gin.Context) {
+	adminID, exists := middleware.GetAdminID(c)
+	if !exists {
+		response.Unauthorized(c, "Admin not authenticated")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid passkey ID")
+		return
+	}
+
+	h.deletePasskey(c, adminID, adminID, uint(id))
+}
+
+// ListUserPasskeys 获取用户的通行密钥
+//
+//	@Summary		获取用户的通行密钥
+//	@Description	列出指定用户注册的通行密钥
+//	@Tags			用户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int													true	"用户ID"
+//	@Success		200	{object}	response.Response{data=[]model.WebAuthnCredential}	"成功获取通行密钥列表"
+//	@Failure		400	{object}	response.Response									"无效的用户ID"
+//	@Failure		401	{object}	response.Response									"未授权"
+//	@Failure		403	{object}	response.Response									"无权限"
+//	@Failure		500	{object}	response.Response									"服务器内部错误"
+//	@Router			/admin/users/{id}/passkeys [get]
+func (h *AdminPasskeyHandler) ListUserPasskeys(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	passkeys, err := h.service.ListPasskeys(c.Request.Context(), uint(id))
+	if err != nil {
+		response.InternalError(c, "Failed to list passkeys")
+		return
+	}
+
+	response.Success(c, passkeys)
+}
+
+// DeleteUserPasskey 删除用户的通行密钥
+//
+//	@Summary		删除用户的通行密钥
+//	@Description	用户丢失设备时由管理员删除其通行密钥，操作会记录审计日志；目标用户拥有后台角色时只有拥有其全部权限的超级管理员可以删除
+//	@Tags			用户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int					true	"用户ID"
+//	@Param			pid	path		int					true	"通行密钥ID"
+//	@Success		200	{object}	response.Response	"删除成功"
+//	@Failure		400	{object}	response.Response	"无效的ID"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		403	{object}	response.Response	"无权限或目标用户权限更高"
+//	@Failure		404	{object}	response.Response	"通行密钥不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/users/{id}/passkeys/{pid} [delete]
+func (h *AdminPasskeyHandler) DeleteUserPasskey(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("pid"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid passkey ID")
+		return
+	}
+
+	h.deletePasskey(c, adminID, uint(userID), uint(id))
+}
+
+func (h *AdminPasskeyHandler) deletePasskey(c *gin.Context, adminID, userID, id uint) {
+	if err := h.service.DeletePasskey(c.Request.Context(), adminID, userID, id, c.ClientIP()); err != nil {
+		if errors.Is(err, service.ErrPasskeyNotFound) {
+			response.NotFound(c, "Passkey not found")
+			return
+		}
+		if errors.Is(err, service.ErrTargetPrivileged) {
+			response.Forbidden(c, "Cannot remove passkeys of a more privileged user")
+			return
+		}
+		h.logger.Error("Failed to delete passkey",
+			zap.Uint("admin_id", adminID),
+			zap.Uint("user_id", userID),
+			zap.Uint("passkey_id", id),
+			zap.Error(err))
+		response.InternalError(c, "Failed to delete passkey")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Passkey deleted successfully", nil)
+}
@@ -0,0 +1,165 @@
+package backendHandler
+
+import (
+	"errors"
+	"strconv"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminSessionHandler 登录会话管理处理器
+type AdminSessionHandler struct {
+	service service.SessionService
+	logger  *zap.Logger
+}
+
+// NewAdminSessionHandler 创建登录会话管理处理器
+func NewAdminSessionHandler(service service.SessionService, logger *zap.Logger) *AdminSessionHandler {
+	return &AdminSessionHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListMySessions 获取当前管理员的登录设备
+//
+//	@Summary		获取当前管理员的登录设备
+//	@Description	列出当前管理员所有有效的登录会话，current 为 true 的是发起请求的设备
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=[]model.UserSession}	"成功获取会话列表"
+//	@Failure		401	{object}	response.Response							"未授权"
+//	@Failure		500	{object}	response.Response							"服务器内部错误"
+//	@Router			/admin/auth/sessions [get]
+func (h *AdminSessionHandler) ListMySessions(c *gin.Context) {
+	claims, exists := middleware.GetClaims(c)
+	if !exists {
+		response.Unauthorized(c, "Admin not authenticated")
+		return
+	}
+
+	sessions, err := h.service.ListSessions(c.Request.Context(), claims.UserID, claims.SessionID)
+	if err != nil {
+		response.InternalError(c, "Failed to list sessions")
+		return
+	}
+
+	response.Success(c, sessions)
+}
+
+// RevokeMySession 移除当前管理员的登录设备
+//
+//	@Summary		移除当前管理员的登录设备
+//	@Description	使当前管理员的指定会话立即退出登录
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string				true	"会话ID"
+//	@Success		200	{object}	response.Response	"移除成功"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		404	{object}	response.Response	"会话不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/auth/sessions/{id} [delete]
+func (h *AdminSessionHandler) RevokeMySession(c *gin.Context) {
+	adminID, exists := middleware.GetAdminID(c)
+	if !exists {
+		response.Unauthorized(c, "Admin not authenticated")
+		return
+	}
+
+	if err := h.service.RevokeSession(c.Request.Context(), adminID, c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			response.NotFound(c, "Session not found")
+			return
+		}
+		h.logger.Error("Failed to revoke session", zap.Uint("admin_id", adminID), zap.Error(err))
+		response.InternalError(c, "Failed to revoke session")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Session revoked successfully", nil)
+}
+
+// ListUserSessions 获取用户的登录设备
+//
+//	@Summary		获取用户的登录设备
+//	@Description	列出指定用户所有有效的登录会话（设备、IP、登录时间和最近活跃时间）
+//	@Tags			用户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int											true	"用户ID"
+//	@Success		200	{object}	response.Response{data=[]model.UserSession}	"成功获取会话列表"
+//	@Failure		400	{object}	response.Response							"无效的用户ID"
+//	@Failure		401	{object}	response.Response							"未授权"
+//	@Failure		403	{object}	response.Response							"无权限"
+//	@Failure		500	{object}	response.Response							"服务器内部错误"
+//	@Router			/admin/users/{id}/sessions [get]
+func (h *AdminSessionHandler) ListUserSessions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	sessions, err := h.service.ListSessions(c.Request.Context(), uint(id), "")
+	if err != nil {
+		response.InternalError(c, "Failed to list sessions")
+		return
+	}
+
+	response.Success(c, sessions)
+}
+
+// RevokeUserSession 强制用户的登录设备下线
+//
+//	@Summary		强制用户的登录设备下线
+//	@Description	使指定用户的某个会话立即退出登录，操作会记录审计日志
+//	@Tags			用户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int					true	"用户ID"
+//	@Param			sid	path		string				true	"会话ID"
+//	@Success		200	{object}	response.Response	"下线成功"
+//	@Failure		400	{object}	response.Response	"无效的用户ID"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		403	{object}	response.Response	"无权限"
+//	@Failure		404	{object}	response.Response	"会话不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/users/{id}/sessions/{sid} [delete]
+func (h *AdminSessionHandler) RevokeUserSession(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	sessionID := c.Param("sid")
+	if err := h.service.AdminRevokeSession(c.Request.Context(), adminID, uint(id), sessionID, c.ClientIP()); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			response.NotFound(c, "Session not found")
+			return
+		}
+		h.logger.Error("Failed to revoke user session",
+			zap.Uint("admin_id", adminID),
+			zap.Uint64("user_id", id),
+			zap.String("session_id", sessionID),
+			zap.Error(err))
+		response.InternalError(c, "Failed to revoke session")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Session revoked successfully", nil)
+}
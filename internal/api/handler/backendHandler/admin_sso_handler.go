@@ -0,0 +1,118 @@
+package backendHandler
+
+import (
+	"errors"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminSSOHandler 管理员单点登录处理器
+type AdminSSOHandler struct {
+	service     service.OIDCService
+	authService service.AdminAuthService
+	cookies     *middleware.SessionCookies
+	logger      *zap.Logger
+}
+
+// NewAdminSSOHandler 创建管理员单点登录处理器
+func NewAdminSSOHandler(service service.OIDCService, authService service.AdminAuthService, cookies *middleware.SessionCookies, logger *zap.Logger) *AdminSSOHandler {
+	return &AdminSSOHandler{
+		service:     service,
+		authService: authService,
+		cookies:     cookies,
+		logger:      logger,
+	}
+}
+
+// SSOCallbackRequest 单点登录回调请求
+type SSOCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`  // 身份提供方回调地址中的 code
+	State string `json:"state" binding:"required"` // 身份提供方回调地址中的 state
+}
+
+// BeginLogin 发起单点登录
+//
+//	@Summary		发起单点登录
+//	@Description	返回身份提供方的授权地址（授权码模式 + PKCE），前端跳转到 authorization_url；登录后身份提供方带 code 和 state 跳转回 redirect_url，再调用 /admin/auth/login/sso 完成登录。同时下发 HttpOnly 的浏览器绑定 Cookie，完成登录的请求必须携带该 Cookie
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	response.Response{data=service.OIDCAuthorization}	"返回授权地址"
+//	@Failure		404	{object}	response.Response									"未启用单点登录"
+//	@Failure		500	{object}	response.Response									"服务器内部错误"
+//	@Router			/admin/auth/login/sso/begin [post]
+func (h *AdminSSOHandler) BeginLogin(c *gin.Context) {
+	authorization, err := h.service.BeginLogin(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCDisabled) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to begin sso login")
+		return
+	}
+
+	h.cookies.SetSSOBinding(c, authorization.Binding, int(authorization.ExpiresIn))
+	response.Success(c, authorization)
+}
+
+// Login 完成单点登录
+//
+//	@Summary		完成单点登录
+//	@Description	提交回调地址中的 code 和 state，校验 ID Token 后返回管理员信息、角色和 Token。首次登录时自动创建账号，每次登录按身份提供方的组同步角色；启用了两步验证时返回 mfa_required 和 mfa_token。每个 state 只能使用一次，且必须由发起登录的浏览器（携带绑定 Cookie）提交
+//	@Tags			管理员认证
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		SSOCallbackRequest								true	"code 和 state"
+//	@Success		200		{object}	response.Response{data=map[string]interface{}}	"登录成功，返回管理员信息、角色和 Token"
+//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		401		{object}	response.Response								"state 无效、不是当前浏览器发起或 ID Token 校验失败"
+//	@Failure		403		{object}	response.Response								"没有后台角色"
+//	@Failure		404		{object}	response.Response								"未启用单点登录"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/auth/login/sso [post]
+func (h *AdminSSOHandler) Login(c *gin.Context) {
+	var req SSOCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	binding := h.cookies.TakeSSOBinding(c)
+	result, err := h.authService.LoginWithOIDC(c.Request.Context(), req.Code, req.State, binding, c.ClientIP())
+	if err != nil {
+		h.logger.Warn("Admin sso login failed", zap.Error(err))
+		switch {
+		case errors.Is(err, service.ErrOIDCDisabled):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, service.ErrOIDCStateInvalid), errors.Is(err, service.ErrOIDCLoginFailed):
+			response.Unauthorized(c, err.Error())
+		case errors.Is(err, service.ErrOIDCAccountConflict):
+			response.BusinessError(c, response.CodeRecordExists, err.Error())
+		case errors.Is(err, service.ErrAdminRoleRequired):
+			response.Forbidden(c, "Admin access required")
+		case errors.Is(err, service.ErrUserInactive):
+			response.BusinessError(c, response.CodeUserDisabled, err.Error())
+		default:
+			response.InternalError(c, "Failed to login")
+		}
+		return
+	}
+
+	if result.MFA != nil {
+		response.SuccessWithMsg(c, "MFA verification required", gin.H{
+			"mfa_required":        true,
+			"mfa_token":           result.MFA.Token,
+			"expires_in":          result.MFA.ExpiresIn,
+			"enrollment_required": result.MFA.EnrollmentRequired,
+		})
+		return
+	}
+
+	h.cookies.SetTokens(c, result.Tokens)
+	response.SuccessWithMsg(c, "Login successful", adminLoginResponse(result))
+}
@@ -1,9 +1,10 @@
 package backendHandler
 
 import (
+	"errors"
 	"strconv"
 	"trx-project/internal/api/middleware"
-	_ "trx-project/internal/model" // 用于 Swagger 文档生成
+	"trx-project/internal/model"
 	"trx-project/internal/service"
 	"trx-project/pkg/response"
 
@@ -13,22 +14,26 @@ import (
 
 // AdminUserHandler 管理员用户管理处理器
 type AdminUserHandler struct {
-	service service.UserService
-	logger  *zap.Logger
+	service         service.UserService
+	passwordService service.PasswordService
+	authz           service.AuthorizationService
+	logger          *zap.Logger
 }
 
 // NewAdminUserHandler 创建管理员用户管理处理器
-func NewAdminUserHandler(service service.UserService, logger *zap.Logger) *AdminUserHandler {
+func NewAdminUserHandler(service service.UserService, passwordService service.PasswordService, authz service.AuthorizationService, logger *zap.Logger) *AdminUserHandler {
 	return &AdminUserHandler{
-		service: service,
-		logger:  logger,
+		service:         service,
+		passwordService: passwordService,
+		authz:           authz,
+		logger:          logger,
 	}
 }
 
 // ListUsers 获取用户列表
 //
 //	@Summary		获取用户列表（后台）
-//	@Description	分页获取用户列表，支持状态筛选和关键词搜索
+//	@Description	分页获取用户列表，支持状态筛选和关键词搜索。列表不按授权条件过滤，只有带条件的 user:read 授权时返回 403
 //	@Tags			用户管理
 //	@Accept			json
 //	@Produce		json
@@ -81,7 +86,7 @@ func (h *AdminUserHandler) ListUsers(c *gin.Context) {
 //	@Success		200	{object}	response.Response{data=model.User}	"成功获取用户详情"
 //	@Failure		400	{object}	response.Response					"无效的用户ID"
 //	@Failure		401	{object}	response.Response					"未授权"
-//	@Failure		403	{object}	response.Response					"无管理员权限"
+//	@Failure		403	{object}	response.Response					"无管理员权限或授权条件不满足"
 //	@Failure		404	{object}	response.Response					"用户不存在"
 //	@Failure		500	{object}	response.Response					"服务器内部错误"
 //	@Router			/admin/users/{id} [get]
@@ -99,14 +104,8 @@ func (h *AdminUserHandler) GetUser(c *gin.Context) {
 		zap.Uint("admin_id", adminID),
 		zap.Uint64("user_id", id))
 
-	user, err := h.service.GetUserByID(c.Request.Context(), uint(id))
-	if err != nil {
-		h.logger.Error("Admin failed to get user", zap.Error(err))
-		if err.Error() == "user not found" {
-			response.NotFound(c, err.Error())
-			return
-		}
-		response.InternalError(c, "Failed to get user")
+	user, ok := h.authorizeUser(c, uint(id), "user:read")
+	if !ok {
 		return
 	}
 
@@ -116,7 +115,7 @@ func (h *AdminUserHandler) GetUser(c *gin.Context) {
 // UpdateUserStatus 更新用户状态
 //
 //	@Summary		更新用户状态（后台）
-//	@Description	更新用户的状态（启用/禁用）
+//	@Description	更新用户的状态（启用/禁用），状态变化时该用户已签发的所有 Token 立即失效
 //	@Tags			用户管理
 //	@Accept			json
 //	@Produce		json
@@ -126,7 +125,7 @@ func (h *AdminUserHandler) GetUser(c *gin.Context) {
 //	@Success		200		{object}	response.Response	"更新成功"
 //	@Failure		400		{object}	response.Response	"请求参数错误"
 //	@Failure		401		{object}	response.Response	"未授权"
-//	@Failure		403		{object}	response.Response	"无管理员权限"
+//	@Failure		403		{object}	response.Response	"无管理员权限或授权条件不满足"
 //	@Failure		404		{object}	response.Response	"用户不存在"
 //	@Failure		500		{object}	response.Response	"服务器内部错误"
 //	@Router			/admin/users/{id}/status [put]
@@ -141,7 +140,7 @@ func (h *AdminUserHandler) UpdateUserStatus(c *gin.Context) {
 	}
 
 	var req struct {
-		Status int `json:"status" binding:"required,oneof=0 1"` // 0: 禁用, 1: 启用
+		Status *int `json:"status" binding:"required,oneof=0 1"` // 0: 禁用, 1: 启用（指针类型，否则 required 会拒绝 0）
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.ValidateError(c, err.Error())
@@ -151,21 +150,19 @@ func (h *AdminUserHandler) UpdateUserStatus(c *gin.Context) {
 	h.logger.Info("Admin updating user status",
 		zap.Uint("admin_id", adminID),
 		zap.Uint64("user_id", id),
-		zap.Int("status", req.Status))
+		zap.Int("status", *req.Status))
+
+	if _, ok := h.authorizeUser(c, uint(id), "user:write"); !ok {
+		return
+	}
 
-	user, err := h.service.GetUserByID(c.Request.Context(), uint(id))
+	user, err := h.service.UpdateStatus(c.Request.Context(), uint(id), *req.Status)
 	if err != nil {
+		h.logger.Error("Admin failed to update user status", zap.Error(err))
 		if err.Error() == "user not found" {
 			response.NotFound(c, err.Error())
 			return
 		}
-		response.InternalError(c, "Failed to get user")
-		return
-	}
-
-	user.Status = req.Status
-	if err := h.service.UpdateUser(c.Request.Context(), user); err != nil {
-		h.logger.Error("Admin failed to update user status", zap.Error(err))
 		response.InternalError(c, "Failed to update user status")
 		return
 	}
@@ -185,7 +182,7 @@ func (h *AdminUserHandler) UpdateUserStatus(c *gin.Context) {
 //	@Success		200	{object}	response.Response	"删除成功"
 //	@Failure		400	{object}	response.Response	"无效的用户ID"
 //	@Failure		401	{object}	response.Response	"未授权"
-//	@Failure		403	{object}	response.Response	"无管理员权限"
+//	@Failure		403	{object}	response.Response	"无管理员权限或授权条件不满足"
 //	@Failure		404	{object}	response.Response	"用户不存在"
 //	@Failure		500	{object}	response.Response	"服务器内部错误"
 //	@Router			/admin/users/{id} [delete]
@@ -203,6 +200,10 @@ func (h *AdminUserHandler) DeleteUser(c *gin.Context) {
 		zap.Uint("admin_id", adminID),
 		zap.Uint64("user_id", id))
 
+	if _, ok := h.authorizeUser(c, uint(id), "user:delete"); !ok {
+		return
+	}
+
 	if err := h.service.DeleteUser(c.Request.Context(), uint(id)); err != nil {
 		h.logger.Error("Admin failed to delete user", zap.Error(err))
 		response.InternalError(c, "Failed to delete user")
@@ -212,6 +213,124 @@ func (h *AdminUserHandler) DeleteUser(c *gin.Context) {
 	response.SuccessWithMsg(c, "User deleted successfully", nil)
 }
 
+// RevokeUserTokens 吊销用户的所有 Token
+//
+//	@Summary		吊销用户所有 Token（后台）
+//	@Description	吊销指定用户已签发的所有 Access Token 和 Refresh Token，强制其在所有设备上重新登录
+//	@Tags			用户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int					true	"用户ID"
+//	@Success		200	{object}	response.Response	"吊销成功"
+//	@Failure		400	{object}	response.Response	"无效的用户ID"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		403	{object}	response.Response	"无管理员权限或授权条件不满足"
+//	@Failure		404	{object}	response.Response	"用户不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/users/{id}/revoke-tokens [post]
+func (h *AdminUserHandler) RevokeUserTokens(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	h.logger.Info("Admin revoking user tokens",
+		zap.Uint("admin_id", adminID),
+		zap.Uint64("user_id", id))
+
+	if _, ok := h.authorizeUser(c, uint(id), "user:write"); !ok {
+		return
+	}
+
+	if err := h.service.RevokeUserTokens(c.Request.Context(), uint(id)); err != nil {
+		h.logger.Error("Admin failed to revoke user tokens", zap.Error(err))
+		if err.Error() == "user not found" {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to revoke user tokens")
+		return
+	}
+
+	response.SuccessWithMsg(c, "User tokens revoked successfully", nil)
+}
+
+// UpdateUserAttributes 设置用户自定义属性
+//
+//	@Summary		设置用户自定义属性（后台）
+//	@Description	整体替换用户的自定义属性（例如 region），授权条件中可以通过 subject.<name> 和 resource.<name> 使用。需要无条件的 user:write 授权，不能修改自己的属性
+//	@Tags			用户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int										true	"用户ID"
+//	@Param			request	body		object{attributes=map[string]string}	true	"自定义属性"
+//	@Success		200		{object}	response.Response{data=model.User}		"设置成功"
+//	@Failure		400		{object}	response.Response						"请求参数错误"
+//	@Failure		401		{object}	response.Response						"未授权"
+//	@Failure		403		{object}	response.Response						"无管理员权限、授权带有条件或修改自己的属性"
+//	@Failure		404		{object}	response.Response						"用户不存在"
+//	@Failure		500		{object}	response.Response						"服务器内部错误"
+//	@Router			/admin/users/{id}/attributes [put]
+func (h *AdminUserHandler) UpdateUserAttributes(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Attributes map[string]string `json:"attributes" binding:"max=20,dive,keys,min=1,max=50,endkeys,max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	h.logger.Info("Admin updating user attributes",
+		zap.Uint("admin_id", adminID),
+		zap.Uint64("user_id", id))
+
+	// 自己的属性是授权条件中的 subject 属性，修改后可能扩大自己的权限
+	if uint(id) == adminID {
+		response.Forbidden(c, "Cannot change your own attributes")
+		return
+	}
+
+	target, ok := h.authorizeUser(c, uint(id), "user:write")
+	if !ok {
+		return
+	}
+
+	// 修改后的用户也必须仍然满足授权条件，不能通过修改属性把用户移出条件范围
+	updated := *target
+	updated.Attributes = req.Attributes
+	if !middleware.Authorize(c, h.authz, "user:write", service.UserResource(&updated), h.logger) {
+		return
+	}
+
+	user, err := h.service.UpdateAttributes(c.Request.Context(), uint(id), req.Attributes)
+	if err != nil {
+		h.logger.Error("Admin failed to update user attributes", zap.Error(err))
+		if err.Error() == "user not found" {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to update user attributes")
+		return
+	}
+
+	response.SuccessWithMsg(c, "User attributes updated successfully", user)
+}
+
 // GetStatistics 获取用户统计信息
 //
 //	@Summary		获取用户统计信息（后台）
@@ -245,7 +364,7 @@ func (h *AdminUserHandler) GetStatistics(c *gin.Context) {
 // ResetPassword 重置用户密码
 //
 //	@Summary		重置用户密码（后台）
-//	@Description	管理员重置指定用户的密码
+//	@Description	管理员重置指定用户的密码，该用户已签发的所有 Token 立即失效，并通知用户密码已被重置；目标用户拥有后台角色时只有拥有其全部权限的超级管理员可以重置，操作会记录审计日志
 //	@Tags			用户管理
 //	@Accept			json
 //	@Produce		json
@@ -253,9 +372,9 @@ func (h *AdminUserHandler) GetStatistics(c *gin.Context) {
 //	@Param			id		path		int							true	"用户ID"
 //	@Param			request	body		object{new_password=string}	true	"新密码"
 //	@Success		200		{object}	response.Response			"重置成功"
-//	@Failure		400		{object}	response.Response			"请求参数错误"
+//	@Failure		400		{object}	response.Response			"请求参数错误或新密码不符合密码策略（data 为字段级错误）"
 //	@Failure		401		{object}	response.Response			"未授权"
-//	@Failure		403		{object}	response.Response			"无管理员权限"
+//	@Failure		403		{object}	response.Response			"无管理员权限、授权条件不满足或目标用户权限更高"
 //	@Failure		404		{object}	response.Response			"用户不存在"
 //	@Failure		500		{object}	response.Response			"服务器内部错误"
 //	@Router			/admin/users/{id}/reset-password [post]
@@ -270,7 +389,7 @@ func (h *AdminUserHandler) ResetPassword(c *gin.Context) {
 	}
 
 	var req struct {
-		NewPassword string `json:"new_password" binding:"required,min=6"`
+		NewPassword string `json:"new_password" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.ValidateError(c, err.Error())
@@ -281,12 +400,58 @@ func (h *AdminUserHandler) ResetPassword(c *gin.Context) {
 		zap.Uint("admin_id", adminID),
 		zap.Uint64("user_id", id))
 
-	// TODO: 实现密码重置逻辑
-	// 1. 验证用户存在
-	// 2. 加密新密码
-	// 3. 更新用户密码
-	// 4. 记录操作日志
-	// 5. 可选：发送通知给用户
+	// 授权条件由路由上的 RequireUserAccess 检查
+	if err := h.passwordService.AdminResetPassword(c.Request.Context(), adminID, uint(id), req.NewPassword, c.ClientIP()); err != nil {
+		h.logger.Error("Admin failed to reset user password", zap.Error(err))
+		if err.Error() == "user not found" {
+			response.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrTargetPrivileged) {
+			response.Forbidden(c, "Cannot reset the password of a more privileged user")
+			return
+		}
+		if respondPasswordPolicyError(c, err, "new_password") {
+			return
+		}
+		response.InternalError(c, "Failed to reset password")
+		return
+	}
 
 	response.SuccessWithMsg(c, "Password reset successfully", nil)
 }
+
+// authorizeUser 加载用户并按授权条件检查当前管理员能否执行 permissionCode 对应的操作，
+// 失败时已写入响应，返回 false
+func (h *AdminUserHandler) authorizeUser(c *gin.Context, id uint, permissionCode string) (*model.User, bool) {
+	user, err := h.service.GetUserByID(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Admin failed to get user", zap.Error(err))
+		if err.Error() == "user not found" {
+			response.NotFound(c, err.Error())
+			return nil, false
+		}
+		response.InternalError(c, "Failed to get user")
+		return nil, false
+	}
+
+	if !middleware.Authorize(c, h.authz, permissionCode, service.UserResource(user), h.logger) {
+		return nil, false
+	}
+	return user, true
+}
+
+// respondPasswordPolicyError 密码不符合策略时返回字段级校验错误，field 为请求中的密码字段名
+func respondPasswordPolicyError(c *gin.Context, err error, field string) bool {
+	var policyErr *service.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	fields := make([]response.FieldError, len(policyErr.Violations))
+	for i, violation := range policyErr.Violations {
+		fields[i] = response.FieldError{Field: field, Code: violation.Code, Message: violation.Message}
+	}
+	response.ValidateError(c, service.ErrPasswordPolicy.Error(), fields...)
+	return true
+}
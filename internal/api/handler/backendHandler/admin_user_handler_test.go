@@ -0,0 +1,176 @@
+package backendHandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/model"
+	"trx-project/internal/service"
+	"trx-project/pkg/policy"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeUserService 只实现处理器用到的方法
+type fakeUserService struct {
+	service.UserService
+	users   map[uint]*model.User
+	updated map[uint]map[string]string
+}
+
+func (s *fakeUserService) GetUserByID(ctx context.Context, id uint) (*model.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (s *fakeUserService) ListUsers(ctx context.Context, page, pageSize int) ([]*model.User, int64, error) {
+	users := make([]*model.User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	return users, int64(len(users)), nil
+}
+
+func (s *fakeUserService) UpdateAttributes(ctx context.Context, id uint, attributes map[string]string) (*model.User, error) {
+	s.updated[id] = attributes
+	user := *s.users[id]
+	user.Attributes = attributes
+	return &user, nil
+}
+
+// regionAuthz 模拟授权：unconditional 中的权限无条件授予，其余权限只对 region 相同的用户生效
+type regionAuthz struct {
+	unconditional map[string]bool
+	region        string
+}
+
+func (a regionAuthz) Authorize(ctx context.Context, userID uint, permissionCode string, resource, request policy.Attributes) error {
+	if a.unconditional[permissionCode] || resource["region"] == a.region {
+		return nil
+	}
+	return service.ErrAccessDenied
+}
+
+func (a regionAuthz) AuthorizeUnconditional(ctx context.Context, userID uint, permissionCode string) error {
+	if a.unconditional[permissionCode] {
+		return nil
+	}
+	return service.ErrAccessDenied
+}
+
+func newTestAdminUserRouter(users *fakeUserService, authz service.AuthorizationService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := zap.NewNop()
+	h := NewAdminUserHandler(users, nil, authz, logger)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("admin_id", uint(1)) })
+	r.GET("/admin/users", middleware.RequireUnconditional("user:read", authz, logger), h.ListUsers)
+	r.PUT("/admin/users/:id/attributes", middleware.RequireUnconditional("user:write", authz, logger), h.UpdateUserAttributes)
+	return r
+}
+
+func newTestUsers() *fakeUserService {
+	return &fakeUserService{
+		users: map[uint]*model.User{
+			1:  {ID: 1, Username: "admin", Attributes: map[string]string{"region": "eu"}},
+			42: {ID: 42, Username: "alice", Attributes: map[string]string{"region": "eu"}},
+			43: {ID: 43, Username: "bob", Attributes: map[string]string{"region": "us"}},
+		},
+		updated: map[uint]map[string]string{},
+	}
+}
+
+func TestAdminUserHandler_ListUsersConditional(t *testing.T) {
+	tests := []struct {
+		name  string
+		authz regionAuthz
+		code  int
+	}{
+		{"unconditional grant", regionAuthz{unconditional: map[string]bool{"user:read": true}}, http.StatusOK},
+		// 列表不按条件过滤，只有带条件的授权时不能查看其他区域的用户
+		{"conditional grant only", regionAuthz{region: "eu"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestAdminUserRouter(newTestUsers(), tt.authz)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users", nil))
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
+
+func TestAdminUserHandler_UpdateUserAttributes(t *testing.T) {
+	unconditional := regionAuthz{unconditional: map[string]bool{"user:write": true}}
+	tests := []struct {
+		name    string
+		authz   regionAuthz
+		path    string
+		body    string
+		code    int
+		updated bool
+	}{
+		{"unconditional grant", unconditional, "/admin/users/43/attributes", `{"attributes":{"region":"eu"}}`, http.StatusOK, true},
+		{"own attributes", unconditional, "/admin/users/1/attributes", `{"attributes":{"region":"us"}}`, http.StatusForbidden, false},
+		// 带条件的授权即使旧属性满足条件也不能修改属性
+		{"conditional grant", regionAuthz{region: "eu"}, "/admin/users/42/attributes", `{"attributes":{"region":"eu"}}`, http.StatusForbidden, false},
+		{"unknown user", unconditional, "/admin/users/99/attributes", `{"attributes":{}}`, http.StatusNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newTestUsers()
+			r := newTestAdminUserRouter(users, tt.authz)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.updated, len(users.updated) > 0)
+		})
+	}
+}
+
+func TestAdminUserHandler_UpdateUserAttributesRecheck(t *testing.T) {
+	// 除了要求无条件授权，处理器还会用修改后的属性重新检查授权条件
+	users := newTestUsers()
+	authz := &recheckAuthz{regionAuthz: regionAuthz{region: "eu"}}
+	r := newTestAdminUserRouter(users, authz)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/admin/users/42/attributes", strings.NewReader(`{"attributes":{"region":"us"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	// 修改前 region=eu 满足条件，修改后 region=us 不满足，不能保存
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, users.updated)
+	assert.Equal(t, []any{"eu", "us"}, authz.checked)
+}
+
+// recheckAuthz 允许 AuthorizeUnconditional，记录 Authorize 检查过的资源区域
+type recheckAuthz struct {
+	regionAuthz
+	checked []any
+}
+
+func (a *recheckAuthz) Authorize(ctx context.Context, userID uint, permissionCode string, resource, request policy.Attributes) error {
+	a.checked = append(a.checked, resource["region"])
+	return a.regionAuthz.Authorize(ctx, userID, permissionCode, resource, request)
+}
+
+func (a *recheckAuthz) AuthorizeUnconditional(ctx context.Context, userID uint, permissionCode string) error {
+	return nil
+}
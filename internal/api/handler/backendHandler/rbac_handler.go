@@ -1,6 +1,7 @@
 package backendHandler
 
 import (
+	"errors"
 	"strconv"
 	"trx-project/internal/model"
 	"trx-project/internal/service"
@@ -90,9 +91,9 @@ func (h *RBACHandler) GetRole(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			request	body		object{name=string,display_name=string,description=string}	true	"角色信息"
-//	@Success		201		{object}	response.Response{data=model.Role}							"创建成功"
-//	@Failure		400		{object}	response.Response											"请求参数错误"
+//	@Param			request	body		object{name=string,display_name=string,description=string,parent_id=int}	true	"角色信息，parent_id 为继承权限的上级角色"
+//	@Success		201		{object}	response.Response{data=model.Role}											"创建成功"
+//	@Failure		400		{object}	response.Response															"请求参数错误或上级角色不存在"
 //	@Failure		401		{object}	response.Response											"未授权"
 //	@Failure		403		{object}	response.Response											"无权限"
 //	@Failure		409		{object}	response.Response											"角色名已存在"
@@ -103,6 +104,7 @@ func (h *RBACHandler) CreateRole(c *gin.Context) {
 		Name        string `json:"name" binding:"required"`
 		DisplayName string `json:"display_name" binding:"required"`
 		Description string `json:"description"`
+		ParentID    *uint  `json:"parent_id" binding:"omitempty,min=1"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -114,22 +116,189 @@ func (h *RBACHandler) CreateRole(c *gin.Context) {
 		Name:        req.Name,
 		DisplayName: req.DisplayName,
 		Description: req.Description,
+		ParentID:    req.ParentID,
 		Status:      1,
 	}
 
 	if err := h.rbacService.CreateRole(c.Request.Context(), role); err != nil {
 		h.logger.Error("Failed to create role", zap.Error(err))
-		if err.Error() == "role name already exists" {
-			response.BusinessError(c, response.CodeUserAlreadyExists, err.Error())
-			return
-		}
-		response.InternalError(c, "Failed to create role")
+		respondRBACError(c, err, "Failed to create role")
 		return
 	}
 
 	response.CreatedWithMsg(c, "Role created successfully", role)
 }
 
+// UpdateRole 更新角色
+//
+//	@Summary		更新角色
+//	@Description	更新角色名称、显示名称和描述，只修改请求中提供的字段；内置角色不能改名
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int															true	"角色ID"
+//	@Param			request	body		object{name=string,display_name=string,description=string}	true	"角色信息"
+//	@Success		200		{object}	response.Response{data=model.Role}							"更新成功"
+//	@Failure		400		{object}	response.Response											"请求参数错误"
+//	@Failure		401		{object}	response.Response											"未授权"
+//	@Failure		403		{object}	response.Response											"无权限或内置角色不能改名"
+//	@Failure		404		{object}	response.Response											"角色不存在"
+//	@Failure		500		{object}	response.Response											"服务器内部错误"
+//	@Router			/admin/rbac/roles/{id} [put]
+func (h *RBACHandler) UpdateRole(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid role ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name        *string `json:"name" binding:"omitempty,min=1,max=50"`
+		DisplayName *string `json:"display_name" binding:"omitempty,min=1,max=100"`
+		Description *string `json:"description" binding:"omitempty,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	role, err := h.rbacService.GetRoleByID(c.Request.Context(), id)
+	if err != nil {
+		response.NotFound(c, "Role not found")
+		return
+	}
+	if req.Name != nil {
+		role.Name = *req.Name
+	}
+	if req.DisplayName != nil {
+		role.DisplayName = *req.DisplayName
+	}
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+
+	if err := h.rbacService.UpdateRole(c.Request.Context(), role); err != nil {
+		h.logger.Error("Failed to update role", zap.Uint("role_id", id), zap.Error(err))
+		respondRBACError(c, err, "Failed to update role")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Role updated successfully", role)
+}
+
+// UpdateRoleStatus 启用或禁用角色
+//
+//	@Summary		启用或禁用角色
+//	@Description	禁用的角色不再授予任何权限，拥有该角色的用户权限立即更新；超级管理员角色不能禁用
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int									true	"角色ID"
+//	@Param			request	body		object{status=int}					true	"状态，1-启用 0-禁用"
+//	@Success		200		{object}	response.Response{data=model.Role}	"更新成功"
+//	@Failure		400		{object}	response.Response					"请求参数错误"
+//	@Failure		401		{object}	response.Response					"未授权"
+//	@Failure		403		{object}	response.Response					"无权限或超级管理员角色不能禁用"
+//	@Failure		404		{object}	response.Response					"角色不存在"
+//	@Failure		500		{object}	response.Response					"服务器内部错误"
+//	@Router			/admin/rbac/roles/{id}/status [put]
+func (h *RBACHandler) UpdateRoleStatus(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid role ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status *int `json:"status" binding:"required,oneof=0 1"` // 0: 禁用, 1: 启用（指针类型，否则 required 会拒绝 0）
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	role, err := h.rbacService.UpdateRoleStatus(c.Request.Context(), id, *req.Status)
+	if err != nil {
+		h.logger.Error("Failed to update role status", zap.Uint("role_id", id), zap.Error(err))
+		respondRBACError(c, err, "Failed to update role status")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Role status updated successfully", role)
+}
+
+// SetRoleParent 设置上级角色
+//
+//	@Summary		设置上级角色
+//	@Description	角色继承上级角色及其所有上级角色的权限，parent_id 为 null 时取消继承；上级角色不能是角色本身或它的下级角色，内置角色的上级角色不能修改
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int									true	"角色ID"
+//	@Param			request	body		object{parent_id=int}				true	"上级角色ID"
+//	@Success		200		{object}	response.Response{data=model.Role}	"设置成功"
+//	@Failure		400		{object}	response.Response					"请求参数错误、上级角色不存在或形成环"
+//	@Failure		401		{object}	response.Response					"未授权"
+//	@Failure		403		{object}	response.Response					"无权限或内置角色不能修改"
+//	@Failure		404		{object}	response.Response					"角色不存在"
+//	@Failure		500		{object}	response.Response					"服务器内部错误"
+//	@Router			/admin/rbac/roles/{id}/parent [put]
+func (h *RBACHandler) SetRoleParent(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid role ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ParentID *uint `json:"parent_id" binding:"omitempty,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	role, err := h.rbacService.SetRoleParent(c.Request.Context(), id, req.ParentID)
+	if err != nil {
+		h.logger.Error("Failed to set role parent", zap.Uint("role_id", id), zap.Error(err))
+		respondRBACError(c, err, "Failed to set role parent")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Role parent updated successfully", role)
+}
+
+// DeleteRole 删除角色
+//
+//	@Summary		删除角色
+//	@Description	删除角色，同时移除角色的权限和所有用户的该角色，下级角色改为继承它的上级角色；内置角色不能删除
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int					true	"角色ID"
+//	@Success		200	{object}	response.Response	"删除成功"
+//	@Failure		400	{object}	response.Response	"无效的角色ID"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		403	{object}	response.Response	"无权限或内置角色不能删除"
+//	@Failure		404	{object}	response.Response	"角色不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/rbac/roles/{id} [delete]
+func (h *RBACHandler) DeleteRole(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid role ID")
+	if !ok {
+		return
+	}
+
+	if err := h.rbacService.DeleteRole(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete role", zap.Uint("role_id", id), zap.Error(err))
+		respondRBACError(c, err, "Failed to delete role")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Role deleted successfully", nil)
+}
+
 // AssignPermissionsToRole 为角色分配权限
 //
 //	@Summary		为角色分配权限
@@ -165,13 +334,133 @@ func (h *RBACHandler) AssignPermissionsToRole(c *gin.Context) {
 
 	if err := h.rbacService.AssignPermissionsToRole(c.Request.Context(), uint(roleID), req.PermissionIDs); err != nil {
 		h.logger.Error("Failed to assign permissions to role", zap.Error(err))
-		response.InternalError(c, "Failed to assign permissions")
+		respondRBACError(c, err, "Failed to assign permissions")
 		return
 	}
 
 	response.SuccessWithMsg(c, "Permissions assigned successfully", nil)
 }
 
+// RemovePermissionsFromRole 移除角色的权限
+//
+//	@Summary		移除角色的权限
+//	@Description	从指定角色移除权限列表，拥有该角色的用户权限立即更新
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int								true	"角色ID"
+//	@Param			request	body		object{permission_ids=[]int}	true	"权限ID列表"
+//	@Success		200		{object}	response.Response				"移除成功"
+//	@Failure		400		{object}	response.Response				"请求参数错误"
+//	@Failure		401		{object}	response.Response				"未授权"
+//	@Failure		403		{object}	response.Response				"无权限"
+//	@Failure		404		{object}	response.Response				"角色不存在"
+//	@Failure		500		{object}	response.Response				"服务器内部错误"
+//	@Router			/admin/rbac/roles/{id}/permissions [delete]
+func (h *RBACHandler) RemovePermissionsFromRole(c *gin.Context) {
+	roleID, ok := parseIDParam(c, "id", "Invalid role ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PermissionIDs []uint `json:"permission_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	if err := h.rbacService.RemovePermissionsFromRole(c.Request.Context(), roleID, req.PermissionIDs); err != nil {
+		h.logger.Error("Failed to remove permissions from role", zap.Uint("role_id", roleID), zap.Error(err))
+		respondRBACError(c, err, "Failed to remove permissions")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Permissions removed successfully", nil)
+}
+
+// GetRoleGrants 获取角色的有效授权
+//
+//	@Summary		获取角色的有效授权
+//	@Description	获取角色直接授予和从上级角色继承的每条授权及其条件，role_id 为授予该权限的角色
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int												true	"角色ID"
+//	@Success		200	{object}	response.Response{data=[]model.PermissionGrant}	"成功获取授权"
+//	@Failure		400	{object}	response.Response								"无效的角色ID"
+//	@Failure		401	{object}	response.Response								"未授权"
+//	@Failure		403	{object}	response.Response								"无权限"
+//	@Failure		404	{object}	response.Response								"角色不存在"
+//	@Failure		500	{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/rbac/roles/{id}/grants [get]
+func (h *RBACHandler) GetRoleGrants(c *gin.Context) {
+	roleID, ok := parseIDParam(c, "id", "Invalid role ID")
+	if !ok {
+		return
+	}
+
+	grants, err := h.rbacService.GetRoleGrants(c.Request.Context(), roleID)
+	if err != nil {
+		h.logger.Error("Failed to get role grants", zap.Uint("role_id", roleID), zap.Error(err))
+		respondRBACError(c, err, "Failed to get role grants")
+		return
+	}
+
+	response.Success(c, grants)
+}
+
+// SetGrantCondition 设置授权条件
+//
+//	@Summary		设置授权条件
+//	@Description	设置角色直接授予的权限的条件表达式，例如 resource.created_by == subject.id；condition 为空时取消条件。带条件的授权只在处理器按资源检查时生效，详见 RBAC 指南
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int							true	"角色ID"
+//	@Param			pid		path		int							true	"权限ID"
+//	@Param			request	body		object{condition=string}	true	"条件表达式"
+//	@Success		200		{object}	response.Response			"设置成功"
+//	@Failure		400		{object}	response.Response			"请求参数错误、条件表达式不合法或角色没有直接授予该权限"
+//	@Failure		401		{object}	response.Response			"未授权"
+//	@Failure		403		{object}	response.Response			"无权限"
+//	@Failure		404		{object}	response.Response			"角色或权限不存在"
+//	@Failure		500		{object}	response.Response			"服务器内部错误"
+//	@Router			/admin/rbac/roles/{id}/permissions/{pid}/condition [put]
+func (h *RBACHandler) SetGrantCondition(c *gin.Context) {
+	roleID, ok := parseIDParam(c, "id", "Invalid role ID")
+	if !ok {
+		return
+	}
+	permissionID, ok := parseIDParam(c, "pid", "Invalid permission ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Condition string `json:"condition" binding:"max=1000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	if err := h.rbacService.SetGrantCondition(c.Request.Context(), roleID, permissionID, req.Condition); err != nil {
+		h.logger.Error("Failed to set grant condition",
+			zap.Uint("role_id", roleID),
+			zap.Uint("permission_id", permissionID),
+			zap.Error(err))
+		respondRBACError(c, err, "Failed to set grant condition")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Grant condition updated successfully", nil)
+}
+
 // ListPermissions 获取权限列表
 //
 //	@Summary		获取权限列表
@@ -196,6 +485,224 @@ func (h *RBACHandler) ListPermissions(c *gin.Context) {
 	response.Success(c, permissions)
 }
 
+// GetPermission 获取权限详情
+//
+//	@Summary		获取权限详情
+//	@Description	获取指定权限的详细信息
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int										true	"权限ID"
+//	@Success		200	{object}	response.Response{data=model.Permission}	"成功获取权限详情"
+//	@Failure		400	{object}	response.Response						"无效的权限ID"
+//	@Failure		401	{object}	response.Response						"未授权"
+//	@Failure		403	{object}	response.Response						"无权限"
+//	@Failure		404	{object}	response.Response						"权限不存在"
+//	@Failure		500	{object}	response.Response						"服务器内部错误"
+//	@Router			/admin/rbac/permissions/{id} [get]
+func (h *RBACHandler) GetPermission(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid permission ID")
+	if !ok {
+		return
+	}
+
+	permission, err := h.rbacService.GetPermissionByID(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get permission", zap.Uint("permission_id", id), zap.Error(err))
+		respondRBACError(c, err, "Failed to get permission")
+		return
+	}
+
+	response.Success(c, permission)
+}
+
+// CreatePermission 创建权限
+//
+//	@Summary		创建权限
+//	@Description	创建新的权限，编码格式为 资源:操作；可以使用通配符创建批量授权，如 user:*、*:read
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		object{code=string,name=string,resource=string,action=string,description=string}	true	"权限信息"
+//	@Success		201		{object}	response.Response{data=model.Permission}											"创建成功"
+//	@Failure		400		{object}	response.Response																	"请求参数错误或编码不合法"
+//	@Failure		401		{object}	response.Response																	"未授权"
+//	@Failure		403		{object}	response.Response																	"无权限"
+//	@Failure		409		{object}	response.Response																	"权限编码已存在"
+//	@Failure		500		{object}	response.Response																	"服务器内部错误"
+//	@Router			/admin/rbac/permissions [post]
+func (h *RBACHandler) CreatePermission(c *gin.Context) {
+	var req struct {
+		Code        string `json:"code" binding:"required,max=100"`
+		Name        string `json:"name" binding:"required,max=100"`
+		Resource    string `json:"resource" binding:"required,max=50"`
+		Action      string `json:"action" binding:"required,max=50"`
+		Description string `json:"description" binding:"max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	permission := &model.Permission{
+		Code:        req.Code,
+		Name:        req.Name,
+		Resource:    req.Resource,
+		Action:      req.Action,
+		Description: req.Description,
+		Status:      1,
+	}
+
+	if err := h.rbacService.CreatePermission(c.Request.Context(), permission); err != nil {
+		h.logger.Error("Failed to create permission", zap.Error(err))
+		respondRBACError(c, err, "Failed to create permission")
+		return
+	}
+
+	response.CreatedWithMsg(c, "Permission created successfully", permission)
+}
+
+// UpdatePermission 更新权限
+//
+//	@Summary		更新权限
+//	@Description	更新权限信息，只修改请求中提供的字段；修改编码后代码中按旧编码检查的接口将不再放行
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int																					true	"权限ID"
+//	@Param			request	body		object{code=string,name=string,resource=string,action=string,description=string}	true	"权限信息"
+//	@Success		200		{object}	response.Response{data=model.Permission}											"更新成功"
+//	@Failure		400		{object}	response.Response																	"请求参数错误或编码不合法"
+//	@Failure		401		{object}	response.Response																	"未授权"
+//	@Failure		403		{object}	response.Response																	"无权限"
+//	@Failure		404		{object}	response.Response																	"权限不存在"
+//	@Failure		409		{object}	response.Response																	"权限编码已存在"
+//	@Failure		500		{object}	response.Response																	"服务器内部错误"
+//	@Router			/admin/rbac/permissions/{id} [put]
+func (h *RBACHandler) UpdatePermission(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid permission ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Code        *string `json:"code" binding:"omitempty,min=1,max=100"`
+		Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+		Resource    *string `json:"resource" binding:"omitempty,min=1,max=50"`
+		Action      *string `json:"action" binding:"omitempty,min=1,max=50"`
+		Description *string `json:"description" binding:"omitempty,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	permission, err := h.rbacService.GetPermissionByID(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get permission", zap.Uint("permission_id", id), zap.Error(err))
+		respondRBACError(c, err, "Failed to update permission")
+		return
+	}
+	if req.Code != nil {
+		permission.Code = *req.Code
+	}
+	if req.Name != nil {
+		permission.Name = *req.Name
+	}
+	if req.Resource != nil {
+		permission.Resource = *req.Resource
+	}
+	if req.Action != nil {
+		permission.Action = *req.Action
+	}
+	if req.Description != nil {
+		permission.Description = *req.Description
+	}
+
+	if err := h.rbacService.UpdatePermission(c.Request.Context(), permission); err != nil {
+		h.logger.Error("Failed to update permission", zap.Uint("permission_id", id), zap.Error(err))
+		respondRBACError(c, err, "Failed to update permission")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Permission updated successfully", permission)
+}
+
+// UpdatePermissionStatus 启用或禁用权限
+//
+//	@Summary		启用或禁用权限
+//	@Description	禁用的权限不再授予任何用户，拥有该权限的用户权限立即更新
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int											true	"权限ID"
+//	@Param			request	body		object{status=int}							true	"状态，1-启用 0-禁用"
+//	@Success		200		{object}	response.Response{data=model.Permission}	"更新成功"
+//	@Failure		400		{object}	response.Response							"请求参数错误"
+//	@Failure		401		{object}	response.Response							"未授权"
+//	@Failure		403		{object}	response.Response							"无权限"
+//	@Failure		404		{object}	response.Response							"权限不存在"
+//	@Failure		500		{object}	response.Response							"服务器内部错误"
+//	@Router			/admin/rbac/permissions/{id}/status [put]
+func (h *RBACHandler) UpdatePermissionStatus(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid permission ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status *int `json:"status" binding:"required,oneof=0 1"` // 0: 禁用, 1: 启用（指针类型，否则 required 会拒绝 0）
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	permission, err := h.rbacService.UpdatePermissionStatus(c.Request.Context(), id, *req.Status)
+	if err != nil {
+		h.logger.Error("Failed to update permission status", zap.Uint("permission_id", id), zap.Error(err))
+		respondRBACError(c, err, "Failed to update permission status")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Permission status updated successfully", permission)
+}
+
+// DeletePermission 删除权限
+//
+//	@Summary		删除权限
+//	@Description	删除权限并从所有角色中移除，拥有该权限的用户权限立即更新
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int					true	"权限ID"
+//	@Success		200	{object}	response.Response	"删除成功"
+//	@Failure		400	{object}	response.Response	"无效的权限ID"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		403	{object}	response.Response	"无权限"
+//	@Failure		404	{object}	response.Response	"权限不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/rbac/permissions/{id} [delete]
+func (h *RBACHandler) DeletePermission(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid permission ID")
+	if !ok {
+		return
+	}
+
+	if err := h.rbacService.DeletePermission(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete permission", zap.Uint("permission_id", id), zap.Error(err))
+		respondRBACError(c, err, "Failed to delete permission")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Permission deleted successfully", nil)
+}
+
 // AssignRoleToUser 为用户分配角色
 //
 //	@Summary		为用户分配角色
@@ -231,13 +738,51 @@ func (h *RBACHandler) AssignRoleToUser(c *gin.Context) {
 
 	if err := h.rbacService.AssignRoleToUser(c.Request.Context(), uint(userID), req.RoleID); err != nil {
 		h.logger.Error("Failed to assign role to user", zap.Error(err))
-		response.InternalError(c, "Failed to assign role")
+		respondRBACError(c, err, "Failed to assign role")
 		return
 	}
 
 	response.SuccessWithMsg(c, "Role assigned successfully", nil)
 }
 
+// RemoveRoleFromUser 撤销用户的角色
+//
+//	@Summary		撤销用户角色
+//	@Description	撤销指定用户的角色，用户权限立即更新
+//	@Tags			RBAC管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int					true	"用户ID"
+//	@Param			rid		path		int					true	"角色ID"
+//	@Success		200		{object}	response.Response	"撤销成功"
+//	@Failure		400		{object}	response.Response	"无效的用户ID或角色ID"
+//	@Failure		401		{object}	response.Response	"未授权"
+//	@Failure		403		{object}	response.Response	"无权限"
+//	@Failure		500		{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/users/{id}/roles/{rid} [delete]
+func (h *RBACHandler) RemoveRoleFromUser(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id", "Invalid user ID")
+	if !ok {
+		return
+	}
+	roleID, ok := parseIDParam(c, "rid", "Invalid role ID")
+	if !ok {
+		return
+	}
+
+	if err := h.rbacService.RemoveRoleFromUser(c.Request.Context(), userID, roleID); err != nil {
+		h.logger.Error("Failed to remove role from user",
+			zap.Uint("user_id", userID),
+			zap.Uint("role_id", roleID),
+			zap.Error(err))
+		respondRBACError(c, err, "Failed to remove role")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Role removed successfully", nil)
+}
+
 // GetUserRoles 获取用户的角色列表
 //
 //	@Summary		获取用户角色
@@ -303,3 +848,32 @@ func (h *RBACHandler) GetUserPermissions(c *gin.Context) {
 
 	response.Success(c, permissions)
 }
+
+// parseIDParam 解析路径中的 ID 参数，无效时返回 400
+func parseIDParam(c *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		response.BadRequest(c, message)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondRBACError 根据 RBAC 错误类型返回响应
+func respondRBACError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrRoleNotFound), errors.Is(err, service.ErrPermissionNotFound),
+		errors.Is(err, service.ErrRoleUserNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, service.ErrRoleNameExists), errors.Is(err, service.ErrPermissionCodeExists):
+		response.BusinessError(c, response.CodeRecordExists, err.Error())
+	case errors.Is(err, service.ErrParentRoleNotFound), errors.Is(err, service.ErrRoleHierarchyCycle),
+		errors.Is(err, service.ErrPermissionCodeInvalid), errors.Is(err, service.ErrGrantNotFound),
+		errors.Is(err, service.ErrGrantConditionInvalid):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrBuiltinRoleProtected):
+		response.Forbidden(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
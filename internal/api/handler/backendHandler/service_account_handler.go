@@ -0,0 +1,268 @@
+package backendHandler
+
+import (
+	"errors"
+	"strconv"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ServiceAccountHandler 服务账号管理处理器
+type ServiceAccountHandler struct {
+	service service.ServiceAccountService
+	logger  *zap.Logger
+}
+
+// NewServiceAccountHandler 创建服务账号管理处理器
+func NewServiceAccountHandler(service service.ServiceAccountService, logger *zap.Logger) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateServiceAccountRequest 创建服务账号请求
+type CreateServiceAccountRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50" example:"svc-report"` // 用户名，3-50个字符
+}
+
+// ListServiceAccounts 获取服务账号列表
+//
+//	@Summary		获取服务账号列表
+//	@Description	列出所有服务账号，服务账号的角色通过 POST /admin/users/{id}/role 分配
+//	@Tags			服务账号
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=[]model.User}	"成功获取服务账号列表"
+//	@Failure		401	{object}	response.Response						"未授权"
+//	@Failure		403	{object}	response.Response						"无权限"
+//	@Failure		500	{object}	response.Response						"服务器内部错误"
+//	@Router			/admin/service-accounts [get]
+func (h *ServiceAccountHandler) ListServiceAccounts(c *gin.Context) {
+	accounts, err := h.service.ListServiceAccounts(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, "Failed to list service accounts")
+		return
+	}
+
+	response.Success(c, accounts)
+}
+
+// CreateServiceAccount 创建服务账号
+//
+//	@Summary		创建服务账号
+//	@Description	创建没有密码、不能登录的服务账号，只能通过 API Key 访问；操作会记录审计日志
+//	@Tags			服务账号
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreateServiceAccountRequest			true	"服务账号信息"
+//	@Success		201		{object}	response.Response{data=model.User}	"创建成功"
+//	@Failure		400		{object}	response.Response					"请求参数错误"
+//	@Failure		401		{object}	response.Response					"未授权"
+//	@Failure		403		{object}	response.Response					"无权限"
+//	@Failure		500		{object}	response.Response					"服务器内部错误"
+//	@Router			/admin/service-accounts [post]
+func (h *ServiceAccountHandler) CreateServiceAccount(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	var req CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	account, err := h.service.CreateServiceAccount(c.Request.Context(), adminID, req.Username, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrServiceAccountExists) {
+			response.BusinessError(c, response.CodeUserAlreadyExists, err.Error())
+			return
+		}
+		h.logger.Error("Failed to create service account", zap.Uint("admin_id", adminID), zap.Error(err))
+		response.InternalError(c, "Failed to create service account")
+		return
+	}
+
+	response.CreatedWithMsg(c, "Service account created successfully", account)
+}
+
+// DeleteServiceAccount 删除服务账号
+//
+//	@Summary		删除服务账号
+//	@Description	删除服务账号并吊销其所有 API Key；操作会记录审计日志
+//	@Tags			服务账号
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int					true	"服务账号ID"
+//	@Success		200	{object}	response.Response	"删除成功"
+//	@Failure		400	{object}	response.Response	"无效的服务账号ID"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		403	{object}	response.Response	"无权限"
+//	@Failure		404	{object}	response.Response	"服务账号不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/service-accounts/{id} [delete]
+func (h *ServiceAccountHandler) DeleteServiceAccount(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid service account ID")
+		return
+	}
+
+	if err := h.service.DeleteServiceAccount(c.Request.Context(), adminID, uint(id), c.ClientIP()); err != nil {
+		if errors.Is(err, service.ErrServiceAccountNotFound) {
+			response.NotFound(c, "Service account not found")
+			return
+		}
+		h.logger.Error("Failed to delete service account", zap.Uint("admin_id", adminID), zap.Error(err))
+		response.InternalError(c, "Failed to delete service account")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Service account deleted successfully", nil)
+}
+
+// ListAPIKeys 获取服务账号的 API Key
+//
+//	@Summary		获取服务账号的 API Key
+//	@Description	列出服务账号的所有 API Key（不包含明文），包括已吊销和已过期的
+//	@Tags			服务账号
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int										true	"服务账号ID"
+//	@Success		200	{object}	response.Response{data=[]model.APIKey}	"成功获取 API Key 列表"
+//	@Failure		400	{object}	response.Response						"无效的服务账号ID"
+//	@Failure		401	{object}	response.Response						"未授权"
+//	@Failure		403	{object}	response.Response						"无权限"
+//	@Failure		404	{object}	response.Response						"服务账号不存在"
+//	@Failure		500	{object}	response.Response						"服务器内部错误"
+//	@Router			/admin/service-accounts/{id}/api-keys [get]
+func (h *ServiceAccountHandler) ListAPIKeys(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid service account ID")
+		return
+	}
+
+	keys, err := h.service.ListKeys(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrServiceAccountNotFound) {
+			response.NotFound(c, "Service account not found")
+			return
+		}
+		response.InternalError(c, "Failed to list API keys")
+		return
+	}
+
+	response.Success(c, keys)
+}
+
+// CreateAPIKey 为服务账号创建 API Key
+//
+//	@Summary		为服务账号创建 API Key
+//	@Description	为服务账号创建 API Key，权限为 scopes 与服务账号角色权限的交集。明文只在本次响应中返回；操作会记录审计日志
+//	@Tags			服务账号
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int												true	"服务账号ID"
+//	@Param			request	body		CreateAPIKeyRequest								true	"API Key 信息"
+//	@Success		201		{object}	response.Response{data=service.CreatedAPIKey}	"创建成功"
+//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		401		{object}	response.Response								"未授权"
+//	@Failure		403		{object}	response.Response								"无权限"
+//	@Failure		404		{object}	response.Response								"服务账号不存在"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/admin/service-accounts/{id}/api-keys [post]
+func (h *ServiceAccountHandler) CreateAPIKey(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid service account ID")
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	key, err := h.service.CreateKey(c.Request.Context(), adminID, uint(id), req.input(), c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrServiceAccountNotFound):
+			response.NotFound(c, "Service account not found")
+		case errors.Is(err, service.ErrAPIKeyScopeInvalid), errors.Is(err, service.ErrAPIKeyTTLInvalid):
+			response.BadRequest(c, err.Error())
+		default:
+			h.logger.Error("Failed to create API key",
+				zap.Uint("admin_id", adminID),
+				zap.Uint64("account_id", id),
+				zap.Error(err))
+			response.InternalError(c, "Failed to create API key")
+		}
+		return
+	}
+
+	response.CreatedWithMsg(c, "API key created successfully, store it now as it will not be shown again", key)
+}
+
+// RevokeAPIKey 吊销服务账号的 API Key
+//
+//	@Summary		吊销服务账号的 API Key
+//	@Description	吊销服务账号的 API Key，立即生效；操作会记录审计日志
+//	@Tags			服务账号
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int					true	"服务账号ID"
+//	@Param			key_id	path		int					true	"API Key ID"
+//	@Success		200		{object}	response.Response	"吊销成功"
+//	@Failure		400		{object}	response.Response	"无效的ID"
+//	@Failure		401		{object}	response.Response	"未授权"
+//	@Failure		403		{object}	response.Response	"无权限"
+//	@Failure		404		{object}	response.Response	"服务账号或 API Key 不存在"
+//	@Failure		500		{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/service-accounts/{id}/api-keys/{key_id} [delete]
+func (h *ServiceAccountHandler) RevokeAPIKey(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid service account ID")
+		return
+	}
+	keyID, err := strconv.ParseUint(c.Param("key_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid API key ID")
+		return
+	}
+
+	if err := h.service.RevokeKey(c.Request.Context(), adminID, uint(id), uint(keyID), c.ClientIP()); err != nil {
+		switch {
+		case errors.Is(err, service.ErrServiceAccountNotFound):
+			response.NotFound(c, "Service account not found")
+		case errors.Is(err, service.ErrAPIKeyNotFound):
+			response.NotFound(c, "API key not found")
+		default:
+			h.logger.Error("Failed to revoke API key",
+				zap.Uint("admin_id", adminID),
+				zap.Uint64("account_id", id),
+				zap.Error(err))
+			response.InternalError(c, "Failed to revoke API key")
+		}
+		return
+	}
+
+	response.SuccessWithMsg(c, "API key revoked successfully", nil)
+}
@@ -0,0 +1,255 @@
+package backendHandler
+
+import (
+	"errors"
+	"strconv"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TenantHandler 租户管理处理器，所有接口只有平台管理员可以访问
+type TenantHandler struct {
+	service service.TenantService
+	logger  *zap.Logger
+}
+
+// NewTenantHandler 创建租户管理处理器
+func NewTenantHandler(service service.TenantService, logger *zap.Logger) *TenantHandler {
+	return &TenantHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateTenantRequest 创建租户请求
+type CreateTenantRequest struct {
+	Name        string `json:"name" binding:"required,alphanum,max=50" example:"acme"`      // 租户标识，字母和数字，创建后不能修改
+	DisplayName string `json:"display_name" binding:"required,max=100" example:"Acme 有限公司"` // 显示名称
+}
+
+// UpdateTenantRequest 修改租户请求
+type UpdateTenantRequest struct {
+	DisplayName string `json:"display_name" binding:"required,max=100" example:"Acme 有限公司"` // 显示名称
+}
+
+// MoveUserTenantRequest 移动用户到其他租户请求
+type MoveUserTenantRequest struct {
+	TenantID uint `json:"tenant_id" binding:"required,min=1" example:"2"` // 目标租户ID
+}
+
+// ListTenants 获取租户列表
+//
+//	@Summary		获取租户列表
+//	@Description	列出所有租户，只有平台管理员可以访问
+//	@Tags			租户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=[]model.Tenant}	"成功获取租户列表"
+//	@Failure		401	{object}	response.Response						"未授权"
+//	@Failure		403	{object}	response.Response						"不是平台管理员"
+//	@Failure		500	{object}	response.Response						"服务器内部错误"
+//	@Router			/admin/tenants [get]
+func (h *TenantHandler) ListTenants(c *gin.Context) {
+	tenants, err := h.service.ListTenants(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, "Failed to list tenants")
+		return
+	}
+
+	response.Success(c, tenants)
+}
+
+// GetTenant 获取租户详情
+//
+//	@Summary		获取租户详情
+//	@Description	获取指定租户的详细信息
+//	@Tags			租户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int										true	"租户ID"
+//	@Success		200	{object}	response.Response{data=model.Tenant}	"成功获取租户详情"
+//	@Failure		400	{object}	response.Response						"无效的租户ID"
+//	@Failure		401	{object}	response.Response						"未授权"
+//	@Failure		403	{object}	response.Response						"不是平台管理员"
+//	@Failure		404	{object}	response.Response						"租户不存在"
+//	@Failure		500	{object}	response.Response						"服务器内部错误"
+//	@Router			/admin/tenants/{id} [get]
+func (h *TenantHandler) GetTenant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID")
+		return
+	}
+
+	t, err := h.service.GetTenant(c.Request.Context(), uint(id))
+	if err != nil {
+		respondTenantError(c, err, "Failed to get tenant")
+		return
+	}
+
+	response.Success(c, t)
+}
+
+// CreateTenant 创建租户
+//
+//	@Summary		创建租户
+//	@Description	创建新的租户，用户通过 PUT /admin/users/{id}/tenant 移入租户
+//	@Tags			租户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreateTenantRequest						true	"租户信息"
+//	@Success		201		{object}	response.Response{data=model.Tenant}	"创建成功"
+//	@Failure		400		{object}	response.Response						"请求参数错误"
+//	@Failure		401		{object}	response.Response						"未授权"
+//	@Failure		403		{object}	response.Response						"不是平台管理员"
+//	@Failure		409		{object}	response.Response						"租户标识已存在"
+//	@Failure		500		{object}	response.Response						"服务器内部错误"
+//	@Router			/admin/tenants [post]
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+	var req CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	t, err := h.service.CreateTenant(c.Request.Context(), req.Name, req.DisplayName)
+	if err != nil {
+		respondTenantError(c, err, "Failed to create tenant")
+		return
+	}
+
+	response.CreatedWithMsg(c, "Tenant created successfully", t)
+}
+
+// UpdateTenant 修改租户
+//
+//	@Summary		修改租户
+//	@Description	修改租户的显示名称，租户标识不能修改
+//	@Tags			租户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int										true	"租户ID"
+//	@Param			request	body		UpdateTenantRequest						true	"租户信息"
+//	@Success		200		{object}	response.Response{data=model.Tenant}	"修改成功"
+//	@Failure		400		{object}	response.Response						"请求参数错误"
+//	@Failure		401		{object}	response.Response						"未授权"
+//	@Failure		403		{object}	response.Response						"不是平台管理员"
+//	@Failure		404		{object}	response.Response						"租户不存在"
+//	@Failure		500		{object}	response.Response						"服务器内部错误"
+//	@Router			/admin/tenants/{id} [put]
+func (h *TenantHandler) UpdateTenant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID")
+		return
+	}
+
+	var req UpdateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	t, err := h.service.UpdateTenant(c.Request.Context(), uint(id), req.DisplayName)
+	if err != nil {
+		respondTenantError(c, err, "Failed to update tenant")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Tenant updated successfully", t)
+}
+
+// DeleteTenant 删除租户
+//
+//	@Summary		删除租户
+//	@Description	删除没有用户的租户，平台租户不能删除
+//	@Tags			租户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int					true	"租户ID"
+//	@Success		200	{object}	response.Response	"删除成功"
+//	@Failure		400	{object}	response.Response	"无效的租户ID或租户下还有用户"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		403	{object}	response.Response	"不是平台管理员或平台租户不能删除"
+//	@Failure		404	{object}	response.Response	"租户不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/admin/tenants/{id} [delete]
+func (h *TenantHandler) DeleteTenant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid tenant ID")
+		return
+	}
+
+	if err := h.service.DeleteTenant(c.Request.Context(), uint(id)); err != nil {
+		respondTenantError(c, err, "Failed to delete tenant")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Tenant deleted successfully", nil)
+}
+
+// MoveUser 移动用户到其他租户
+//
+//	@Summary		移动用户到其他租户
+//	@Description	将用户移到另一个租户，移除用户在原租户的所有角色并吊销其所有 Token；操作会记录审计日志
+//	@Tags			租户管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int						true	"用户ID"
+//	@Param			request	body		MoveUserTenantRequest	true	"目标租户"
+//	@Success		200		{object}	response.Response		"移动成功"
+//	@Failure		400		{object}	response.Response		"请求参数错误"
+//	@Failure		401		{object}	response.Response		"未授权"
+//	@Failure		403		{object}	response.Response		"不是平台管理员"
+//	@Failure		404		{object}	response.Response		"用户或租户不存在"
+//	@Failure		500		{object}	response.Response		"服务器内部错误"
+//	@Router			/admin/users/{id}/tenant [put]
+func (h *TenantHandler) MoveUser(c *gin.Context) {
+	adminID, _ := middleware.GetAdminID(c)
+
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req MoveUserTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	if err := h.service.MoveUser(c.Request.Context(), adminID, uint(userID), req.TenantID, c.ClientIP()); err != nil {
+		respondTenantError(c, err, "Failed to move user")
+		return
+	}
+
+	response.SuccessWithMsg(c, "User moved successfully", nil)
+}
+
+// respondTenantError 将租户服务的错误转换为对应的响应
+func respondTenantError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrTenantNotFound), errors.Is(err, service.ErrTenantUserNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, service.ErrTenantNameExists):
+		response.BusinessError(c, response.CodeRecordExists, err.Error())
+	case errors.Is(err, service.ErrTenantNotEmpty):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrPlatformTenantProtected):
+		response.Forbidden(c, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
@@ -0,0 +1,151 @@
+package frontendHandler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// APIKeyHandler 个人 API Key 处理器
+type APIKeyHandler struct {
+	service service.APIKeyService
+	logger  *zap.Logger
+}
+
+// NewAPIKeyHandler 创建个人 API Key 处理器
+func NewAPIKeyHandler(service service.APIKeyService, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateAPIKeyRequest 创建 API Key 请求
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100" example:"my-script"`   // 名称
+	Scopes        []string `json:"scopes" example:"user:read"`                            // 允许使用的权限编码，只在需要权限的接口上生效
+	ExpiresInDays int      `json:"expires_in_days" binding:"required,min=1" example:"90"` // 有效期（天），不超过 auth.api_key_max_ttl_days
+}
+
+// ListAPIKeys 获取 API Key 列表
+//
+//	@Summary		获取 API Key 列表
+//	@Description	列出当前用户的所有 API Key（不包含明文），包括已吊销和已过期的
+//	@Tags			用户接口
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=[]model.APIKey}	"成功获取 API Key 列表"
+//	@Failure		401	{object}	response.Response						"未授权"
+//	@Failure		403	{object}	response.Response						"不能使用 API Key 访问"
+//	@Failure		500	{object}	response.Response						"服务器内部错误"
+//	@Router			/user/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	keys, err := h.service.ListKeys(c.Request.Context(), userID)
+	if err != nil {
+		response.InternalError(c, "Failed to list API keys")
+		return
+	}
+
+	response.Success(c, keys)
+}
+
+// CreateAPIKey 创建 API Key
+//
+//	@Summary		创建 API Key
+//	@Description	创建个人 API Key，请求时使用 Authorization: ApiKey <key>。明文只在本次响应中返回，请妥善保存
+//	@Tags			用户接口
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreateAPIKeyRequest								true	"API Key 信息"
+//	@Success		201		{object}	response.Response{data=service.CreatedAPIKey}	"创建成功"
+//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		401		{object}	response.Response								"未授权"
+//	@Failure		403		{object}	response.Response								"不能使用 API Key 访问"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/user/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	key, err := h.service.CreateKey(c.Request.Context(), userID, userID, service.CreateAPIKeyInput{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresIn: time.Duration(req.ExpiresInDays) * 24 * time.Hour,
+	}, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyScopeInvalid) || errors.Is(err, service.ErrAPIKeyTTLInvalid) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to create API key", zap.Uint("user_id", userID), zap.Error(err))
+		response.InternalError(c, "Failed to create API key")
+		return
+	}
+
+	response.CreatedWithMsg(c, "API key created successfully, store it now as it will not be shown again", key)
+}
+
+// RevokeAPIKey 吊销 API Key
+//
+//	@Summary		吊销 API Key
+//	@Description	吊销当前用户的 API Key，立即生效
+//	@Tags			用户接口
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int					true	"API Key ID"
+//	@Success		200	{object}	response.Response	"吊销成功"
+//	@Failure		400	{object}	response.Response	"无效的 API Key ID"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		403	{object}	response.Response	"不能使用 API Key 访问"
+//	@Failure		404	{object}	response.Response	"API Key 不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/user/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid API key ID")
+		return
+	}
+
+	if err := h.service.RevokeKey(c.Request.Context(), userID, userID, uint(id), c.ClientIP()); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			response.NotFound(c, "API key not found")
+			return
+		}
+		h.logger.Error("Failed to revoke API key", zap.Uint("user_id", userID), zap.Error(err))
+		response.InternalError(c, "Failed to revoke API key")
+		return
+	}
+
+	response.SuccessWithMsg(c, "API key revoked successfully", nil)
+}
@@ -0,0 +1,95 @@
+package frontendHandler
+
+import (
+	"errors"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EmailVerificationHandler 邮箱验证处理器
+type EmailVerificationHandler struct {
+	service service.EmailVerificationService
+	logger  *zap.Logger
+}
+
+// NewEmailVerificationHandler 创建邮箱验证处理器
+func NewEmailVerificationHandler(service service.EmailVerificationService, logger *zap.Logger) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// VerifyEmailRequest 验证邮箱请求
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"` // 验证链接中的 token
+}
+
+// ResendVerificationRequest 重新发送验证邮件请求
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email" example:"test@example.com"` // 注册邮箱
+}
+
+// VerifyEmail 验证邮箱
+//
+//	@Summary		验证邮箱
+//	@Description	使用验证链接中的 token 完成邮箱验证，重复验证同一链接返回成功
+//	@Tags			公开接口
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		VerifyEmailRequest					true	"验证链接中的 token"
+//	@Success		200		{object}	response.Response{data=model.User}	"验证成功，返回用户信息"
+//	@Failure		400		{object}	response.Response					"请求参数错误或 token 无效"
+//	@Failure		500		{object}	response.Response					"服务器内部错误"
+//	@Router			/public/email/verify [post]
+func (h *EmailVerificationHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	user, err := h.service.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		h.logger.Warn("Failed to verify email", zap.Error(err))
+		if errors.Is(err, service.ErrEmailVerificationTokenInvalid) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to verify email")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Email verified successfully", user)
+}
+
+// ResendVerification 重新发送验证邮件
+//
+//	@Summary		重新发送验证邮件
+//	@Description	向注册邮箱重新发送验证链接；邮箱不存在、已验证或两次发送间隔过短（不发送邮件）时同样返回成功，避免泄露账号信息
+//	@Tags			公开接口
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ResendVerificationRequest	true	"注册邮箱"
+//	@Success		200		{object}	response.Response			"已受理"
+//	@Failure		400		{object}	response.Response			"请求参数错误"
+//	@Failure		500		{object}	response.Response			"服务器内部错误"
+//	@Router			/public/email/resend [post]
+func (h *EmailVerificationHandler) ResendVerification(c *gin.Context) {
+	var req ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	if err := h.service.ResendVerification(c.Request.Context(), req.Email); err != nil {
+		h.logger.Error("Failed to resend email verification", zap.Error(err))
+		response.InternalError(c, "Failed to resend email verification")
+		return
+	}
+
+	response.SuccessWithMsg(c, "If the email is registered and not yet verified, a verification link has been sent", nil)
+}
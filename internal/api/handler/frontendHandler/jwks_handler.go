@@ -0,0 +1,28 @@
+package frontendHandler
+
+import (
+	"net/http"
+	"trx-project/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler JWKS 公钥发布处理器
+type JWKSHandler struct {
+	keys *jwt.KeySet
+}
+
+// NewJWKSHandler 创建 JWKS 处理器
+func NewJWKSHandler(jwtConfig jwt.Config) *JWKSHandler {
+	return &JWKSHandler{
+		keys: jwtConfig.KeySet(),
+	}
+}
+
+// GetJWKS 获取 JWT 验证公钥（RFC 7517）
+// 包括即将启用和仍在轮换重叠窗口内的密钥，使用 HS256 时返回空集合
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// JWKS 是标准格式，不使用统一响应包装
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,119 @@
+package frontendHandler
+
+import (
+	"errors"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MagicLinkHandler 免密登录处理器
+type MagicLinkHandler struct {
+	service service.MagicLinkService
+	cookies *middleware.SessionCookies
+	logger  *zap.Logger
+}
+
+// NewMagicLinkHandler 创建免密登录处理器
+func NewMagicLinkHandler(service service.MagicLinkService, cookies *middleware.SessionCookies, logger *zap.Logger) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		service: service,
+		cookies: cookies,
+		logger:  logger,
+	}
+}
+
+// MagicLinkRequest 申请免密登录链接请求
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email" example:"test@example.com"` // 注册邮箱
+}
+
+// MagicLinkLoginRequest 使用免密登录链接请求
+type MagicLinkLoginRequest struct {
+	Token string `json:"token" binding:"required"` // 登录链接中的 token
+	Nonce string `json:"nonce" binding:"required"` // 申请链接时返回的 nonce
+}
+
+// RequestMagicLink 申请免密登录链接
+//
+//	@Summary		申请免密登录链接
+//	@Description	向注册邮箱发送一次性登录链接，返回的 nonce 需要由当前浏览器保存，使用链接时一并提交；邮箱不存在或同一邮箱申请过于频繁（不发送邮件）时同样返回成功，避免泄露账号信息
+//	@Tags			公开接口
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		MagicLinkRequest								true	"注册邮箱"
+//	@Success		200		{object}	response.Response{data=map[string]interface{}}	"已受理，返回 nonce"
+//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/public/login/magic-link [post]
+func (h *MagicLinkHandler) RequestMagicLink(c *gin.Context) {
+	var req MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	nonce, err := h.service.RequestLink(c.Request.Context(), req.Email, c.ClientIP())
+	if err != nil {
+		h.logger.Error("Failed to request magic link", zap.Error(err))
+		response.InternalError(c, "Failed to request magic link")
+		return
+	}
+
+	response.SuccessWithMsg(c, "If the email is registered, a sign-in link has been sent", gin.H{
+		"nonce": nonce,
+	})
+}
+
+// LoginWithMagicLink 使用免密登录链接登录
+//
+//	@Summary		使用免密登录链接登录
+//	@Description	提交登录链接中的 token 和申请时返回的 nonce，成功后返回用户信息和 JWT Token；链接只能使用一次。启用了两步验证时返回 mfa_required 和 mfa_token，需要调用 /public/login/mfa 完成登录
+//	@Tags			公开接口
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		MagicLinkLoginRequest							true	"token 和 nonce"
+//	@Success		200		{object}	response.Response{data=map[string]interface{}}	"登录成功，返回用户信息和 Token"
+//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		401		{object}	response.Response								"链接无效、已过期、已使用或不是由当前浏览器申请"
+//	@Failure		403		{object}	response.Response								"账号已被禁用"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/public/login/magic-link/verify [post]
+func (h *MagicLinkHandler) LoginWithMagicLink(c *gin.Context) {
+	var req MagicLinkLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	result, err := h.service.Login(c.Request.Context(), req.Token, req.Nonce)
+	if err != nil {
+		h.logger.Warn("Failed to login with magic link", zap.Error(err))
+		if errors.Is(err, service.ErrMagicLinkInvalid) {
+			response.Unauthorized(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrUserInactive) {
+			response.BusinessError(c, response.CodeUserDisabled, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to login")
+		return
+	}
+
+	if result.MFA != nil {
+		response.SuccessWithMsg(c, "MFA verification required", gin.H{
+			"mfa_required":        true,
+			"mfa_token":           result.MFA.Token,
+			"expires_in":          result.MFA.ExpiresIn,
+			"enrollment_required": result.MFA.EnrollmentRequired,
+		})
+		return
+	}
+
+	h.cookies.SetTokens(c, result.Tokens)
+	response.SuccessWithMsg(c, "Login successful", loginResponse(result))
+}
@@ -0,0 +1,223 @@
+package frontendHandler
+
+import (
+	"errors"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MFAHandler 用户两步验证处理器
+type MFAHandler struct {
+	service service.MFAService
+	logger  *zap.Logger
+}
+
+// NewMFAHandler 创建用户两步验证处理器
+func NewMFAHandler(service service.MFAService, logger *zap.Logger) *MFAHandler {
+	return &MFAHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// MFACodeRequest 两步验证码请求
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"` // 验证器 App 中的 6 位验证码或恢复码
+}
+
+// GetStatus 获取两步验证状态
+//
+//	@Summary		获取两步验证状态
+//	@Description	获取当前用户是否已启用两步验证、角色是否要求启用以及剩余恢复码数量
+//	@Tags			两步验证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=service.MFAStatus}	"成功获取状态"
+//	@Failure		401	{object}	response.Response							"未授权"
+//	@Failure		500	{object}	response.Response							"服务器内部错误"
+//	@Router			/user/mfa [get]
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	status, err := h.service.GetStatus(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get mfa status", zap.Uint("user_id", userID), zap.Error(err))
+		response.InternalError(c, "Failed to get mfa status")
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// BeginEnrollment 开始绑定两步验证
+//
+//	@Summary		开始绑定两步验证
+//	@Description	生成新的 TOTP 密钥，返回 otpauth 链接用于生成二维码；调用确认接口前不会生效
+//	@Tags			两步验证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=service.MFAEnrollment}	"返回密钥和 otpauth 链接"
+//	@Failure		400	{object}	response.Response								"已启用两步验证"
+//	@Failure		401	{object}	response.Response								"未授权"
+//	@Failure		500	{object}	response.Response								"服务器内部错误"
+//	@Router			/user/mfa/enroll [post]
+func (h *MFAHandler) BeginEnrollment(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	enrollment, err := h.service.BeginEnrollment(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Warn("Failed to begin mfa enrollment", zap.Uint("user_id", userID), zap.Error(err))
+		respondMFAError(c, err, "Failed to begin mfa enrollment")
+		return
+	}
+
+	response.Success(c, enrollment)
+}
+
+// ConfirmEnrollment 确认绑定两步验证
+//
+//	@Summary		确认绑定两步验证
+//	@Description	校验验证器 App 中的验证码后启用两步验证，返回的恢复码只展示这一次
+//	@Tags			两步验证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		MFACodeRequest									true	"验证码"
+//	@Success		200		{object}	response.Response{data=map[string]interface{}}	"启用成功，返回恢复码"
+//	@Failure		400		{object}	response.Response								"请求参数错误或尚未开始绑定"
+//	@Failure		401		{object}	response.Response								"未授权"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/user/mfa/confirm [post]
+func (h *MFAHandler) ConfirmEnrollment(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	codes, err := h.service.ConfirmEnrollment(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.logger.Warn("Failed to confirm mfa enrollment", zap.Uint("user_id", userID), zap.Error(err))
+		respondMFAError(c, err, "Failed to confirm mfa enrollment")
+		return
+	}
+
+	response.SuccessWithMsg(c, "MFA enabled successfully", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// Disable 关闭两步验证
+//
+//	@Summary		关闭两步验证
+//	@Description	校验验证码或恢复码后关闭两步验证；角色要求两步验证时不能关闭
+//	@Tags			两步验证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		MFACodeRequest		true	"验证码或恢复码"
+//	@Success		200		{object}	response.Response	"关闭成功"
+//	@Failure		400		{object}	response.Response	"请求参数错误或未启用两步验证"
+//	@Failure		401		{object}	response.Response	"未授权"
+//	@Failure		500		{object}	response.Response	"服务器内部错误"
+//	@Router			/user/mfa/disable [post]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	if err := h.service.Disable(c.Request.Context(), userID, req.Code); err != nil {
+		h.logger.Warn("Failed to disable mfa", zap.Uint("user_id", userID), zap.Error(err))
+		respondMFAError(c, err, "Failed to disable mfa")
+		return
+	}
+
+	response.SuccessWithMsg(c, "MFA disabled successfully", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+//
+//	@Summary		重新生成恢复码
+//	@Description	校验验证码或恢复码后生成新的恢复码，原有恢复码全部失效
+//	@Tags			两步验证
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		MFACodeRequest									true	"验证码或恢复码"
+//	@Success		200		{object}	response.Response{data=map[string]interface{}}	"返回新的恢复码"
+//	@Failure		400		{object}	response.Response								"请求参数错误或未启用两步验证"
+//	@Failure		401		{object}	response.Response								"未授权"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/user/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.logger.Warn("Failed to regenerate recovery codes", zap.Uint("user_id", userID), zap.Error(err))
+		respondMFAError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Recovery codes regenerated successfully", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// respondMFAError 根据两步验证错误类型返回响应
+func respondMFAError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrMFAInvalidCode):
+		response.BusinessError(c, response.CodeUserMFAInvalid, err.Error())
+	case errors.Is(err, service.ErrMFARequiredByRole):
+		response.BusinessError(c, response.CodeUserMFARequired, err.Error())
+	case errors.Is(err, service.ErrMFAChallengeInvalid):
+		response.Unauthorized(c, err.Error())
+	case errors.Is(err, service.ErrMFAAlreadyEnabled),
+		errors.Is(err, service.ErrMFANotEnabled),
+		errors.Is(err, service.ErrMFAEnrollmentNotStarted):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrUserInactive):
+		response.BusinessError(c, response.CodeUserDisabled, err.Error())
+	default:
+		response.InternalError(c, message)
+	}
+}
@@ -0,0 +1,167 @@
+package frontendHandler
+
+import (
+	"errors"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PasswordHandler 密码管理处理器
+type PasswordHandler struct {
+	service service.PasswordService
+	cookies *middleware.SessionCookies
+	logger  *zap.Logger
+}
+
+// NewPasswordHandler 创建密码管理处理器
+func NewPasswordHandler(service service.PasswordService, cookies *middleware.SessionCookies, logger *zap.Logger) *PasswordHandler {
+	return &PasswordHandler{
+		service: service,
+		cookies: cookies,
+		logger:  logger,
+	}
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required" example:"password123"` // 原密码
+	NewPassword string `json:"new_password" binding:"required" example:"newpassword"` // 新密码，须符合密码策略
+}
+
+// ForgotPasswordRequest 找回密码请求
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"test@example.com"` // 注册邮箱
+}
+
+// ResetPasswordRequest 重置密码请求
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`                              // 重置密码链接中的 token
+	NewPassword string `json:"new_password" binding:"required" example:"newpassword"` // 新密码，须符合密码策略
+}
+
+// ChangePassword 修改密码
+//
+//	@Summary		修改密码
+//	@Description	校验原密码后修改密码，所有设备上的 Token 立即失效，响应中返回当前设备使用的新 Token
+//	@Tags			用户接口
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		ChangePasswordRequest						true	"原密码和新密码"
+//	@Success		200		{object}	response.Response{data=service.TokenPair}	"修改成功，返回新的 Token 对"
+//	@Failure		400		{object}	response.Response							"请求参数错误、原密码错误或新密码不符合密码策略（data 为字段级错误）"
+//	@Failure		401		{object}	response.Response							"未授权"
+//	@Failure		500		{object}	response.Response							"服务器内部错误"
+//	@Router			/user/password [put]
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	tokens, err := h.service.ChangePassword(c.Request.Context(), userID, req.OldPassword, req.NewPassword)
+	if err != nil {
+		h.logger.Warn("Failed to change password", zap.Uint("user_id", userID), zap.Error(err))
+		if errors.Is(err, service.ErrPasswordIncorrect) {
+			response.BusinessError(c, response.CodeUserPasswordError, err.Error())
+			return
+		}
+		if respondPasswordPolicyError(c, err, "new_password") {
+			return
+		}
+		response.InternalError(c, "Failed to change password")
+		return
+	}
+
+	h.cookies.SetTokens(c, tokens)
+	response.SuccessWithMsg(c, "Password changed successfully", tokens)
+}
+
+// ForgotPassword 找回密码
+//
+//	@Summary		找回密码
+//	@Description	向注册邮箱发送重置密码链接；无论邮箱是否存在、是否超过每小时申请上限都返回成功，避免泄露账号信息
+//	@Tags			公开接口
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ForgotPasswordRequest	true	"注册邮箱"
+//	@Success		200		{object}	response.Response		"已受理"
+//	@Failure		400		{object}	response.Response		"请求参数错误"
+//	@Failure		500		{object}	response.Response		"服务器内部错误"
+//	@Router			/public/password/forgot [post]
+func (h *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		h.logger.Error("Failed to request password reset", zap.Error(err))
+		response.InternalError(c, "Failed to request password reset")
+		return
+	}
+
+	response.SuccessWithMsg(c, "If the email is registered, a password reset link has been sent", nil)
+}
+
+// ResetPassword 重置密码
+//
+//	@Summary		重置密码
+//	@Description	使用重置密码链接中的 token 设置新密码，token 只能使用一次；成功后所有设备上的 Token 立即失效
+//	@Tags			公开接口
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ResetPasswordRequest	true	"token 和新密码"
+//	@Success		200		{object}	response.Response		"重置成功"
+//	@Failure		400		{object}	response.Response		"请求参数错误、token 无效或新密码不符合密码策略（data 为字段级错误）"
+//	@Failure		500		{object}	response.Response		"服务器内部错误"
+//	@Router			/public/password/reset [post]
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	if err := h.service.ResetPasswordWithToken(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		h.logger.Warn("Failed to reset password", zap.Error(err))
+		if errors.Is(err, service.ErrPasswordResetTokenInvalid) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		if respondPasswordPolicyError(c, err, "new_password") {
+			return
+		}
+		response.InternalError(c, "Failed to reset password")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Password reset successfully", nil)
+}
+
+// respondPasswordPolicyError 密码不符合策略时返回字段级校验错误，field 为请求中的密码字段名
+func respondPasswordPolicyError(c *gin.Context, err error, field string) bool {
+	var policyErr *service.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	fields := make([]response.FieldError, len(policyErr.Violations))
+	for i, violation := range policyErr.Violations {
+		fields[i] = response.FieldError{Field: field, Code: violation.Code, Message: violation.Message}
+	}
+	response.ValidateError(c, service.ErrPasswordPolicy.Error(), fields...)
+	return true
+}
@@ -0,0 +1,118 @@
+package frontendHandler
+
+import (
+	"errors"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SessionHandler 登录设备管理处理器
+type SessionHandler struct {
+	service service.SessionService
+	logger  *zap.Logger
+}
+
+// NewSessionHandler 创建登录设备管理处理器
+func NewSessionHandler(service service.SessionService, logger *zap.Logger) *SessionHandler {
+	return &SessionHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListSessions 获取登录设备列表
+//
+//	@Summary		获取登录设备列表
+//	@Description	列出当前用户所有有效的登录会话（设备、IP、登录时间和最近活跃时间），current 为 true 的是发起请求的设备
+//	@Tags			用户接口
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=[]model.UserSession}	"成功获取会话列表"
+//	@Failure		401	{object}	response.Response							"未授权"
+//	@Failure		500	{object}	response.Response							"服务器内部错误"
+//	@Router			/user/sessions [get]
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	claims, exists := middleware.GetClaims(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	sessions, err := h.service.ListSessions(c.Request.Context(), claims.UserID, claims.SessionID)
+	if err != nil {
+		response.InternalError(c, "Failed to list sessions")
+		return
+	}
+
+	response.Success(c, sessions)
+}
+
+// RevokeSession 移除登录设备
+//
+//	@Summary		移除登录设备
+//	@Description	使指定会话立即退出登录，该设备上的 Access Token 和 Refresh Token 全部失效
+//	@Tags			用户接口
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string				true	"会话ID"
+//	@Success		200	{object}	response.Response	"移除成功"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		404	{object}	response.Response	"会话不存在"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/user/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.service.RevokeSession(c.Request.Context(), userID, c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			response.NotFound(c, "Session not found")
+			return
+		}
+		h.logger.Error("Failed to revoke session", zap.Uint("user_id", userID), zap.Error(err))
+		response.InternalError(c, "Failed to revoke session")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Session revoked successfully", nil)
+}
+
+// RevokeOtherSessions 退出其他设备
+//
+//	@Summary		退出其他设备
+//	@Description	使除当前设备外的所有会话立即退出登录
+//	@Tags			用户接口
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response{data=map[string]interface{}}	"退出成功，返回移除的会话数量"
+//	@Failure		401	{object}	response.Response								"未授权"
+//	@Failure		500	{object}	response.Response								"服务器内部错误"
+//	@Router			/user/sessions [delete]
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	claims, exists := middleware.GetClaims(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	revoked, err := h.service.RevokeOtherSessions(c.Request.Context(), claims.UserID, claims.SessionID)
+	if err != nil {
+		h.logger.Error("Failed to revoke other sessions", zap.Uint("user_id", claims.UserID), zap.Error(err))
+		response.InternalError(c, "Failed to revoke other sessions")
+		return
+	}
+
+	response.SuccessWithMsg(c, "Other sessions revoked successfully", gin.H{
+		"revoked": revoked,
+	})
+}
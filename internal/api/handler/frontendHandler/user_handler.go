@@ -1,7 +1,11 @@
 package frontendHandler
 
 import (
+	"errors"
+	"math"
+	"net/http"
 	"strconv"
+	"trx-project/internal/api/middleware"
 	_ "trx-project/internal/model" // 用于 Swagger 文档生成
 	"trx-project/internal/service"
 	"trx-project/pkg/response"
@@ -12,13 +16,15 @@ import (
 
 type UserHandler struct {
 	service service.UserService
+	cookies *middleware.SessionCookies
 	logger  *zap.Logger
 }
 
 // NewUserHandler 创建新的用户处理器
-func NewUserHandler(service service.UserService, logger *zap.Logger) *UserHandler {
+func NewUserHandler(service service.UserService, cookies *middleware.SessionCookies, logger *zap.Logger) *UserHandler {
 	return &UserHandler{
 		service: service,
+		cookies: cookies,
 		logger:  logger,
 	}
 }
@@ -27,7 +33,7 @@ func NewUserHandler(service service.UserService, logger *zap.Logger) *UserHandle
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50" example:"testuser"` // 用户名，3-50个字符
 	Email    string `json:"email" binding:"required,email" example:"test@example.com"`   // 邮箱地址
-	Password string `json:"password" binding:"required,min=6" example:"password123"`     // 密码，最少6个字符
+	Password string `json:"password" binding:"required" example:"password123"`           // 密码，须符合密码策略
 }
 
 // LoginRequest 用户登录请求
@@ -36,16 +42,37 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required" example:"password123"` // 密码
 }
 
+// MFALoginRequest 两步验证登录请求
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`             // 登录接口返回的 mfa_token
+	Code     string `json:"code" binding:"required" example:"123456"` // 6 位验证码或恢复码
+}
+
+// MFASetupRequest 登录时绑定两步验证请求
+type MFASetupRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"` // 登录接口返回的 mfa_token
+}
+
+// RefreshTokenRequest 刷新 Token 请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"` // Refresh Token，使用 Cookie 会话时可以省略
+}
+
+// LogoutRequest 退出登录请求
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // Refresh Token（可选）
+}
+
 // Register 用户注册
 //
 //	@Summary		用户注册
-//	@Description	创建新用户账号，注册成功后返回用户信息和 JWT Token
+//	@Description	创建新用户账号并发送邮箱验证链接，注册成功后返回用户信息和 JWT Token；配置要求验证邮箱时不返回 Token，data 中 email_verification_required 为 true
 //	@Tags			公开接口
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		RegisterRequest									true	"注册信息"
 //	@Success		201		{object}	response.Response{data=map[string]interface{}}	"注册成功，返回用户信息和 Token"
-//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		400		{object}	response.Response								"请求参数错误或密码不符合密码策略（data 为字段级错误）"
 //	@Failure		409		{object}	response.Response								"用户名或邮箱已存在"
 //	@Failure		500		{object}	response.Response								"服务器内部错误"
 //	@Router			/public/register [post]
@@ -56,7 +83,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.service.Register(c.Request.Context(), req.Username, req.Email, req.Password)
+	user, tokens, err := h.service.Register(c.Request.Context(), req.Username, req.Email, req.Password)
 	if err != nil {
 		h.logger.Error("Failed to register user", zap.Error(err))
 		// 根据错误类型返回不同的响应
@@ -68,20 +95,35 @@ func (h *UserHandler) Register(c *gin.Context) {
 			response.BusinessError(c, response.CodeUserAlreadyExists, err.Error())
 			return
 		}
+		if respondPasswordPolicyError(c, err, "password") {
+			return
+		}
 		response.InternalError(c, "Failed to register user")
 		return
 	}
 
+	if tokens == nil {
+		response.CreatedWithMsg(c, "User registered successfully, please verify your email", gin.H{
+			"user":                        user,
+			"email_verification_required": true,
+		})
+		return
+	}
+
+	h.cookies.SetTokens(c, tokens)
 	response.CreatedWithMsg(c, "User registered successfully", gin.H{
-		"user":  user,
-		"token": token,
+		"user":               user,
+		"token":              tokens.AccessToken,
+		"refresh_token":      tokens.RefreshToken,
+		"expires_in":         tokens.ExpiresIn,
+		"refresh_expires_in": tokens.RefreshExpiresIn,
 	})
 }
 
 // Login 用户登录
 //
 //	@Summary		用户登录
-//	@Description	使用用户名和密码登录，成功后返回用户信息和 JWT Token
+//	@Description	使用用户名和密码登录，成功后返回用户信息和 JWT Token；启用了两步验证时返回 mfa_required 和 mfa_token，需要调用 /public/login/mfa 完成登录
 //	@Tags			公开接口
 //	@Accept			json
 //	@Produce		json
@@ -90,6 +132,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 //	@Failure		400		{object}	response.Response								"请求参数错误"
 //	@Failure		401		{object}	response.Response								"用户名或密码错误"
 //	@Failure		403		{object}	response.Response								"账号已被禁用"
+//	@Failure		429		{object}	response.Response								"登录失败次数过多，暂时锁定（业务码 20011，Retry-After 为剩余秒数）"
 //	@Failure		500		{object}	response.Response								"服务器内部错误"
 //	@Router			/public/login [post]
 func (h *UserHandler) Login(c *gin.Context) {
@@ -99,26 +142,236 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.service.Login(c.Request.Context(), req.Username, req.Password)
+	result, err := h.service.Login(c.Request.Context(), req.Username, req.Password, c.ClientIP())
 	if err != nil {
 		h.logger.Error("Failed to login", zap.Error(err))
 		// 根据错误类型返回不同的响应
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, response.CodeUserLoginLocked, err.Error())
+			return
+		}
 		if err.Error() == "invalid username or password" {
 			response.Unauthorized(c, err.Error())
 			return
 		}
-		if err.Error() == "user account is inactive" {
+		if errors.Is(err, service.ErrUserInactive) {
 			response.BusinessError(c, response.CodeUserDisabled, err.Error())
 			return
 		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			response.BusinessError(c, response.CodeUserEmailNotVerified, err.Error())
+			return
+		}
 		response.InternalError(c, "Failed to login")
 		return
 	}
 
-	response.SuccessWithMsg(c, "Login successful", gin.H{
-		"user":  user,
-		"token": token,
-	})
+	if result.MFA != nil {
+		response.SuccessWithMsg(c, "MFA verification required", gin.H{
+			"mfa_required":        true,
+			"mfa_token":           result.MFA.Token,
+			"expires_in":          result.MFA.ExpiresIn,
+			"enrollment_required": result.MFA.EnrollmentRequired,
+		})
+		return
+	}
+
+	h.cookies.SetTokens(c, result.Tokens)
+	response.SuccessWithMsg(c, "Login successful", loginResponse(result))
+}
+
+// SetupLoginMFA 登录时绑定两步验证
+//
+//	@Summary		登录时绑定两步验证
+//	@Description	角色要求两步验证但用户尚未绑定时（enrollment_required=true），使用 mfa_token 获取 TOTP 密钥，再调用 /public/login/mfa 提交验证码完成绑定和登录
+//	@Tags			公开接口
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		MFASetupRequest									true	"mfa_token"
+//	@Success		200		{object}	response.Response{data=service.MFAEnrollment}	"返回密钥和 otpauth 链接"
+//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		401		{object}	response.Response								"mfa_token 无效或已过期"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/public/login/mfa/setup [post]
+func (h *UserHandler) SetupLoginMFA(c *gin.Context) {
+	var req MFASetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	enrollment, err := h.service.BeginMFAEnrollment(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		h.logger.Warn("Failed to begin mfa enrollment", zap.Error(err))
+		respondMFAError(c, err, "Failed to begin mfa enrollment")
+		return
+	}
+
+	response.Success(c, enrollment)
+}
+
+// LoginMFA 完成两步验证登录
+//
+//	@Summary		完成两步验证登录
+//	@Description	提交登录接口返回的 mfa_token 和验证码（或恢复码），成功后返回用户信息和 JWT Token；每个 mfa_token 最多尝试 5 次
+//	@Tags			公开接口
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		MFALoginRequest									true	"mfa_token 和验证码"
+//	@Success		200		{object}	response.Response{data=map[string]interface{}}	"登录成功，返回用户信息和 Token"
+//	@Failure		400		{object}	response.Response								"请求参数错误"
+//	@Failure		401		{object}	response.Response								"mfa_token 无效或已过期"
+//	@Failure		500		{object}	response.Response								"服务器内部错误"
+//	@Router			/public/login/mfa [post]
+func (h *UserHandler) LoginMFA(c *gin.Context) {
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateError(c, err.Error())
+		return
+	}
+
+	result, err := h.service.CompleteMFALogin(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		h.logger.Warn("Failed to complete mfa login", zap.Error(err))
+		respondMFAError(c, err, "Failed to login")
+		return
+	}
+
+	h.cookies.SetTokens(c, result.Tokens)
+	response.SuccessWithMsg(c, "Login successful", loginResponse(result))
+}
+
+// loginResponse 登录成功的响应数据
+func loginResponse(result *service.LoginResult) gin.H {
+	data := gin.H{
+		"user":               result.User,
+		"token":              result.Tokens.AccessToken,
+		"refresh_token":      result.Tokens.RefreshToken,
+		"expires_in":         result.Tokens.ExpiresIn,
+		"refresh_expires_in": result.Tokens.RefreshExpiresIn,
+	}
+	// 登录过程中完成绑定时返回恢复码，只展示这一次
+	if len(result.RecoveryCodes) > 0 {
+		data["recovery_codes"] = result.RecoveryCodes
+	}
+	return data
+}
+
+// RefreshToken 刷新 Token
+//
+//	@Summary		刷新 Token
+//	@Description	使用 Refresh Token 换取新的 Token 对，旧的 Refresh Token 立即失效；重复使用已失效的 Refresh Token 会吊销整个登录会话。使用 Cookie 会话时请求体可以为空，从 Cookie 中读取 Refresh Token 并下发新的 Cookie
+//	@Tags			公开接口
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		RefreshTokenRequest							false	"Refresh Token"
+//	@Success		200		{object}	response.Response{data=service.TokenPair}	"刷新成功，返回新的 Token 对"
+//	@Failure		400		{object}	response.Response							"请求参数错误"
+//	@Failure		401		{object}	response.Response							"Refresh Token 无效"
+//	@Failure		500		{object}	response.Response							"服务器内部错误"
+//	@Router			/public/refresh [post]
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	// 使用 Cookie 会话时请求体可以为空
+	var req RefreshTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidateError(c, err.Error())
+			return
+		}
+	}
+	if req.RefreshToken == "" {
+		req.RefreshToken = h.cookies.RefreshToken(c)
+	}
+	if req.RefreshToken == "" {
+		response.ValidateError(c, "refresh_token is required")
+		return
+	}
+
+	tokens, err := h.service.RefreshToken(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		h.logger.Warn("Failed to refresh token", zap.Error(err))
+		if errors.Is(err, service.ErrRefreshTokenInvalid) || errors.Is(err, service.ErrRefreshTokenReused) {
+			h.cookies.Clear(c)
+			response.Unauthorized(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to refresh token")
+		return
+	}
+
+	h.cookies.SetTokens(c, tokens)
+	response.SuccessWithMsg(c, "Token refreshed successfully", tokens)
+}
+
+// Logout 退出登录
+//
+//	@Summary		退出登录
+//	@Description	吊销当前 Access Token，请求体携带 Refresh Token 时一并吊销该登录会话；吊销对所有实例立即生效
+//	@Tags			用户接口
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		LogoutRequest		false	"Refresh Token（可选）"
+//	@Success		200		{object}	response.Response	"退出成功"
+//	@Failure		400		{object}	response.Response	"请求参数错误"
+//	@Failure		401		{object}	response.Response	"未授权"
+//	@Failure		500		{object}	response.Response	"服务器内部错误"
+//	@Router			/user/logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	claims, exists := middleware.GetClaims(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	// 请求体可选
+	var req LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ValidateError(c, err.Error())
+			return
+		}
+	}
+
+	if err := h.service.Logout(c.Request.Context(), claims, req.RefreshToken); err != nil {
+		h.logger.Error("Logout failed", zap.Uint("user_id", claims.UserID), zap.Error(err))
+		response.InternalError(c, "Failed to logout")
+		return
+	}
+
+	h.cookies.Clear(c)
+	response.SuccessWithMsg(c, "Logout successful", nil)
+}
+
+// LogoutAll 退出所有设备
+//
+//	@Summary		退出所有设备
+//	@Description	吊销当前用户在所有设备上的 Access Token 和 Refresh Token，包括当前请求使用的 Token
+//	@Tags			用户接口
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	response.Response	"退出成功"
+//	@Failure		401	{object}	response.Response	"未授权"
+//	@Failure		500	{object}	response.Response	"服务器内部错误"
+//	@Router			/user/logout-all [post]
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.service.RevokeUserTokens(c.Request.Context(), userID); err != nil {
+		h.logger.Error("Logout all failed", zap.Uint("user_id", userID), zap.Error(err))
+		response.InternalError(c, "Failed to logout")
+		return
+	}
+
+	h.cookies.Clear(c)
+	response.SuccessWithMsg(c, "Logged out from all devices", nil)
 }
 
 // GetUser 获取用户信息
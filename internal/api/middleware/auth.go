@@ -1,7 +1,10 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
+	"trx-project/internal/model"
+	"trx-project/internal/service"
 	"trx-project/pkg/jwt"
 	"trx-project/pkg/response"
 
@@ -9,11 +12,15 @@ import (
 	"go.uber.org/zap"
 )
 
+// apiKeyScheme API Key 认证方式：Authorization: ApiKey <key>
+const apiKeyScheme = "ApiKey "
+
 // Auth 用户认证中间件（前台）
-func Auth(jwtSecret string, logger *zap.Logger) gin.HandlerFunc {
+// 支持 Bearer Token、API Key 和会话 Cookie，API Key 的所有者拥有后台角色时只能用于后台
+func Auth(tokenService service.TokenService, apiKeyService service.APIKeyService, cookies *SessionCookies, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 从 Header 获取 token
-		tokenString := c.GetHeader("Authorization")
+		// 从 Header 或会话 Cookie 获取 token
+		tokenString := credentials(c, cookies)
 		if tokenString == "" {
 			logger.Warn("Missing authorization token")
 			response.Unauthorized(c, "Missing authorization token")
@@ -21,22 +28,38 @@ func Auth(jwtSecret string, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
+		if rawKey, ok := apiKeyCredentials(tokenString); ok {
+			principal, ok := authenticateAPIKey(c, apiKeyService, rawKey, logger)
+			if !ok {
+				return
+			}
+			if principal.Role != jwt.RoleUser {
+				logger.Warn("API key owner is not a frontend user",
+					zap.String("role", principal.Role),
+					zap.Uint("user_id", principal.User.ID))
+				response.Unauthorized(c, "Invalid API key")
+				c.Abort()
+				return
+			}
+
+			c.Set("user_id", principal.User.ID)
+			c.Set("username", principal.User.Username)
+			c.Set("role", principal.Role)
+			setTenant(c, principal.User.TenantID)
+			c.Next()
+			return
+		}
+
 		// 移除 Bearer 前缀
 		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
-		// 解析 JWT Token
-		claims, err := jwt.ParseToken(tokenString, jwtSecret)
+		// 解析 JWT Token 并检查吊销状态
+		claims, err := tokenService.ParseAccessToken(c.Request.Context(), tokenString)
 		if err != nil {
 			logger.Warn("Invalid token",
 				zap.Error(err),
 				zap.String("path", c.Request.URL.Path))
-
-			if err == jwt.ErrTokenExpired {
-				response.BusinessError(c, response.CodeUserTokenExpired, "Token has expired")
-			} else {
-				response.Unauthorized(c, "Invalid token")
-			}
-			c.Abort()
+			abortWithTokenError(c, err)
 			return
 		}
 
@@ -50,13 +73,25 @@ func Auth(jwtSecret string, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
-		// TODO: 可选 - 从数据库验证用户状态是否正常
-
 		// 将用户信息存入上下文
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
 		c.Set("token", tokenString)
+		c.Set("claims", claims)
+		setTenant(c, claims.TenantID)
+
+		// 管理员模拟登录：记录每个请求的两个身份
+		if claims.Impersonated() {
+			c.Set("impersonator_id", claims.Actor.UserID)
+			logger.Info("Impersonated request",
+				zap.Uint("user_id", claims.UserID),
+				zap.String("username", claims.Username),
+				zap.Uint("impersonator_id", claims.Actor.UserID),
+				zap.String("impersonator", claims.Actor.Username),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path))
+		}
 
 		logger.Debug("User authenticated",
 			zap.Uint("user_id", claims.UserID),
@@ -68,10 +103,11 @@ func Auth(jwtSecret string, logger *zap.Logger) gin.HandlerFunc {
 }
 
 // AdminAuth 管理员认证中间件（后台）
-func AdminAuth(jwtSecret string, logger *zap.Logger) gin.HandlerFunc {
+// 支持 Bearer Token、API Key 和会话 Cookie，API Key 的所有者必须拥有后台角色
+func AdminAuth(tokenService service.TokenService, apiKeyService service.APIKeyService, cookies *SessionCookies, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 从 Header 获取 token
-		tokenString := c.GetHeader("Authorization")
+		// 从 Header 或会话 Cookie 获取 token
+		tokenString := credentials(c, cookies)
 		if tokenString == "" {
 			logger.Warn("Admin: Missing authorization token")
 			response.Unauthorized(c, "Missing authorization token")
@@ -79,22 +115,37 @@ func AdminAuth(jwtSecret string, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
+		if rawKey, ok := apiKeyCredentials(tokenString); ok {
+			principal, ok := authenticateAPIKey(c, apiKeyService, rawKey, logger)
+			if !ok {
+				return
+			}
+			if principal.Role != jwt.RoleAdmin && principal.Role != jwt.RoleSuperAdmin {
+				logger.Warn("API key owner is not an admin",
+					zap.Uint("user_id", principal.User.ID))
+				response.Forbidden(c, "Admin access required")
+				c.Abort()
+				return
+			}
+
+			c.Set("admin_id", principal.User.ID)
+			c.Set("username", principal.User.Username)
+			c.Set("admin_role", principal.Role)
+			setTenant(c, principal.User.TenantID)
+			c.Next()
+			return
+		}
+
 		// 移除 Bearer 前缀
 		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
-		// 解析 JWT Token
-		claims, err := jwt.ParseToken(tokenString, jwtSecret)
+		// 解析 JWT Token 并检查吊销状态
+		claims, err := tokenService.ParseAccessToken(c.Request.Context(), tokenString)
 		if err != nil {
 			logger.Warn("Invalid admin token",
 				zap.Error(err),
 				zap.String("path", c.Request.URL.Path))
-
-			if err == jwt.ErrTokenExpired {
-				response.BusinessError(c, response.CodeUserTokenExpired, "Token has expired")
-			} else {
-				response.Unauthorized(c, "Invalid token")
-			}
-			c.Abort()
+			abortWithTokenError(c, err)
 			return
 		}
 
@@ -108,13 +159,13 @@ func AdminAuth(jwtSecret string, logger *zap.Logger) gin.HandlerFunc {
 			return
 		}
 
-		// TODO: 可选 - 从数据库验证管理员状态
-
 		// 将管理员信息存入上下文
 		c.Set("admin_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("admin_role", claims.Role)
 		c.Set("token", tokenString)
+		c.Set("claims", claims)
+		setTenant(c, claims.TenantID)
 
 		logger.Debug("Admin authenticated",
 			zap.Uint("admin_id", claims.UserID),
@@ -128,25 +179,52 @@ func AdminAuth(jwtSecret string, logger *zap.Logger) gin.HandlerFunc {
 
 // OptionalAuth 可选认证中间件
 // 如果有 token 则验证，没有也不阻止
-func OptionalAuth(jwtSecret string, logger *zap.Logger) gin.HandlerFunc {
+func OptionalAuth(tokenService service.TokenService, apiKeyService service.APIKeyService, cookies *SessionCookies, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString := c.GetHeader("Authorization")
+		tokenString := credentials(c, cookies)
 		if tokenString == "" {
 			// 没有 token，继续执行，但不设置用户信息
 			c.Next()
 			return
 		}
 
+		if rawKey, ok := apiKeyCredentials(tokenString); ok {
+			principal, err := apiKeyService.Authenticate(c.Request.Context(), rawKey)
+			if err == nil && principal.Role == jwt.RoleUser {
+				c.Set("user_id", principal.User.ID)
+				c.Set("username", principal.User.Username)
+				c.Set("role", principal.Role)
+				c.Set("api_key", principal.Key)
+				setTenant(c, principal.User.TenantID)
+			} else {
+				logger.Debug("Optional auth: Invalid API key, continue as guest",
+					zap.Error(err))
+			}
+			c.Next()
+			return
+		}
+
 		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
 		// 尝试解析 token
-		claims, err := jwt.ParseToken(tokenString, jwtSecret)
+		claims, err := tokenService.ParseAccessToken(c.Request.Context(), tokenString)
 		if err == nil {
 			// Token 有效，设置用户信息
 			c.Set("user_id", claims.UserID)
 			c.Set("username", claims.Username)
 			c.Set("role", claims.Role)
 			c.Set("token", tokenString)
+			c.Set("claims", claims)
+			setTenant(c, claims.TenantID)
+			if claims.Impersonated() {
+				c.Set("impersonator_id", claims.Actor.UserID)
+				logger.Info("Impersonated request",
+					zap.Uint("user_id", claims.UserID),
+					zap.Uint("impersonator_id", claims.Actor.UserID),
+					zap.String("impersonator", claims.Actor.Username),
+					zap.String("method", c.Request.Method),
+					zap.String("path", c.Request.URL.Path))
+			}
 			logger.Debug("Optional auth: User identified",
 				zap.Uint("user_id", claims.UserID),
 				zap.String("username", claims.Username))
@@ -159,6 +237,95 @@ func OptionalAuth(jwtSecret string, logger *zap.Logger) gin.HandlerFunc {
 	}
 }
 
+// credentials 读取请求凭证，优先使用 Authorization 头，没有时使用会话 Cookie 中的 Access Token
+func credentials(c *gin.Context, cookies *SessionCookies) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		return header
+	}
+	if token := cookies.accessToken(c); token != "" {
+		return "Bearer " + token
+	}
+	return ""
+}
+
+// apiKeyCredentials 解析 ApiKey 认证方式，返回 API Key 明文
+func apiKeyCredentials(header string) (string, bool) {
+	if len(header) <= len(apiKeyScheme) || !strings.EqualFold(header[:len(apiKeyScheme)], apiKeyScheme) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(apiKeyScheme):]), true
+}
+
+// authenticateAPIKey 校验 API Key 并把 Key 存入上下文，失败时中止请求
+func authenticateAPIKey(c *gin.Context, apiKeyService service.APIKeyService, rawKey string, logger *zap.Logger) (*service.APIKeyPrincipal, bool) {
+	principal, err := apiKeyService.Authenticate(c.Request.Context(), rawKey)
+	if err != nil {
+		logger.Warn("Invalid API key",
+			zap.Error(err),
+			zap.String("path", c.Request.URL.Path))
+		abortWithTokenError(c, err)
+		return nil, false
+	}
+
+	c.Set("api_key", principal.Key)
+
+	logger.Debug("API key authenticated",
+		zap.Uint("user_id", principal.User.ID),
+		zap.Uint("key_id", principal.Key.ID),
+		zap.String("path", c.Request.URL.Path))
+	return principal, true
+}
+
+// DenyAPIKey 禁止使用 API Key 访问，用于修改密码、两步验证、会话和 API Key 管理等只允许登录会话执行的操作
+func DenyAPIKey(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key, ok := GetAPIKey(c); ok {
+			logger.Warn("API key not allowed",
+				zap.Uint("key_id", key.ID),
+				zap.String("path", c.Request.URL.Path))
+			response.Forbidden(c, "This operation requires a login session")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// DenyImpersonation 禁止管理员模拟登录时访问，用于修改密码、两步验证、会话和 API Key 管理等敏感操作
+func DenyImpersonation(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if impersonatorID, ok := GetImpersonatorID(c); ok {
+			logger.Warn("Sensitive action blocked while impersonating",
+				zap.Uint("impersonator_id", impersonatorID),
+				zap.String("path", c.Request.URL.Path))
+			response.Forbidden(c, "This operation is not allowed while impersonating a user")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// abortWithTokenError 根据 Token 解析错误返回对应的响应并中止请求
+func abortWithTokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAPIKeyInvalid):
+		response.Unauthorized(c, "Invalid API key")
+	case errors.Is(err, jwt.ErrTokenExpired):
+		response.BusinessError(c, response.CodeUserTokenExpired, "Token has expired")
+	case errors.Is(err, service.ErrTokenRevoked):
+		response.BusinessError(c, response.CodeUserTokenInvalid, "Token has been revoked")
+	case errors.Is(err, service.ErrUserDisabled):
+		response.BusinessError(c, response.CodeUserDisabled, "User account is disabled")
+	case errors.Is(err, jwt.ErrTokenInvalid), errors.Is(err, jwt.ErrTokenMalformed), errors.Is(err, jwt.ErrTokenNotValidYet):
+		response.Unauthorized(c, "Invalid token")
+	default:
+		// 吊销列表或用户状态不可用时拒绝请求（fail closed）
+		response.InternalError(c, "Failed to verify token")
+	}
+	c.Abort()
+}
+
 // CheckPermission 检查权限中间件
 func CheckPermission(permission string, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -208,6 +375,33 @@ func GetAdminID(c *gin.Context) (uint, bool) {
 	return adminID.(uint), true
 }
 
+// GetClaims 从上下文获取当前 Token 的 Claims
+func GetClaims(c *gin.Context) (*jwt.Claims, bool) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return nil, false
+	}
+	return claims.(*jwt.Claims), true
+}
+
+// GetAPIKey 从上下文获取当前请求使用的 API Key，使用 Token 认证时返回 false
+func GetAPIKey(c *gin.Context) (*model.APIKey, bool) {
+	key, exists := c.Get("api_key")
+	if !exists {
+		return nil, false
+	}
+	return key.(*model.APIKey), true
+}
+
+// GetImpersonatorID 从上下文获取正在模拟该用户的管理员 ID，不是模拟登录时返回 false
+func GetImpersonatorID(c *gin.Context) (uint, bool) {
+	impersonatorID, exists := c.Get("impersonator_id")
+	if !exists {
+		return 0, false
+	}
+	return impersonatorID.(uint), true
+}
+
 // GetAdminRole 从上下文获取管理员角色
 func GetAdminRole(c *gin.Context) (string, bool) {
 	role, exists := c.Get("admin_role")
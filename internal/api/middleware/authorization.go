@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"time"
+	"trx-project/internal/service"
+	"trx-project/pkg/policy"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Authorize 检查当前管理员能否对已加载的资源执行 permissionCode 对应的操作（授权条件见 AuthorizationService），
+// 不允许时写入 403 响应并返回 false，处理器应直接返回
+// 路由上仍需 RequirePermission 检查权限编码和 API Key 的 scopes
+func Authorize(c *gin.Context, authz service.AuthorizationService, permissionCode string, resource policy.Attributes, logger *zap.Logger) bool {
+	adminID, ok := GetAdminID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return false
+	}
+
+	err := authz.Authorize(c.Request.Context(), adminID, permissionCode, resource, RequestAttributes(c))
+	return authorized(c, adminID, permissionCode, err, logger)
+}
+
+// RequireUnconditional 要求当前管理员拥有 permissionCode 的无条件授权（见 AuthorizationService.AuthorizeUnconditional），
+// 用于列表等无法按授权条件逐个检查资源的接口，需要放在 RequirePermission 之后
+func RequireUnconditional(permissionCode string, authz service.AuthorizationService, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ok := GetAdminID(c)
+		if !ok {
+			response.Unauthorized(c, "Unauthorized")
+			c.Abort()
+			return
+		}
+
+		err := authz.AuthorizeUnconditional(c.Request.Context(), adminID, permissionCode)
+		if !authorized(c, adminID, permissionCode, err, logger) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorized 根据授权结果写入 403 或 500 响应，允许时返回 true
+func authorized(c *gin.Context, adminID uint, permissionCode string, err error, logger *zap.Logger) bool {
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, service.ErrAccessDenied) {
+		response.Forbidden(c, "Permission denied: "+permissionCode)
+		return false
+	}
+
+	logger.Error("Failed to authorize request",
+		zap.Uint("admin_id", adminID),
+		zap.String("permission", permissionCode),
+		zap.Error(err))
+	response.InternalError(c, "Failed to authorize request")
+	return false
+}
+
+// RequireUserAccess 加载路径参数 :id 指定的用户并按授权条件检查，用于处理器不自行加载用户的 /users/:id/... 路由
+// 需要放在 RequirePermission 之后
+func RequireUserAccess(permissionCode string, authz service.AuthorizationService, userService service.UserService, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			response.BadRequest(c, "Invalid user ID")
+			c.Abort()
+			return
+		}
+
+		user, err := userService.GetUserByID(c.Request.Context(), uint(id))
+		if err != nil {
+			if err.Error() == "user not found" {
+				response.NotFound(c, err.Error())
+			} else {
+				logger.Error("Failed to load user for authorization", zap.Uint64("user_id", id), zap.Error(err))
+				response.InternalError(c, "Failed to get user")
+			}
+			c.Abort()
+			return
+		}
+
+		if !Authorize(c, authz, permissionCode, service.UserResource(user), logger) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequestAttributes 授权条件中的 request 属性：ip、method、path（路由模板）、hour 和 weekday（服务器时区，周日为 0）
+func RequestAttributes(c *gin.Context) policy.Attributes {
+	now := time.Now()
+	return policy.Attributes{
+		"ip":      c.ClientIP(),
+		"method":  c.Request.Method,
+		"path":    c.FullPath(),
+		"hour":    now.Hour(),
+		"weekday": int(now.Weekday()),
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"trx-project/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientInfo 把客户端 IP 和 User-Agent 放入请求 context，登录时记录到会话中
+func ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := service.WithClientInfo(c.Request.Context(), service.ClientInfo{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
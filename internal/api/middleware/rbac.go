@@ -9,7 +9,8 @@ import (
 )
 
 // RequirePermission RBAC 权限检查中间件
-// 要求用户必须拥有指定的权限才能访问
+// 要求用户必须拥有指定的权限才能访问，使用 API Key 时权限还必须在 Key 的 scopes 中
+// 三个中间件都通过 RBACService 检查，授权支持通配符（如 user:*）和蕴含（如 user:write 蕴含 user:read）
 func RequirePermission(permissionCode string, rbacService service.RBACService, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从上下文获取管理员 ID
@@ -29,6 +30,15 @@ func RequirePermission(permissionCode string, rbacService service.RBACService, l
 			return
 		}
 
+		if !apiKeyAllows(c, rbacService, permissionCode) {
+			logger.Warn("Permission not in API key scopes",
+				zap.Uint("admin_id", userID),
+				zap.String("permission", permissionCode))
+			response.Forbidden(c, "Permission denied: "+permissionCode)
+			c.Abort()
+			return
+		}
+
 		// 检查权限
 		err := rbacService.CheckPermission(c.Request.Context(), userID, permissionCode)
 		if err != nil {
@@ -69,6 +79,9 @@ func RequireAnyPermission(permissionCodes []string, rbacService service.RBACServ
 		// 检查是否拥有任一权限
 		hasPermission := false
 		for _, permCode := range permissionCodes {
+			if !apiKeyAllows(c, rbacService, permCode) {
+				continue
+			}
 			err := rbacService.CheckPermission(c.Request.Context(), userID, permCode)
 			if err == nil {
 				hasPermission = true
@@ -109,7 +122,7 @@ func RequireAllPermissions(permissionCodes []string, rbacService service.RBACSer
 		// 检查是否拥有所有权限
 		for _, permCode := range permissionCodes {
 			err := rbacService.CheckPermission(c.Request.Context(), userID, permCode)
-			if err != nil {
+			if err != nil || !apiKeyAllows(c, rbacService, permCode) {
 				logger.Warn("Permission denied (require all)",
 					zap.Uint("admin_id", userID),
 					zap.String("missing_permission", permCode))
@@ -122,3 +135,28 @@ func RequireAllPermissions(permissionCodes []string, rbacService service.RBACSer
 		c.Next()
 	}
 }
+
+// RequireAPIKeyScope 使用 API Key 访问时要求权限在 Key 的 scopes 中，使用 Token 访问时不做限制
+// 用于前台接口：前台接口不检查用户权限，但 API Key 只能访问 scopes 覆盖的接口
+func RequireAPIKeyScope(permissionCode string, rbacService service.RBACService, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !apiKeyAllows(c, rbacService, permissionCode) {
+			key, _ := GetAPIKey(c)
+			logger.Warn("Permission not in API key scopes",
+				zap.Uint("key_id", key.ID),
+				zap.String("permission", permissionCode),
+				zap.String("path", c.Request.URL.Path))
+			response.Forbidden(c, "Permission denied: "+permissionCode)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// apiKeyAllows 使用 API Key 访问时检查权限是否在 Key 的 scopes 中（与用户权限使用相同的通配符和蕴含规则），
+// 使用 Token 访问时总是返回 true
+func apiKeyAllows(c *gin.Context, rbacService service.RBACService, permissionCode string) bool {
+	key, ok := GetAPIKey(c)
+	return !ok || rbacService.GrantsAllow(key.Scopes, permissionCode)
+}
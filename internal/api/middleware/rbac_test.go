@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trx-project/internal/model"
+	"trx-project/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// scopeRBACService 只实现 GrantsAllow，按精确匹配检查 scopes
+type scopeRBACService struct {
+	service.RBACService
+}
+
+func (scopeRBACService) GrantsAllow(grants []string, permissionCode string) bool {
+	for _, grant := range grants {
+		if grant == permissionCode {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRequireAPIKeyScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		key  *model.APIKey
+		code int
+	}{
+		{"token request not restricted", nil, http.StatusOK},
+		{"scope allows", &model.APIKey{ID: 1, Scopes: []string{"user:read"}}, http.StatusOK},
+		{"scope missing", &model.APIKey{ID: 2, Scopes: []string{"user:write"}}, http.StatusForbidden},
+		{"no scopes", &model.APIKey{ID: 3}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/profile", func(c *gin.Context) {
+				if tt.key != nil {
+					c.Set("api_key", tt.key)
+				}
+			}, RequireAPIKeyScope("user:read", scopeRBACService{}, zap.NewNop()), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/profile", nil))
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
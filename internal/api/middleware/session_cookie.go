@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"trx-project/internal/service"
+	"trx-project/pkg/config"
+	"trx-project/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SessionCookies 浏览器 Cookie 会话
+// Access Token 和 Refresh Token 放在 HttpOnly Cookie 中，CSRF Token 放在前端脚本可读的 Cookie 中，
+// 写请求需要把 CSRF Token 放到请求头（double submit），跨站请求无法读取 Cookie 因此无法伪造请求头
+type SessionCookies struct {
+	cfg         config.SessionCookieConfig
+	name        string
+	refreshName string
+	csrfName    string
+	ssoName     string
+	sameSite    http.SameSite
+}
+
+// NewSessionCookies 创建浏览器 Cookie 会话，prefix 用于未配置 Cookie 名称时生成默认名称
+func NewSessionCookies(cfg config.SessionCookieConfig, prefix string) *SessionCookies {
+	s := &SessionCookies{
+		cfg:         cfg,
+		name:        cfg.Name,
+		refreshName: cfg.RefreshName,
+		csrfName:    cfg.CSRFCookieName,
+		sameSite:    http.SameSiteLaxMode,
+	}
+	if s.name == "" {
+		s.name = prefix + "_session"
+	}
+	if s.refreshName == "" {
+		s.refreshName = prefix + "_refresh"
+	}
+	if s.csrfName == "" {
+		s.csrfName = prefix + "_csrf"
+	}
+	s.ssoName = prefix + "_sso"
+
+	switch strings.ToLower(cfg.SameSite) {
+	case "strict":
+		s.sameSite = http.SameSiteStrictMode
+	case "none":
+		s.sameSite = http.SameSiteNoneMode
+	}
+	return s
+}
+
+// Enabled 是否启用 Cookie 会话
+func (s *SessionCookies) Enabled() bool {
+	return s != nil && s.cfg.Enabled
+}
+
+// SetTokens 下发 Token Cookie 和新的 CSRF Token，未启用时不做任何事
+func (s *SessionCookies) SetTokens(c *gin.Context, tokens *service.TokenPair) {
+	if !s.Enabled() || tokens == nil {
+		return
+	}
+
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		// 随机数不可用时不下发 Cookie，客户端仍可使用响应中的 Token
+		return
+	}
+
+	s.set(c, s.name, tokens.AccessToken, s.cfg.CookiePath(), int(tokens.ExpiresIn), true)
+	s.set(c, s.refreshName, tokens.RefreshToken, s.cfg.RefreshCookiePath(), int(tokens.RefreshExpiresIn), true)
+	s.set(c, s.csrfName, csrfToken, s.cfg.CookiePath(), int(tokens.RefreshExpiresIn), false)
+}
+
+// Clear 删除 Token 和 CSRF Cookie，未启用时不做任何事
+func (s *SessionCookies) Clear(c *gin.Context) {
+	if !s.Enabled() {
+		return
+	}
+
+	s.set(c, s.name, "", s.cfg.CookiePath(), -1, true)
+	s.set(c, s.refreshName, "", s.cfg.RefreshCookiePath(), -1, true)
+	s.set(c, s.csrfName, "", s.cfg.CookiePath(), -1, false)
+}
+
+// RefreshToken 从 Cookie 中读取 Refresh Token，请求体没有携带时使用
+func (s *SessionCookies) RefreshToken(c *gin.Context) string {
+	if !s.Enabled() {
+		return ""
+	}
+	value, _ := c.Cookie(s.refreshName)
+	return value
+}
+
+// accessToken 从 Cookie 中读取 Access Token
+func (s *SessionCookies) accessToken(c *gin.Context) string {
+	if !s.Enabled() {
+		return ""
+	}
+	value, _ := c.Cookie(s.name)
+	return value
+}
+
+// SetSSOBinding 下发单点登录的浏览器绑定值，回调时用于确认登录由当前浏览器发起
+// 与是否启用 Cookie 会话无关，使用相同的域名、Secure 和 SameSite 设置
+func (s *SessionCookies) SetSSOBinding(c *gin.Context, binding string, maxAge int) {
+	s.set(c, s.ssoName, binding, s.cfg.CookiePath(), maxAge, true)
+}
+
+// TakeSSOBinding 读取并删除单点登录的浏览器绑定值，每个授权请求只能使用一次
+func (s *SessionCookies) TakeSSOBinding(c *gin.Context) string {
+	value, _ := c.Cookie(s.ssoName)
+	s.set(c, s.ssoName, "", s.cfg.CookiePath(), -1, true)
+	return value
+}
+
+// CSRF CSRF 校验中间件
+// 只校验使用 Cookie 认证的写请求：带有 Authorization 头的请求不会被浏览器跨站自动附带，不需要校验
+func (s *SessionCookies) CSRF(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.Enabled() || s.cfg.CSRFDisabled || isSafeMethod(c.Request.Method) || c.GetHeader("Authorization") != "" {
+			c.Next()
+			return
+		}
+
+		// 没有会话 Cookie 的请求不会使用 Cookie 认证
+		_, accessErr := c.Cookie(s.name)
+		_, refreshErr := c.Cookie(s.refreshName)
+		if accessErr != nil && refreshErr != nil {
+			c.Next()
+			return
+		}
+
+		expected, _ := c.Cookie(s.csrfName)
+		actual := c.GetHeader(s.cfg.CSRFHeader())
+		if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) != 1 {
+			logger.Warn("CSRF token mismatch",
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.Bool("header_present", actual != ""))
+			response.Forbidden(c, "Invalid CSRF token")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func (s *SessionCookies) set(c *gin.Context, name, value, path string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   s.cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   s.cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: s.sameSite,
+	})
+}
+
+// newCSRFToken 生成随机 CSRF Token
+func newCSRFToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// isSafeMethod 是否为不修改状态的请求方法
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trx-project/internal/service"
+	"trx-project/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestSessionCookies(cfg config.SessionCookieConfig) *SessionCookies {
+	cfg.Enabled = true
+	return NewSessionCookies(cfg, "trx")
+}
+
+// serveCSRF 经过 CSRF 中间件发送请求，返回状态码
+func serveCSRF(cookies *SessionCookies, req *http.Request) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(cookies.CSRF(zap.NewNop()))
+	r.Any("/resource", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestSessionCookies_CSRF(t *testing.T) {
+	cookies := newTestSessionCookies(config.SessionCookieConfig{})
+
+	request := func(method, csrfCookie, csrfHeader string) *http.Request {
+		req := httptest.NewRequest(method, "/resource", nil)
+		req.AddCookie(&http.Cookie{Name: "trx_session", Value: "access-token"})
+		if csrfCookie != "" {
+			req.AddCookie(&http.Cookie{Name: "trx_csrf", Value: csrfCookie})
+		}
+		if csrfHeader != "" {
+			req.Header.Set("X-CSRF-Token", csrfHeader)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"matching header", request(http.MethodPost, "csrf-token", "csrf-token"), http.StatusOK},
+		{"missing header", request(http.MethodPost, "csrf-token", ""), http.StatusForbidden},
+		{"mismatched header", request(http.MethodDelete, "csrf-token", "other-token"), http.StatusForbidden},
+		{"missing csrf cookie", request(http.MethodPut, "", "csrf-token"), http.StatusForbidden},
+		{"GET exempt", request(http.MethodGet, "csrf-token", ""), http.StatusOK},
+		{"HEAD exempt", request(http.MethodHead, "", ""), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, serveCSRF(cookies, tt.req))
+		})
+	}
+
+	t.Run("refresh cookie alone requires CSRF", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/resource", nil)
+		req.AddCookie(&http.Cookie{Name: "trx_refresh", Value: "refresh-token"})
+		assert.Equal(t, http.StatusForbidden, serveCSRF(cookies, req))
+	})
+
+	t.Run("bearer request exempt", func(t *testing.T) {
+		// 带 Authorization 头的请求不会被浏览器跨站自动附带
+		req := request(http.MethodPost, "csrf-token", "")
+		req.Header.Set("Authorization", "Bearer access-token")
+		assert.Equal(t, http.StatusOK, serveCSRF(cookies, req))
+	})
+
+	t.Run("request without session cookie exempt", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/resource", nil)
+		assert.Equal(t, http.StatusOK, serveCSRF(cookies, req))
+	})
+
+	t.Run("custom header name", func(t *testing.T) {
+		custom := newTestSessionCookies(config.SessionCookieConfig{CSRFHeaderName: "X-XSRF-Token"})
+		req := request(http.MethodPost, "csrf-token", "csrf-token")
+		assert.Equal(t, http.StatusForbidden, serveCSRF(custom, req))
+		req = request(http.MethodPost, "csrf-token", "")
+		req.Header.Set("X-XSRF-Token", "csrf-token")
+		assert.Equal(t, http.StatusOK, serveCSRF(custom, req))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		req := request(http.MethodPost, "csrf-token", "")
+		assert.Equal(t, http.StatusOK, serveCSRF(newTestSessionCookies(config.SessionCookieConfig{CSRFDisabled: true}), req))
+		req = request(http.MethodPost, "csrf-token", "")
+		assert.Equal(t, http.StatusOK, serveCSRF(NewSessionCookies(config.SessionCookieConfig{}, "trx"), req))
+	})
+}
+
+func TestCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cookies := newTestSessionCookies(config.SessionCookieConfig{})
+
+	newContext := func(header, cookie string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/resource", nil)
+		if header != "" {
+			c.Request.Header.Set("Authorization", header)
+		}
+		if cookie != "" {
+			c.Request.AddCookie(&http.Cookie{Name: "trx_session", Value: cookie})
+		}
+		return c
+	}
+
+	// Authorization 头优先于 Cookie
+	assert.Equal(t, "Bearer header-token", credentials(newContext("Bearer header-token", "cookie-token"), cookies))
+	assert.Equal(t, "ApiKey key", credentials(newContext("ApiKey key", "cookie-token"), cookies))
+	assert.Equal(t, "Bearer cookie-token", credentials(newContext("", "cookie-token"), cookies))
+	assert.Equal(t, "", credentials(newContext("", ""), cookies))
+
+	// 未启用 Cookie 会话时忽略 Cookie
+	assert.Equal(t, "", credentials(newContext("", "cookie-token"), NewSessionCookies(config.SessionCookieConfig{}, "trx")))
+	assert.Equal(t, "", credentials(newContext("", "cookie-token"), nil))
+}
+
+func TestSessionCookies_SetTokensAndClear(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cookies := newTestSessionCookies(config.SessionCookieConfig{
+		Name:        "admin_session",
+		RefreshPath: "/api/v1/admin/auth/refresh",
+		Domain:      "example.com",
+		Secure:      true,
+		SameSite:    "Strict",
+	})
+	tokens := &service.TokenPair{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 900, RefreshExpiresIn: 86400}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	cookies.SetTokens(c, tokens)
+
+	set := map[string]*http.Cookie{}
+	for _, cookie := range w.Result().Cookies() {
+		set[cookie.Name] = cookie
+	}
+	require.Len(t, set, 3)
+
+	access := set["admin_session"]
+	require.NotNil(t, access)
+	assert.Equal(t, "access", access.Value)
+	assert.Equal(t, "/", access.Path)
+	assert.Equal(t, 900, access.MaxAge)
+	assert.True(t, access.HttpOnly)
+
+	refresh := set["trx_refresh"]
+	require.NotNil(t, refresh)
+	assert.Equal(t, "refresh", refresh.Value)
+	assert.Equal(t, "/api/v1/admin/auth/refresh", refresh.Path)
+	assert.Equal(t, 86400, refresh.MaxAge)
+	assert.True(t, refresh.HttpOnly)
+
+	// CSRF Cookie 需要前端脚本读取
+	csrf := set["trx_csrf"]
+	require.NotNil(t, csrf)
+	assert.NotEmpty(t, csrf.Value)
+	assert.Equal(t, 86400, csrf.MaxAge)
+	assert.False(t, csrf.HttpOnly)
+
+	for _, cookie := range set {
+		assert.Equal(t, "example.com", cookie.Domain, cookie.Name)
+		assert.True(t, cookie.Secure, cookie.Name)
+		assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite, cookie.Name)
+	}
+
+	// 每次下发新的 CSRF Token
+	w2 := httptest.NewRecorder()
+	c2, _ := gin.CreateTestContext(w2)
+	cookies.SetTokens(c2, tokens)
+	for _, cookie := range w2.Result().Cookies() {
+		if cookie.Name == "trx_csrf" {
+			assert.NotEqual(t, csrf.Value, cookie.Value)
+		}
+	}
+
+	// Clear 使用相同的路径删除所有 Cookie
+	w3 := httptest.NewRecorder()
+	c3, _ := gin.CreateTestContext(w3)
+	cookies.Clear(c3)
+	cleared := w3.Result().Cookies()
+	require.Len(t, cleared, 3)
+	for _, cookie := range cleared {
+		assert.Empty(t, cookie.Value, cookie.Name)
+		assert.Less(t, cookie.MaxAge, 0, cookie.Name)
+		assert.Equal(t, set[cookie.Name].Path, cookie.Path, cookie.Name)
+	}
+
+	// 未启用时不下发 Cookie
+	w4 := httptest.NewRecorder()
+	c4, _ := gin.CreateTestContext(w4)
+	NewSessionCookies(config.SessionCookieConfig{}, "trx").SetTokens(c4, tokens)
+	assert.Empty(t, w4.Result().Cookies())
+}
+
+func TestSessionCookies_SSOBinding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// 未启用 Cookie 会话时同样下发，使用会话 Cookie 的属性
+	cookies := NewSessionCookies(config.SessionCookieConfig{Secure: true, SameSite: "strict"}, "trx_admin")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	cookies.SetSSOBinding(c, "binding", 600)
+	set := w.Result().Cookies()
+	require.Len(t, set, 1)
+	assert.Equal(t, "trx_admin_sso", set[0].Name)
+	assert.Equal(t, "binding", set[0].Value)
+	assert.Equal(t, 600, set[0].MaxAge)
+	assert.True(t, set[0].HttpOnly)
+	assert.True(t, set[0].Secure)
+	assert.Equal(t, http.SameSiteStrictMode, set[0].SameSite)
+
+	// 读取后删除
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/auth/login/sso", nil)
+	c.Request.AddCookie(&http.Cookie{Name: "trx_admin_sso", Value: "binding"})
+	assert.Equal(t, "binding", cookies.TakeSSOBinding(c))
+	cleared := w.Result().Cookies()
+	require.Len(t, cleared, 1)
+	assert.Empty(t, cleared[0].Value)
+	assert.Less(t, cleared[0].MaxAge, 0)
+}
+
+func TestNewSessionCookies_SameSite(t *testing.T) {
+	assert.Equal(t, http.SameSiteLaxMode, NewSessionCookies(config.SessionCookieConfig{}, "trx").sameSite)
+	assert.Equal(t, http.SameSiteStrictMode, NewSessionCookies(config.SessionCookieConfig{SameSite: "strict"}, "trx").sameSite)
+	assert.Equal(t, http.SameSiteNoneMode, NewSessionCookies(config.SessionCookieConfig{SameSite: "none"}, "trx").sameSite)
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"trx-project/internal/model"
+	"trx-project/internal/service"
+	"trx-project/pkg/response"
+	"trx-project/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TenantHeader 平台管理员通过该请求头指定要操作的租户
+const TenantHeader = "X-Tenant-ID"
+
+// PermissionTenantManage 平台管理员权限，平台租户中拥有该权限的管理员可以跨租户操作
+const PermissionTenantManage = "tenant:manage"
+
+// setTenant 记录当前用户所属的租户，请求 context 只能访问该租户的数据
+// 租户功能上线前签发的 Token 没有租户，视为平台租户
+func setTenant(c *gin.Context, tenantID uint) {
+	if tenantID == 0 {
+		tenantID = model.PlatformTenantID
+	}
+	c.Set("tenant_id", tenantID)
+	c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), tenantID))
+}
+
+// TenantScope 确定后台请求的租户范围，需要放在 AdminAuth 之后
+//
+// 普通管理员只能访问自己所属租户的数据；平台管理员默认可以访问所有租户的数据，
+// 通过 X-Tenant-ID 请求头可以限定到某个租户
+func TenantScope(rbacService service.RBACService, tenantService service.TenantService, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ok := GetAdminID(c)
+		if !ok {
+			response.Unauthorized(c, "Unauthorized")
+			c.Abort()
+			return
+		}
+		homeTenantID, _ := GetTenantID(c)
+
+		var requested uint
+		if header := c.GetHeader(TenantHeader); header != "" {
+			id, err := strconv.ParseUint(header, 10, 32)
+			if err != nil || id == 0 {
+				response.BadRequest(c, "Invalid tenant ID")
+				c.Abort()
+				return
+			}
+			requested = uint(id)
+		}
+
+		platformAdmin := false
+		if homeTenantID == model.PlatformTenantID && apiKeyAllows(c, rbacService, PermissionTenantManage) {
+			has, err := rbacService.HasPermission(c.Request.Context(), adminID, PermissionTenantManage)
+			if err != nil {
+				logger.Error("Failed to check platform admin permission", zap.Uint("admin_id", adminID), zap.Error(err))
+				response.InternalError(c, "Internal error")
+				c.Abort()
+				return
+			}
+			platformAdmin = has
+		}
+
+		if !platformAdmin {
+			if requested != 0 && requested != homeTenantID {
+				logger.Warn("Cross-tenant access denied",
+					zap.Uint("admin_id", adminID),
+					zap.Uint("tenant_id", homeTenantID),
+					zap.Uint("requested_tenant_id", requested))
+				response.Forbidden(c, "Cross-tenant access denied")
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Set("platform_admin", true)
+		if requested == 0 {
+			c.Request = c.Request.WithContext(tenant.WithAllTenants(c.Request.Context()))
+			c.Next()
+			return
+		}
+
+		if _, err := tenantService.GetTenant(c.Request.Context(), requested); err != nil {
+			if errors.Is(err, service.ErrTenantNotFound) {
+				response.NotFound(c, err.Error())
+			} else {
+				response.InternalError(c, "Failed to get tenant")
+			}
+			c.Abort()
+			return
+		}
+		c.Set("tenant_id", requested)
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), requested))
+		c.Next()
+	}
+}
+
+// RequirePlatformAdmin 要求当前管理员是平台管理员，用于租户管理和修改所有租户共用的角色、权限定义
+// 需要放在 TenantScope 之后
+func RequirePlatformAdmin(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsPlatformAdmin(c) {
+			adminID, _ := GetAdminID(c)
+			logger.Warn("Platform admin required", zap.Uint("admin_id", adminID))
+			response.Forbidden(c, "Permission denied: "+PermissionTenantManage)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetTenantID 从上下文获取当前请求操作的租户，平台管理员未指定租户时为其所属的平台租户
+func GetTenantID(c *gin.Context) (uint, bool) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		return 0, false
+	}
+	return tenantID.(uint), true
+}
+
+// IsPlatformAdmin 当前管理员是否是平台管理员，由 TenantScope 设置
+func IsPlatformAdmin(c *gin.Context) bool {
+	return c.GetBool("platform_admin")
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trx-project/internal/model"
+	"trx-project/internal/service"
+	"trx-project/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// tenantRBACService platformAdmins 中的用户拥有 tenant:manage，scopes 按精确匹配检查
+type tenantRBACService struct {
+	scopeRBACService
+	platformAdmins map[uint]bool
+	err            error
+}
+
+func (s tenantRBACService) HasPermission(ctx context.Context, userID uint, permissionCode string) (bool, error) {
+	return permissionCode == PermissionTenantManage && s.platformAdmins[userID], s.err
+}
+
+// fakeTenantService 只有 tenants 中的租户存在
+type fakeTenantService struct {
+	service.TenantService
+	tenants map[uint]bool
+}
+
+func (s fakeTenantService) GetTenant(ctx context.Context, id uint) (*model.Tenant, error) {
+	if !s.tenants[id] {
+		return nil, service.ErrTenantNotFound
+	}
+	return &model.Tenant{ID: id}, nil
+}
+
+// tenantScopeResult 请求到达处理器时看到的租户范围
+type tenantScopeResult struct {
+	Code          int
+	TenantID      uint
+	AllTenants    bool
+	PlatformAdmin bool
+}
+
+func serveTenantScope(t *testing.T, rbac tenantRBACService, adminID, homeTenantID uint, header string, key *model.APIKey, extra ...gin.HandlerFunc) tenantScopeResult {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := zap.NewNop()
+	tenants := fakeTenantService{tenants: map[uint]bool{model.PlatformTenantID: true, 2: true, 3: true}}
+
+	var result tenantScopeResult
+	handlers := []gin.HandlerFunc{
+		func(c *gin.Context) {
+			c.Set("admin_id", adminID)
+			if key != nil {
+				c.Set("api_key", key)
+			}
+			setTenant(c, homeTenantID)
+		},
+		TenantScope(rbac, tenants, logger),
+	}
+	handlers = append(handlers, extra...)
+	handlers = append(handlers, func(c *gin.Context) {
+		ctx := c.Request.Context()
+		result.TenantID, _ = tenant.FromContext(ctx)
+		result.AllTenants = tenant.IsAllTenants(ctx)
+		result.PlatformAdmin = IsPlatformAdmin(c)
+		c.Status(http.StatusOK)
+	})
+
+	r := gin.New()
+	r.GET("/admin/users", handlers...)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+	if header != "" {
+		req.Header.Set(TenantHeader, header)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	result.Code = w.Code
+	return result
+}
+
+func TestTenantScope(t *testing.T) {
+	rbac := tenantRBACService{platformAdmins: map[uint]bool{1: true, 5: true}}
+
+	tests := []struct {
+		name     string
+		adminID  uint
+		home     uint
+		header   string
+		key      *model.APIKey
+		expected tenantScopeResult
+	}{
+		{"tenant admin scoped to own tenant", 2, 2, "", nil, tenantScopeResult{Code: http.StatusOK, TenantID: 2}},
+		{"tenant admin may name own tenant", 2, 2, "2", nil, tenantScopeResult{Code: http.StatusOK, TenantID: 2}},
+		{"tenant admin cannot name other tenant", 2, 2, "3", nil, tenantScopeResult{Code: http.StatusForbidden}},
+		// tenant:manage 只在平台租户中生效
+		{"tenant:manage outside platform tenant", 5, 2, "3", nil, tenantScopeResult{Code: http.StatusForbidden}},
+		{"platform tenant admin without tenant:manage", 3, model.PlatformTenantID, "2", nil, tenantScopeResult{Code: http.StatusForbidden}},
+		{"platform admin sees all tenants", 1, model.PlatformTenantID, "", nil, tenantScopeResult{Code: http.StatusOK, AllTenants: true, PlatformAdmin: true}},
+		{"platform admin scoped by header", 1, model.PlatformTenantID, "2", nil, tenantScopeResult{Code: http.StatusOK, TenantID: 2, PlatformAdmin: true}},
+		{"platform admin unknown tenant", 1, model.PlatformTenantID, "9", nil, tenantScopeResult{Code: http.StatusNotFound}},
+		{"invalid header", 1, model.PlatformTenantID, "abc", nil, tenantScopeResult{Code: http.StatusBadRequest}},
+		// API Key 的 scopes 不包含 tenant:manage 时只能访问所属租户
+		{"api key without tenant:manage scope", 1, model.PlatformTenantID, "2", &model.APIKey{Scopes: []string{"user:read"}}, tenantScopeResult{Code: http.StatusForbidden}},
+		{"api key with tenant:manage scope", 1, model.PlatformTenantID, "2", &model.APIKey{Scopes: []string{PermissionTenantManage}}, tenantScopeResult{Code: http.StatusOK, TenantID: 2, PlatformAdmin: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, serveTenantScope(t, rbac, tt.adminID, tt.home, tt.header, tt.key))
+		})
+	}
+}
+
+func TestTenantScope_PermissionCheckFails(t *testing.T) {
+	rbac := tenantRBACService{err: errors.New("redis unavailable")}
+	result := serveTenantScope(t, rbac, 1, model.PlatformTenantID, "", nil)
+	assert.Equal(t, http.StatusInternalServerError, result.Code)
+}
+
+func TestRequirePlatformAdmin(t *testing.T) {
+	rbac := tenantRBACService{platformAdmins: map[uint]bool{1: true}}
+	logger := zap.NewNop()
+
+	assert.Equal(t, http.StatusOK, serveTenantScope(t, rbac, 1, model.PlatformTenantID, "", nil, RequirePlatformAdmin(logger)).Code)
+	// 平台管理员限定到某个租户时仍是平台管理员
+	assert.Equal(t, http.StatusOK, serveTenantScope(t, rbac, 1, model.PlatformTenantID, "2", nil, RequirePlatformAdmin(logger)).Code)
+	assert.Equal(t, http.StatusForbidden, serveTenantScope(t, rbac, 2, 2, "", nil, RequirePlatformAdmin(logger)).Code)
+	assert.Equal(t, http.StatusForbidden, serveTenantScope(t, rbac, 3, model.PlatformTenantID, "", nil, RequirePlatformAdmin(logger)).Code)
+}
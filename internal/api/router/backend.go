@@ -20,11 +20,27 @@ import (
 
 // SetupBackend 设置后端路由器
 func SetupBackend(
+	adminAuthHandler *backendHandler.AdminAuthHandler,
 	adminUserHandler *backendHandler.AdminUserHandler,
 	rbacHandler *backendHandler.RBACHandler,
+	adminMFAHandler *backendHandler.AdminMFAHandler,
+	adminLockoutHandler *backendHandler.AdminLockoutHandler,
+	adminSessionHandler *backendHandler.AdminSessionHandler,
+	adminAPIKeyHandler *backendHandler.AdminAPIKeyHandler,
+	serviceAccountHandler *backendHandler.ServiceAccountHandler,
+	impersonationHandler *backendHandler.ImpersonationHandler,
+	adminPasskeyHandler *backendHandler.AdminPasskeyHandler,
+	adminSSOHandler *backendHandler.AdminSSOHandler,
+	tenantHandler *backendHandler.TenantHandler,
 	rbacService service.RBACService,
-	jwtSecret string,
+	authzService service.AuthorizationService,
+	userService service.UserService,
+	tenantService service.TenantService,
+	tokenService service.TokenService,
+	apiKeyService service.APIKeyService,
+	cookies *middleware.SessionCookies,
 	redisClient *redis.Client,
+	m *metrics.Metrics,
 	cfg *config.Config,
 	logger *zap.Logger,
 	mode string,
@@ -34,11 +50,8 @@ func SetupBackend(
 
 	r := gin.New()
 
-	// 创建 Prometheus 指标
-	m := metrics.NewMetrics("trx")
-
 	// 应用全局中间件
-	// 顺序很重要：Recovery → OpenTelemetry → RequestID → Prometheus → Logger → CORS
+	// 顺序很重要：Recovery → OpenTelemetry → RequestID → ClientInfo → Prometheus → Logger → CORS
 	r.Use(middleware.Recovery(logger))
 
 	// OpenTelemetry 链路追踪
@@ -48,6 +61,7 @@ func SetupBackend(
 	}
 
 	r.Use(middleware.RequestID(logger))                  // 请求 ID 追踪
+	r.Use(middleware.ClientInfo())                       // 客户端 IP 和 User-Agent，登录时记录到会话中
 	r.Use(middleware.PrometheusMiddleware(m, "backend")) // Prometheus 监控
 	r.Use(middleware.Logger(logger))                     // 日志记录（会包含请求 ID）
 	r.Use(middleware.CORS())
@@ -87,36 +101,120 @@ func SetupBackend(
 
 	// API v1 路由
 	v1 := r.Group("/api/v1")
+	v1.Use(cookies.CSRF(logger)) // 使用会话 Cookie 认证的写请求需要校验 CSRF Token
 	{
-		// 所有后台接口都需要管理员认证
+		// 管理员登录（无需认证）
+		adminPublic := v1.Group("/admin/auth")
+		{
+			adminPublic.POST("/login", adminAuthHandler.Login)
+			adminPublic.POST("/login/mfa", adminAuthHandler.LoginMFA)                // 完成两步验证登录
+			adminPublic.POST("/login/mfa/setup", adminAuthHandler.SetupLoginMFA)     // 登录时绑定两步验证
+			adminPublic.POST("/login/passkey/begin", adminPasskeyHandler.BeginLogin) // 发起通行密钥登录
+			adminPublic.POST("/login/passkey", adminPasskeyHandler.Login)            // 通行密钥登录，视为已完成两步验证
+			adminPublic.POST("/login/sso/begin", adminSSOHandler.BeginLogin)         // 发起单点登录，返回授权地址
+			adminPublic.POST("/login/sso", adminSSOHandler.Login)                    // 单点登录回调
+			adminPublic.POST("/refresh", adminAuthHandler.RefreshToken)
+		}
+
+		// 其余后台接口都需要管理员认证
 		admin := v1.Group("/admin")
-		admin.Use(middleware.AdminAuth(jwtSecret, logger))
+		admin.Use(middleware.AdminAuth(tokenService, apiKeyService, cookies, logger))
 		// 管理员用户级别限流（需要在认证中间件之后）
 		if cfg.RateLimit.Enabled && cfg.RateLimit.UserRate != "" {
 			rateLimiter := middleware.NewRateLimiter(redisClient, logger)
 			admin.Use(rateLimiter.UserRateLimit(cfg.RateLimit.UserRate))
 		}
 		{
+			// ==================== 管理员认证 ====================
+			adminAuth := admin.Group("/auth")
+			{
+				adminAuth.GET("/me", adminAuthHandler.Me) // 当前管理员信息
+
+				// 以下接口只能使用登录会话访问，不能使用 API Key
+				adminSession := adminAuth.Group("", middleware.DenyAPIKey(logger))
+				adminSession.POST("/logout", adminAuthHandler.Logout) // 退出登录
+
+				// 登录设备
+				adminSession.GET("/sessions", adminSessionHandler.ListMySessions)         // 当前管理员的登录设备
+				adminSession.DELETE("/sessions/:id", adminSessionHandler.RevokeMySession) // 移除登录设备
+
+				// 两步验证
+				adminSession.GET("/mfa", adminMFAHandler.GetStatus)                               // 两步验证状态
+				adminSession.POST("/mfa/enroll", adminMFAHandler.BeginEnrollment)                 // 开始绑定
+				adminSession.POST("/mfa/confirm", adminMFAHandler.ConfirmEnrollment)              // 确认绑定
+				adminSession.POST("/mfa/disable", adminMFAHandler.Disable)                        // 关闭
+				adminSession.POST("/mfa/recovery-codes", adminMFAHandler.RegenerateRecoveryCodes) // 重新生成恢复码
+
+				// 通行密钥
+				adminSession.GET("/passkeys", adminPasskeyHandler.ListMyPasskeys)                    // 当前管理员的通行密钥
+				adminSession.POST("/passkeys/register/begin", adminPasskeyHandler.BeginRegistration) // 发起注册
+				adminSession.POST("/passkeys/register", adminPasskeyHandler.FinishRegistration)      // 完成注册
+				adminSession.DELETE("/passkeys/:id", adminPasskeyHandler.DeleteMyPasskey)            // 删除通行密钥
+
+				// API Key
+				adminSession.GET("/api-keys", adminAPIKeyHandler.ListMyAPIKeys)         // 当前管理员的 API Key
+				adminSession.POST("/api-keys", adminAPIKeyHandler.CreateMyAPIKey)       // 创建 API Key
+				adminSession.DELETE("/api-keys/:id", adminAPIKeyHandler.RevokeMyAPIKey) // 吊销 API Key
+			}
+
+			// 以下接口按租户隔离数据：普通管理员只能访问自己租户的数据，平台管理员可以通过 X-Tenant-ID 指定租户
+			scoped := admin.Group("", middleware.TenantScope(rbacService, tenantService, logger))
+			platformAdmin := middleware.RequirePlatformAdmin(logger)
+
 			// ==================== RBAC 管理 ====================
-			rbac := admin.Group("/rbac")
-			rbac.Use(middleware.RequirePermission("rbac:manage", rbacService, logger)) // 需要 RBAC 管理权限
+			// 角色和权限接口没有可以按授权条件检查的用户，需要无条件的 rbac:manage 授权
+			rbac := scoped.Group("/rbac")
+			rbac.Use(
+				middleware.RequirePermission("rbac:manage", rbacService, logger), // 需要 RBAC 管理权限
+				middleware.RequireUnconditional("rbac:manage", authzService, logger),
+			)
 			{
+				// 角色和权限定义由所有租户共用，只有平台管理员可以修改
+				rbacPlatform := rbac.Group("", platformAdmin)
+
 				// 角色管理
-				rbac.GET("/roles", rbacHandler.ListRoles)                                // 获取角色列表
-				rbac.GET("/roles/:id", rbacHandler.GetRole)                              // 获取角色详情
-				rbac.POST("/roles", rbacHandler.CreateRole)                              // 创建角色
-				rbac.POST("/roles/:id/permissions", rbacHandler.AssignPermissionsToRole) // 为角色分配权限
+				rbac.GET("/roles", rbacHandler.ListRoles)                                                // 获取角色列表
+				rbac.GET("/roles/:id", rbacHandler.GetRole)                                              // 获取角色详情
+				rbacPlatform.POST("/roles", rbacHandler.CreateRole)                                      // 创建角色
+				rbacPlatform.PUT("/roles/:id", rbacHandler.UpdateRole)                                   // 更新角色
+				rbacPlatform.PUT("/roles/:id/status", rbacHandler.UpdateRoleStatus)                      // 启用或禁用角色
+				rbacPlatform.PUT("/roles/:id/parent", rbacHandler.SetRoleParent)                         // 设置上级角色
+				rbacPlatform.DELETE("/roles/:id", rbacHandler.DeleteRole)                                // 删除角色
+				rbacPlatform.POST("/roles/:id/permissions", rbacHandler.AssignPermissionsToRole)         // 为角色分配权限
+				rbacPlatform.DELETE("/roles/:id/permissions", rbacHandler.RemovePermissionsFromRole)     // 移除角色的权限
+				rbac.GET("/roles/:id/grants", rbacHandler.GetRoleGrants)                                 // 获取角色的有效授权及条件
+				rbacPlatform.PUT("/roles/:id/permissions/:pid/condition", rbacHandler.SetGrantCondition) // 设置授权条件
+				rbacPlatform.PUT("/roles/:id/mfa", adminMFAHandler.SetRoleMFARequirement)                // 设置角色两步验证要求
 
 				// 权限管理
-				rbac.GET("/permissions", rbacHandler.ListPermissions) // 获取权限列表
+				rbac.GET("/permissions", rbacHandler.ListPermissions)                           // 获取权限列表
+				rbac.GET("/permissions/:id", rbacHandler.GetPermission)                         // 获取权限详情
+				rbacPlatform.POST("/permissions", rbacHandler.CreatePermission)                 // 创建权限
+				rbacPlatform.PUT("/permissions/:id", rbacHandler.UpdatePermission)              // 更新权限
+				rbacPlatform.PUT("/permissions/:id/status", rbacHandler.UpdatePermissionStatus) // 启用或禁用权限
+				rbacPlatform.DELETE("/permissions/:id", rbacHandler.DeletePermission)           // 删除权限
+			}
+
+			// ==================== 租户管理 ====================
+			tenants := scoped.Group("/tenants", platformAdmin) // 只有平台管理员可以管理租户
+			{
+				tenants.GET("", tenantHandler.ListTenants)         // 租户列表
+				tenants.GET("/:id", tenantHandler.GetTenant)       // 租户详情
+				tenants.POST("", tenantHandler.CreateTenant)       // 创建租户
+				tenants.PUT("/:id", tenantHandler.UpdateTenant)    // 修改租户
+				tenants.DELETE("/:id", tenantHandler.DeleteTenant) // 删除租户
 			}
 
 			// ==================== 用户管理 ====================
-			adminUsers := admin.Group("/users")
+			adminUsers := scoped.Group("/users")
 			{
+				// 操作单个用户的接口还会按授权条件检查该用户（AdminUserHandler 自行检查，重置密码和其他处理器使用 RequireUserAccess）
+
 				// 查看用户（需要 user:read 权限）
+				// 列表不按授权条件过滤，只有带条件的 user:read 授权时不能查看列表
 				adminUsers.GET("",
 					middleware.RequirePermission("user:read", rbacService, logger),
+					middleware.RequireUnconditional("user:read", authzService, logger),
 					adminUserHandler.ListUsers)
 				adminUsers.GET("/:id",
 					middleware.RequirePermission("user:read", rbacService, logger),
@@ -128,7 +226,43 @@ func SetupBackend(
 					adminUserHandler.UpdateUserStatus)
 				adminUsers.POST("/:id/reset-password",
 					middleware.RequirePermission("user:write", rbacService, logger),
+					middleware.RequireUserAccess("user:write", authzService, userService, logger),
 					adminUserHandler.ResetPassword)
+				adminUsers.POST("/:id/revoke-tokens",
+					middleware.RequirePermission("user:write", rbacService, logger),
+					adminUserHandler.RevokeUserTokens)
+				// 属性会改变授权条件的判断结果，需要无条件的 user:write 授权
+				adminUsers.PUT("/:id/attributes",
+					middleware.RequirePermission("user:write", rbacService, logger),
+					middleware.RequireUnconditional("user:write", authzService, logger),
+					adminUserHandler.UpdateUserAttributes) // 设置自定义属性
+				adminUsers.DELETE("/:id/mfa",
+					middleware.RequirePermission("user:write", rbacService, logger),
+					middleware.RequireUserAccess("user:write", authzService, userService, logger),
+					adminMFAHandler.ResetUserMFA)
+				adminUsers.GET("/:id/sessions",
+					middleware.RequirePermission("user:read", rbacService, logger),
+					middleware.RequireUserAccess("user:read", authzService, userService, logger),
+					adminSessionHandler.ListUserSessions) // 用户的登录设备
+				adminUsers.DELETE("/:id/sessions/:sid",
+					middleware.RequirePermission("user:write", rbacService, logger),
+					middleware.RequireUserAccess("user:write", authzService, userService, logger),
+					adminSessionHandler.RevokeUserSession) // 强制登录设备下线
+				adminUsers.GET("/:id/passkeys",
+					middleware.RequirePermission("user:read", rbacService, logger),
+					middleware.RequireUserAccess("user:read", authzService, userService, logger),
+					adminPasskeyHandler.ListUserPasskeys) // 用户的通行密钥
+				adminUsers.DELETE("/:id/passkeys/:pid",
+					middleware.RequirePermission("user:write", rbacService, logger),
+					middleware.RequireUserAccess("user:write", authzService, userService, logger),
+					adminPasskeyHandler.DeleteUserPasskey) // 删除通行密钥
+
+				// 模拟用户（需要 user:impersonate 权限，不能使用 API Key）
+				adminUsers.POST("/:id/impersonate",
+					middleware.DenyAPIKey(logger),
+					middleware.RequirePermission("user:impersonate", rbacService, logger),
+					middleware.RequireUserAccess("user:impersonate", authzService, userService, logger),
+					impersonationHandler.Impersonate)
 
 				// 删除用户（需要 user:delete 权限）
 				adminUsers.DELETE("/:id",
@@ -138,18 +272,63 @@ func SetupBackend(
 				// 用户角色管理（需要 rbac:manage 权限）
 				adminUsers.POST("/:id/role",
 					middleware.RequirePermission("rbac:manage", rbacService, logger),
+					middleware.RequireUserAccess("rbac:manage", authzService, userService, logger),
 					rbacHandler.AssignRoleToUser)
 				adminUsers.GET("/:id/roles",
 					middleware.RequirePermission("rbac:manage", rbacService, logger),
+					middleware.RequireUserAccess("rbac:manage", authzService, userService, logger),
 					rbacHandler.GetUserRoles)
+				adminUsers.DELETE("/:id/roles/:rid",
+					middleware.RequirePermission("rbac:manage", rbacService, logger),
+					middleware.RequireUserAccess("rbac:manage", authzService, userService, logger),
+					rbacHandler.RemoveRoleFromUser)
 				adminUsers.GET("/:id/permissions",
 					middleware.RequirePermission("rbac:manage", rbacService, logger),
+					middleware.RequireUserAccess("rbac:manage", authzService, userService, logger),
 					rbacHandler.GetUserPermissions)
+
+				// 移动用户到其他租户（只有平台管理员可以操作）
+				adminUsers.PUT("/:id/tenant", platformAdmin, tenantHandler.MoveUser)
+			}
+
+			// ==================== 登录锁定 ====================
+			// 锁定按用户名和 IP 记录在 Redis 中，不属于任何租户，只有平台管理员可以查看和解除；没有可以按授权条件检查的用户，需要无条件的授权
+			lockouts := scoped.Group("/lockouts", platformAdmin)
+			{
+				lockouts.GET("",
+					middleware.RequirePermission("user:read", rbacService, logger),
+					middleware.RequireUnconditional("user:read", authzService, logger),
+					adminLockoutHandler.ListLockouts) // 查看登录锁定
+				lockouts.DELETE("",
+					middleware.RequirePermission("user:write", rbacService, logger),
+					middleware.RequireUnconditional("user:write", authzService, logger),
+					adminLockoutHandler.ClearLockout) // 解除登录锁定
+			}
+
+			// ==================== 服务账号 ====================
+			// 授权条件只对用户管理接口生效，服务账号接口需要无条件的 apikey:manage 授权
+			serviceAccounts := scoped.Group("/service-accounts")
+			serviceAccounts.Use(
+				middleware.DenyAPIKey(logger), // API Key 不能创建 API Key
+				middleware.RequirePermission("apikey:manage", rbacService, logger),
+				middleware.RequireUnconditional("apikey:manage", authzService, logger),
+			)
+			{
+				serviceAccounts.GET("", serviceAccountHandler.ListServiceAccounts)                  // 服务账号列表
+				serviceAccounts.POST("", serviceAccountHandler.CreateServiceAccount)                // 创建服务账号
+				serviceAccounts.DELETE("/:id", serviceAccountHandler.DeleteServiceAccount)          // 删除服务账号
+				serviceAccounts.GET("/:id/api-keys", serviceAccountHandler.ListAPIKeys)             // 服务账号的 API Key
+				serviceAccounts.POST("/:id/api-keys", serviceAccountHandler.CreateAPIKey)           // 创建 API Key
+				serviceAccounts.DELETE("/:id/api-keys/:key_id", serviceAccountHandler.RevokeAPIKey) // 吊销 API Key
 			}
 
 			// ==================== 统计信息 ====================
-			adminStats := admin.Group("/statistics")
-			adminStats.Use(middleware.RequirePermission("statistics:read", rbacService, logger))
+			// 统计全部用户，需要无条件的授权
+			adminStats := scoped.Group("/statistics")
+			adminStats.Use(
+				middleware.RequirePermission("statistics:read", rbacService, logger),
+				middleware.RequireUnconditional("statistics:read", authzService, logger),
+			)
 			{
 				adminStats.GET("/users", adminUserHandler.GetStatistics) // 用户统计
 			}
@@ -0,0 +1,299 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"trx-project/internal/api/handler/backendHandler"
+	"trx-project/internal/api/middleware"
+	"trx-project/internal/model"
+	"trx-project/internal/service"
+	"trx-project/pkg/config"
+	"trx-project/pkg/jwt"
+	"trx-project/pkg/metrics"
+	"trx-project/pkg/policy"
+	"trx-project/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// 测试数据：租户 2 的管理员（ID 10）、平台管理员（ID 1），租户 3 中的用户 100
+const (
+	tenantAdminToken   = "tenant-admin"
+	platformAdminToken = "platform-admin"
+	otherTenantUserID  = "100"
+)
+
+var testUsers = map[uint]*model.User{
+	10:  {ID: 10, Username: "acme-admin", TenantID: 2},
+	100: {ID: 100, Username: "globex-user", TenantID: 3, AccountType: model.AccountTypeService},
+}
+
+// visibleUser 模拟 GORM 租户插件：context 限定了租户时只能看到该租户的用户
+func visibleUser(ctx context.Context, id uint) (*model.User, bool) {
+	user, ok := testUsers[id]
+	if !ok {
+		return nil, false
+	}
+	if tenantID, scoped := tenant.FromContext(ctx); scoped && tenantID != user.TenantID {
+		return nil, false
+	}
+	return user, true
+}
+
+type testTokenService struct {
+	service.TokenService
+}
+
+func (testTokenService) ParseAccessToken(ctx context.Context, token string) (*jwt.Claims, error) {
+	switch token {
+	case tenantAdminToken:
+		return &jwt.Claims{UserID: 10, Username: "acme-admin", Role: jwt.RoleAdmin, TenantID: 2}, nil
+	case platformAdminToken:
+		return &jwt.Claims{UserID: 1, Username: "root", Role: jwt.RoleSuperAdmin, TenantID: model.PlatformTenantID}, nil
+	}
+	return nil, jwt.ErrTokenInvalid
+}
+
+// testRBACService 两个管理员拥有所有权限，只有平台管理员拥有 tenant:manage
+type testRBACService struct {
+	service.RBACService
+}
+
+func (testRBACService) CheckPermission(ctx context.Context, userID uint, permissionCode string) error {
+	return nil
+}
+
+func (testRBACService) HasPermission(ctx context.Context, userID uint, permissionCode string) (bool, error) {
+	return permissionCode != middleware.PermissionTenantManage || userID == 1, nil
+}
+
+func (testRBACService) GrantsAllow(grants []string, permissionCode string) bool {
+	return true
+}
+
+// testAuthzService 允许所有请求，conditional 为 true 时模拟只有带条件的授权
+type testAuthzService struct {
+	conditional bool
+}
+
+func (testAuthzService) Authorize(ctx context.Context, userID uint, permissionCode string, resource, request policy.Attributes) error {
+	return nil
+}
+
+func (s testAuthzService) AuthorizeUnconditional(ctx context.Context, userID uint, permissionCode string) error {
+	if s.conditional {
+		return service.ErrAccessDenied
+	}
+	return nil
+}
+
+type testUserService struct {
+	service.UserService
+}
+
+func (testUserService) GetUserByID(ctx context.Context, id uint) (*model.User, error) {
+	if user, ok := visibleUser(ctx, id); ok {
+		return user, nil
+	}
+	return nil, errors.New("user not found")
+}
+
+type testTenantService struct {
+	service.TenantService
+}
+
+func (testTenantService) GetTenant(ctx context.Context, id uint) (*model.Tenant, error) {
+	if id > 3 {
+		return nil, service.ErrTenantNotFound
+	}
+	return &model.Tenant{ID: id}, nil
+}
+
+type testServiceAccountService struct {
+	service.ServiceAccountService
+}
+
+func (testServiceAccountService) find(ctx context.Context, id uint) error {
+	if user, ok := visibleUser(ctx, id); ok && user.IsServiceAccount() {
+		return nil
+	}
+	return service.ErrServiceAccountNotFound
+}
+
+func (s testServiceAccountService) DeleteServiceAccount(ctx context.Context, actorID, id uint, ip string) error {
+	return s.find(ctx, id)
+}
+
+func (s testServiceAccountService) CreateKey(ctx context.Context, actorID, accountID uint, input service.CreateAPIKeyInput, ip string) (*service.CreatedAPIKey, error) {
+	return nil, s.find(ctx, accountID)
+}
+
+func (s testServiceAccountService) ListKeys(ctx context.Context, accountID uint) ([]*model.APIKey, error) {
+	return nil, s.find(ctx, accountID)
+}
+
+func (s testServiceAccountService) RevokeKey(ctx context.Context, actorID, accountID, keyID uint, ip string) error {
+	return s.find(ctx, accountID)
+}
+
+var testMetrics = metrics.NewMetrics("router_test")
+
+// newTestBackend 创建后台路由，只有租户隔离测试用到的处理器是真实的，其余处理器不会被调用
+func newTestBackend() *gin.Engine {
+	return newTestBackendWithAuthz(testAuthzService{})
+}
+
+func newTestBackendWithAuthz(authz testAuthzService) *gin.Engine {
+	logger := zap.NewNop()
+	userService := testUserService{}
+	tenantService := testTenantService{}
+
+	return SetupBackend(
+		nil,
+		backendHandler.NewAdminUserHandler(userService, nil, authz, logger),
+		nil, nil, nil, nil, nil,
+		backendHandler.NewServiceAccountHandler(testServiceAccountService{}, logger),
+		nil, nil, nil,
+		backendHandler.NewTenantHandler(tenantService, logger),
+		testRBACService{},
+		authz,
+		userService,
+		tenantService,
+		testTokenService{},
+		nil,
+		middleware.NewSessionCookies(config.SessionCookieConfig{}, "trx"),
+		nil,
+		testMetrics,
+		&config.Config{},
+		logger,
+		gin.TestMode,
+	)
+}
+
+// testBody 满足各个处理器请求体校验的请求体，确保请求在加载资源时才被拒绝
+const testBody = `{"status":1,"new_password":"n3w-Passw0rd!","attributes":{},"name":"key","expires_in_days":1,"role_id":1,"reason":"test","tenant_id":2}`
+
+func serveAdmin(r *gin.Engine, method, path, token, tenantHeader string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(testBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if tenantHeader != "" {
+		req.Header.Set(middleware.TenantHeader, tenantHeader)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+// scopedRoutes 所有按租户隔离的后台路由，路径参数替换为租户 3 中的用户
+func scopedRoutes(r *gin.Engine) []gin.RouteInfo {
+	var routes []gin.RouteInfo
+	for _, route := range r.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/v1/admin/") || strings.HasPrefix(route.Path, "/api/v1/admin/auth/") {
+			continue
+		}
+		segments := strings.Split(route.Path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = otherTenantUserID
+			}
+		}
+		route.Path = strings.Join(segments, "/")
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+func TestBackend_CrossTenantHeaderForbidden(t *testing.T) {
+	r := newTestBackend()
+	routes := scopedRoutes(r)
+	assert.NotEmpty(t, routes)
+
+	// 租户管理员通过 X-Tenant-ID 访问其他租户，任何后台接口都返回 403
+	for _, route := range routes {
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			assert.Equal(t, http.StatusForbidden, serveAdmin(r, route.Method, route.Path, tenantAdminToken, "3"))
+		})
+	}
+}
+
+func TestBackend_CrossTenantResourceNotFound(t *testing.T) {
+	r := newTestBackend()
+
+	for _, route := range scopedRoutes(r) {
+		prefixUsers := strings.HasPrefix(route.Path, "/api/v1/admin/users/"+otherTenantUserID)
+		prefixAccounts := strings.HasPrefix(route.Path, "/api/v1/admin/service-accounts/"+otherTenantUserID)
+		if !prefixUsers && !prefixAccounts || strings.HasSuffix(route.Path, "/tenant") {
+			continue
+		}
+
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			// 租户 2 的管理员访问租户 3 的用户，用户像不存在一样
+			assert.Equal(t, http.StatusNotFound, serveAdmin(r, route.Method, route.Path, tenantAdminToken, ""))
+			// 平台管理员限定到租户 2 时同样看不到
+			assert.Equal(t, http.StatusNotFound, serveAdmin(r, route.Method, route.Path, platformAdminToken, "2"))
+		})
+	}
+}
+
+func TestBackend_PlatformAdminRoutes(t *testing.T) {
+	r := newTestBackend()
+
+	routes := []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/admin/tenants"},
+		{http.MethodGet, "/api/v1/admin/tenants/3"},
+		{http.MethodPost, "/api/v1/admin/tenants"},
+		{http.MethodPut, "/api/v1/admin/tenants/3"},
+		{http.MethodDelete, "/api/v1/admin/tenants/3"},
+		{http.MethodPut, "/api/v1/admin/users/100/tenant"},
+		{http.MethodGet, "/api/v1/admin/lockouts"},
+		{http.MethodDelete, "/api/v1/admin/lockouts"},
+		{http.MethodPost, "/api/v1/admin/rbac/roles"},
+		{http.MethodPut, "/api/v1/admin/rbac/roles/3"},
+		{http.MethodDelete, "/api/v1/admin/rbac/roles/3"},
+		{http.MethodPut, "/api/v1/admin/rbac/roles/3/permissions/1/condition"},
+		{http.MethodPost, "/api/v1/admin/rbac/permissions"},
+		{http.MethodDelete, "/api/v1/admin/rbac/permissions/1"},
+	}
+
+	// 租户管理员不能访问租户管理、登录锁定和共用的角色权限定义
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			assert.Equal(t, http.StatusForbidden, serveAdmin(r, route.method, route.path, tenantAdminToken, ""))
+		})
+	}
+}
+
+func TestBackend_ConditionalGrantRoutes(t *testing.T) {
+	r := newTestBackendWithAuthz(testAuthzService{conditional: true})
+
+	prefixes := []string{
+		"/api/v1/admin/rbac/",
+		"/api/v1/admin/service-accounts",
+		"/api/v1/admin/statistics/",
+		"/api/v1/admin/lockouts",
+	}
+	var checked int
+	for _, route := range scopedRoutes(r) {
+		matched := false
+		for _, prefix := range prefixes {
+			matched = matched || strings.HasPrefix(route.Path, prefix)
+		}
+		if !matched {
+			continue
+		}
+		checked++
+
+		// 这些接口没有可以按条件检查的用户，只有带条件的授权时返回 403
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			assert.Equal(t, http.StatusForbidden, serveAdmin(r, route.Method, route.Path, platformAdminToken, ""))
+		})
+	}
+	assert.Positive(t, checked)
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"trx-project/internal/api/handler/frontendHandler"
 	"trx-project/internal/api/middleware"
+	"trx-project/internal/service"
 	"trx-project/pkg/config"
 	"trx-project/pkg/metrics"
 
@@ -20,8 +21,19 @@ import (
 // SetupFrontend 设置前端路由器
 func SetupFrontend(
 	userHandler *frontendHandler.UserHandler,
-	jwtSecret string,
+	jwksHandler *frontendHandler.JWKSHandler,
+	mfaHandler *frontendHandler.MFAHandler,
+	passwordHandler *frontendHandler.PasswordHandler,
+	emailVerificationHandler *frontendHandler.EmailVerificationHandler,
+	sessionHandler *frontendHandler.SessionHandler,
+	apiKeyHandler *frontendHandler.APIKeyHandler,
+	magicLinkHandler *frontendHandler.MagicLinkHandler,
+	tokenService service.TokenService,
+	apiKeyService service.APIKeyService,
+	rbacService service.RBACService,
+	cookies *middleware.SessionCookies,
 	redisClient *redis.Client,
+	m *metrics.Metrics,
 	cfg *config.Config,
 	logger *zap.Logger,
 	mode string,
@@ -31,11 +43,8 @@ func SetupFrontend(
 
 	r := gin.New()
 
-	// 创建 Prometheus 指标
-	m := metrics.NewMetrics("trx")
-
 	// 应用全局中间件
-	// 顺序很重要：Recovery → OpenTelemetry → RequestID → Prometheus → Logger → CORS
+	// 顺序很重要：Recovery → OpenTelemetry → RequestID → ClientInfo → Prometheus → Logger → CORS
 	r.Use(middleware.Recovery(logger))
 
 	// OpenTelemetry 链路追踪
@@ -45,6 +54,7 @@ func SetupFrontend(
 	}
 
 	r.Use(middleware.RequestID(logger))                   // 请求 ID 追踪
+	r.Use(middleware.ClientInfo())                        // 客户端 IP 和 User-Agent，登录时记录到会话中
 	r.Use(middleware.PrometheusMiddleware(m, "frontend")) // Prometheus 监控
 	r.Use(middleware.Logger(logger))                      // 日志记录（会包含请求 ID）
 	r.Use(middleware.CORS())
@@ -75,6 +85,9 @@ func SetupFrontend(
 		})
 	})
 
+	// JWT 验证公钥，供其他服务离线验证 Token
+	r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// Prometheus metrics 端点
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
@@ -84,25 +97,65 @@ func SetupFrontend(
 
 	// API v1 路由
 	v1 := r.Group("/api/v1")
+	v1.Use(cookies.CSRF(logger)) // 使用会话 Cookie 认证的写请求需要校验 CSRF Token
 	{
 		// 公开接口（无需认证）
 		public := v1.Group("/public")
 		{
 			public.POST("/register", userHandler.Register)
 			public.POST("/login", userHandler.Login)
+			public.POST("/login/mfa", userHandler.LoginMFA)                              // 完成两步验证登录
+			public.POST("/login/mfa/setup", userHandler.SetupLoginMFA)                   // 登录时绑定两步验证
+			public.POST("/login/magic-link", magicLinkHandler.RequestMagicLink)          // 申请免密登录链接
+			public.POST("/login/magic-link/verify", magicLinkHandler.LoginWithMagicLink) // 使用免密登录链接登录
+			public.POST("/refresh", userHandler.RefreshToken)
+			public.POST("/password/forgot", passwordHandler.ForgotPassword)           // 发送重置密码链接
+			public.POST("/password/reset", passwordHandler.ResetPassword)             // 使用链接中的 token 重置密码
+			public.POST("/email/verify", emailVerificationHandler.VerifyEmail)        // 使用链接中的 token 验证邮箱
+			public.POST("/email/resend", emailVerificationHandler.ResendVerification) // 重新发送邮箱验证链接
 		}
 
 		// 用户接口（需要用户认证）
 		user := v1.Group("/user")
-		user.Use(middleware.Auth(jwtSecret, logger))
+		user.Use(middleware.Auth(tokenService, apiKeyService, cookies, logger))
 		// 用户级别限流（需要在认证中间件之后）
 		if cfg.RateLimit.Enabled && cfg.RateLimit.UserRate != "" {
 			rateLimiter := middleware.NewRateLimiter(redisClient, logger)
 			user.Use(rateLimiter.UserRateLimit(cfg.RateLimit.UserRate))
 		}
 		{
-			user.GET("/profile", userHandler.GetProfile)
-			user.PUT("/profile", userHandler.UpdateProfile)
+			// 使用 API Key 访问时每个接口都要检查 Key 的 scopes
+			user.GET("/profile", middleware.RequireAPIKeyScope("user:read", rbacService, logger), userHandler.GetProfile)
+			user.PUT("/profile",
+				middleware.RequireAPIKeyScope("user:write", rbacService, logger),
+				middleware.DenyImpersonation(logger),
+				userHandler.UpdateProfile)
+
+			// 以下接口只能使用登录会话访问，不能使用 API Key
+			session := user.Group("", middleware.DenyAPIKey(logger))
+			session.POST("/logout", userHandler.Logout) // 模拟登录时用于提前结束模拟
+
+			// 以下接口在管理员模拟登录时同样不可用
+			account := session.Group("", middleware.DenyImpersonation(logger))
+			account.POST("/logout-all", userHandler.LogoutAll)
+			account.PUT("/password", passwordHandler.ChangePassword)
+
+			// 登录设备管理
+			account.GET("/sessions", sessionHandler.ListSessions)
+			account.DELETE("/sessions", sessionHandler.RevokeOtherSessions) // 退出其他设备
+			account.DELETE("/sessions/:id", sessionHandler.RevokeSession)
+
+			// 两步验证
+			account.GET("/mfa", mfaHandler.GetStatus)
+			account.POST("/mfa/enroll", mfaHandler.BeginEnrollment)
+			account.POST("/mfa/confirm", mfaHandler.ConfirmEnrollment)
+			account.POST("/mfa/disable", mfaHandler.Disable)
+			account.POST("/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+
+			// API Key
+			account.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+			account.POST("/api-keys", apiKeyHandler.CreateAPIKey)
+			account.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
 		}
 
 		// 兼容旧接口（临时保留）
@@ -112,16 +165,19 @@ func SetupFrontend(
 			users.POST("/login", userHandler.Login)
 			// 以下接口需要认证
 			usersAuth := users.Group("")
-			usersAuth.Use(middleware.Auth(jwtSecret, logger))
+			usersAuth.Use(middleware.Auth(tokenService, apiKeyService, cookies, logger))
 			// 用户级别限流
 			if cfg.RateLimit.Enabled && cfg.RateLimit.UserRate != "" {
 				rateLimiter := middleware.NewRateLimiter(redisClient, logger)
 				usersAuth.Use(rateLimiter.UserRateLimit(cfg.RateLimit.UserRate))
 			}
 			{
-				usersAuth.GET("", userHandler.ListUsers)
-				usersAuth.GET("/:id", userHandler.GetUser)
-				usersAuth.DELETE("/:id", userHandler.DeleteUser)
+				usersAuth.GET("", middleware.RequireAPIKeyScope("user:read", rbacService, logger), userHandler.ListUsers)
+				usersAuth.GET("/:id", middleware.RequireAPIKeyScope("user:read", rbacService, logger), userHandler.GetUser)
+				usersAuth.DELETE("/:id",
+					middleware.RequireAPIKeyScope("user:delete", rbacService, logger),
+					middleware.DenyImpersonation(logger),
+					userHandler.DeleteUser)
 			}
 		}
 	}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// APIKeyPrefix API Key 的固定前缀，便于识别和密钥扫描
+const APIKeyPrefix = "trx_"
+
+// APIKey API Key，明文只在创建时返回一次，数据库中只保存 SHA-256 哈希
+// 请求的权限为 Scopes 与所有者当前权限的交集
+type APIKey struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"` // 所有者，可以是普通用户、管理员或服务账号
+	Name       string     `gorm:"not null;size:100" json:"name"`
+	Prefix     string     `gorm:"not null;size:16" json:"prefix"`          // 明文的前几位，用于在列表中辨认
+	KeyHash    string     `gorm:"uniqueIndex;not null;size:64" json:"-"`   // 明文的 SHA-256 十六进制
+	Scopes     []string   `gorm:"serializer:json;type:json" json:"scopes"` // 允许使用的权限编码
+	CreatedBy  uint       `gorm:"not null" json:"created_by"`              // 创建人，为所有者本人或管理服务账号的管理员
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`              // 过期时间
+	LastUsedAt *time.Time `json:"last_used_at"`                            // 最近使用时间
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`                    // 吊销时间，为空表示有效
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Active 是否未吊销且未过期
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && now.Before(k.ExpiresAt)
+}
+
+// HasScope 是否包含指定权限编码（精确匹配，接口权限检查使用 RBACService.GrantsAllow，支持通配符和蕴含）
+func (k *APIKey) HasScope(code string) bool {
+	for _, scope := range k.Scopes {
+		if scope == code {
+			return true
+		}
+	}
+	return false
+}
+
+// TableName 指定表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
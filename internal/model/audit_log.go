@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// 审计操作类型
+const (
+	AuditActionMFAReset              = "mfa.reset"               // 管理员重置用户两步验证
+	AuditActionPasswordReset         = "password.admin_reset"    // 管理员重置用户密码
+	AuditActionRoleMFAUpdated        = "role.mfa_updated"        // 修改角色的两步验证要求
+	AuditActionLoginLockoutCleared   = "login.lockout_cleared"   // 管理员解除登录锁定
+	AuditActionSessionRevoked        = "session.revoked"         // 管理员强制用户会话退出登录
+	AuditActionAPIKeyCreated         = "api_key.created"         // 创建 API Key
+	AuditActionAPIKeyRevoked         = "api_key.revoked"         // 吊销 API Key
+	AuditActionServiceAccountCreated = "service_account.created" // 创建服务账号
+	AuditActionServiceAccountDeleted = "service_account.deleted" // 删除服务账号
+	AuditActionUserImpersonated      = "user.impersonated"       // 管理员模拟用户登录前台
+	AuditActionPasskeyRegistered     = "passkey.registered"      // 注册通行密钥
+	AuditActionPasskeyRemoved        = "passkey.removed"         // 删除通行密钥
+	AuditActionSSOUserProvisioned    = "sso.user_provisioned"    // 单点登录首次登录自动创建账号
+	AuditActionSSORolesSynced        = "sso.roles_synced"        // 单点登录按组映射同步角色
+	AuditActionLDAPUserProvisioned   = "ldap.user_provisioned"   // LDAP 首次登录自动创建账号
+	AuditActionLDAPRolesSynced       = "ldap.roles_synced"       // LDAP 登录按组映射同步角色
+	AuditActionUserTenantChanged     = "user.tenant_changed"     // 平台管理员将用户移到其他租户
+)
+
+// AuditLog 审计日志，记录管理员的敏感操作
+type AuditLog struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	ActorID    uint      `gorm:"index;not null" json:"actor_id"`        // 操作人用户 ID
+	Action     string    `gorm:"index;not null;size:100" json:"action"` // 操作类型
+	TargetType string    `gorm:"not null;size:50" json:"target_type"`   // 操作对象类型：user, role, login
+	TargetID   uint      `gorm:"not null" json:"target_id"`             // 操作对象 ID
+	Detail     string    `gorm:"size:1000" json:"detail"`               // 操作详情
+	IP         string    `gorm:"size:64" json:"ip"`                     // 操作人 IP
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName 指定表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
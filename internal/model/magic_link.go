@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// MagicLinkToken 免密登录链接令牌，只保存摘要，每个令牌只能使用一次
+type MagicLinkToken struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex;not null;size:64" json:"-"` // 令牌 SHA-256 摘要
+	NonceHash string     `gorm:"not null;size:64" json:"-"`             // 发起请求的浏览器持有的 nonce 的 SHA-256 摘要
+	IP        string     `gorm:"size:45" json:"ip"`                     // 发起请求的 IP
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"` // 使用（或作废）时间，为空表示未使用
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (MagicLinkToken) TableName() string {
+	return "magic_link_tokens"
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// UserMFA 用户两步验证（TOTP）配置
+type UserMFA struct {
+	UserID      uint       `gorm:"primarykey" json:"user_id"`
+	Secret      string     `gorm:"not null;size:64" json:"-"`             // TOTP 密钥（Base32）
+	Enabled     bool       `gorm:"not null;default:false" json:"enabled"` // 是否已完成绑定确认
+	LastCounter int64      `gorm:"not null;default:0" json:"-"`           // 最近一次使用的 TOTP 时间步，防止验证码重放
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`                // 绑定确认时间
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// MFARecoveryCode 两步验证恢复码，只保存摘要，每个恢复码只能使用一次
+type MFARecoveryCode struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"uniqueIndex;not null;size:64" json:"-"` // 恢复码 SHA-256 摘要
+	UsedAt    *time.Time `json:"used_at,omitempty"`                     // 使用时间，为空表示未使用
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
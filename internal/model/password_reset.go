@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// PasswordResetToken 重置密码令牌，只保存摘要，每个令牌只能使用一次
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex;not null;size:64" json:"-"` // 令牌 SHA-256 摘要
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"` // 使用（或作废）时间，为空表示未使用
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
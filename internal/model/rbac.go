@@ -6,19 +6,29 @@ import (
 	"gorm.io/gorm"
 )
 
+// 内置角色名称
+const (
+	RoleSuperAdmin = "superadmin" // 超级管理员
+	RoleAdmin      = "admin"      // 管理员
+	RoleEditor     = "editor"     // 编辑
+	RoleViewer     = "viewer"     // 查看者
+)
+
 // Role 角色模型
 type Role struct {
 	ID          uint           `gorm:"primarykey" json:"id"`
 	Name        string         `gorm:"uniqueIndex;not null;size:50" json:"name"` // 角色名称：superadmin, admin, editor, viewer
 	DisplayName string         `gorm:"not null;size:100" json:"display_name"`    // 显示名称：超级管理员、管理员、编辑、查看者
 	Description string         `gorm:"size:500" json:"description"`              // 角色描述
+	ParentID    *uint          `gorm:"index" json:"parent_id"`                   // 上级角色，继承上级及其所有上级角色的权限
 	Status      int            `gorm:"default:1;not null" json:"status"`         // 状态：1-启用 0-禁用
+	MFARequired bool           `gorm:"default:false" json:"mfa_required"`        // 拥有该角色的用户是否必须启用两步验证
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// 关联
-	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions,omitempty"` // 角色拥有的权限
+	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions,omitempty"` // 角色直接拥有的权限，不含继承的权限
 }
 
 // Permission 权限模型
@@ -39,11 +49,25 @@ type Permission struct {
 type RolePermission struct {
 	RoleID       uint      `gorm:"primarykey" json:"role_id"`
 	PermissionID uint      `gorm:"primarykey" json:"permission_id"`
+	Condition    string    `gorm:"not null;size:1000;default:''" json:"condition"` // 授权条件表达式，为空表示无条件授权
 	CreatedAt    time.Time `json:"created_at"`
 }
 
-// UserRole 用户角色关联模型
+// PermissionGrant 一条生效的授权：角色（含继承）授予的权限编码和条件
+type PermissionGrant struct {
+	RoleID    uint   `json:"role_id"`
+	Code      string `json:"code"`
+	Condition string `json:"condition"`
+}
+
+// Conditional 授权是否带有条件
+func (g *PermissionGrant) Conditional() bool {
+	return g.Condition != ""
+}
+
+// UserRole 用户角色关联模型，角色分配属于用户所在的租户
 type UserRole struct {
+	TenantID  uint      `gorm:"primarykey" json:"tenant_id"`
 	UserID    uint      `gorm:"primarykey" json:"user_id"`
 	RoleID    uint      `gorm:"primarykey" json:"role_id"`
 	CreatedAt time.Time `json:"created_at"`
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PlatformTenantID 平台租户，迁移前的所有数据属于平台租户，其中拥有 tenant:manage 权限的管理员可以跨租户操作
+const PlatformTenantID uint = 1
+
+// Tenant 租户（组织），用户和用户的角色分配属于某个租户，角色和权限的定义由所有租户共用
+type Tenant struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	Name        string         `gorm:"uniqueIndex;not null;size:50" json:"name"` // 租户标识
+	DisplayName string         `gorm:"not null;size:100" json:"display_name"`    // 显示名称
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName 指定表名
+func (Tenant) TableName() string {
+	return "tenants"
+}
@@ -6,8 +6,15 @@ import (
 	"gorm.io/gorm"
 )
 
+// 账号类型
+const (
+	AccountTypeUser    = "user"    // 普通账号，使用密码登录
+	AccountTypeService = "service" // 服务账号，没有密码，只能通过 API Key 访问
+)
+
 type User struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
+	TenantID  uint           `gorm:"index;not null;default:1" json:"tenant_id"` // 所属租户，按 context 中的租户自动过滤
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -15,6 +22,32 @@ type User struct {
 	Email     string         `gorm:"uniqueIndex;not null;size:100" json:"email"`
 	Password  string         `gorm:"not null;size:255" json:"-"`
 	Status    int            `gorm:"default:1" json:"status"` // 1: 活跃, 0: 禁用
+
+	// 账号类型：user 或 service
+	AccountType string `gorm:"not null;size:20;default:user" json:"account_type"`
+
+	// 创建该账号的管理员，自行注册的账号为空
+	CreatedBy *uint `gorm:"index" json:"created_by"`
+	// 自定义属性，例如 region，供授权条件使用
+	Attributes map[string]string `gorm:"serializer:json;type:json" json:"attributes"`
+
+	// Token 版本，递增后该用户之前签发的所有 Token 立即失效
+	TokenVersion uint `gorm:"not null;default:0" json:"-"`
+
+	// 邮箱验证时间，为空表示等待验证
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// 最近一次发送验证邮件的时间，用于限制重发频率
+	EmailVerificationSentAt *time.Time `json:"-"`
+}
+
+// EmailVerified 邮箱是否已验证
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsServiceAccount 是否为服务账号
+func (u *User) IsServiceAccount() bool {
+	return u.AccountType == AccountTypeService
 }
 
 func (User) TableName() string {
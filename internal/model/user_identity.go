@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// UserIdentity 用户在外部身份提供方（OpenID Connect）的身份，同一提供方的 subject 只能关联一个用户
+type UserIdentity struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	Provider    string     `gorm:"not null;size:255;uniqueIndex:idx_user_identities_provider_subject" json:"provider"` // 身份提供方 issuer
+	Subject     string     `gorm:"not null;size:255;uniqueIndex:idx_user_identities_provider_subject" json:"subject"`  // 身份提供方中的用户 ID（sub）
+	Email       string     `gorm:"size:100" json:"email"`                                                              // 最近一次登录时的邮箱
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
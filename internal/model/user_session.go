@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// UserSession 登录会话，每次登录创建一个，ID 与 Refresh Token 家族 ID 相同
+// 会话签发的 Access Token 携带 sid，会话被吊销后该会话的所有 Token 立即失效
+type UserSession struct {
+	ID         string     `gorm:"primarykey;size:36" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Role       string     `gorm:"not null;size:50" json:"role"` // 会话签发的 Token 角色
+	Device     string     `gorm:"size:100" json:"device"`       // 根据 User-Agent 识别的设备，如 "Chrome on macOS"
+	IP         string     `gorm:"size:64" json:"ip"`
+	UserAgent  string     `gorm:"size:500" json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"` // Refresh Token 过期时间，每次刷新顺延
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`       // 退出登录或被吊销的时间，为空表示有效
+
+	Current bool `gorm:"-" json:"current"` // 是否为发起请求的会话，仅用于接口返回
+}
+
+// TableName 指定表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
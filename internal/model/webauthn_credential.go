@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// WebAuthnCredential 管理员注册的通行密钥（WebAuthn 凭证），只保存公钥
+type WebAuthnCredential struct {
+	ID              uint       `gorm:"primarykey" json:"id"`
+	UserID          uint       `gorm:"index;not null" json:"user_id"`
+	Name            string     `gorm:"not null;size:100" json:"name"`                      // 用户填写的名称，便于区分设备
+	CredentialID    string     `gorm:"uniqueIndex;not null;size:255" json:"credential_id"` // 凭证 ID（base64url）
+	PublicKey       []byte     `gorm:"not null" json:"-"`                                  // COSE 编码的公钥
+	AttestationType string     `gorm:"not null;size:32" json:"attestation_type"`
+	Transports      string     `gorm:"size:255" json:"transports"` // 验证器支持的传输方式，逗号分隔：usb,nfc,ble,internal,hybrid
+	AAGUID          string     `gorm:"size:36" json:"aaguid"`      // 验证器型号
+	SignCount       uint32     `gorm:"not null;default:0" json:"sign_count"`
+	BackupEligible  bool       `gorm:"not null;default:false" json:"backup_eligible"` // 是否为可同步的通行密钥
+	BackupState     bool       `gorm:"not null;default:false" json:"backup_state"`    // 是否已同步到其他设备
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"trx-project/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository 审计日志数据访问接口
+type AuditRepository interface {
+	Create(ctx context.Context, log *model.AuditLog) error
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository 创建审计日志 repository
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, log *model.AuditLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
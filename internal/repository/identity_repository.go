@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"trx-project/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// IdentityRepository 外部身份数据访问接口
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *model.UserIdentity) error
+	// GetByProviderSubject 根据身份提供方和 subject 获取身份，不存在时返回 gorm.ErrRecordNotFound
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	// TouchLogin 记录一次登录的时间和邮箱
+	TouchLogin(ctx context.Context, id uint, email string, loginAt time.Time) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+// NewIdentityRepository 创建外部身份 repository
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *identityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) TouchLogin(ctx context.Context, id uint, email string, loginAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.UserIdentity{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"email":         email,
+			"last_login_at": loginAt,
+		}).Error
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"trx-project/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// MagicLinkRepository 免密登录链接令牌数据访问接口
+type MagicLinkRepository interface {
+	Create(ctx context.Context, token *model.MagicLinkToken) error
+	// GetActive 获取未使用且未过期的令牌，不存在时返回 gorm.ErrRecordNotFound
+	GetActive(ctx context.Context, tokenHash string) (*model.MagicLinkToken, error)
+	// MarkUsed 标记令牌已使用，返回 false 表示令牌已被其他请求使用
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	// CountSince 统计用户在 since 之后申请的链接数量
+	CountSince(ctx context.Context, userID uint, since time.Time) (int64, error)
+	// InvalidateByUserID 作废用户所有未使用的令牌
+	InvalidateByUserID(ctx context.Context, userID uint) error
+}
+
+type magicLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewMagicLinkRepository 创建免密登录链接令牌 repository
+func NewMagicLinkRepository(db *gorm.DB) MagicLinkRepository {
+	return &magicLinkRepository{db: db}
+}
+
+func (r *magicLinkRepository) Create(ctx context.Context, token *model.MagicLinkToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *magicLinkRepository) GetActive(ctx context.Context, tokenHash string) (*model.MagicLinkToken, error) {
+	var token model.MagicLinkToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *magicLinkRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	// 条件更新保证并发请求中只有一个能使用成功
+	result := r.db.WithContext(ctx).Model(&model.MagicLinkToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *magicLinkRepository) CountSince(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.MagicLinkToken{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *magicLinkRepository) InvalidateByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&model.MagicLinkToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
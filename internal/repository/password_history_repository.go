@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"trx-project/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// PasswordHistoryRepository 历史密码数据访问接口
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, history *model.PasswordHistory) error
+	// ListRecent 按时间倒序返回用户最近的 limit 条历史密码
+	ListRecent(ctx context.Context, userID uint, limit int) ([]*model.PasswordHistory, error)
+	// Prune 只保留用户最近的 keep 条历史密码
+	Prune(ctx context.Context, userID uint, keep int) error
+}
+
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordHistoryRepository 创建历史密码 repository
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{db: db}
+}
+
+func (r *passwordHistoryRepository) Create(ctx context.Context, history *model.PasswordHistory) error {
+	return r.db.WithContext(ctx).Create(history).Error
+}
+
+func (r *passwordHistoryRepository) ListRecent(ctx context.Context, userID uint, limit int) ([]*model.PasswordHistory, error) {
+	var histories []*model.PasswordHistory
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&histories).Error
+	return histories, err
+}
+
+func (r *passwordHistoryRepository) Prune(ctx context.Context, userID uint, keep int) error {
+	if keep <= 0 {
+		return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.PasswordHistory{}).Error
+	}
+
+	recent, err := r.ListRecent(ctx, userID, keep)
+	if err != nil {
+		return err
+	}
+	if len(recent) < keep {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND id < ?", userID, recent[len(recent)-1].ID).
+		Delete(&model.PasswordHistory{}).Error
+}
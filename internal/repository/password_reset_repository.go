@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"trx-project/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetRepository 重置密码令牌数据访问接口
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *model.PasswordResetToken) error
+	// GetValid 获取未使用且未过期的令牌，不会使用令牌，不存在时返回 gorm.ErrRecordNotFound
+	GetValid(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error)
+	// Consume 使用令牌，令牌不存在、已过期或已使用时返回 gorm.ErrRecordNotFound
+	Consume(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error)
+	// CountSince 统计用户在 since 之后申请的令牌数量
+	CountSince(ctx context.Context, userID uint, since time.Time) (int64, error)
+	// InvalidateByUserID 作废用户所有未使用的令牌
+	InvalidateByUserID(ctx context.Context, userID uint) error
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository 创建重置密码令牌 repository
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *model.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *passwordResetRepository) GetValid(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordResetRepository) Consume(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+			First(&token).Error; err != nil {
+			return err
+		}
+
+		// 条件更新保证并发请求中只有一个能使用成功
+		result := tx.Model(&model.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		token.UsedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordResetRepository) CountSince(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.PasswordResetToken{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *passwordResetRepository) InvalidateByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&model.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"trx-project/internal/model"
+	"trx-project/pkg/tenant"
 
 	"gorm.io/gorm"
 )
@@ -16,7 +17,12 @@ type RBACRepository interface {
 	ListRoles(ctx context.Context) ([]*model.Role, error)
 	CreateRole(ctx context.Context, role *model.Role) error
 	UpdateRole(ctx context.Context, role *model.Role) error
+	// DeleteRole 删除角色，同时移除角色的权限和用户分配，下级角色改为继承被删除角色的上级角色
 	DeleteRole(ctx context.Context, id uint) error
+	// GetRoleAncestorIDs 获取角色的所有上级角色 ID，不含角色本身
+	GetRoleAncestorIDs(ctx context.Context, roleID uint) ([]uint, error)
+	// GetRoleDescendantIDs 获取角色（任一）的所有下级角色 ID，不含角色本身
+	GetRoleDescendantIDs(ctx context.Context, roleIDs []uint) ([]uint, error)
 
 	// Permission 相关
 	GetPermissionByCode(ctx context.Context, code string) (*model.Permission, error)
@@ -24,21 +30,68 @@ type RBACRepository interface {
 	ListPermissions(ctx context.Context) ([]*model.Permission, error)
 	CreatePermission(ctx context.Context, permission *model.Permission) error
 	UpdatePermission(ctx context.Context, permission *model.Permission) error
+	// DeletePermission 删除权限，同时从所有角色中移除
 	DeletePermission(ctx context.Context, id uint) error
+	// GetPermissionRoleIDs 获取拥有指定权限的角色 ID
+	GetPermissionRoleIDs(ctx context.Context, permissionID uint) ([]uint, error)
 
 	// RolePermission 相关
 	AssignPermissionsToRole(ctx context.Context, roleID uint, permissionIDs []uint) error
 	RemovePermissionsFromRole(ctx context.Context, roleID uint, permissionIDs []uint) error
+	// GetRolePermissions 获取角色的有效权限，包括从上级角色继承的权限
 	GetRolePermissions(ctx context.Context, roleID uint) ([]*model.Permission, error)
+	// GetRoleGrants 获取角色的有效授权及条件，包括从上级角色继承的授权
+	GetRoleGrants(ctx context.Context, roleID uint) ([]*model.PermissionGrant, error)
+	// SetGrantCondition 设置角色直接授予的权限的条件，角色没有直接授予该权限时返回 gorm.ErrRecordNotFound
+	SetGrantCondition(ctx context.Context, roleID, permissionID uint, condition string) error
 
 	// UserRole 相关
+	// AssignRoleToUser 在用户所属租户中为用户分配角色，用户不存在或不属于当前租户时返回 gorm.ErrRecordNotFound
 	AssignRoleToUser(ctx context.Context, userID, roleID uint) error
 	RemoveRoleFromUser(ctx context.Context, userID, roleID uint) error
+	// GetUserRoles 获取用户在所属租户中的角色
 	GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error)
+	// GetRoleUserIDs 获取分配了指定角色（任一）的用户 ID
+	GetRoleUserIDs(ctx context.Context, roleIDs []uint) ([]uint, error)
+	// GetUserPermissions 获取用户通过启用的角色（包括继承）获得的启用权限，不区分授权是否带条件
 	GetUserPermissions(ctx context.Context, userID uint) ([]*model.Permission, error)
-	HasPermission(ctx context.Context, userID uint, permissionCode string) (bool, error)
+	// GetUserGrants 获取用户通过启用的角色（包括继承）获得的启用权限的每条授权及条件
+	GetUserGrants(ctx context.Context, userID uint) ([]*model.PermissionGrant, error)
 }
 
+// 角色继承查询使用递归 CTE（MySQL 8.0+），UNION 去重，数据中出现环时也能结束
+
+// userRoleTreeCTE 用户在所属租户中直接拥有的启用角色及它们的所有上级角色
+// 上级角色被禁用时只影响直接拥有它的用户，下级角色仍然继承它的权限
+// 按用户所属租户而不是请求的租户过滤，平台管理员跨租户操作时仍使用自己的角色
+const userRoleTreeCTE = `WITH RECURSIVE role_tree (id) AS (
+	SELECT roles.id FROM user_roles
+	JOIN users ON users.id = user_roles.user_id AND users.tenant_id = user_roles.tenant_id
+	JOIN roles ON roles.id = user_roles.role_id
+	WHERE user_roles.user_id = ? AND roles.status = 1 AND roles.deleted_at IS NULL
+	UNION
+	SELECT parent.id FROM role_tree
+	JOIN roles AS child ON child.id = role_tree.id
+	JOIN roles AS parent ON parent.id = child.parent_id AND parent.deleted_at IS NULL
+)`
+
+// roleTreeCTE 角色本身及它的所有上级角色
+const roleTreeCTE = `WITH RECURSIVE role_tree (id) AS (
+	SELECT roles.id FROM roles WHERE roles.id = ? AND roles.deleted_at IS NULL
+	UNION
+	SELECT parent.id FROM role_tree
+	JOIN roles AS child ON child.id = role_tree.id
+	JOIN roles AS parent ON parent.id = child.parent_id AND parent.deleted_at IS NULL
+)`
+
+// grantsQuery 与角色树 CTE 拼接，查询角色树上每条启用权限的授权及条件
+const grantsQuery = `
+SELECT role_permissions.role_id, permissions.code, role_permissions.condition FROM permissions
+JOIN role_permissions ON role_permissions.permission_id = permissions.id
+JOIN role_tree ON role_tree.id = role_permissions.role_id
+WHERE permissions.status = 1 AND permissions.deleted_at IS NULL
+ORDER BY permissions.code, role_permissions.role_id`
+
 type rbacRepository struct {
 	db *gorm.DB
 }
@@ -83,7 +136,46 @@ func (r *rbacRepository) UpdateRole(ctx context.Context, role *model.Role) error
 }
 
 func (r *rbacRepository) DeleteRole(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&model.Role{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var role model.Role
+		if err := tx.First(&role, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Role{}).Where("parent_id = ?", id).Update("parent_id", role.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", id).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		// 角色由所有租户共用，移除所有租户中的分配
+		if err := tx.Scopes(tenant.SkipScope).Where("role_id = ?", id).Delete(&model.UserRole{}).Error; err != nil {
+			return err
+		}
+		// 角色名唯一，直接删除记录以便重新使用同名角色
+		return tx.Unscoped().Delete(&model.Role{}, id).Error
+	})
+}
+
+func (r *rbacRepository) GetRoleAncestorIDs(ctx context.Context, roleID uint) ([]uint, error) {
+	var roleIDs []uint
+	err := r.db.WithContext(ctx).Raw(roleTreeCTE+" SELECT id FROM role_tree WHERE id <> ?", roleID, roleID).
+		Scan(&roleIDs).Error
+	return roleIDs, err
+}
+
+func (r *rbacRepository) GetRoleDescendantIDs(ctx context.Context, roleIDs []uint) ([]uint, error) {
+	var descendantIDs []uint
+	if len(roleIDs) == 0 {
+		return descendantIDs, nil
+	}
+	err := r.db.WithContext(ctx).Raw(`WITH RECURSIVE descendants (id) AS (
+	SELECT roles.id FROM roles WHERE roles.parent_id IN ? AND roles.deleted_at IS NULL
+	UNION
+	SELECT roles.id FROM descendants
+	JOIN roles ON roles.parent_id = descendants.id AND roles.deleted_at IS NULL
+) SELECT id FROM descendants WHERE id NOT IN ?`, roleIDs, roleIDs).
+		Scan(&descendantIDs).Error
+	return descendantIDs, err
 }
 
 // Permission 相关实现
@@ -115,7 +207,22 @@ func (r *rbacRepository) UpdatePermission(ctx context.Context, permission *model
 }
 
 func (r *rbacRepository) DeletePermission(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&model.Permission{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("permission_id = ?", id).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		// 权限编码唯一，直接删除记录以便重新使用同一编码
+		return tx.Unscoped().Delete(&model.Permission{}, id).Error
+	})
+}
+
+func (r *rbacRepository) GetPermissionRoleIDs(ctx context.Context, permissionID uint) ([]uint, error) {
+	var roleIDs []uint
+	err := r.db.WithContext(ctx).
+		Model(&model.RolePermission{}).
+		Where("permission_id = ?", permissionID).
+		Pluck("role_id", &roleIDs).Error
+	return roleIDs, err
 }
 
 // RolePermission 相关实现
@@ -144,19 +251,59 @@ func (r *rbacRepository) RemovePermissionsFromRole(ctx context.Context, roleID u
 
 func (r *rbacRepository) GetRolePermissions(ctx context.Context, roleID uint) ([]*model.Permission, error) {
 	var permissions []*model.Permission
-	err := r.db.WithContext(ctx).
-		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
-		Where("role_permissions.role_id = ?", roleID).
-		Find(&permissions).Error
+	err := r.db.WithContext(ctx).Raw(roleTreeCTE+`
+SELECT DISTINCT permissions.* FROM permissions
+JOIN role_permissions ON role_permissions.permission_id = permissions.id
+JOIN role_tree ON role_tree.id = role_permissions.role_id
+WHERE permissions.status = 1 AND permissions.deleted_at IS NULL`, roleID).
+		Scan(&permissions).Error
 	return permissions, err
 }
 
+func (r *rbacRepository) GetRoleGrants(ctx context.Context, roleID uint) ([]*model.PermissionGrant, error) {
+	var grants []*model.PermissionGrant
+	err := r.db.WithContext(ctx).Raw(roleTreeCTE+grantsQuery, roleID).
+		Scan(&grants).Error
+	return grants, err
+}
+
+func (r *rbacRepository) SetGrantCondition(ctx context.Context, roleID, permissionID uint, condition string) error {
+	result := r.db.WithContext(ctx).
+		Model(&model.RolePermission{}).
+		Where("role_id = ? AND permission_id = ?", roleID, permissionID).
+		Update("condition", condition)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// 条件没有变化时 MySQL 同样返回 0 行，确认授权是否存在
+		var count int64
+		if err := r.db.WithContext(ctx).
+			Model(&model.RolePermission{}).
+			Where("role_id = ? AND permission_id = ?", roleID, permissionID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	return nil
+}
+
 // UserRole 相关实现
 
 func (r *rbacRepository) AssignRoleToUser(ctx context.Context, userID, roleID uint) error {
+	// 角色分配在用户所属的租户中，用户不属于当前租户时返回 gorm.ErrRecordNotFound
+	var user model.User
+	if err := r.db.WithContext(ctx).Select("id", "tenant_id").First(&user, userID).Error; err != nil {
+		return err
+	}
+
 	userRole := &model.UserRole{
-		UserID: userID,
-		RoleID: roleID,
+		TenantID: user.TenantID,
+		UserID:   userID,
+		RoleID:   roleID,
 	}
 	return r.db.WithContext(ctx).Create(userRole).Error
 }
@@ -171,30 +318,41 @@ func (r *rbacRepository) GetUserRoles(ctx context.Context, userID uint) ([]*mode
 	var roles []*model.Role
 	err := r.db.WithContext(ctx).
 		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Joins("JOIN users ON users.id = user_roles.user_id AND users.tenant_id = user_roles.tenant_id").
 		Where("user_roles.user_id = ?", userID).
 		Find(&roles).Error
 	return roles, err
 }
 
-func (r *rbacRepository) GetUserPermissions(ctx context.Context, userID uint) ([]*model.Permission, error) {
-	var permissions []*model.Permission
+func (r *rbacRepository) GetRoleUserIDs(ctx context.Context, roleIDs []uint) ([]uint, error) {
+	var userIDs []uint
+	if len(roleIDs) == 0 {
+		return userIDs, nil
+	}
+	// 角色由所有租户共用，返回所有租户中的用户以便使他们的缓存失效
 	err := r.db.WithContext(ctx).
+		Scopes(tenant.SkipScope).
+		Model(&model.UserRole{}).
 		Distinct().
-		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
-		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
-		Where("user_roles.user_id = ? AND permissions.status = 1", userID).
-		Find(&permissions).Error
-	return permissions, err
+		Where("role_id IN ?", roleIDs).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
 }
 
-func (r *rbacRepository) HasPermission(ctx context.Context, userID uint, permissionCode string) (bool, error) {
-	var count int64
-	err := r.db.WithContext(ctx).
-		Table("permissions").
-		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
-		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
-		Where("user_roles.user_id = ? AND permissions.code = ? AND permissions.status = 1", userID, permissionCode).
-		Count(&count).Error
+func (r *rbacRepository) GetUserPermissions(ctx context.Context, userID uint) ([]*model.Permission, error) {
+	var permissions []*model.Permission
+	err := r.db.WithContext(ctx).Raw(userRoleTreeCTE+`
+SELECT DISTINCT permissions.* FROM permissions
+JOIN role_permissions ON role_permissions.permission_id = permissions.id
+JOIN role_tree ON role_tree.id = role_permissions.role_id
+WHERE permissions.status = 1 AND permissions.deleted_at IS NULL`, userID).
+		Scan(&permissions).Error
+	return permissions, err
+}
 
-	return count > 0, err
+func (r *rbacRepository) GetUserGrants(ctx context.Context, userID uint) ([]*model.PermissionGrant, error) {
+	var grants []*model.PermissionGrant
+	err := r.db.WithContext(ctx).Raw(userRoleTreeCTE+grantsQuery, userID).
+		Scan(&grants).Error
+	return grants, err
 }
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"trx-project/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository 登录会话数据访问接口
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.UserSession) error
+	GetByID(ctx context.Context, id string) (*model.UserSession, error)
+	// ListActiveByUserID 列出用户未吊销且未过期的会话，最近活跃的在前
+	ListActiveByUserID(ctx context.Context, userID uint) ([]*model.UserSession, error)
+	// Touch 更新最近活跃时间，expiresAt 不为零时同时顺延过期时间
+	Touch(ctx context.Context, id string, seenAt, expiresAt time.Time) error
+	// Revoke 吊销会话，会话不存在或已吊销时返回 false
+	Revoke(ctx context.Context, id string) (bool, error)
+	// RevokeByUserID 吊销用户的所有会话
+	RevokeByUserID(ctx context.Context, userID uint) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository 创建登录会话 repository
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *model.UserSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id string) (*model.UserSession, error) {
+	var session model.UserSession
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) ListActiveByUserID(ctx context.Context, userID uint) ([]*model.UserSession, error) {
+	var sessions []*model.UserSession
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id string, seenAt, expiresAt time.Time) error {
+	updates := map[string]interface{}{"last_seen_at": seenAt}
+	if !expiresAt.IsZero() {
+		updates["expires_at"] = expiresAt
+	}
+	return r.db.WithContext(ctx).Model(&model.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(updates).Error
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *sessionRepository) RevokeByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&model.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
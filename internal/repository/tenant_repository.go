@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"trx-project/internal/model"
+	"trx-project/pkg/tenant"
+
+	"gorm.io/gorm"
+)
+
+// TenantRepository 租户数据访问接口
+// 租户表本身没有 tenant_id，不受租户过滤影响；涉及用户的操作跨租户执行
+type TenantRepository interface {
+	Create(ctx context.Context, t *model.Tenant) error
+	GetByID(ctx context.Context, id uint) (*model.Tenant, error)
+	GetByName(ctx context.Context, name string) (*model.Tenant, error)
+	List(ctx context.Context) ([]*model.Tenant, error)
+	Update(ctx context.Context, t *model.Tenant) error
+	Delete(ctx context.Context, id uint) error
+	// CountUsers 统计租户下的用户数（不含已删除的用户）
+	CountUsers(ctx context.Context, id uint) (int64, error)
+	// MoveUser 将用户移到另一个租户，并删除用户在原租户的角色分配
+	MoveUser(ctx context.Context, userID, tenantID uint) error
+}
+
+type tenantRepository struct {
+	db *gorm.DB
+}
+
+// NewTenantRepository 创建租户 repository
+func NewTenantRepository(db *gorm.DB) TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+func (r *tenantRepository) Create(ctx context.Context, t *model.Tenant) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *tenantRepository) GetByID(ctx context.Context, id uint) (*model.Tenant, error) {
+	var t model.Tenant
+	if err := r.db.WithContext(ctx).First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *tenantRepository) GetByName(ctx context.Context, name string) (*model.Tenant, error) {
+	var t model.Tenant
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *tenantRepository) List(ctx context.Context) ([]*model.Tenant, error) {
+	var tenants []*model.Tenant
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&tenants).Error
+	return tenants, err
+}
+
+func (r *tenantRepository) Update(ctx context.Context, t *model.Tenant) error {
+	return r.db.WithContext(ctx).Save(t).Error
+}
+
+func (r *tenantRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Tenant{}, id).Error
+}
+
+func (r *tenantRepository) CountUsers(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Scopes(tenant.SkipScope).
+		Model(&model.User{}).
+		Where("tenant_id = ?", id).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *tenantRepository) MoveUser(ctx context.Context, userID, tenantID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Scopes(tenant.SkipScope).
+			Model(&model.User{}).
+			Where("id = ?", userID).
+			Update("tenant_id", tenantID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// 角色分配属于原租户，不能带到新租户
+		return tx.Scopes(tenant.SkipScope).
+			Where("user_id = ?", userID).
+			Delete(&model.UserRole{}).Error
+	})
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"time"
 	"trx-project/internal/model"
+	"trx-project/pkg/tenant"
 
 	"gorm.io/gorm"
 )
@@ -10,11 +12,24 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id uint) (*model.User, error)
+	// GetByUsername 根据用户名获取用户，用户名全局唯一，不按租户过滤
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	// GetByEmail 根据邮箱获取用户，邮箱全局唯一，不按租户过滤
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	Update(ctx context.Context, user *model.User) error
 	Delete(ctx context.Context, id uint) error
 	List(ctx context.Context, offset, limit int) ([]*model.User, int64, error)
+	// ListByAccountType 列出指定类型的所有账号
+	ListByAccountType(ctx context.Context, accountType string) ([]*model.User, error)
+	GetStatus(ctx context.Context, id uint) (status int, deleted bool, err error)
+	GetTokenVersion(ctx context.Context, id uint) (uint, error)
+	IncrementTokenVersion(ctx context.Context, id uint) (uint, error)
+	// MarkEmailVerified 标记邮箱已验证，email 与当前邮箱不一致或已验证时返回 false
+	MarkEmailVerified(ctx context.Context, id uint, email string) (bool, error)
+	// TouchEmailVerificationSentAt 记录验证邮件发送时间，距上次发送不足 interval 时返回 false
+	TouchEmailVerificationSentAt(ctx context.Context, id uint, interval time.Duration) (bool, error)
+	// UpdatePasswordHash 密码哈希仍为 oldHash 时替换为 newHash，用于登录时升级哈希参数，密码已被修改时返回 false
+	UpdatePasswordHash(ctx context.Context, id uint, oldHash, newHash string) (bool, error)
 }
 
 type userRepository struct {
@@ -41,7 +56,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uint) (*model.User, err
 
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
+	err := r.db.WithContext(ctx).Scopes(tenant.SkipScope).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +65,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Scopes(tenant.SkipScope).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +73,9 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.U
 }
 
 func (r *userRepository) Update(ctx context.Context, user *model.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+	// token_version 只能通过 IncrementTokenVersion 修改，email_verification_sent_at 只能通过
+	// TouchEmailVerificationSentAt 修改，避免用旧值覆盖
+	return r.db.WithContext(ctx).Omit("token_version", "email_verification_sent_at").Save(user).Error
 }
 
 func (r *userRepository) Delete(ctx context.Context, id uint) error {
@@ -79,3 +96,82 @@ func (r *userRepository) List(ctx context.Context, offset, limit int) ([]*model.
 
 	return users, total, nil
 }
+
+func (r *userRepository) ListByAccountType(ctx context.Context, accountType string) ([]*model.User, error) {
+	var users []*model.User
+	err := r.db.WithContext(ctx).Where("account_type = ?", accountType).Order("id ASC").Find(&users).Error
+	return users, err
+}
+
+// GetStatus 获取用户状态，包括已软删除的用户
+func (r *userRepository) GetStatus(ctx context.Context, id uint) (int, bool, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Unscoped().Select("status", "deleted_at").Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return 0, false, err
+	}
+	return user.Status, user.DeletedAt.Valid, nil
+}
+
+// GetTokenVersion 获取用户当前 Token 版本
+func (r *userRepository) GetTokenVersion(ctx context.Context, id uint) (uint, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Select("token_version").Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return 0, err
+	}
+	return user.TokenVersion, nil
+}
+
+// IncrementTokenVersion 递增用户 Token 版本并返回新版本
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, id uint) (uint, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).Where("id = ?", id).
+			UpdateColumn("token_version", gorm.Expr("token_version + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Select("token_version").Where("id = ?", id).First(&user).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return user.TokenVersion, nil
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uint, email string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND email = ? AND email_verified_at IS NULL", id, email).
+		UpdateColumn("email_verified_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *userRepository) TouchEmailVerificationSentAt(ctx context.Context, id uint, interval time.Duration) (bool, error) {
+	now := time.Now()
+	// 条件更新，并发请求中只有一个能通过
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND (email_verification_sent_at IS NULL OR email_verification_sent_at <= ?)", id, now.Add(-interval)).
+		UpdateColumn("email_verification_sent_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *userRepository) UpdatePasswordHash(ctx context.Context, id uint, oldHash, newHash string) (bool, error) {
+	// 条件更新，避免覆盖并发修改的密码；不修改 updated_at 和 token_version
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND password = ?", id, oldHash).
+		UpdateColumn("password", newHash)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
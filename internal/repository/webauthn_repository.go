@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"trx-project/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// WebAuthnRepository 通行密钥数据访问接口
+type WebAuthnRepository interface {
+	Create(ctx context.Context, credential *model.WebAuthnCredential) error
+	// ListByUserID 列出用户的通行密钥，最早注册的在前
+	ListByUserID(ctx context.Context, userID uint) ([]*model.WebAuthnCredential, error)
+	// GetByCredentialID 根据凭证 ID 获取通行密钥，不存在时返回 gorm.ErrRecordNotFound
+	GetByCredentialID(ctx context.Context, credentialID string) (*model.WebAuthnCredential, error)
+	// UpdateUsage 登录成功后更新签名计数器、同步状态和最后使用时间
+	UpdateUsage(ctx context.Context, id uint, signCount uint32, backupState bool, usedAt time.Time) error
+	// Delete 删除用户的通行密钥，不存在时返回 false
+	Delete(ctx context.Context, userID, id uint) (bool, error)
+}
+
+type webAuthnRepository struct {
+	db *gorm.DB
+}
+
+// NewWebAuthnRepository 创建通行密钥 repository
+func NewWebAuthnRepository(db *gorm.DB) WebAuthnRepository {
+	return &webAuthnRepository{db: db}
+}
+
+func (r *webAuthnRepository) Create(ctx context.Context, credential *model.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Create(credential).Error
+}
+
+func (r *webAuthnRepository) ListByUserID(ctx context.Context, userID uint) ([]*model.WebAuthnCredential, error) {
+	var credentials []*model.WebAuthnCredential
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *webAuthnRepository) GetByCredentialID(ctx context.Context, credentialID string) (*model.WebAuthnCredential, error) {
+	var credential model.WebAuthnCredential
+	if err := r.db.WithContext(ctx).Where("credential_id = ?", credentialID).First(&credential).Error; err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *webAuthnRepository) UpdateUsage(ctx context.Context, id uint, signCount uint32, backupState bool, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.WebAuthnCredential{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"sign_count":   signCount,
+			"backup_state": backupState,
+			"last_used_at": usedAt,
+		}).Error
+}
+
+func (r *webAuthnRepository) Delete(ctx context.Context, userID, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&model.WebAuthnCredential{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
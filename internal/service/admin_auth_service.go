@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"trx-project/internal/model"
+	"trx-project/pkg/jwt"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrAdminRoleRequired 用户没有任何可登录后台的角色
+	ErrAdminRoleRequired = errors.New("admin role required")
+	// ErrTargetPrivileged 目标用户拥有当前管理员不具备的后台角色或权限，不能替其重置凭证
+	ErrTargetPrivileged = errors.New("target user has privileges the admin lacks")
+)
+
+// AdminAuthService 后台管理员认证服务
+type AdminAuthService interface {
+	// Login 校验用户名密码和后台角色并签发 Token，ip 用于登录失败计数
+	Login(ctx context.Context, username, password, ip string) (*LoginResult, error)
+	BeginMFAEnrollment(ctx context.Context, mfaToken string) (*MFAEnrollment, error)
+	CompleteMFALogin(ctx context.Context, mfaToken, code string) (*LoginResult, error)
+	// LoginWithPasskey 校验通行密钥登录仪式并签发 Token
+	// 通行密钥本身要求用户验证（PIN 或生物识别），视为已完成两步验证，不再要求验证码
+	LoginWithPasskey(ctx context.Context, challengeID string, response []byte) (*LoginResult, error)
+	// LoginWithOIDC 完成单点登录回调并签发 Token，binding 为发起登录时写入浏览器 Cookie 的绑定值；身份提供方不替代本地两步验证
+	LoginWithOIDC(ctx context.Context, code, state, binding, ip string) (*LoginResult, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error)
+	Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error
+	GetProfile(ctx context.Context, adminID uint) (*model.User, []*model.Role, []*model.Permission, error)
+}
+
+type adminAuthService struct {
+	userService    UserService
+	rbacService    RBACService
+	tokenService   TokenService
+	mfaService     MFAService
+	passkeyService PasskeyService
+	oidcService    OIDCService
+	loginGuard     LoginProtectionService
+	logger         *zap.Logger
+}
+
+// NewAdminAuthService 创建后台管理员认证服务
+func NewAdminAuthService(userService UserService, rbacService RBACService, tokenService TokenService, mfaService MFAService, passkeyService PasskeyService, oidcService OIDCService, loginGuard LoginProtectionService, logger *zap.Logger) AdminAuthService {
+	return &adminAuthService{
+		userService:    userService,
+		rbacService:    rbacService,
+		tokenService:   tokenService,
+		mfaService:     mfaService,
+		passkeyService: passkeyService,
+		oidcService:    oidcService,
+		loginGuard:     loginGuard,
+		logger:         logger,
+	}
+}
+
+func (s *adminAuthService) Login(ctx context.Context, username, password, ip string) (*LoginResult, error) {
+	if err := s.loginGuard.Check(ctx, loginServiceBackend, username, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.Authenticate(ctx, username, password)
+	if err != nil {
+		s.loginGuard.RecordFailure(ctx, loginServiceBackend, username, ip, err)
+		return nil, err
+	}
+
+	// 确认有后台角色后才算登录成功，RecordFailure 和 RecordSuccess 都会释放 Check 占用的名额
+	roles, tokenRole, err := s.resolveRole(ctx, user.ID)
+	if err != nil {
+		s.loginGuard.RecordFailure(ctx, loginServiceBackend, username, ip, err)
+		return nil, err
+	}
+	s.loginGuard.RecordSuccess(ctx, username, ip)
+
+	// 启用了两步验证或角色要求两步验证时，先返回挑战
+	challenge, err := s.mfaService.StartLogin(ctx, user.ID, tokenRole)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		s.logger.Info("Admin login requires mfa", zap.Uint("admin_id", user.ID))
+		return &LoginResult{User: user, Roles: roles, MFA: challenge}, nil
+	}
+
+	tokens, err := s.tokenService.IssueTokenPair(ctx, user, tokenRole, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Admin logged in successfully",
+		zap.Uint("admin_id", user.ID),
+		zap.String("username", username),
+		zap.String("role", tokenRole))
+	return &LoginResult{User: user, Roles: roles, Tokens: tokens}, nil
+}
+
+func (s *adminAuthService) BeginMFAEnrollment(ctx context.Context, mfaToken string) (*MFAEnrollment, error) {
+	return s.mfaService.BeginLoginEnrollment(ctx, mfaToken, true)
+}
+
+func (s *adminAuthService) CompleteMFALogin(ctx context.Context, mfaToken, code string) (*LoginResult, error) {
+	result, err := s.mfaService.CompleteLogin(ctx, mfaToken, code, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// 密码校验后账号可能已被禁用或角色已变化
+	user, err := s.userService.GetUserByID(ctx, result.UserID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, ErrUserInactive
+		}
+		return nil, err
+	}
+	if user.Status != 1 {
+		return nil, ErrUserInactive
+	}
+
+	roles, tokenRole, err := s.resolveRole(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.tokenService.IssueTokenPair(ctx, user, tokenRole, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Admin logged in successfully with mfa",
+		zap.Uint("admin_id", user.ID),
+		zap.String("role", tokenRole))
+	return &LoginResult{User: user, Roles: roles, Tokens: tokens, RecoveryCodes: result.RecoveryCodes}, nil
+}
+
+func (s *adminAuthService) LoginWithPasskey(ctx context.Context, challengeID string, response []byte) (*LoginResult, error) {
+	user, err := s.passkeyService.FinishLogin(ctx, challengeID, response)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, ErrPasskeyVerificationFailed
+		}
+		return nil, err
+	}
+	if user.Status != 1 {
+		return nil, ErrUserInactive
+	}
+
+	roles, tokenRole, err := s.resolveRole(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.tokenService.IssueTokenPair(ctx, user, tokenRole, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Admin logged in successfully with passkey",
+		zap.Uint("admin_id", user.ID),
+		zap.String("role", tokenRole))
+	return &LoginResult{User: user, Roles: roles, Tokens: tokens}, nil
+}
+
+func (s *adminAuthService) LoginWithOIDC(ctx context.Context, code, state, binding, ip string) (*LoginResult, error) {
+	// 账号状态在同步角色之前检查，未启用时返回 ErrUserInactive
+	user, err := s.oidcService.CompleteLogin(ctx, code, state, binding, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, tokenRole, err := s.resolveRole(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.mfaService.StartLogin(ctx, user.ID, tokenRole)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		s.logger.Info("Admin sso login requires mfa", zap.Uint("admin_id", user.ID))
+		return &LoginResult{User: user, Roles: roles, MFA: challenge}, nil
+	}
+
+	tokens, err := s.tokenService.IssueTokenPair(ctx, user, tokenRole, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Admin logged in successfully with sso",
+		zap.Uint("admin_id", user.ID),
+		zap.String("role", tokenRole))
+	return &LoginResult{User: user, Roles: roles, Tokens: tokens}, nil
+}
+
+// resolveRole 获取用户角色并推导 Token 角色，Token 中的角色由 user_roles 推导，而不是由调用方指定
+func (s *adminAuthService) resolveRole(ctx context.Context, userID uint) ([]*model.Role, string, error) {
+	roles, err := s.rbacService.GetUserRoles(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get admin roles", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, "", err
+	}
+
+	tokenRole, ok := ResolveAdminRole(roles)
+	if !ok {
+		s.logger.Warn("Admin login rejected: no admin role", zap.Uint("user_id", userID))
+		return nil, "", ErrAdminRoleRequired
+	}
+	return roles, tokenRole, nil
+}
+
+// RefreshToken 使用 Refresh Token 轮换出新的 Token 对，并重新根据 user_roles 推导角色
+func (s *adminAuthService) RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	session, err := s.tokenService.ConsumeRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 前台签发的 Refresh Token 不能在后台使用
+	if session.Role == jwt.RoleUser {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	user, err := s.userService.GetUserByID(ctx, session.UserID)
+	if err != nil && err.Error() != "user not found" {
+		return nil, err
+	}
+	var roles []*model.Role
+	if err == nil && user.Status == 1 {
+		if roles, err = s.rbacService.GetUserRoles(ctx, user.ID); err != nil {
+			s.logger.Error("Failed to get admin roles", zap.Uint("user_id", user.ID), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	// 用户已删除、被禁用或不再拥有后台角色时，终止整个会话
+	tokenRole, ok := ResolveAdminRole(roles)
+	if !ok {
+		s.logger.Warn("Admin refresh rejected, revoking family",
+			zap.Uint("admin_id", session.UserID),
+			zap.String("family_id", session.FamilyID))
+		if err := s.tokenService.RevokeRefreshFamily(ctx, session.FamilyID); err != nil {
+			s.logger.Error("Failed to revoke refresh token family", zap.Error(err))
+		}
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	return s.tokenService.IssueTokenPair(ctx, user, tokenRole, session.FamilyID)
+}
+
+// Logout 管理员退出登录，吊销当前 Access Token 及其 Refresh Token 会话
+func (s *adminAuthService) Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error {
+	if err := s.tokenService.Logout(ctx, claims, refreshToken); err != nil {
+		return err
+	}
+
+	s.logger.Info("Admin logged out", zap.Uint("admin_id", claims.UserID))
+	return nil
+}
+
+func (s *adminAuthService) GetProfile(ctx context.Context, adminID uint) (*model.User, []*model.Role, []*model.Permission, error) {
+	user, err := s.userService.GetUserByID(ctx, adminID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	roles, err := s.rbacService.GetUserRoles(ctx, adminID)
+	if err != nil {
+		s.logger.Error("Failed to get admin roles", zap.Uint("admin_id", adminID), zap.Error(err))
+		return nil, nil, nil, err
+	}
+
+	permissions, err := s.rbacService.GetUserPermissions(ctx, adminID)
+	if err != nil {
+		s.logger.Error("Failed to get admin permissions", zap.Uint("admin_id", adminID), zap.Error(err))
+		return nil, nil, nil, err
+	}
+
+	return user, roles, permissions, nil
+}
+
+// ResolveAdminRole 根据用户已分配且启用的角色推导管理员 Token 角色
+// 拥有 superadmin 角色时返回 superadmin，拥有其他任一启用角色时返回 admin
+func ResolveAdminRole(roles []*model.Role) (string, bool) {
+	tokenRole := ""
+	for _, role := range roles {
+		if role.Status != 1 {
+			continue
+		}
+		if role.Name == model.RoleSuperAdmin {
+			return jwt.RoleSuperAdmin, true
+		}
+		tokenRole = jwt.RoleAdmin
+	}
+	return tokenRole, tokenRole != ""
+}
+
+// checkTargetPrivileges 管理员重置其他用户的凭证（密码、两步验证、通行密钥）前检查目标用户，
+// 避免只有 user:write 的管理员借此接管权限更高的账号：
+// 目标拥有后台角色时只有 superadmin 可以操作，且目标的每个权限管理员都必须拥有
+func checkTargetPrivileges(ctx context.Context, rbacService RBACService, adminID, userID uint) error {
+	roles, err := rbacService.GetUserRoles(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if _, isAdmin := ResolveAdminRole(roles); !isAdmin {
+		return nil
+	}
+
+	adminRoles, err := rbacService.GetUserRoles(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if adminRole, _ := ResolveAdminRole(adminRoles); adminRole != jwt.RoleSuperAdmin {
+		return ErrTargetPrivileged
+	}
+
+	permissions, err := rbacService.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		has, err := rbacService.HasPermission(ctx, adminID, permission.Code)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrTargetPrivileged
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"trx-project/internal/model"
+	"trx-project/pkg/jwt"
+	"trx-project/pkg/notifier"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestAdminAuthService(userRepo *MockUserRepository, rbac *MockRBACService, tokens *MockTokenService, mfa *MockMFAService, guard *MockLoginProtectionService) AdminAuthService {
+	logger := zap.NewNop()
+	verifier := newTestEmailVerificationService(userRepo, notifier.NewMemoryNotifier(), false)
+	userService := NewUserService(userRepo, nil, logger, tokens, new(MockUserStatusService), mfa, verifier, guard, NewLocalAuthenticator(userRepo, testPasswordHasher, logger), testPasswordHasher, testPasswordPolicy)
+	return NewAdminAuthService(userService, rbac, tokens, mfa, nil, nil, guard, logger)
+}
+
+func TestResolveAdminRole(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []*model.Role
+		role  string
+		ok    bool
+	}{
+		{"no roles", nil, "", false},
+		{"only disabled roles", []*model.Role{{Name: model.RoleAdmin, Status: 0}}, "", false},
+		{"any enabled role", []*model.Role{{Name: model.RoleViewer, Status: 1}}, jwt.RoleAdmin, true},
+		{"superadmin wins regardless of order", []*model.Role{
+			{Name: model.RoleEditor, Status: 1},
+			{Name: model.RoleSuperAdmin, Status: 1},
+		}, jwt.RoleSuperAdmin, true},
+		{"disabled superadmin falls back to admin", []*model.Role{
+			{Name: model.RoleSuperAdmin, Status: 0},
+			{Name: model.RoleViewer, Status: 1},
+		}, jwt.RoleAdmin, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, ok := ResolveAdminRole(tt.roles)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.role, role)
+		})
+	}
+}
+
+func TestAdminAuthService_Login(t *testing.T) {
+	ctx := context.Background()
+	ip := "192.0.2.1"
+	hash, err := testPasswordHasher.Hash("password123")
+	require.NoError(t, err)
+
+	t.Run("Non-admin user rejected", func(t *testing.T) {
+		userRepo, rbac, tokens, mfa, guard := new(MockUserRepository), new(MockRBACService), new(MockTokenService), new(MockMFAService), new(MockLoginProtectionService)
+		s := newTestAdminAuthService(userRepo, rbac, tokens, mfa, guard)
+
+		userRepo.On("GetByUsername", ctx, "alice").Return(&model.User{ID: 2, Username: "alice", Password: hash, Status: 1}, nil)
+		guard.On("Check", ctx, loginServiceBackend, "alice", ip).Return(nil)
+		guard.On("RecordFailure", ctx, loginServiceBackend, "alice", ip, ErrAdminRoleRequired).Return()
+		rbac.On("GetUserRoles", ctx, uint(2)).Return([]*model.Role{{Name: model.RoleAdmin, Status: 0}}, nil)
+
+		result, err := s.Login(ctx, "alice", "password123", ip)
+		assert.ErrorIs(t, err, ErrAdminRoleRequired)
+		assert.Nil(t, result)
+		// 没有后台角色也计入登录失败，避免用普通账号探测后台
+		guard.AssertCalled(t, "RecordFailure", ctx, loginServiceBackend, "alice", ip, ErrAdminRoleRequired)
+		guard.AssertNotCalled(t, "RecordSuccess", mock.Anything, mock.Anything, mock.Anything)
+		tokens.AssertNotCalled(t, "IssueTokenPair", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Token role derived from roles", func(t *testing.T) {
+		userRepo, rbac, tokens, mfa, guard := new(MockUserRepository), new(MockRBACService), new(MockTokenService), new(MockMFAService), new(MockLoginProtectionService)
+		s := newTestAdminAuthService(userRepo, rbac, tokens, mfa, guard)
+
+		user := &model.User{ID: 1, Username: "root", Password: hash, Status: 1}
+		userRepo.On("GetByUsername", ctx, "root").Return(user, nil)
+		guard.On("Check", ctx, loginServiceBackend, "root", ip).Return(nil)
+		guard.On("RecordSuccess", ctx, "root", ip).Return()
+		rbac.On("GetUserRoles", ctx, uint(1)).Return([]*model.Role{
+			{Name: model.RoleEditor, Status: 1},
+			{Name: model.RoleSuperAdmin, Status: 1},
+		}, nil)
+		mfa.On("StartLogin", ctx, uint(1), jwt.RoleSuperAdmin).Return(nil, nil)
+		tokens.On("IssueTokenPair", ctx, user, jwt.RoleSuperAdmin, "").Return(&TokenPair{AccessToken: "access"}, nil)
+
+		result, err := s.Login(ctx, "root", "password123", ip)
+		require.NoError(t, err)
+		assert.Equal(t, "access", result.Tokens.AccessToken)
+		assert.Len(t, result.Roles, 2)
+		tokens.AssertExpectations(t)
+		guard.AssertExpectations(t)
+	})
+
+	t.Run("Disabled account rejected", func(t *testing.T) {
+		userRepo, rbac, tokens, mfa, guard := new(MockUserRepository), new(MockRBACService), new(MockTokenService), new(MockMFAService), new(MockLoginProtectionService)
+		s := newTestAdminAuthService(userRepo, rbac, tokens, mfa, guard)
+
+		userRepo.On("GetByUsername", ctx, "bob").Return(&model.User{ID: 3, Username: "bob", Password: hash, Status: 0}, nil)
+		guard.On("Check", ctx, loginServiceBackend, "bob", ip).Return(nil)
+		guard.On("RecordFailure", ctx, loginServiceBackend, "bob", ip, ErrUserInactive).Return()
+
+		result, err := s.Login(ctx, "bob", "password123", ip)
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		rbac.AssertNotCalled(t, "GetUserRoles", mock.Anything, mock.Anything)
+		tokens.AssertNotCalled(t, "IssueTokenPair", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminAuthService_CompleteMFALoginDisabled(t *testing.T) {
+	ctx := context.Background()
+	userRepo, rbac, tokens, mfa, guard := new(MockUserRepository), new(MockRBACService), new(MockTokenService), new(MockMFAService), new(MockLoginProtectionService)
+	s := newTestAdminAuthService(userRepo, rbac, tokens, mfa, guard)
+
+	// 密码校验后、输入验证码前账号被禁用
+	mfa.On("CompleteLogin", ctx, "mfa-token", "123456", true).Return(&MFALoginResult{UserID: 1}, nil)
+	userRepo.On("GetByID", ctx, uint(1)).Return(&model.User{ID: 1, Username: "root", Status: 0}, nil)
+
+	result, err := s.CompleteMFALogin(ctx, "mfa-token", "123456")
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	tokens.AssertNotCalled(t, "IssueTokenPair", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/config"
+	"trx-project/pkg/jwt"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrAPIKeyInvalid API Key 不存在、已吊销或已过期
+	ErrAPIKeyInvalid = errors.New("api key is invalid or expired")
+	// ErrAPIKeyNotFound API Key 不存在或不属于该用户
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrAPIKeyScopeInvalid scopes 中包含不存在的权限编码
+	ErrAPIKeyScopeInvalid = errors.New("unknown permission in scopes")
+	// ErrAPIKeyTTLInvalid 有效期超出允许范围
+	ErrAPIKeyTTLInvalid = errors.New("api key expiry is out of range")
+)
+
+const (
+	// apiKeySecretBytes 随机部分的字节数
+	apiKeySecretBytes = 32
+	// apiKeyDisplayPrefixLen 列表中展示的明文长度
+	apiKeyDisplayPrefixLen = 12
+	// apiKeyTouchInterval 最近使用时间的最小更新间隔
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyPrincipal 通过 API Key 认证的主体
+type APIKeyPrincipal struct {
+	Key  *model.APIKey
+	User *model.User
+	// Role 根据所有者的角色推导，与登录签发的 Token 角色一致：user、admin 或 superadmin
+	Role string
+}
+
+// CreateAPIKeyInput 创建 API Key 的参数
+type CreateAPIKeyInput struct {
+	Name      string
+	Scopes    []string
+	ExpiresIn time.Duration
+}
+
+// CreatedAPIKey 新创建的 API Key，Key 为明文，只在创建时返回一次
+type CreatedAPIKey struct {
+	*model.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyService API Key 服务
+type APIKeyService interface {
+	// Authenticate 校验 API Key 明文并返回认证主体
+	Authenticate(ctx context.Context, rawKey string) (*APIKeyPrincipal, error)
+	// CreateKey 为 ownerID 创建 API Key，actorID 为操作人
+	CreateKey(ctx context.Context, actorID, ownerID uint, input CreateAPIKeyInput, ip string) (*CreatedAPIKey, error)
+	// ListKeys 列出用户的所有 API Key
+	ListKeys(ctx context.Context, ownerID uint) ([]*model.APIKey, error)
+	// RevokeKey 吊销用户的某个 API Key，不属于该用户时返回 ErrAPIKeyNotFound
+	RevokeKey(ctx context.Context, actorID, ownerID, keyID uint, ip string) error
+	// RevokeUserKeys 吊销用户的所有 API Key
+	RevokeUserKeys(ctx context.Context, ownerID uint) error
+}
+
+type apiKeyService struct {
+	repo        repository.APIKeyRepository
+	userRepo    repository.UserRepository
+	rbacService RBACService
+	audit       AuditService
+	logger      *zap.Logger
+	maxTTL      time.Duration
+}
+
+// NewAPIKeyService 创建 API Key 服务
+func NewAPIKeyService(repo repository.APIKeyRepository, userRepo repository.UserRepository, rbacService RBACService, audit AuditService, logger *zap.Logger, cfg *config.Config) APIKeyService {
+	return &apiKeyService{
+		repo:        repo,
+		userRepo:    userRepo,
+		rbacService: rbacService,
+		audit:       audit,
+		logger:      logger,
+		maxTTL:      cfg.Auth.APIKeyMaxTTL(),
+	}
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*APIKeyPrincipal, error) {
+	if !strings.HasPrefix(rawKey, model.APIKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	key, err := s.repo.GetByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyInvalid
+		}
+		s.logger.Error("Failed to get api key", zap.Error(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserDisabled
+		}
+		s.logger.Error("Failed to get api key owner", zap.Uint("user_id", key.UserID), zap.Error(err))
+		return nil, err
+	}
+	if user.Status != 1 {
+		return nil, ErrUserDisabled
+	}
+
+	roles, err := s.rbacService.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Failed to get api key owner roles", zap.Uint("user_id", user.ID), zap.Error(err))
+		return nil, err
+	}
+	role, ok := ResolveAdminRole(roles)
+	if !ok {
+		role = jwt.RoleUser
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			s.logger.Warn("Failed to update api key last used time", zap.Uint("key_id", key.ID), zap.Error(err))
+		}
+	}
+
+	return &APIKeyPrincipal{Key: key, User: user, Role: role}, nil
+}
+
+func (s *apiKeyService) CreateKey(ctx context.Context, actorID, ownerID uint, input CreateAPIKeyInput, ip string) (*CreatedAPIKey, error) {
+	if input.ExpiresIn <= 0 || input.ExpiresIn > s.maxTTL {
+		return nil, fmt.Errorf("%w: at most %d days", ErrAPIKeyTTLInvalid, int(s.maxTTL/(24*time.Hour)))
+	}
+
+	scopes, err := s.normalizeScopes(ctx, input.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &model.APIKey{
+		UserID:    ownerID,
+		Name:      strings.TrimSpace(input.Name),
+		Prefix:    rawKey[:apiKeyDisplayPrefixLen],
+		KeyHash:   hashAPIKey(rawKey),
+		Scopes:    scopes,
+		CreatedBy: actorID,
+		ExpiresAt: time.Now().Add(input.ExpiresIn),
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		s.logger.Error("Failed to create api key", zap.Uint("user_id", ownerID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("API key created",
+		zap.Uint("actor_id", actorID),
+		zap.Uint("user_id", ownerID),
+		zap.Uint("key_id", key.ID),
+		zap.Strings("scopes", scopes))
+
+	if err := s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    actorID,
+		Action:     model.AuditActionAPIKeyCreated,
+		TargetType: "user",
+		TargetID:   ownerID,
+		Detail:     fmt.Sprintf("key_id=%d name=%s prefix=%s scopes=%s", key.ID, key.Name, key.Prefix, strings.Join(scopes, ",")),
+		IP:         ip,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &CreatedAPIKey{APIKey: key, Key: rawKey}, nil
+}
+
+func (s *apiKeyService) ListKeys(ctx context.Context, ownerID uint) ([]*model.APIKey, error) {
+	keys, err := s.repo.ListByUserID(ctx, ownerID)
+	if err != nil {
+		s.logger.Error("Failed to list api keys", zap.Uint("user_id", ownerID), zap.Error(err))
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *apiKeyService) RevokeKey(ctx context.Context, actorID, ownerID, keyID uint, ip string) error {
+	key, err := s.repo.GetByID(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		s.logger.Error("Failed to get api key", zap.Uint("key_id", keyID), zap.Error(err))
+		return err
+	}
+	if key.UserID != ownerID {
+		return ErrAPIKeyNotFound
+	}
+
+	revoked, err := s.repo.Revoke(ctx, keyID)
+	if err != nil {
+		s.logger.Error("Failed to revoke api key", zap.Uint("key_id", keyID), zap.Error(err))
+		return err
+	}
+	if !revoked {
+		// 已经吊销过，重复吊销视为成功
+		return nil
+	}
+
+	s.logger.Info("API key revoked",
+		zap.Uint("actor_id", actorID),
+		zap.Uint("user_id", ownerID),
+		zap.Uint("key_id", keyID))
+
+	return s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    actorID,
+		Action:     model.AuditActionAPIKeyRevoked,
+		TargetType: "user",
+		TargetID:   ownerID,
+		Detail:     fmt.Sprintf("key_id=%d name=%s prefix=%s", key.ID, key.Name, key.Prefix),
+		IP:         ip,
+	})
+}
+
+func (s *apiKeyService) RevokeUserKeys(ctx context.Context, ownerID uint) error {
+	if err := s.repo.RevokeByUserID(ctx, ownerID); err != nil {
+		s.logger.Error("Failed to revoke user api keys", zap.Uint("user_id", ownerID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// normalizeScopes 去重并校验权限编码都存在
+func (s *apiKeyService) normalizeScopes(ctx context.Context, scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return []string{}, nil
+	}
+
+	permissions, err := s.rbacService.ListPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		known[permission.Code] = true
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if seen[scope] {
+			continue
+		}
+		if !known[scope] {
+			return nil, fmt.Errorf("%w: %s", ErrAPIKeyScopeInvalid, scope)
+		}
+		seen[scope] = true
+		normalized = append(normalized, scope)
+	}
+	return normalized, nil
+}
+
+// generateAPIKey 生成带前缀的随机 API Key 明文
+func generateAPIKey() (string, error) {
+	buf := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return model.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashAPIKey 计算 API Key 明文的哈希，明文本身为高熵随机数，不需要加盐或慢哈希
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/pkg/config"
+	"trx-project/pkg/jwt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MockAPIKeyRepository 模拟 API Key 仓库
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByID(ctx context.Context, id uint) (*model.APIKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) ListByUserID(ctx context.Context, userID uint) ([]*model.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, id uint) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) RevokeByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockRBACService 模拟 RBAC 服务
+type MockRBACService struct {
+	mock.Mock
+}
+
+func (m *MockRBACService) GetRoleByName(ctx context.Context, name string) (*model.Role, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+func (m *MockRBACService) GetRoleByID(ctx context.Context, id uint) (*model.Role, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+func (m *MockRBACService) GetRoleWithPermissions(ctx context.Context, roleID uint) (*model.Role, error) {
+	args := m.Called(ctx, roleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+func (m *MockRBACService) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.Role), args.Error(1)
+}
+
+func (m *MockRBACService) CreateRole(ctx context.Context, role *model.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) UpdateRole(ctx context.Context, role *model.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) UpdateRoleStatus(ctx context.Context, id uint, status int) (*model.Role, error) {
+	args := m.Called(ctx, id, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+func (m *MockRBACService) SetRoleParent(ctx context.Context, id uint, parentID *uint) (*model.Role, error) {
+	args := m.Called(ctx, id, parentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+func (m *MockRBACService) DeleteRole(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) ListPermissions(ctx context.Context) ([]*model.Permission, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.Permission), args.Error(1)
+}
+
+func (m *MockRBACService) GetPermissionByID(ctx context.Context, id uint) (*model.Permission, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Permission), args.Error(1)
+}
+
+func (m *MockRBACService) CreatePermission(ctx context.Context, permission *model.Permission) error {
+	args := m.Called(ctx, permission)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) UpdatePermission(ctx context.Context, permission *model.Permission) error {
+	args := m.Called(ctx, permission)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) UpdatePermissionStatus(ctx context.Context, id uint, status int) (*model.Permission, error) {
+	args := m.Called(ctx, id, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Permission), args.Error(1)
+}
+
+func (m *MockRBACService) DeletePermission(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) AssignPermissionsToRole(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	args := m.Called(ctx, roleID, permissionIDs)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) RemovePermissionsFromRole(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	args := m.Called(ctx, roleID, permissionIDs)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) GetRolePermissions(ctx context.Context, roleID uint) ([]*model.Permission, error) {
+	args := m.Called(ctx, roleID)
+	return args.Get(0).([]*model.Permission), args.Error(1)
+}
+
+func (m *MockRBACService) GetRoleGrants(ctx context.Context, roleID uint) ([]*model.PermissionGrant, error) {
+	args := m.Called(ctx, roleID)
+	return args.Get(0).([]*model.PermissionGrant), args.Error(1)
+}
+
+func (m *MockRBACService) SetGrantCondition(ctx context.Context, roleID, permissionID uint, condition string) error {
+	args := m.Called(ctx, roleID, permissionID, condition)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) AssignRoleToUser(ctx context.Context, userID, roleID uint) error {
+	args := m.Called(ctx, userID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) RemoveRoleFromUser(ctx context.Context, userID, roleID uint) error {
+	args := m.Called(ctx, userID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*model.Role), args.Error(1)
+}
+
+func (m *MockRBACService) GetUserPermissions(ctx context.Context, userID uint) ([]*model.Permission, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*model.Permission), args.Error(1)
+}
+
+func (m *MockRBACService) HasPermission(ctx context.Context, userID uint, permissionCode string) (bool, error) {
+	args := m.Called(ctx, userID, permissionCode)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRBACService) CheckPermission(ctx context.Context, userID uint, permissionCode string) error {
+	args := m.Called(ctx, userID, permissionCode)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) GrantsAllow(grants []string, permissionCode string) bool {
+	args := m.Called(grants, permissionCode)
+	return args.Bool(0)
+}
+
+// MockAuditService 模拟审计服务
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Record(ctx context.Context, log *model.AuditLog) error {
+	args := m.Called(ctx, log)
+	return args.Error(0)
+}
+
+func newTestAPIKeyService(repo *MockAPIKeyRepository, userRepo *MockUserRepository, rbac *MockRBACService, audit *MockAuditService) APIKeyService {
+	cfg := &config.Config{Auth: config.AuthConfig{APIKeyMaxTTLDays: 30}}
+	return NewAPIKeyService(repo, userRepo, rbac, audit, zap.NewNop(), cfg)
+}
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockAPIKeyRepository)
+	userRepo := new(MockUserRepository)
+	rbac := new(MockRBACService)
+	audit := new(MockAuditService)
+	service := newTestAPIKeyService(repo, userRepo, rbac, audit)
+
+	rbac.On("ListPermissions", ctx).Return([]*model.Permission{{Code: "user:read"}, {Code: "user:write"}}, nil)
+	audit.On("Record", ctx, mock.Anything).Return(nil)
+
+	var stored *model.APIKey
+	repo.On("Create", ctx, mock.AnythingOfType("*model.APIKey")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*model.APIKey)
+		stored.ID = 7
+	}).Return(nil)
+
+	created, err := service.CreateKey(ctx, 1, 2, CreateAPIKeyInput{
+		Name:      "nightly",
+		Scopes:    []string{"user:read", "user:read"},
+		ExpiresIn: 7 * 24 * time.Hour,
+	}, "127.0.0.1")
+	require.NoError(t, err)
+
+	// 明文带前缀，数据库只保存哈希
+	assert.True(t, strings.HasPrefix(created.Key, model.APIKeyPrefix))
+	assert.Equal(t, created.Key[:len(stored.Prefix)], stored.Prefix)
+	assert.NotContains(t, stored.KeyHash, created.Key)
+	assert.Equal(t, []string{"user:read"}, stored.Scopes)
+	assert.Equal(t, uint(1), stored.CreatedBy)
+
+	owner := &model.User{ID: 2, Username: "svc-report", Status: 1, AccountType: model.AccountTypeService}
+	repo.On("GetByHash", ctx, stored.KeyHash).Return(stored, nil)
+	repo.On("TouchLastUsed", ctx, uint(7), mock.AnythingOfType("time.Time")).Return(nil)
+	userRepo.On("GetByID", ctx, uint(2)).Return(owner, nil)
+	rbac.On("GetUserRoles", ctx, uint(2)).Return([]*model.Role{{Name: model.RoleAdmin, Status: 1}}, nil)
+
+	principal, err := service.Authenticate(ctx, created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, owner, principal.User)
+	assert.Equal(t, jwt.RoleAdmin, principal.Role)
+	assert.True(t, principal.Key.HasScope("user:read"))
+	assert.False(t, principal.Key.HasScope("user:write"))
+
+	// 没有前缀或未知的 Key 无效
+	_, err = service.Authenticate(ctx, "not-a-key")
+	assert.ErrorIs(t, err, ErrAPIKeyInvalid)
+	repo.On("GetByHash", ctx, hashAPIKey(model.APIKeyPrefix+"unknown")).Return(nil, gorm.ErrRecordNotFound)
+	_, err = service.Authenticate(ctx, model.APIKeyPrefix+"unknown")
+	assert.ErrorIs(t, err, ErrAPIKeyInvalid)
+
+	// 吊销后无效
+	now := time.Now()
+	stored.RevokedAt = &now
+	_, err = service.Authenticate(ctx, created.Key)
+	assert.ErrorIs(t, err, ErrAPIKeyInvalid)
+
+	// 所有者被禁用后无效
+	stored.RevokedAt = nil
+	owner.Status = 0
+	_, err = service.Authenticate(ctx, created.Key)
+	assert.ErrorIs(t, err, ErrUserDisabled)
+}
+
+func TestAPIKeyService_CreateKeyValidation(t *testing.T) {
+	ctx := context.Background()
+	rbac := new(MockRBACService)
+	service := newTestAPIKeyService(new(MockAPIKeyRepository), new(MockUserRepository), rbac, new(MockAuditService))
+
+	rbac.On("ListPermissions", ctx).Return([]*model.Permission{{Code: "user:read"}}, nil)
+
+	_, err := service.CreateKey(ctx, 1, 1, CreateAPIKeyInput{Name: "k", Scopes: []string{"rbac:manage"}, ExpiresIn: 24 * time.Hour}, "")
+	assert.ErrorIs(t, err, ErrAPIKeyScopeInvalid)
+
+	_, err = service.CreateKey(ctx, 1, 1, CreateAPIKeyInput{Name: "k", ExpiresIn: 31 * 24 * time.Hour}, "")
+	assert.ErrorIs(t, err, ErrAPIKeyTTLInvalid)
+}
+
+func TestAPIKeyService_RevokeKeyOwnership(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockAPIKeyRepository)
+	audit := new(MockAuditService)
+	service := newTestAPIKeyService(repo, new(MockUserRepository), new(MockRBACService), audit)
+
+	repo.On("GetByID", ctx, uint(7)).Return(&model.APIKey{ID: 7, UserID: 2, Name: "nightly"}, nil)
+	repo.On("Revoke", ctx, uint(7)).Return(true, nil).Once()
+	audit.On("Record", ctx, mock.Anything).Return(nil)
+
+	// 其他用户的 Key 按不存在处理
+	assert.ErrorIs(t, service.RevokeKey(ctx, 1, 1, 7, ""), ErrAPIKeyNotFound)
+	require.NoError(t, service.RevokeKey(ctx, 1, 2, 7, ""))
+	repo.AssertNumberOfCalls(t, "Revoke", 1)
+}
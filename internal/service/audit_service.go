@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// AuditService 审计日志服务
+type AuditService interface {
+	// Record 记录一条审计日志
+	Record(ctx context.Context, log *model.AuditLog) error
+}
+
+type auditService struct {
+	repo   repository.AuditRepository
+	logger *zap.Logger
+}
+
+// NewAuditService 创建审计日志服务
+func NewAuditService(repo repository.AuditRepository, logger *zap.Logger) AuditService {
+	return &auditService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *auditService) Record(ctx context.Context, log *model.AuditLog) error {
+	if err := s.repo.Create(ctx, log); err != nil {
+		s.logger.Error("Failed to record audit log",
+			zap.Uint("actor_id", log.ActorID),
+			zap.String("action", log.Action),
+			zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Audit log recorded",
+		zap.Uint("actor_id", log.ActorID),
+		zap.String("action", log.Action),
+		zap.String("target_type", log.TargetType),
+		zap.Uint("target_id", log.TargetID))
+	return nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/config"
+	"trx-project/pkg/passhash"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 凭证校验器名称，对应 auth.authenticators 中的配置
+const (
+	AuthenticatorLocal = "local"
+	AuthenticatorLDAP  = "ldap"
+)
+
+var (
+	// ErrAuthenticatorUnavailable 凭证校验器暂时不可用（如目录服务连接失败）
+	ErrAuthenticatorUnavailable = errors.New("authentication backend unavailable")
+)
+
+// Authenticator 用户名密码的凭证校验器
+// 只校验凭证并返回对应的本地用户，账号状态和邮箱验证由 UserService.Authenticate 统一检查
+type Authenticator interface {
+	Name() string
+	// Authenticate 密码错误或找不到用户时返回 ErrInvalidCredentials，后端不可用时返回 ErrAuthenticatorUnavailable
+	Authenticate(ctx context.Context, username, password string) (*model.User, error)
+}
+
+// LocalAuthenticator 使用 users 表中的密码哈希校验，校验通过后把使用旧算法或旧参数的哈希升级为当前配置
+type LocalAuthenticator struct {
+	repo   repository.UserRepository
+	hasher *passhash.Hasher
+	logger *zap.Logger
+}
+
+// NewLocalAuthenticator 创建本地密码校验器
+func NewLocalAuthenticator(repo repository.UserRepository, hasher *passhash.Hasher, logger *zap.Logger) *LocalAuthenticator {
+	return &LocalAuthenticator{repo: repo, hasher: hasher, logger: logger}
+}
+
+func (a *LocalAuthenticator) Name() string {
+	return AuthenticatorLocal
+}
+
+func (a *LocalAuthenticator) Authenticate(ctx context.Context, username, password string) (*model.User, error) {
+	user, err := a.repo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		a.logger.Error("Failed to get user", zap.Error(err))
+		return nil, err
+	}
+
+	// 服务账号没有密码，不能登录
+	if user.IsServiceAccount() {
+		return nil, ErrInvalidCredentials
+	}
+
+	// 验证密码，单点登录和 LDAP 创建的账号没有本地密码，不会通过
+	if err := a.hasher.Verify(user.Password, password); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if a.hasher.NeedsRehash(user.Password) {
+		a.rehash(ctx, user, password)
+	}
+
+	return user, nil
+}
+
+// rehash 使用当前配置重新哈希密码，失败时不影响本次登录
+func (a *LocalAuthenticator) rehash(ctx context.Context, user *model.User, password string) {
+	hashed, err := a.hasher.Hash(password)
+	if err != nil {
+		a.logger.Error("Failed to rehash password", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+
+	updated, err := a.repo.UpdatePasswordHash(ctx, user.ID, user.Password, hashed)
+	if err != nil {
+		a.logger.Error("Failed to update password hash", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+	if updated {
+		user.Password = hashed
+		a.logger.Info("Password hash upgraded", zap.Uint("user_id", user.ID))
+	}
+}
+
+// authenticatorChain 按配置顺序依次尝试多个凭证校验器
+type authenticatorChain struct {
+	authenticators []Authenticator
+	logger         *zap.Logger
+}
+
+// NewAuthenticator 按 auth.authenticators 的顺序组合凭证校验器
+func NewAuthenticator(local *LocalAuthenticator, ldap *LDAPAuthenticator, logger *zap.Logger, cfg *config.Config) (Authenticator, error) {
+	available := map[string]Authenticator{
+		AuthenticatorLocal: local,
+		AuthenticatorLDAP:  ldap,
+	}
+
+	chain := &authenticatorChain{logger: logger}
+	seen := make(map[string]bool)
+	for _, name := range cfg.Auth.AuthenticatorOrder() {
+		authenticator, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown authenticator %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate authenticator %q", name)
+		}
+		if name == AuthenticatorLDAP && cfg.Auth.LDAP.URL == "" {
+			return nil, errors.New("auth.ldap.url is required when ldap authenticator is enabled")
+		}
+		seen[name] = true
+		chain.authenticators = append(chain.authenticators, authenticator)
+	}
+
+	if len(chain.authenticators) == 1 {
+		return chain.authenticators[0], nil
+	}
+	return chain, nil
+}
+
+func (c *authenticatorChain) Name() string {
+	return "chain"
+}
+
+// Authenticate 前一个校验器密码错误、找不到用户或不可用时尝试下一个，其他错误（如账号已禁用）直接返回
+// 所有校验器都失败时，只要有一个明确拒绝了凭证就返回 ErrInvalidCredentials，计入登录失败次数
+func (c *authenticatorChain) Authenticate(ctx context.Context, username, password string) (*model.User, error) {
+	result := ErrAuthenticatorUnavailable
+	for _, authenticator := range c.authenticators {
+		user, err := authenticator.Authenticate(ctx, username, password)
+		switch {
+		case err == nil:
+			c.logger.Debug("Credentials verified",
+				zap.String("authenticator", authenticator.Name()),
+				zap.String("username", username))
+			return user, nil
+		case errors.Is(err, ErrInvalidCredentials):
+			result = ErrInvalidCredentials
+		case errors.Is(err, ErrAuthenticatorUnavailable):
+			c.logger.Warn("Authenticator unavailable, trying next",
+				zap.String("authenticator", authenticator.Name()),
+				zap.Error(err))
+		default:
+			return nil, err
+		}
+	}
+	return nil, result
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/permission"
+	"trx-project/pkg/policy"
+	"trx-project/pkg/tenant"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrAccessDenied 用户没有对该资源执行操作的权限
+	ErrAccessDenied = errors.New("access denied")
+)
+
+// AuthorizationService 资源级授权服务，在 RBAC 权限之上按授权条件检查具体资源
+//
+// 路由上的权限中间件只检查用户是否拥有权限编码，带条件的授权在这一步视为拥有权限；
+// 操作具体资源的处理器加载资源后调用 Authorize，由授权条件决定是否允许
+type AuthorizationService interface {
+	// Authorize 检查用户能否对资源执行 permissionCode 对应的操作，
+	// 任一匹配的授权没有条件或条件成立时允许，否则返回 ErrAccessDenied
+	Authorize(ctx context.Context, userID uint, permissionCode string, resource, request policy.Attributes) error
+	// AuthorizeUnconditional 检查用户是否拥有 permissionCode 的无条件授权，只有带条件的授权时返回 ErrAccessDenied
+	// 用于无法按条件逐个检查的操作（如列表）和会改变条件判断结果的操作（如修改用户属性）
+	AuthorizeUnconditional(ctx context.Context, userID uint, permissionCode string) error
+}
+
+// grantSource 授权服务读取用户授权使用的数据源，由 repository.RBACRepository 实现
+type grantSource interface {
+	GetUserGrants(ctx context.Context, userID uint) ([]*model.PermissionGrant, error)
+	GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error)
+}
+
+// subjectSource 授权服务读取用户属性使用的数据源，由 repository.UserRepository 实现
+type subjectSource interface {
+	GetByID(ctx context.Context, id uint) (*model.User, error)
+}
+
+type authorizationService struct {
+	grants   grantSource
+	subjects subjectSource
+	matcher  *permission.Matcher
+	logger   *zap.Logger
+
+	// compiled 已编译的条件表达式：源码 -> *policy.Expression
+	compiled sync.Map
+}
+
+// NewAuthorizationService 创建资源级授权服务
+func NewAuthorizationService(rbacRepo repository.RBACRepository, userRepo repository.UserRepository, matcher *permission.Matcher, logger *zap.Logger) AuthorizationService {
+	return newAuthorizationService(rbacRepo, userRepo, matcher, logger)
+}
+
+func newAuthorizationService(grants grantSource, subjects subjectSource, matcher *permission.Matcher, logger *zap.Logger) *authorizationService {
+	return &authorizationService{
+		grants:   grants,
+		subjects: subjects,
+		matcher:  matcher,
+		logger:   logger,
+	}
+}
+
+func (s *authorizationService) Authorize(ctx context.Context, userID uint, permissionCode string, resource, request policy.Attributes) error {
+	grants, err := s.grants.GetUserGrants(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var subject policy.Attributes
+	for _, grant := range grants {
+		if !s.matcher.Allows([]string{grant.Code}, permissionCode) {
+			continue
+		}
+		if !grant.Conditional() {
+			return nil
+		}
+
+		// 有条件的授权才需要用户属性，只加载一次
+		if subject == nil {
+			if subject, err = s.subject(ctx, userID); err != nil {
+				return err
+			}
+		}
+		if s.conditionHolds(grant, policy.Env{Subject: subject, Resource: resource, Request: request}) {
+			return nil
+		}
+	}
+
+	s.logger.Warn("Access denied",
+		zap.Uint("user_id", userID),
+		zap.String("permission", permissionCode),
+		zap.Any("resource_id", resource["id"]))
+	return ErrAccessDenied
+}
+
+func (s *authorizationService) AuthorizeUnconditional(ctx context.Context, userID uint, permissionCode string) error {
+	grants, err := s.grants.GetUserGrants(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, grant := range grants {
+		if !grant.Conditional() && s.matcher.Allows([]string{grant.Code}, permissionCode) {
+			return nil
+		}
+	}
+
+	s.logger.Warn("Access denied, unconditional grant required",
+		zap.Uint("user_id", userID),
+		zap.String("permission", permissionCode))
+	return ErrAccessDenied
+}
+
+// conditionHolds 对授权条件求值，条件无法编译或求值出错时视为不成立
+func (s *authorizationService) conditionHolds(grant *model.PermissionGrant, env policy.Env) bool {
+	expr, err := s.compile(grant.Condition)
+	if err != nil {
+		s.logger.Error("Invalid grant condition",
+			zap.Uint("role_id", grant.RoleID),
+			zap.String("permission", grant.Code),
+			zap.String("condition", grant.Condition),
+			zap.Error(err))
+		return false
+	}
+
+	ok, err := expr.Eval(env)
+	if err != nil {
+		s.logger.Warn("Failed to evaluate grant condition",
+			zap.Uint("role_id", grant.RoleID),
+			zap.String("permission", grant.Code),
+			zap.String("condition", grant.Condition),
+			zap.Error(err))
+		return false
+	}
+	return ok
+}
+
+func (s *authorizationService) compile(condition string) (*policy.Expression, error) {
+	if expr, ok := s.compiled.Load(condition); ok {
+		return expr.(*policy.Expression), nil
+	}
+	expr, err := policy.Compile(condition)
+	if err != nil {
+		return nil, err
+	}
+	s.compiled.Store(condition, expr)
+	return expr, nil
+}
+
+// subject 用户的属性：id、username、email、account_type、roles（启用的直接角色名），
+// 以及用户自定义属性（如 subject.region），自定义属性不会覆盖内置属性
+func (s *authorizationService) subject(ctx context.Context, userID uint) (policy.Attributes, error) {
+	// 平台管理员可能正在操作其他租户，自己的账号不在当前租户中
+	user, err := s.subjects.GetByID(tenant.WithAllTenants(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.grants.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := policy.Attributes{}
+	for key, value := range user.Attributes {
+		subject[key] = value
+	}
+	roleNames := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if role.Status == 1 {
+			roleNames = append(roleNames, role.Name)
+		}
+	}
+	subject["id"] = user.ID
+	subject["username"] = user.Username
+	subject["email"] = user.Email
+	subject["account_type"] = user.AccountType
+	subject["roles"] = roleNames
+	return subject, nil
+}
+
+// UserResource 用户作为授权资源时的属性：id、username、email、account_type、status、created_by，
+// 以及用户自定义属性（如 resource.region），自定义属性不会覆盖内置属性
+// created_by 只有后台创建的服务账号才有，自行注册的用户为 null
+func UserResource(user *model.User) policy.Attributes {
+	resource := policy.Attributes{}
+	for key, value := range user.Attributes {
+		resource[key] = value
+	}
+	resource["id"] = user.ID
+	resource["username"] = user.Username
+	resource["email"] = user.Email
+	resource["account_type"] = user.AccountType
+	resource["status"] = user.Status
+	resource["created_by"] = user.CreatedBy
+	return resource
+}
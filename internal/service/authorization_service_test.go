@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"trx-project/internal/model"
+	"trx-project/pkg/permission"
+	"trx-project/pkg/policy"
+	"trx-project/pkg/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestAuthorizationService(t *testing.T) (*authorizationService, *MockRBACRepository, *MockUserRepository) {
+	rbacRepo := new(MockRBACRepository)
+	userRepo := new(MockUserRepository)
+	matcher, err := permission.NewMatcher(map[string][]string{
+		"user:write":  {"user:read"},
+		"user:delete": {"user:write"},
+	})
+	require.NoError(t, err)
+	return newAuthorizationService(rbacRepo, userRepo, matcher, zap.NewNop()), rbacRepo, userRepo
+}
+
+func TestAuthorizationService_Unconditional(t *testing.T) {
+	ctx := context.Background()
+	s, rbacRepo, userRepo := newTestAuthorizationService(t)
+
+	rbacRepo.On("GetUserGrants", ctx, uint(1)).Return([]*model.PermissionGrant{
+		{RoleID: 2, Code: "user:*"},
+	}, nil)
+
+	// 无条件授权不需要加载用户属性
+	require.NoError(t, s.Authorize(ctx, 1, "user:delete", policy.Attributes{"id": uint(42)}, nil))
+	assert.ErrorIs(t, s.Authorize(ctx, 1, "rbac:manage", policy.Attributes{"id": uint(42)}, nil), ErrAccessDenied)
+	userRepo.AssertNotCalled(t, "GetByID")
+}
+
+func TestAuthorizationService_Ownership(t *testing.T) {
+	ctx := context.Background()
+	s, rbacRepo, userRepo := newTestAuthorizationService(t)
+
+	// 编辑只能修改自己创建的用户，user:write 蕴含 user:read，条件同样适用
+	rbacRepo.On("GetUserGrants", ctx, uint(7)).Return([]*model.PermissionGrant{
+		{RoleID: 3, Code: "user:write", Condition: "resource.created_by == subject.id"},
+	}, nil)
+	rbacRepo.On("GetUserRoles", ctx, uint(7)).Return([]*model.Role{{ID: 3, Name: model.RoleEditor, Status: 1}}, nil)
+	// 用户属性跨租户加载，平台管理员操作其他租户时也能找到自己
+	userRepo.On("GetByID", mock.MatchedBy(tenant.IsAllTenants), uint(7)).Return(&model.User{ID: 7, Username: "editor"}, nil)
+
+	creator := uint(7)
+	own := UserResource(&model.User{ID: 42, CreatedBy: &creator})
+	other := UserResource(&model.User{ID: 43})
+
+	require.NoError(t, s.Authorize(ctx, 7, "user:write", own, nil))
+	require.NoError(t, s.Authorize(ctx, 7, "user:read", own, nil))
+	assert.ErrorIs(t, s.Authorize(ctx, 7, "user:write", other, nil), ErrAccessDenied)
+	assert.ErrorIs(t, s.Authorize(ctx, 7, "user:delete", own, nil), ErrAccessDenied)
+}
+
+func TestAuthorizationService_AuthorizeUnconditional(t *testing.T) {
+	ctx := context.Background()
+	s, rbacRepo, userRepo := newTestAuthorizationService(t)
+
+	rbacRepo.On("GetUserGrants", ctx, uint(5)).Return([]*model.PermissionGrant{
+		{RoleID: 5, Code: "user:write", Condition: "resource.region == subject.region"},
+		{RoleID: 6, Code: "user:read"},
+	}, nil)
+
+	// 带条件的 user:write 不算，无条件的 user:read 不蕴含 user:write
+	require.NoError(t, s.AuthorizeUnconditional(ctx, 5, "user:read"))
+	assert.ErrorIs(t, s.AuthorizeUnconditional(ctx, 5, "user:write"), ErrAccessDenied)
+	userRepo.AssertNotCalled(t, "GetByID")
+}
+
+func TestAuthorizationService_Region(t *testing.T) {
+	ctx := context.Background()
+	s, rbacRepo, userRepo := newTestAuthorizationService(t)
+
+	// 客服只能查看本区域的用户，或在工作时间查看服务账号
+	rbacRepo.On("GetUserGrants", ctx, uint(8)).Return([]*model.PermissionGrant{
+		{RoleID: 5, Code: "user:read", Condition: "resource.region == subject.region"},
+		{RoleID: 6, Code: "user:read", Condition: `resource.account_type == "service" && request.hour >= 9 && request.hour < 18`},
+	}, nil)
+	rbacRepo.On("GetUserRoles", ctx, uint(8)).Return([]*model.Role{{ID: 5, Name: "support", Status: 1}}, nil)
+	userRepo.On("GetByID", mock.Anything, uint(8)).Return(&model.User{ID: 8, Attributes: map[string]string{"region": "eu"}}, nil)
+
+	eu := UserResource(&model.User{ID: 42, AccountType: model.AccountTypeUser, Attributes: map[string]string{"region": "eu"}})
+	us := UserResource(&model.User{ID: 43, AccountType: model.AccountTypeUser, Attributes: map[string]string{"region": "us"}})
+	serviceAccount := UserResource(&model.User{ID: 44, AccountType: model.AccountTypeService})
+
+	require.NoError(t, s.Authorize(ctx, 8, "user:read", eu, policy.Attributes{"hour": 3}))
+	assert.ErrorIs(t, s.Authorize(ctx, 8, "user:read", us, policy.Attributes{"hour": 10}), ErrAccessDenied)
+	require.NoError(t, s.Authorize(ctx, 8, "user:read", serviceAccount, policy.Attributes{"hour": 10}))
+	assert.ErrorIs(t, s.Authorize(ctx, 8, "user:read", serviceAccount, policy.Attributes{"hour": 20}), ErrAccessDenied)
+}
+
+func TestAuthorizationService_InvalidCondition(t *testing.T) {
+	ctx := context.Background()
+	s, rbacRepo, userRepo := newTestAuthorizationService(t)
+
+	// 无法编译或求值出错的条件视为不成立，不会放行
+	rbacRepo.On("GetUserGrants", ctx, uint(9)).Return([]*model.PermissionGrant{
+		{RoleID: 3, Code: "user:read", Condition: "resource.created_by =="},
+		{RoleID: 4, Code: "user:read", Condition: "resource.id < subject.username"},
+	}, nil)
+	rbacRepo.On("GetUserRoles", ctx, uint(9)).Return([]*model.Role{}, nil)
+	userRepo.On("GetByID", mock.Anything, uint(9)).Return(&model.User{ID: 9, Username: "bob"}, nil)
+
+	err := s.Authorize(ctx, 9, "user:read", policy.Attributes{"id": uint(1)}, nil)
+	assert.ErrorIs(t, err, ErrAccessDenied)
+}
+
+func TestAuthorizationService_RepositoryError(t *testing.T) {
+	ctx := context.Background()
+	s, rbacRepo, _ := newTestAuthorizationService(t)
+
+	rbacRepo.On("GetUserGrants", ctx, uint(1)).Return([]*model.PermissionGrant(nil), errors.New("db down"))
+
+	err := s.Authorize(ctx, 1, "user:read", nil, nil)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrAccessDenied)
+}
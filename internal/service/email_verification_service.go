@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/config"
+	"trx-project/pkg/notifier"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrEmailNotVerified 配置要求验证邮箱，用户尚未验证
+	ErrEmailNotVerified = errors.New("email not verified")
+	// ErrEmailVerificationTokenInvalid 邮箱验证令牌签名错误、已过期或邮箱已变更
+	ErrEmailVerificationTokenInvalid = errors.New("email verification token is invalid or expired")
+	// ErrEmailVerificationThrottled 距上次发送验证邮件的时间太短
+	ErrEmailVerificationThrottled = errors.New("email verification was sent recently, please try again later")
+)
+
+// EmailVerificationService 邮箱验证服务
+// 验证令牌为 <user_id>.<expires_at>.<signature>，签名覆盖用户当前邮箱，邮箱变更后旧链接自动失效，不需要落库
+type EmailVerificationService interface {
+	// Required 邮箱验证前是否禁止登录
+	Required() bool
+	// SendVerification 向用户邮箱发送验证链接，已验证时直接返回，发送过于频繁时返回 ErrEmailVerificationThrottled
+	SendVerification(ctx context.Context, user *model.User) error
+	// ResendVerification 按邮箱重新发送验证链接，邮箱不存在、已验证或发送过于频繁（不发送邮件）时同样返回成功，避免泄露账号是否存在
+	ResendVerification(ctx context.Context, email string) error
+	// VerifyEmail 校验令牌并标记邮箱已验证，重复验证同一链接返回成功
+	VerifyEmail(ctx context.Context, token string) (*model.User, error)
+}
+
+type emailVerificationService struct {
+	userRepo       repository.UserRepository
+	notifier       notifier.Notifier
+	logger         *zap.Logger
+	secret         []byte
+	required       bool
+	verifyURL      string
+	ttl            time.Duration
+	resendInterval time.Duration
+}
+
+// NewEmailVerificationService 创建邮箱验证服务
+func NewEmailVerificationService(
+	userRepo repository.UserRepository,
+	notifier notifier.Notifier,
+	logger *zap.Logger,
+	cfg *config.Config,
+) (EmailVerificationService, error) {
+	secret := cfg.Auth.EmailVerificationSecret
+	if secret == "" {
+		secret = cfg.JWT.Secret
+	}
+	if secret == "" {
+		return nil, errors.New("email verification secret is not configured")
+	}
+
+	return &emailVerificationService{
+		userRepo:       userRepo,
+		notifier:       notifier,
+		logger:         logger,
+		secret:         []byte(secret),
+		required:       cfg.Auth.RequireEmailVerification,
+		verifyURL:      cfg.Auth.EmailVerificationURL,
+		ttl:            cfg.Auth.EmailVerificationTTL(),
+		resendInterval: cfg.Auth.EmailVerificationResendInterval(),
+	}, nil
+}
+
+func (s *emailVerificationService) Required() bool {
+	return s.required
+}
+
+func (s *emailVerificationService) SendVerification(ctx context.Context, user *model.User) error {
+	if user.EmailVerified() {
+		return nil
+	}
+
+	// 条件更新发送时间，并发请求中只有一个会发送
+	ok, err := s.userRepo.TouchEmailVerificationSentAt(ctx, user.ID, s.resendInterval)
+	if err != nil {
+		s.logger.Error("Failed to update email verification sent time", zap.Uint("user_id", user.ID), zap.Error(err))
+		return err
+	}
+	if !ok {
+		return ErrEmailVerificationThrottled
+	}
+
+	token := s.sign(user.ID, user.Email, time.Now().Add(s.ttl))
+	link := s.verifyLink(token)
+	err = s.notifier.Send(ctx, &notifier.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to verify your email address. The link expires in %d hours.\n\n%s\n\nIf you did not create an account, you can ignore this message.",
+			user.Username, int(s.ttl.Hours()), link),
+		Data: map[string]string{
+			"token": token,
+			"link":  link,
+		},
+	})
+	if err != nil {
+		s.logger.Error("Failed to send email verification message", zap.Uint("user_id", user.ID), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Email verification sent", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+func (s *emailVerificationService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Info("Email verification requested for unknown email")
+			return nil
+		}
+		s.logger.Error("Failed to get user", zap.Error(err))
+		return err
+	}
+	if user.Status != 1 {
+		s.logger.Info("Email verification requested for inactive user", zap.Uint("user_id", user.ID))
+		return nil
+	}
+
+	// 限制只对存在的账号生效，返回错误会泄露账号是否存在
+	if err := s.SendVerification(ctx, user); err != nil && !errors.Is(err, ErrEmailVerificationThrottled) {
+		return err
+	}
+	return nil
+}
+
+func (s *emailVerificationService) VerifyEmail(ctx context.Context, token string) (*model.User, error) {
+	userID, expiresAt, ok := parseEmailVerificationToken(token)
+	if !ok || time.Now().After(expiresAt) {
+		return nil, ErrEmailVerificationTokenInvalid
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEmailVerificationTokenInvalid
+		}
+		s.logger.Error("Failed to get user", zap.Error(err))
+		return nil, err
+	}
+
+	// 按用户当前邮箱重新计算签名，邮箱变更后旧链接失效
+	if !hmac.Equal([]byte(token), []byte(s.sign(user.ID, user.Email, expiresAt))) {
+		return nil, ErrEmailVerificationTokenInvalid
+	}
+	if user.EmailVerified() {
+		return user, nil
+	}
+
+	ok, err = s.userRepo.MarkEmailVerified(ctx, user.ID, user.Email)
+	if err != nil {
+		s.logger.Error("Failed to mark email verified", zap.Uint("user_id", user.ID), zap.Error(err))
+		return nil, err
+	}
+	if !ok {
+		// 并发请求已完成验证，或邮箱刚刚被修改
+		return nil, ErrEmailVerificationTokenInvalid
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	s.logger.Info("Email verified", zap.Uint("user_id", user.ID))
+	return user, nil
+}
+
+// sign 生成验证令牌 <user_id>.<expires_at>.<signature>
+func (s *emailVerificationService) sign(userID uint, email string, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d.%d", userID, expiresAt.Unix())
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("email-verify|" + payload + "|" + email))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyLink 生成验证链接，未配置页面地址时直接返回令牌
+func (s *emailVerificationService) verifyLink(token string) string {
+	if s.verifyURL == "" {
+		return token
+	}
+
+	u, err := url.Parse(s.verifyURL)
+	if err != nil {
+		s.logger.Warn("Invalid email verification url", zap.String("url", s.verifyURL), zap.Error(err))
+		return token
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func parseEmailVerificationToken(token string) (uint, time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, time.Time{}, false
+	}
+	userID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return uint(userID), time.Unix(expiresAt, 0), true
+}
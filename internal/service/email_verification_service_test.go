@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/pkg/config"
+	"trx-project/pkg/notifier"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestEmailVerificationService(mockRepo *MockUserRepository, notify notifier.Notifier, required bool) EmailVerificationService {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{Secret: "test-secret"},
+		Auth: config.AuthConfig{
+			RequireEmailVerification: required,
+			EmailVerificationURL:     "https://example.com/verify-email",
+		},
+	}
+	service, err := NewEmailVerificationService(mockRepo, notify, zap.NewNop(), cfg)
+	if err != nil {
+		panic(err)
+	}
+	return service
+}
+
+func TestEmailVerificationService_VerifyFlow(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	notify := notifier.NewMemoryNotifier()
+	service := newTestEmailVerificationService(mockRepo, notify, true)
+
+	user := &model.User{ID: 1, Username: "testuser", Email: "test@example.com", Status: 1}
+	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+	// 第一次发送成功，间隔内再次发送被限制：不发送邮件，但和邮箱不存在时一样返回成功
+	mockRepo.On("TouchEmailVerificationSentAt", ctx, user.ID, time.Minute).Return(true, nil).Once()
+	require.NoError(t, service.ResendVerification(ctx, user.Email))
+	mockRepo.On("TouchEmailVerificationSentAt", ctx, user.ID, time.Minute).Return(false, nil).Once()
+	require.NoError(t, service.ResendVerification(ctx, user.Email))
+	require.Len(t, notify.Messages(), 1)
+	// 注册时直接发送仍然返回限制错误，由调用方记录
+	mockRepo.On("TouchEmailVerificationSentAt", ctx, user.ID, time.Minute).Return(false, nil).Once()
+	assert.ErrorIs(t, service.SendVerification(ctx, user), ErrEmailVerificationThrottled)
+
+	message := notify.Last()
+	token := message.Data["token"]
+	require.NotEmpty(t, token)
+	assert.Contains(t, message.Data["link"], "https://example.com/verify-email?token=")
+
+	// 篡改过的令牌无效
+	_, err := service.VerifyEmail(ctx, token+"x")
+	assert.ErrorIs(t, err, ErrEmailVerificationTokenInvalid)
+
+	// 邮箱变更后旧链接失效
+	user.Email = "changed@example.com"
+	_, err = service.VerifyEmail(ctx, token)
+	assert.ErrorIs(t, err, ErrEmailVerificationTokenInvalid)
+	user.Email = "test@example.com"
+
+	mockRepo.On("MarkEmailVerified", ctx, user.ID, user.Email).Return(true, nil).Once()
+	verified, err := service.VerifyEmail(ctx, token)
+	require.NoError(t, err)
+	assert.True(t, verified.EmailVerified())
+
+	// 重复验证同一链接返回成功
+	_, err = service.VerifyEmail(ctx, token)
+	assert.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "MarkEmailVerified", 1)
+}
+
+func TestEmailVerificationService_ExpiredToken(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	service := newTestEmailVerificationService(mockRepo, notifier.NewMemoryNotifier(), true)
+
+	token := service.(*emailVerificationService).sign(1, "test@example.com", time.Now().Add(-time.Second))
+	_, err := service.VerifyEmail(ctx, token)
+	assert.ErrorIs(t, err, ErrEmailVerificationTokenInvalid)
+	mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestUserService_Authenticate_RequiresVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	mockRepo := new(MockUserRepository)
+	verifier := newTestEmailVerificationService(mockRepo, notifier.NewMemoryNotifier(), true)
+	service := NewUserService(mockRepo, nil, zap.NewNop(), new(MockTokenService), new(MockUserStatusService), nil, verifier, nil, NewLocalAuthenticator(mockRepo, testPasswordHasher, zap.NewNop()), testPasswordHasher, testPasswordPolicy)
+
+	user := &model.User{ID: 1, Username: "testuser", Password: string(hashed), Status: 1}
+	mockRepo.On("GetByUsername", ctx, user.Username).Return(user, nil)
+
+	_, err = service.Authenticate(ctx, user.Username, "password123")
+	assert.ErrorIs(t, err, ErrEmailNotVerified)
+
+	// 密码错误时不提示邮箱未验证
+	_, err = service.Authenticate(ctx, user.Username, "wrong")
+	assert.EqualError(t, err, "invalid username or password")
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	authenticated, err := service.Authenticate(ctx, user.Username, "password123")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, authenticated.ID)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// mappedGroupRoles 返回外部组映射到的本地角色名
+func mappedGroupRoles(groupRoles map[string][]string, groups []string) map[string]bool {
+	roles := make(map[string]bool)
+	for _, group := range groups {
+		for _, role := range groupRoles[group] {
+			roles[role] = true
+		}
+	}
+	return roles
+}
+
+// syncGroupRoles 按组映射同步用户角色，供单点登录和 LDAP 等外部身份源使用
+// 只增删 groupRoles 中出现过的角色，手动分配的其他角色保持不变；配置了不存在的角色时跳过
+func syncGroupRoles(ctx context.Context, rbacService RBACService, logger *zap.Logger, userID uint, groupRoles map[string][]string, groups []string) (added, removed []string, err error) {
+	managed := make(map[string]bool)
+	for _, roles := range groupRoles {
+		for _, role := range roles {
+			managed[role] = true
+		}
+	}
+	wanted := mappedGroupRoles(groupRoles, groups)
+
+	current, err := rbacService.GetUserRoles(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get user roles", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, nil, err
+	}
+
+	assigned := make(map[string]bool)
+	for _, role := range current {
+		assigned[role.Name] = true
+		if managed[role.Name] && !wanted[role.Name] {
+			if err := rbacService.RemoveRoleFromUser(ctx, userID, role.ID); err != nil {
+				logger.Error("Failed to remove role", zap.Uint("user_id", userID), zap.String("role", role.Name), zap.Error(err))
+				return nil, nil, err
+			}
+			removed = append(removed, role.Name)
+		}
+	}
+	for name := range wanted {
+		if assigned[name] {
+			continue
+		}
+		role, err := rbacService.GetRoleByName(ctx, name)
+		if err != nil {
+			logger.Warn("Mapped role not found", zap.String("role", name), zap.Error(err))
+			continue
+		}
+		if err := rbacService.AssignRoleToUser(ctx, userID, role.ID); err != nil {
+			logger.Error("Failed to assign role", zap.Uint("user_id", userID), zap.String("role", name), zap.Error(err))
+			return nil, nil, err
+		}
+		added = append(added, name)
+	}
+
+	sort.Strings(added)
+	return added, removed, nil
+}
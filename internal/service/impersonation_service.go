@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/config"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PermissionUserImpersonate 模拟用户所需的权限，模拟期间每次请求都会重新检查
+const PermissionUserImpersonate = "user:impersonate"
+
+// ErrImpersonationNotAllowed 不能模拟该用户：自己、服务账号、已禁用的用户或拥有后台角色的用户
+var ErrImpersonationNotAllowed = errors.New("impersonation of this user is not allowed")
+
+// ImpersonationToken 模拟登录签发的前台 Token
+type ImpersonationToken struct {
+	Token     string      `json:"token"`      // 带有 act 声明的 Access Token
+	ExpiresIn int64       `json:"expires_in"` // 有效期（秒），到期后需要重新发起模拟
+	User      *model.User `json:"user"`       // 被模拟的用户
+}
+
+// ImpersonationService 管理员模拟用户服务，用于客服在前台复现用户问题
+type ImpersonationService interface {
+	// Impersonate 签发模拟 userID 的短期前台 Token 并记录审计日志
+	Impersonate(ctx context.Context, adminID, userID uint, reason, ip string) (*ImpersonationToken, error)
+}
+
+type impersonationService struct {
+	userRepo     repository.UserRepository
+	rbacService  RBACService
+	tokenService TokenService
+	audit        AuditService
+	logger       *zap.Logger
+	ttl          time.Duration
+}
+
+// NewImpersonationService 创建管理员模拟用户服务
+func NewImpersonationService(userRepo repository.UserRepository, rbacService RBACService, tokenService TokenService, audit AuditService, logger *zap.Logger, cfg *config.Config) ImpersonationService {
+	return &impersonationService{
+		userRepo:     userRepo,
+		rbacService:  rbacService,
+		tokenService: tokenService,
+		audit:        audit,
+		logger:       logger,
+		ttl:          cfg.Auth.ImpersonationTTL(),
+	}
+}
+
+func (s *impersonationService) Impersonate(ctx context.Context, adminID, userID uint, reason, ip string) (*ImpersonationToken, error) {
+	if adminID == userID {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	actor, err := s.userRepo.GetByID(ctx, adminID)
+	if err != nil {
+		s.logger.Error("Failed to get impersonator", zap.Uint("admin_id", adminID), zap.Error(err))
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		s.logger.Error("Failed to get user", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	if user.Status != 1 || user.IsServiceAccount() {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	// 模拟拥有后台角色的用户可能被用来绕过权限，只允许模拟普通用户
+	roles, err := s.rbacService.GetUserRoles(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get user roles", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	if _, isAdmin := ResolveAdminRole(roles); isAdmin {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	token, err := s.tokenService.IssueImpersonationToken(ctx, user, actor, s.ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Warn("Admin started impersonating user",
+		zap.Uint("admin_id", adminID),
+		zap.String("admin_username", actor.Username),
+		zap.Uint("user_id", userID),
+		zap.String("username", user.Username),
+		zap.String("reason", reason))
+
+	if err := s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    adminID,
+		Action:     model.AuditActionUserImpersonated,
+		TargetType: "user",
+		TargetID:   userID,
+		Detail:     fmt.Sprintf("username=%s ttl=%s reason=%s", user.Username, s.ttl, reason),
+		IP:         ip,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &ImpersonationToken{
+		Token:     token,
+		ExpiresIn: int64(s.ttl.Seconds()),
+		User:      user,
+	}, nil
+}
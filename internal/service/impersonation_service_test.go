@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestImpersonationService_Impersonate(t *testing.T) {
+	ctx := context.Background()
+	userRepo := new(MockUserRepository)
+	rbac := new(MockRBACService)
+	tokenService := new(MockTokenService)
+	audit := new(MockAuditService)
+	cfg := &config.Config{Auth: config.AuthConfig{ImpersonationTTLMinutes: 10}}
+	service := NewImpersonationService(userRepo, rbac, tokenService, audit, zap.NewNop(), cfg)
+
+	actor := &model.User{ID: 1, Username: "support", Status: 1}
+	user := &model.User{ID: 2, Username: "alice", Status: 1}
+	userRepo.On("GetByID", ctx, uint(1)).Return(actor, nil)
+	userRepo.On("GetByID", ctx, uint(2)).Return(user, nil)
+	rbac.On("GetUserRoles", ctx, uint(2)).Return([]*model.Role{}, nil)
+	tokenService.On("IssueImpersonationToken", ctx, user, actor, 10*time.Minute).Return("impersonation-token", nil)
+	audit.On("Record", ctx, mock.MatchedBy(func(log *model.AuditLog) bool {
+		return log.Action == model.AuditActionUserImpersonated && log.ActorID == 1 && log.TargetID == 2
+	})).Return(nil)
+
+	result, err := service.Impersonate(ctx, 1, 2, "ticket #1024", "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "impersonation-token", result.Token)
+	assert.Equal(t, int64(600), result.ExpiresIn)
+	assert.Equal(t, user, result.User)
+	audit.AssertExpectations(t)
+}
+
+func TestImpersonationService_NotAllowed(t *testing.T) {
+	ctx := context.Background()
+	userRepo := new(MockUserRepository)
+	rbac := new(MockRBACService)
+	tokenService := new(MockTokenService)
+	service := NewImpersonationService(userRepo, rbac, tokenService, new(MockAuditService), zap.NewNop(), &config.Config{})
+
+	userRepo.On("GetByID", ctx, uint(1)).Return(&model.User{ID: 1, Username: "support", Status: 1}, nil)
+	userRepo.On("GetByID", ctx, uint(2)).Return(&model.User{ID: 2, Username: "ops", Status: 1}, nil)
+	userRepo.On("GetByID", ctx, uint(3)).Return(&model.User{ID: 3, Username: "svc", Status: 1, AccountType: model.AccountTypeService}, nil)
+	rbac.On("GetUserRoles", ctx, uint(2)).Return([]*model.Role{{Name: model.RoleAdmin, Status: 1}}, nil)
+
+	// 不能模拟自己、拥有后台角色的用户和服务账号
+	_, err := service.Impersonate(ctx, 1, 1, "", "")
+	assert.ErrorIs(t, err, ErrImpersonationNotAllowed)
+	_, err = service.Impersonate(ctx, 1, 2, "", "")
+	assert.ErrorIs(t, err, ErrImpersonationNotAllowed)
+	_, err = service.Impersonate(ctx, 1, 3, "", "")
+	assert.ErrorIs(t, err, ErrImpersonationNotAllowed)
+
+	tokenService.AssertNumberOfCalls(t, "IssueImpersonationToken", 0)
+}
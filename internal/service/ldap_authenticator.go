@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/config"
+
+	"github.com/go-ldap/ldap/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// ldapIdentityProvider user_identities 中 LDAP 账号的 provider
+	ldapIdentityProvider = "ldap"
+	// ldapEmailDomain 目录中没有邮箱的账号使用的占位邮箱域名，保留域名，不会真正收到邮件
+	ldapEmailDomain = "ldap.invalid"
+)
+
+// LDAPAuthenticator 使用 LDAP / Active Directory 校验密码
+// 先用服务账号按 user_filter 查找用户条目，再以条目 DN 和密码绑定；校验通过后按 (ldap, 用户名) 关联本地账号，
+// 首次登录时自动创建没有本地密码的账号，每次登录按 group_roles 同步角色
+type LDAPAuthenticator struct {
+	repo        repository.IdentityRepository
+	userRepo    repository.UserRepository
+	rbacService RBACService
+	audit       AuditService
+	logger      *zap.Logger
+	cfg         config.LDAPConfig
+	groupRoles  map[string][]string // 组 DN 统一转为小写
+}
+
+// NewLDAPAuthenticator 创建 LDAP 凭证校验器
+func NewLDAPAuthenticator(
+	repo repository.IdentityRepository,
+	userRepo repository.UserRepository,
+	rbacService RBACService,
+	audit AuditService,
+	logger *zap.Logger,
+	cfg *config.Config,
+) *LDAPAuthenticator {
+	groupRoles := make(map[string][]string, len(cfg.Auth.LDAP.GroupRoles))
+	for group, roles := range cfg.Auth.LDAP.GroupRoles {
+		key := strings.ToLower(group)
+		groupRoles[key] = append(groupRoles[key], roles...)
+	}
+
+	return &LDAPAuthenticator{
+		repo:        repo,
+		userRepo:    userRepo,
+		rbacService: rbacService,
+		audit:       audit,
+		logger:      logger,
+		cfg:         cfg.Auth.LDAP,
+		groupRoles:  groupRoles,
+	}
+}
+
+func (a *LDAPAuthenticator) Name() string {
+	return AuthenticatorLDAP
+}
+
+// ldapProfile 目录中用户条目的信息
+type ldapProfile struct {
+	dn       string
+	username string
+	email    string
+	groups   []string
+}
+
+func (a *LDAPAuthenticator) Authenticate(ctx context.Context, username, password string) (*model.User, error) {
+	// 空密码的绑定在 LDAP 中是匿名绑定，会直接成功
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	profile, err := a.verify(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.resolveUser(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	added, removed, err := syncGroupRoles(ctx, a.rbacService, a.logger, user.ID, a.groupRoles, profile.groups)
+	if err != nil {
+		return nil, err
+	}
+	if len(added) > 0 || len(removed) > 0 {
+		a.logger.Info("LDAP roles synced",
+			zap.Uint("user_id", user.ID),
+			zap.Strings("added", added),
+			zap.Strings("removed", removed))
+		a.recordAudit(ctx, user.ID, model.AuditActionLDAPRolesSynced,
+			fmt.Sprintf("added=%s removed=%s", strings.Join(added, ","), strings.Join(removed, ",")))
+	}
+
+	return user, nil
+}
+
+// verify 查找用户条目并以用户 DN 绑定校验密码
+func (a *LDAPAuthenticator) verify(username, password string) (*ldapProfile, error) {
+	conn, err := a.dial()
+	if err != nil {
+		a.logger.Error("Failed to connect to ldap server", zap.String("url", a.cfg.URL), zap.Error(err))
+		return nil, fmt.Errorf("%w: %v", ErrAuthenticatorUnavailable, err)
+	}
+	defer conn.Close()
+
+	if a.cfg.BindDN != "" {
+		if err := conn.Bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+			a.logger.Error("Failed to bind ldap service account", zap.String("bind_dn", a.cfg.BindDN), zap.Error(err))
+			return nil, fmt.Errorf("%w: %v", ErrAuthenticatorUnavailable, err)
+		}
+	}
+
+	usernameAttr := a.cfg.UsernameAttributeName()
+	emailAttr := a.cfg.EmailAttributeName()
+	groupAttr := a.cfg.GroupAttributeName()
+	result, err := conn.Search(ldap.NewSearchRequest(
+		a.cfg.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(a.cfg.Timeout().Seconds()), false,
+		fmt.Sprintf(a.cfg.UserFilterTemplate(), ldap.EscapeFilter(username)),
+		[]string{usernameAttr, emailAttr, groupAttr},
+		nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		a.logger.Error("Failed to search ldap user", zap.Error(err))
+		return nil, fmt.Errorf("%w: %v", ErrAuthenticatorUnavailable, err)
+	}
+	// 找不到用户或过滤器匹配到多个条目时都按凭证错误处理
+	if result == nil || len(result.Entries) != 1 {
+		a.logger.Debug("LDAP user not found or ambiguous", zap.String("username", username))
+		return nil, ErrInvalidCredentials
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		a.logger.Error("Failed to bind ldap user", zap.String("dn", entry.DN), zap.Error(err))
+		return nil, fmt.Errorf("%w: %v", ErrAuthenticatorUnavailable, err)
+	}
+
+	profile := &ldapProfile{
+		dn:       entry.DN,
+		username: entry.GetEqualFoldAttributeValue(usernameAttr),
+		email:    entry.GetEqualFoldAttributeValue(emailAttr),
+	}
+	if profile.username == "" {
+		profile.username = username
+	}
+	for _, group := range entry.GetEqualFoldAttributeValues(groupAttr) {
+		profile.groups = append(profile.groups, strings.ToLower(group))
+	}
+	return profile, nil
+}
+
+func (a *LDAPAuthenticator) dial() (*ldap.Conn, error) {
+	u, err := url.Parse(a.cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: a.cfg.InsecureSkipVerify, // 由配置显式开启，仅用于测试环境
+	}
+
+	conn, err := ldap.DialURL(a.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: a.cfg.Timeout()}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(a.cfg.Timeout())
+
+	if a.cfg.StartTLS && u.Scheme == "ldap" {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// resolveUser 找到目录账号关联的本地用户，首次登录时创建账号
+// 用户名或邮箱已被未关联的本地账号使用时不自动关联，按凭证错误处理，由后续的校验器（如 local）继续尝试
+func (a *LDAPAuthenticator) resolveUser(ctx context.Context, profile *ldapProfile) (*model.User, error) {
+	subject := strings.ToLower(profile.username)
+	identity, err := a.repo.GetByProviderSubject(ctx, ldapIdentityProvider, subject)
+	if err == nil {
+		user, err := a.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrUserInactive
+			}
+			a.logger.Error("Failed to get user", zap.Uint("user_id", identity.UserID), zap.Error(err))
+			return nil, err
+		}
+		if err := a.repo.TouchLogin(ctx, identity.ID, profile.email, time.Now()); err != nil {
+			a.logger.Error("Failed to update identity", zap.Uint("identity_id", identity.ID), zap.Error(err))
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		a.logger.Error("Failed to get identity", zap.Error(err))
+		return nil, err
+	}
+
+	username := profile.username
+	if len(username) > 50 {
+		username = username[:50]
+	}
+	email := profile.email
+	if email == "" {
+		email = fmt.Sprintf("%s@%s", subject, ldapEmailDomain)
+	}
+
+	conflict, err := a.localAccountExists(ctx, username, email)
+	if err != nil {
+		return nil, err
+	}
+	if conflict {
+		a.logger.Warn("LDAP login conflicts with local account",
+			zap.String("username", username),
+			zap.String("dn", profile.dn))
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	user := &model.User{
+		Username:    username,
+		Email:       email,
+		Status:      1,
+		AccountType: model.AccountTypeUser,
+		// 邮箱由目录管理，视为已验证
+		EmailVerifiedAt: &now,
+	}
+	if err := a.userRepo.Create(ctx, user); err != nil {
+		a.logger.Error("Failed to provision ldap user", zap.String("username", username), zap.Error(err))
+		return nil, err
+	}
+
+	err = a.repo.Create(ctx, &model.UserIdentity{
+		UserID:      user.ID,
+		Provider:    ldapIdentityProvider,
+		Subject:     subject,
+		Email:       profile.email,
+		LastLoginAt: &now,
+	})
+	if err != nil {
+		a.logger.Error("Failed to create identity", zap.Uint("user_id", user.ID), zap.Error(err))
+		return nil, err
+	}
+
+	a.logger.Info("LDAP user provisioned", zap.Uint("user_id", user.ID), zap.String("username", username))
+	a.recordAudit(ctx, user.ID, model.AuditActionLDAPUserProvisioned,
+		fmt.Sprintf("dn=%s username=%s", profile.dn, username))
+	return user, nil
+}
+
+func (a *LDAPAuthenticator) localAccountExists(ctx context.Context, username, email string) (bool, error) {
+	if _, err := a.userRepo.GetByUsername(ctx, username); !errors.Is(err, gorm.ErrRecordNotFound) {
+		if err != nil {
+			a.logger.Error("Failed to get user", zap.Error(err))
+			return false, err
+		}
+		return true, nil
+	}
+	if _, err := a.userRepo.GetByEmail(ctx, email); !errors.Is(err, gorm.ErrRecordNotFound) {
+		if err != nil {
+			a.logger.Error("Failed to get user", zap.Error(err))
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (a *LDAPAuthenticator) recordAudit(ctx context.Context, userID uint, action, detail string) {
+	err := a.audit.Record(ctx, &model.AuditLog{
+		ActorID:    userID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   userID,
+		Detail:     detail,
+	})
+	if err != nil {
+		a.logger.Error("Failed to record ldap audit log", zap.String("action", action), zap.Error(err))
+	}
+}
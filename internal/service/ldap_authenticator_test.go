@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/pkg/config"
+
+	"github.com/jimlambrt/gldap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	testLDAPBindDN       = "cn=readonly,dc=example,dc=com"
+	testLDAPBindPassword = "readonly-secret"
+)
+
+// testDirectoryUser 测试目录中的用户条目
+type testDirectoryUser struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+// startTestLDAPServer 启动进程内的 LDAP 测试服务器，只实现简单绑定和按 uid 查找
+func startTestLDAPServer(t *testing.T, users map[string]*testDirectoryUser) string {
+	mux, err := gldap.NewMux()
+	require.NoError(t, err)
+
+	require.NoError(t, mux.Bind(func(w *gldap.ResponseWriter, r *gldap.Request) {
+		resp := r.NewBindResponse(gldap.WithResponseCode(gldap.ResultInvalidCredentials))
+		defer func() { _ = w.Write(resp) }()
+
+		msg, err := r.GetSimpleBindMessage()
+		if err != nil {
+			return
+		}
+		if msg.UserName == testLDAPBindDN && string(msg.Password) == testLDAPBindPassword {
+			resp.SetResultCode(gldap.ResultSuccess)
+			return
+		}
+		for _, user := range users {
+			if strings.EqualFold(msg.UserName, user.dn) && string(msg.Password) == user.password {
+				resp.SetResultCode(gldap.ResultSuccess)
+				return
+			}
+		}
+	}))
+
+	require.NoError(t, mux.Search(func(w *gldap.ResponseWriter, r *gldap.Request) {
+		resp := r.NewSearchDoneResponse(gldap.WithResponseCode(gldap.ResultSuccess))
+		defer func() { _ = w.Write(resp) }()
+
+		msg, err := r.GetSearchMessage()
+		if err != nil {
+			resp.SetResultCode(gldap.ResultOperationsError)
+			return
+		}
+		for uid, user := range users {
+			if strings.Contains(msg.Filter, "(uid="+uid+")") {
+				_ = w.Write(r.NewSearchResponseEntry(user.dn, gldap.WithAttributes(user.attributes)))
+			}
+		}
+	}))
+
+	server, err := gldap.NewServer()
+	require.NoError(t, err)
+	require.NoError(t, server.Router(mux))
+
+	// 先占用一个空闲端口再交给测试服务器
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	go func() { _ = server.Run(addr) }()
+	t.Cleanup(func() { _ = server.Stop() })
+	require.Eventually(t, server.Ready, 5*time.Second, 10*time.Millisecond)
+	return "ldap://" + addr
+}
+
+func testLDAPConfig(url string) *config.Config {
+	return &config.Config{Auth: config.AuthConfig{
+		Authenticators: []string{AuthenticatorLDAP, AuthenticatorLocal},
+		LDAP: config.LDAPConfig{
+			URL:            url,
+			BindDN:         testLDAPBindDN,
+			BindPassword:   testLDAPBindPassword,
+			BaseDN:         "ou=people,dc=example,dc=com",
+			TimeoutSeconds: 2,
+			GroupRoles: map[string][]string{
+				"cn=trx-admins,ou=groups,dc=example,dc=com": {"admin"},
+			},
+		},
+	}}
+}
+
+func TestLDAPAuthenticator_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	url := startTestLDAPServer(t, map[string]*testDirectoryUser{
+		"alice": {
+			dn:       "uid=alice,ou=people,dc=example,dc=com",
+			password: "alice-pass",
+			attributes: map[string][]string{
+				"uid":      {"alice"},
+				"mail":     {"alice@example.com"},
+				"memberOf": {"cn=TRX-Admins,ou=groups,dc=example,dc=com", "cn=staff,ou=groups,dc=example,dc=com"},
+			},
+		},
+	})
+
+	repo := new(MockIdentityRepository)
+	userRepo := new(MockUserRepository)
+	rbac := new(MockRBACService)
+	audit := new(MockAuditService)
+	authenticator := NewLDAPAuthenticator(repo, userRepo, rbac, audit, zap.NewNop(), testLDAPConfig(url))
+
+	// 密码错误、用户不存在和空密码（匿名绑定）都按凭证错误处理
+	_, err := authenticator.Authenticate(ctx, "alice", "wrong-pass")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = authenticator.Authenticate(ctx, "nobody", "alice-pass")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = authenticator.Authenticate(ctx, "alice", "")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	repo.AssertNotCalled(t, "GetByProviderSubject", mock.Anything, mock.Anything, mock.Anything)
+
+	// 首次登录：创建账号、关联用户名，并按组（不区分大小写）分配角色
+	repo.On("GetByProviderSubject", ctx, "ldap", "alice").Return(nil, gorm.ErrRecordNotFound).Once()
+	userRepo.On("GetByUsername", ctx, "alice").Return(nil, gorm.ErrRecordNotFound)
+	userRepo.On("GetByEmail", ctx, "alice@example.com").Return(nil, gorm.ErrRecordNotFound)
+	var created *model.User
+	userRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*model.User)
+		created.ID = 5
+	}).Return(nil)
+	repo.On("Create", ctx, mock.MatchedBy(func(identity *model.UserIdentity) bool {
+		return identity.UserID == 5 && identity.Provider == "ldap" && identity.Subject == "alice"
+	})).Return(nil)
+	audit.On("Record", ctx, mock.AnythingOfType("*model.AuditLog")).Return(nil)
+	rbac.On("GetUserRoles", ctx, uint(5)).Return([]*model.Role{}, nil).Once()
+	rbac.On("GetRoleByName", ctx, "admin").Return(&model.Role{ID: 2, Name: "admin", Status: 1}, nil)
+	rbac.On("AssignRoleToUser", ctx, uint(5), uint(2)).Return(nil).Once()
+
+	user, err := authenticator.Authenticate(ctx, "alice", "alice-pass")
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), user.ID)
+	assert.Equal(t, "alice@example.com", created.Email)
+	assert.Empty(t, created.Password)
+	assert.True(t, created.EmailVerified())
+
+	// 再次登录：通过关联找到账号，角色已同步时不再修改
+	repo.On("GetByProviderSubject", ctx, "ldap", "alice").Return(&model.UserIdentity{ID: 8, UserID: 5}, nil).Once()
+	repo.On("TouchLogin", ctx, uint(8), "alice@example.com", mock.AnythingOfType("time.Time")).Return(nil)
+	userRepo.On("GetByID", ctx, uint(5)).Return(created, nil)
+	rbac.On("GetUserRoles", ctx, uint(5)).Return([]*model.Role{{ID: 2, Name: "admin", Status: 1}}, nil).Once()
+
+	user, err = authenticator.Authenticate(ctx, "alice", "alice-pass")
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), user.ID)
+	userRepo.AssertNumberOfCalls(t, "Create", 1)
+	rbac.AssertNumberOfCalls(t, "AssignRoleToUser", 1)
+}
+
+func TestAuthenticatorChain_Fallback(t *testing.T) {
+	ctx := context.Background()
+	url := startTestLDAPServer(t, map[string]*testDirectoryUser{
+		"carol": {
+			dn:         "uid=carol,ou=people,dc=example,dc=com",
+			password:   "directory-pass",
+			attributes: map[string][]string{"uid": {"carol"}, "mail": {"carol@example.com"}},
+		},
+	})
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("local-pass"), bcrypt.MinCost)
+	require.NoError(t, err)
+	local := &model.User{ID: 3, Username: "carol", Email: "carol@example.com", Password: string(hash), Status: 1}
+
+	repo := new(MockIdentityRepository)
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByUsername", ctx, "carol").Return(local, nil)
+	repo.On("GetByProviderSubject", ctx, "ldap", "carol").Return(nil, gorm.ErrRecordNotFound)
+
+	newChain := func(cfg *config.Config) Authenticator {
+		ldap := NewLDAPAuthenticator(repo, userRepo, new(MockRBACService), new(MockAuditService), zap.NewNop(), cfg)
+		authenticator, err := NewAuthenticator(NewLocalAuthenticator(userRepo, testPasswordHasher, zap.NewNop()), ldap, zap.NewNop(), cfg)
+		require.NoError(t, err)
+		return authenticator
+	}
+	chain := newChain(testLDAPConfig(url))
+
+	// 目录密码正确，但用户名已被未关联的本地账号使用，不自动关联，本地密码同样校验失败
+	_, err = chain.Authenticate(ctx, "carol", "directory-pass")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	// 目录拒绝后使用本地密码
+	user, err := chain.Authenticate(ctx, "carol", "local-pass")
+	require.NoError(t, err)
+	assert.Equal(t, local.ID, user.ID)
+
+	// 目录不可用时回退到本地密码
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedURL := "ldap://" + listener.Addr().String()
+	require.NoError(t, listener.Close())
+	chain = newChain(testLDAPConfig(closedURL))
+
+	user, err = chain.Authenticate(ctx, "carol", "local-pass")
+	require.NoError(t, err)
+	assert.Equal(t, local.ID, user.ID)
+	_, err = chain.Authenticate(ctx, "carol", "wrong-pass")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// 只有不可用的目录时返回不可用错误，不计入登录失败次数
+	cfg := testLDAPConfig(closedURL)
+	cfg.Auth.Authenticators = []string{AuthenticatorLDAP}
+	_, err = newChain(cfg).Authenticate(ctx, "carol", "local-pass")
+	assert.ErrorIs(t, err, ErrAuthenticatorUnavailable)
+
+	// 未知的校验器和缺少地址的 LDAP 配置在启动时报错
+	cfg.Auth.Authenticators = []string{"kerberos"}
+	_, err = NewAuthenticator(nil, nil, zap.NewNop(), cfg)
+	assert.Error(t, err)
+	cfg.Auth.Authenticators = []string{AuthenticatorLDAP}
+	cfg.Auth.LDAP.URL = ""
+	_, err = NewAuthenticator(nil, nil, zap.NewNop(), cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/pkg/cache"
+	"trx-project/pkg/config"
+	"trx-project/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrInvalidCredentials 用户名或密码错误
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrLoginLocked 登录失败次数过多，暂时禁止登录
+	ErrLoginLocked = errors.New("too many failed login attempts, please try again later")
+	// ErrLoginLockoutScopeInvalid 锁定维度只能是 username 或 ip
+	ErrLoginLockoutScopeInvalid = errors.New("lockout scope must be username or ip")
+)
+
+// 登录入口，用于 user_login_failures_total 指标的 service 标签
+const (
+	loginServiceFrontend = "frontend"
+	loginServiceBackend  = "backend"
+)
+
+// 登录失败原因，用于 user_login_failures_total 指标的 reason 标签
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureLocked             = "locked"
+	LoginFailureInactive           = "inactive"
+	LoginFailureEmailNotVerified   = "email_not_verified"
+	LoginFailureAdminRoleRequired  = "admin_role_required"
+	LoginFailureError              = "error"
+)
+
+// LoginLockedError 登录被锁定，RetryAfter 为剩余等待时间
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return ErrLoginLocked.Error()
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+// LoginProtectionService 登录暴力破解防护
+// 按用户名和 IP 分别统计密码错误次数，超过阈值后每次失败需要等待递增的时间，达到上限后临时锁定
+type LoginProtectionService interface {
+	// Check 校验密码前检查用户名和 IP 是否被锁定，并原子地占用一次登录名额，锁定时返回 *LoginLockedError
+	// 失败次数加上正在校验的次数不能超过上限，并发的猜测不能绕过限制；
+	// 通过检查后必须调用 RecordFailure 或 RecordSuccess 释放名额
+	Check(ctx context.Context, service, username, ip string) error
+	// RecordFailure 记录一次登录失败并释放名额，只有密码错误会累计失败次数，其他原因只记录指标
+	RecordFailure(ctx context.Context, service, username, ip string, err error)
+	// RecordSuccess 登录成功后清除该用户名的失败次数并释放名额，IP 的失败次数保留到窗口期结束
+	RecordSuccess(ctx context.Context, username, ip string)
+	// ListLockouts 列出当前被锁定的用户名和 IP
+	ListLockouts(ctx context.Context) ([]*cache.LoginAttemptState, error)
+	// GetLockout 查看用户名或 IP 的失败次数和锁定状态
+	GetLockout(ctx context.Context, scope, value string) (*cache.LoginAttemptState, error)
+	// ClearLockout 管理员解除用户名或 IP 的锁定
+	ClearLockout(ctx context.Context, adminID uint, scope, value, ip string) error
+}
+
+type loginProtectionService struct {
+	store   *cache.LoginAttemptStore
+	audit   AuditService
+	metrics *metrics.Metrics
+	logger  *zap.Logger
+	cfg     config.LoginProtectionConfig
+}
+
+// NewLoginProtectionService 创建登录暴力破解防护服务
+func NewLoginProtectionService(store *cache.LoginAttemptStore, audit AuditService, metrics *metrics.Metrics, logger *zap.Logger, cfg *config.Config) LoginProtectionService {
+	return &loginProtectionService{
+		store:   store,
+		audit:   audit,
+		metrics: metrics,
+		logger:  logger,
+		cfg:     cfg.Auth.LoginProtection,
+	}
+}
+
+func (s *loginProtectionService) Check(ctx context.Context, service, username, ip string) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	var reserved []loginAttemptKey
+	var retryAfter time.Duration
+	locked := false
+	for _, key := range s.keys(username, ip) {
+		ok, lockedFor, err := s.store.Reserve(ctx, key.scope, key.value, key.limit)
+		if err != nil {
+			// Redis 不可用时不阻止登录，密码校验仍然有效
+			s.logger.Error("Failed to check login lockout", zap.String("scope", key.scope), zap.Error(err))
+			continue
+		}
+		if ok {
+			reserved = append(reserved, key)
+			continue
+		}
+
+		locked = true
+		if lockedFor == 0 {
+			// 剩余名额被正在校验密码的请求占满，等它们的结果记录后再重试
+			lockedFor = s.cfg.BaseDelay()
+		}
+		if lockedFor > retryAfter {
+			retryAfter = lockedFor
+		}
+	}
+
+	if locked {
+		s.release(ctx, reserved)
+		s.metrics.UserLoginFailures.WithLabelValues(service, LoginFailureLocked).Inc()
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (s *loginProtectionService) RecordFailure(ctx context.Context, service, username, ip string, err error) {
+	reason := loginFailureReason(err)
+	s.metrics.UserLoginFailures.WithLabelValues(service, reason).Inc()
+
+	if !s.cfg.Enabled {
+		return
+	}
+
+	// 账号禁用、邮箱未验证等情况说明密码正确，不计入失败次数
+	keys := s.keys(username, ip)
+	if reason == LoginFailureInvalidCredentials {
+		for _, key := range keys {
+			s.recordFailure(ctx, key)
+		}
+	}
+	// 先记录失败和锁定再释放名额，其他请求不会在两者之间拿到名额
+	s.release(ctx, keys)
+}
+
+func (s *loginProtectionService) recordFailure(ctx context.Context, key loginAttemptKey) {
+	failures, err := s.store.RecordFailure(ctx, key.scope, key.value, s.cfg.FailureWindow())
+	if err != nil {
+		s.logger.Error("Failed to record login failure", zap.String("scope", key.scope), zap.Error(err))
+		return
+	}
+
+	delay := LoginFailureDelay(failures, key.limit, &s.cfg)
+	if delay <= 0 {
+		return
+	}
+	if err := s.store.Lock(ctx, key.scope, key.value, delay); err != nil {
+		s.logger.Error("Failed to lock login", zap.String("scope", key.scope), zap.Error(err))
+		return
+	}
+	if failures >= key.limit {
+		s.logger.Warn("Login locked after too many failures",
+			zap.String("scope", key.scope),
+			zap.String("value", key.value),
+			zap.Int64("failures", failures),
+			zap.Duration("lockout", delay))
+	}
+}
+
+func (s *loginProtectionService) RecordSuccess(ctx context.Context, username, ip string) {
+	if !s.cfg.Enabled {
+		return
+	}
+
+	// 清除用户名的失败次数时一并清除了占用的名额，IP 只需要释放名额
+	if err := s.store.Clear(ctx, cache.LoginAttemptScopeUsername, normalizeLoginUsername(username)); err != nil {
+		s.logger.Error("Failed to clear login failures", zap.Error(err))
+	}
+	s.release(ctx, s.keys(username, ip)[1:])
+}
+
+// release 释放 Check 占用的登录名额
+func (s *loginProtectionService) release(ctx context.Context, keys []loginAttemptKey) {
+	for _, key := range keys {
+		if err := s.store.Release(ctx, key.scope, key.value); err != nil {
+			s.logger.Error("Failed to release login attempt", zap.String("scope", key.scope), zap.Error(err))
+		}
+	}
+}
+
+func (s *loginProtectionService) ListLockouts(ctx context.Context) ([]*cache.LoginAttemptState, error) {
+	return s.store.ListLocked(ctx)
+}
+
+func (s *loginProtectionService) GetLockout(ctx context.Context, scope, value string) (*cache.LoginAttemptState, error) {
+	value, err := normalizeLockoutValue(scope, value)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Get(ctx, scope, value)
+}
+
+func (s *loginProtectionService) ClearLockout(ctx context.Context, adminID uint, scope, value, ip string) error {
+	value, err := normalizeLockoutValue(scope, value)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.Clear(ctx, scope, value); err != nil {
+		return err
+	}
+
+	s.logger.Info("Admin cleared login lockout",
+		zap.Uint("admin_id", adminID),
+		zap.String("scope", scope),
+		zap.String("value", value))
+
+	return s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    adminID,
+		Action:     model.AuditActionLoginLockoutCleared,
+		TargetType: "login",
+		Detail:     fmt.Sprintf("%s=%s", scope, value),
+		IP:         ip,
+	})
+}
+
+type loginAttemptKey struct {
+	scope string
+	value string
+	limit int64
+}
+
+func (s *loginProtectionService) keys(username, ip string) []loginAttemptKey {
+	keys := []loginAttemptKey{{
+		scope: cache.LoginAttemptScopeUsername,
+		value: normalizeLoginUsername(username),
+		limit: s.cfg.UsernameLimit(),
+	}}
+	if ip != "" {
+		keys = append(keys, loginAttemptKey{
+			scope: cache.LoginAttemptScopeIP,
+			value: ip,
+			limit: s.cfg.IPLimit(),
+		})
+	}
+	return keys
+}
+
+// LoginFailureDelay 根据失败次数计算需要等待的时间
+// 前 delay_after 次失败不等待，之后从 base_delay 开始每次翻倍（不超过 max_delay），达到 limit 次后锁定 lockout_minutes
+func LoginFailureDelay(failures, limit int64, cfg *config.LoginProtectionConfig) time.Duration {
+	if failures >= limit {
+		return cfg.Lockout()
+	}
+
+	free := cfg.FreeAttempts()
+	if failures <= free {
+		return 0
+	}
+
+	delay := cfg.BaseDelay()
+	for i := free + 1; i < failures && delay < cfg.MaxDelay(); i++ {
+		delay *= 2
+	}
+	if delay > cfg.MaxDelay() {
+		delay = cfg.MaxDelay()
+	}
+	return delay
+}
+
+func loginFailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
+		return LoginFailureInvalidCredentials
+	case errors.Is(err, ErrLoginLocked):
+		return LoginFailureLocked
+	case errors.Is(err, ErrEmailNotVerified):
+		return LoginFailureEmailNotVerified
+	case errors.Is(err, ErrAdminRoleRequired):
+		return LoginFailureAdminRoleRequired
+	case errors.Is(err, ErrUserInactive):
+		return LoginFailureInactive
+	default:
+		return LoginFailureError
+	}
+}
+
+// normalizeLoginUsername 用户名按小写计数，避免通过大小写变化绕过限制（MySQL 默认排序规则不区分大小写）
+func normalizeLoginUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func normalizeLockoutValue(scope, value string) (string, error) {
+	switch scope {
+	case cache.LoginAttemptScopeUsername:
+		return normalizeLoginUsername(value), nil
+	case cache.LoginAttemptScopeIP:
+		return strings.TrimSpace(value), nil
+	default:
+		return "", ErrLoginLockoutScopeInvalid
+	}
+}
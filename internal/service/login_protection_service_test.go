@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"trx-project/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginFailureDelay(t *testing.T) {
+	cfg := &config.LoginProtectionConfig{
+		DelayAfter:       3,
+		BaseDelaySeconds: 1,
+		MaxDelaySeconds:  10,
+		LockoutMinutes:   15,
+	}
+
+	tests := []struct {
+		failures int64
+		want     time.Duration
+	}{
+		{1, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{7, 8 * time.Second},
+		{8, 10 * time.Second}, // 不超过等待上限
+		{19, 10 * time.Second},
+		{20, 15 * time.Minute}, // 达到上限后锁定
+		{25, 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, LoginFailureDelay(tt.failures, 20, cfg), "failures=%d", tt.failures)
+	}
+}
+
+func TestLoginFailureReason(t *testing.T) {
+	assert.Equal(t, LoginFailureInvalidCredentials, loginFailureReason(ErrInvalidCredentials))
+	assert.Equal(t, LoginFailureLocked, loginFailureReason(&LoginLockedError{RetryAfter: time.Second}))
+	assert.Equal(t, LoginFailureInactive, loginFailureReason(ErrUserInactive))
+	assert.Equal(t, LoginFailureInactive, loginFailureReason(fmt.Errorf("ldap: %w", ErrUserInactive)))
+	assert.Equal(t, LoginFailureEmailNotVerified, loginFailureReason(ErrEmailNotVerified))
+	assert.Equal(t, LoginFailureAdminRoleRequired, loginFailureReason(ErrAdminRoleRequired))
+	assert.Equal(t, LoginFailureError, loginFailureReason(assert.AnError))
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/config"
+	"trx-project/pkg/jwt"
+	"trx-project/pkg/notifier"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrMagicLinkInvalid 登录链接签名错误、已过期、已使用或不是由当前浏览器申请
+	ErrMagicLinkInvalid = errors.New("magic link is invalid or expired")
+)
+
+// MagicLinkService 免密登录服务
+// 登录链接中的令牌为 <id>.<signature>，签名覆盖用户当前邮箱，数据库只保存 id 的摘要；
+// 申请时返回的 nonce 由发起请求的浏览器保存，使用链接时必须一并提交，转发给他人的链接无法使用
+type MagicLinkService interface {
+	// RequestLink 向邮箱发送登录链接并返回 nonce，邮箱不存在或申请过于频繁（不发送邮件）时同样返回 nonce，避免泄露账号是否存在
+	RequestLink(ctx context.Context, email, ip string) (string, error)
+	// Login 校验登录链接和 nonce 并签发 Token，启用了两步验证时返回挑战；链接只能使用一次
+	Login(ctx context.Context, token, nonce string) (*LoginResult, error)
+}
+
+type magicLinkService struct {
+	repo         repository.MagicLinkRepository
+	userRepo     repository.UserRepository
+	mfaService   MFAService
+	tokenService TokenService
+	notifier     notifier.Notifier
+	logger       *zap.Logger
+	secret       []byte
+	linkURL      string
+	ttl          time.Duration
+	limit        int64
+}
+
+// NewMagicLinkService 创建免密登录服务
+func NewMagicLinkService(
+	repo repository.MagicLinkRepository,
+	userRepo repository.UserRepository,
+	mfaService MFAService,
+	tokenService TokenService,
+	notifier notifier.Notifier,
+	logger *zap.Logger,
+	cfg *config.Config,
+) (MagicLinkService, error) {
+	secret := cfg.Auth.EmailVerificationSecret
+	if secret == "" {
+		secret = cfg.JWT.Secret
+	}
+	if secret == "" {
+		return nil, errors.New("magic link secret is not configured")
+	}
+
+	return &magicLinkService{
+		repo:         repo,
+		userRepo:     userRepo,
+		mfaService:   mfaService,
+		tokenService: tokenService,
+		notifier:     notifier,
+		logger:       logger,
+		secret:       []byte(secret),
+		linkURL:      cfg.Auth.MagicLinkURL,
+		ttl:          cfg.Auth.MagicLinkTTL(),
+		limit:        cfg.Auth.MagicLinkLimit(),
+	}, nil
+}
+
+func (s *magicLinkService) RequestLink(ctx context.Context, email, ip string) (string, error) {
+	nonce, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Info("Magic link requested for unknown email")
+			return nonce, nil
+		}
+		s.logger.Error("Failed to get user", zap.Error(err))
+		return "", err
+	}
+	if user.Status != 1 || user.IsServiceAccount() {
+		s.logger.Info("Magic link requested for inactive user", zap.Uint("user_id", user.ID))
+		return nonce, nil
+	}
+
+	count, err := s.repo.CountSince(ctx, user.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		s.logger.Error("Failed to count magic links", zap.Uint("user_id", user.ID), zap.Error(err))
+		return "", err
+	}
+	if count >= s.limit {
+		// 限制只对存在的账号生效，返回错误会泄露账号是否存在
+		s.logger.Warn("Magic link requests throttled", zap.Uint("user_id", user.ID), zap.String("ip", ip))
+		return nonce, nil
+	}
+
+	// 每次只有最新的链接有效
+	if err := s.repo.InvalidateByUserID(ctx, user.ID); err != nil {
+		s.logger.Error("Failed to invalidate magic links", zap.Uint("user_id", user.ID), zap.Error(err))
+		return "", err
+	}
+
+	id, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	record := &model.MagicLinkToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(id),
+		NonceHash: hashToken(nonce),
+		IP:        ip,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	if err := s.repo.Create(ctx, record); err != nil {
+		s.logger.Error("Failed to create magic link", zap.Uint("user_id", user.ID), zap.Error(err))
+		return "", err
+	}
+
+	token := s.sign(id, user.Email)
+	link := s.loginLink(token)
+	err = s.notifier.Send(ctx, &notifier.Message{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to sign in. The link expires in %d minutes, can only be used once and only works in the browser where you requested it.\n\n%s\n\nIf you did not request this link, you can ignore this message.",
+			user.Username, int(s.ttl.Minutes()), link),
+		Data: map[string]string{
+			"token": token,
+			"link":  link,
+		},
+	})
+	if err != nil {
+		// 不向调用方返回错误，避免通过响应差异判断邮箱是否存在
+		s.logger.Error("Failed to send magic link message", zap.Uint("user_id", user.ID), zap.Error(err))
+		return nonce, nil
+	}
+
+	s.logger.Info("Magic link requested", zap.Uint("user_id", user.ID))
+	return nonce, nil
+}
+
+func (s *magicLinkService) Login(ctx context.Context, token, nonce string) (*LoginResult, error) {
+	id, _, ok := strings.Cut(token, ".")
+	if !ok || id == "" || nonce == "" {
+		return nil, ErrMagicLinkInvalid
+	}
+
+	record, err := s.repo.GetActive(ctx, hashToken(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMagicLinkInvalid
+		}
+		s.logger.Error("Failed to get magic link", zap.Error(err))
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, record.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMagicLinkInvalid
+		}
+		s.logger.Error("Failed to get user", zap.Error(err))
+		return nil, err
+	}
+
+	// 按用户当前邮箱重新计算签名，邮箱变更后旧链接失效；nonce 不一致说明链接在其他浏览器中打开
+	if !hmac.Equal([]byte(token), []byte(s.sign(id, user.Email))) {
+		return nil, ErrMagicLinkInvalid
+	}
+	if !hmac.Equal([]byte(hashToken(nonce)), []byte(record.NonceHash)) {
+		s.logger.Warn("Magic link used from another browser", zap.Uint("user_id", user.ID))
+		return nil, ErrMagicLinkInvalid
+	}
+
+	ok, err = s.repo.MarkUsed(ctx, record.ID)
+	if err != nil {
+		s.logger.Error("Failed to mark magic link used", zap.Uint("user_id", user.ID), zap.Error(err))
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrMagicLinkInvalid
+	}
+
+	if user.Status != 1 {
+		return nil, ErrUserInactive
+	}
+
+	// 能打开邮件中的链接说明邮箱可用
+	if !user.EmailVerified() {
+		if _, err := s.userRepo.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+			s.logger.Error("Failed to mark email verified", zap.Uint("user_id", user.ID), zap.Error(err))
+			return nil, err
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	// 免密登录只替代密码，启用了两步验证时仍然需要验证码
+	challenge, err := s.mfaService.StartLogin(ctx, user.ID, jwt.RoleUser)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		s.logger.Info("Magic link login requires mfa", zap.Uint("user_id", user.ID))
+		return &LoginResult{User: user, MFA: challenge}, nil
+	}
+
+	tokens, err := s.tokenService.IssueTokenPair(ctx, user, jwt.RoleUser, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("User logged in with magic link", zap.String("username", user.Username))
+	return &LoginResult{User: user, Tokens: tokens}, nil
+}
+
+// sign 生成登录令牌 <id>.<signature>
+func (s *magicLinkService) sign(id, email string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("magic-link|" + id + "|" + email))
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// loginLink 生成登录链接，未配置页面地址时直接返回令牌
+func (s *magicLinkService) loginLink(token string) string {
+	if s.linkURL == "" {
+		return token
+	}
+
+	u, err := url.Parse(s.linkURL)
+	if err != nil {
+		s.logger.Warn("Invalid magic link url", zap.String("url", s.linkURL), zap.Error(err))
+		return token
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/pkg/config"
+	"trx-project/pkg/jwt"
+	"trx-project/pkg/notifier"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MockMagicLinkRepository 模拟免密登录链接仓库
+type MockMagicLinkRepository struct {
+	mock.Mock
+}
+
+func (m *MockMagicLinkRepository) Create(ctx context.Context, token *model.MagicLinkToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockMagicLinkRepository) GetActive(ctx context.Context, tokenHash string) (*model.MagicLinkToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MagicLinkToken), args.Error(1)
+}
+
+func (m *MockMagicLinkRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMagicLinkRepository) CountSince(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	args := m.Called(ctx, userID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMagicLinkRepository) InvalidateByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockMFAService 模拟两步验证服务
+type MockMFAService struct {
+	mock.Mock
+}
+
+func (m *MockMFAService) GetStatus(ctx context.Context, userID uint) (*MFAStatus, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MFAStatus), args.Error(1)
+}
+
+func (m *MockMFAService) BeginEnrollment(ctx context.Context, userID uint) (*MFAEnrollment, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MFAEnrollment), args.Error(1)
+}
+
+func (m *MockMFAService) ConfirmEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMFAService) Verify(ctx context.Context, userID uint, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) Disable(ctx context.Context, userID uint, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMFAService) IsRequired(ctx context.Context, userID uint) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFAService) Reset(ctx context.Context, adminID, userID uint, ip string) error {
+	args := m.Called(ctx, adminID, userID, ip)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) SetRoleRequirement(ctx context.Context, adminID, roleID uint, required bool, ip string) (*model.Role, error) {
+	args := m.Called(ctx, adminID, roleID, required, ip)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+func (m *MockMFAService) StartLogin(ctx context.Context, userID uint, role string) (*MFAChallenge, error) {
+	args := m.Called(ctx, userID, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MFAChallenge), args.Error(1)
+}
+
+func (m *MockMFAService) BeginLoginEnrollment(ctx context.Context, mfaToken string, admin bool) (*MFAEnrollment, error) {
+	args := m.Called(ctx, mfaToken, admin)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MFAEnrollment), args.Error(1)
+}
+
+func (m *MockMFAService) CompleteLogin(ctx context.Context, mfaToken, code string, admin bool) (*MFALoginResult, error) {
+	args := m.Called(ctx, mfaToken, code, admin)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MFALoginResult), args.Error(1)
+}
+
+func newTestMagicLinkService(repo *MockMagicLinkRepository, userRepo *MockUserRepository, mfa *MockMFAService, tokens *MockTokenService, notify notifier.Notifier) MagicLinkService {
+	cfg := &config.Config{
+		JWT:  config.JWTConfig{Secret: "test-secret"},
+		Auth: config.AuthConfig{MagicLinkURL: "https://example.com/magic-login", MagicLinkMaxPerHour: 2},
+	}
+	service, err := NewMagicLinkService(repo, userRepo, mfa, tokens, notify, zap.NewNop(), cfg)
+	if err != nil {
+		panic(err)
+	}
+	return service
+}
+
+func TestMagicLinkService_LoginFlow(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockMagicLinkRepository)
+	userRepo := new(MockUserRepository)
+	mfa := new(MockMFAService)
+	tokens := new(MockTokenService)
+	notify := notifier.NewMemoryNotifier()
+	service := newTestMagicLinkService(repo, userRepo, mfa, tokens, notify)
+
+	now := time.Now()
+	user := &model.User{ID: 1, Username: "testuser", Email: "test@example.com", Status: 1, EmailVerifiedAt: &now}
+	userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	repo.On("CountSince", ctx, user.ID, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	repo.On("InvalidateByUserID", ctx, user.ID).Return(nil)
+
+	var stored *model.MagicLinkToken
+	repo.On("Create", ctx, mock.AnythingOfType("*model.MagicLinkToken")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*model.MagicLinkToken)
+		stored.ID = 9
+	}).Return(nil)
+
+	nonce, err := service.RequestLink(ctx, user.Email, "127.0.0.1")
+	require.NoError(t, err)
+	require.NotEmpty(t, nonce)
+	require.Len(t, notify.Messages(), 1)
+
+	token := notify.Last().Data["token"]
+	assert.Contains(t, notify.Last().Data["link"], "https://example.com/magic-login?token=")
+	// 数据库只保存摘要
+	assert.Equal(t, hashToken(nonce), stored.NonceHash)
+	assert.NotContains(t, token, stored.TokenHash)
+
+	repo.On("GetActive", ctx, stored.TokenHash).Return(stored, nil)
+
+	// 篡改过的令牌和其他浏览器的 nonce 无效
+	_, err = service.Login(ctx, token+"x", nonce)
+	assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+	_, err = service.Login(ctx, token, "other-browser")
+	assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+
+	pair := &TokenPair{AccessToken: "access", RefreshToken: "refresh"}
+	mfa.On("StartLogin", ctx, user.ID, jwt.RoleUser).Return(nil, nil)
+	tokens.On("IssueTokenPair", ctx, user, jwt.RoleUser, "").Return(pair, nil)
+	repo.On("MarkUsed", ctx, uint(9)).Return(true, nil).Once()
+
+	result, err := service.Login(ctx, token, nonce)
+	require.NoError(t, err)
+	assert.Equal(t, pair, result.Tokens)
+
+	// 链接只能使用一次
+	repo.On("MarkUsed", ctx, uint(9)).Return(false, nil).Once()
+	_, err = service.Login(ctx, token, nonce)
+	assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+}
+
+func TestMagicLinkService_RequestLink(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockMagicLinkRepository)
+	userRepo := new(MockUserRepository)
+	notify := notifier.NewMemoryNotifier()
+	service := newTestMagicLinkService(repo, userRepo, new(MockMFAService), new(MockTokenService), notify)
+
+	// 邮箱不存在时同样返回 nonce，不发送邮件
+	userRepo.On("GetByEmail", ctx, "unknown@example.com").Return(nil, gorm.ErrRecordNotFound)
+	nonce, err := service.RequestLink(ctx, "unknown@example.com", "")
+	require.NoError(t, err)
+	assert.NotEmpty(t, nonce)
+
+	// 达到每小时上限后不再发送，但和邮箱不存在时一样返回 nonce
+	user := &model.User{ID: 1, Username: "testuser", Email: "test@example.com", Status: 1}
+	userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	repo.On("CountSince", ctx, user.ID, mock.AnythingOfType("time.Time")).Return(int64(2), nil)
+	nonce, err = service.RequestLink(ctx, user.Email, "")
+	require.NoError(t, err)
+	assert.NotEmpty(t, nonce)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	assert.Empty(t, notify.Messages())
+}
@@ -0,0 +1,569 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/cache"
+	"trx-project/pkg/config"
+	"trx-project/pkg/jwt"
+	"trx-project/pkg/totp"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrMFAAlreadyEnabled 两步验证已启用
+	ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+	// ErrMFANotEnabled 两步验证未启用
+	ErrMFANotEnabled = errors.New("mfa not enabled")
+	// ErrMFAEnrollmentNotStarted 尚未开始绑定两步验证
+	ErrMFAEnrollmentNotStarted = errors.New("mfa enrollment not started")
+	// ErrMFAInvalidCode 验证码或恢复码错误
+	ErrMFAInvalidCode = errors.New("invalid mfa code")
+	// ErrMFAChallengeInvalid 登录挑战不存在、已过期或已用完尝试次数
+	ErrMFAChallengeInvalid = errors.New("mfa challenge is invalid or expired")
+	// ErrMFARequiredByRole 用户的角色要求启用两步验证，不能关闭
+	ErrMFARequiredByRole = errors.New("mfa is required by user role")
+)
+
+const (
+	// mfaChallengeTTL 密码校验通过后完成两步验证的时限
+	mfaChallengeTTL = 5 * time.Minute
+	// mfaChallengeMaxAttempts 每个登录挑战最多尝试的次数
+	mfaChallengeMaxAttempts = 5
+	// mfaCodeSkew 允许前后各一个时间步的时钟偏差
+	mfaCodeSkew = 1
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+)
+
+var totpCodePattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+// MFAStatus 用户两步验证状态
+type MFAStatus struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"` // 用户的角色是否要求启用
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// MFAEnrollment 两步验证绑定信息，secret 和 otpauth_uri 用于在验证器 App 中添加账号
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// MFAChallenge 登录时需要完成的两步验证挑战
+type MFAChallenge struct {
+	Token              string `json:"mfa_token"`
+	ExpiresIn          int64  `json:"expires_in"`          // 秒
+	EnrollmentRequired bool   `json:"enrollment_required"` // 角色要求两步验证但用户尚未绑定，需要先完成绑定
+}
+
+// MFALoginResult 完成登录挑战的结果
+type MFALoginResult struct {
+	UserID        uint
+	Role          string
+	RecoveryCodes []string // 登录时完成绑定才会返回
+}
+
+// MFAService 两步验证（TOTP）服务
+type MFAService interface {
+	GetStatus(ctx context.Context, userID uint) (*MFAStatus, error)
+	// BeginEnrollment 生成新的密钥，确认前不会生效
+	BeginEnrollment(ctx context.Context, userID uint) (*MFAEnrollment, error)
+	// ConfirmEnrollment 校验验证码后启用两步验证，返回一次性展示的恢复码
+	ConfirmEnrollment(ctx context.Context, userID uint, code string) ([]string, error)
+	// Verify 校验验证码或恢复码
+	Verify(ctx context.Context, userID uint, code string) error
+	Disable(ctx context.Context, userID uint, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	// IsRequired 用户是否拥有要求两步验证的角色
+	IsRequired(ctx context.Context, userID uint) (bool, error)
+
+	// Reset 管理员清除用户的两步验证（用户丢失设备和恢复码时）
+	// 目标用户拥有管理员不具备的后台角色或权限时返回 ErrTargetPrivileged
+	Reset(ctx context.Context, adminID, userID uint, ip string) error
+	// SetRoleRequirement 设置角色是否要求两步验证
+	SetRoleRequirement(ctx context.Context, adminID, roleID uint, required bool, ip string) (*model.Role, error)
+
+	// StartLogin 密码校验通过后调用，不需要两步验证时返回 nil
+	StartLogin(ctx context.Context, userID uint, role string) (*MFAChallenge, error)
+	// BeginLoginEnrollment 登录过程中为尚未绑定的用户生成密钥
+	BeginLoginEnrollment(ctx context.Context, mfaToken string, admin bool) (*MFAEnrollment, error)
+	// CompleteLogin 校验登录挑战的验证码，admin 表示是否为后台登录
+	CompleteLogin(ctx context.Context, mfaToken, code string, admin bool) (*MFALoginResult, error)
+}
+
+// mfaChallengeStore 两步验证服务保存登录挑战使用的存储，由 cache.MFAChallengeStore 实现
+type mfaChallengeStore interface {
+	Save(ctx context.Context, tokenHash string, record *cache.MFAChallengeRecord) error
+	Get(ctx context.Context, tokenHash string) (*cache.MFAChallengeRecord, error)
+	IncrementAttempts(ctx context.Context, tokenHash string, expiresAt time.Time) (int64, error)
+	Delete(ctx context.Context, tokenHash string) (bool, error)
+}
+
+type mfaService struct {
+	repo        repository.MFARepository
+	userRepo    repository.UserRepository
+	rbacService RBACService
+	audit       AuditService
+	challenges  mfaChallengeStore
+	logger      *zap.Logger
+	issuer      string
+}
+
+// NewMFAService 创建两步验证服务
+func NewMFAService(
+	repo repository.MFARepository,
+	userRepo repository.UserRepository,
+	rbacService RBACService,
+	audit AuditService,
+	challenges *cache.MFAChallengeStore,
+	logger *zap.Logger,
+	cfg *config.Config,
+) MFAService {
+	issuer := cfg.Auth.MFAIssuer
+	if issuer == "" {
+		issuer = cfg.JWT.Issuer
+	}
+	return newMFAService(repo, userRepo, rbacService, audit, challenges, logger, issuer)
+}
+
+func newMFAService(
+	repo repository.MFARepository,
+	userRepo repository.UserRepository,
+	rbacService RBACService,
+	audit AuditService,
+	challenges mfaChallengeStore,
+	logger *zap.Logger,
+	issuer string,
+) *mfaService {
+	return &mfaService{
+		repo:        repo,
+		userRepo:    userRepo,
+		rbacService: rbacService,
+		audit:       audit,
+		challenges:  challenges,
+		logger:      logger,
+		issuer:      issuer,
+	}
+}
+
+func (s *mfaService) GetStatus(ctx context.Context, userID uint) (*MFAStatus, error) {
+	required, err := s.IsRequired(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{Required: required}
+	mfa, err := s.getMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return status, nil
+	}
+
+	status.Enabled = true
+	if status.RecoveryCodesRemaining, err = s.repo.CountUnusedRecoveryCodes(ctx, userID); err != nil {
+		s.logger.Error("Failed to count recovery codes", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return status, nil
+}
+
+func (s *mfaService) BeginEnrollment(ctx context.Context, userID uint) (*MFAEnrollment, error) {
+	mfa, err := s.getMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		s.logger.Error("Failed to get user", zap.Error(err))
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新开始绑定会覆盖之前未确认的密钥
+	if mfa == nil {
+		mfa = &model.UserMFA{UserID: userID}
+	}
+	mfa.Secret = secret
+	if err := s.repo.Save(ctx, mfa); err != nil {
+		s.logger.Error("Failed to save mfa secret", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+func (s *mfaService) ConfirmEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	mfa, err := s.getMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, ErrMFAEnrollmentNotStarted
+	}
+	if mfa.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	counter, ok := totp.Validate(mfa.Secret, code, time.Now(), mfaCodeSkew)
+	if !ok {
+		return nil, ErrMFAInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Enable(ctx, userID, counter, hashes); err != nil {
+		s.logger.Error("Failed to enable mfa", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("MFA enabled", zap.Uint("user_id", userID))
+	return codes, nil
+}
+
+func (s *mfaService) Verify(ctx context.Context, userID uint, code string) error {
+	mfa, err := s.getMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return ErrMFANotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if totpCodePattern.MatchString(code) {
+		counter, ok := totp.Validate(mfa.Secret, code, time.Now(), mfaCodeSkew)
+		if !ok {
+			return ErrMFAInvalidCode
+		}
+		// 同一时间步的验证码只能使用一次
+		updated, err := s.repo.UpdateLastCounter(ctx, userID, counter)
+		if err != nil {
+			s.logger.Error("Failed to update mfa counter", zap.Uint("user_id", userID), zap.Error(err))
+			return err
+		}
+		if !updated {
+			s.logger.Warn("Rejected replayed mfa code", zap.Uint("user_id", userID))
+			return ErrMFAInvalidCode
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		s.logger.Error("Failed to use recovery code", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+	if !used {
+		return ErrMFAInvalidCode
+	}
+
+	s.logger.Info("Recovery code used", zap.Uint("user_id", userID))
+	return nil
+}
+
+func (s *mfaService) Disable(ctx context.Context, userID uint, code string) error {
+	required, err := s.IsRequired(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequiredByRole
+	}
+
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, userID); err != nil {
+		s.logger.Error("Failed to disable mfa", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("MFA disabled", zap.Uint("user_id", userID))
+	return nil
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		s.logger.Error("Failed to replace recovery codes", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Recovery codes regenerated", zap.Uint("user_id", userID))
+	return codes, nil
+}
+
+func (s *mfaService) IsRequired(ctx context.Context, userID uint) (bool, error) {
+	roles, err := s.rbacService.GetUserRoles(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get user roles", zap.Uint("user_id", userID), zap.Error(err))
+		return false, err
+	}
+
+	for _, role := range roles {
+		if role.Status == 1 && role.MFARequired {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *mfaService) Reset(ctx context.Context, adminID, userID uint, ip string) error {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		s.logger.Error("Failed to get user", zap.Error(err))
+		return err
+	}
+
+	// 清除两步验证后只凭密码即可登录，不能替权限更高的用户清除
+	if err := checkTargetPrivileges(ctx, s.rbacService, adminID, userID); err != nil {
+		if errors.Is(err, ErrTargetPrivileged) {
+			s.logger.Warn("Admin mfa reset rejected: target is more privileged",
+				zap.Uint("admin_id", adminID),
+				zap.Uint("user_id", userID))
+		} else {
+			s.logger.Error("Failed to check target privileges", zap.Uint("user_id", userID), zap.Error(err))
+		}
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, userID); err != nil {
+		s.logger.Error("Failed to reset mfa", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	return s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    adminID,
+		Action:     model.AuditActionMFAReset,
+		TargetType: "user",
+		TargetID:   userID,
+		IP:         ip,
+	})
+}
+
+func (s *mfaService) SetRoleRequirement(ctx context.Context, adminID, roleID uint, required bool, ip string) (*model.Role, error) {
+	role, err := s.rbacService.GetRoleByID(ctx, roleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("role not found")
+		}
+		return nil, err
+	}
+
+	if role.MFARequired == required {
+		return role, nil
+	}
+
+	role.MFARequired = required
+	if err := s.rbacService.UpdateRole(ctx, role); err != nil {
+		s.logger.Error("Failed to update role", zap.Uint("role_id", roleID), zap.Error(err))
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    adminID,
+		Action:     model.AuditActionRoleMFAUpdated,
+		TargetType: "role",
+		TargetID:   roleID,
+		Detail:     fmt.Sprintf("mfa_required=%t", required),
+		IP:         ip,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (s *mfaService) StartLogin(ctx context.Context, userID uint, role string) (*MFAChallenge, error) {
+	mfa, err := s.getMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := mfa != nil && mfa.Enabled
+
+	if !enabled {
+		required, err := s.IsRequired(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !required {
+			return nil, nil
+		}
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &cache.MFAChallengeRecord{
+		UserID:    userID,
+		Role:      role,
+		Enroll:    !enabled,
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	}
+	if err := s.challenges.Save(ctx, hashToken(token), record); err != nil {
+		s.logger.Error("Failed to save mfa challenge", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	return &MFAChallenge{
+		Token:              token,
+		ExpiresIn:          int64(mfaChallengeTTL.Seconds()),
+		EnrollmentRequired: record.Enroll,
+	}, nil
+}
+
+func (s *mfaService) BeginLoginEnrollment(ctx context.Context, mfaToken string, admin bool) (*MFAEnrollment, error) {
+	record, err := s.getChallenge(ctx, hashToken(mfaToken), admin)
+	if err != nil {
+		return nil, err
+	}
+	if !record.Enroll {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	return s.BeginEnrollment(ctx, record.UserID)
+}
+
+func (s *mfaService) CompleteLogin(ctx context.Context, mfaToken, code string, admin bool) (*MFALoginResult, error) {
+	tokenHash := hashToken(mfaToken)
+	record, err := s.getChallenge(ctx, tokenHash, admin)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.challenges.IncrementAttempts(ctx, tokenHash, record.ExpiresAt)
+	if err != nil {
+		s.logger.Error("Failed to count mfa attempts", zap.Error(err))
+		return nil, err
+	}
+	if attempts > mfaChallengeMaxAttempts {
+		s.logger.Warn("MFA challenge attempts exceeded", zap.Uint("user_id", record.UserID))
+		if _, err := s.challenges.Delete(ctx, tokenHash); err != nil {
+			s.logger.Error("Failed to delete mfa challenge", zap.Error(err))
+		}
+		return nil, ErrMFAChallengeInvalid
+	}
+
+	result := &MFALoginResult{UserID: record.UserID, Role: record.Role}
+	if record.Enroll {
+		result.RecoveryCodes, err = s.ConfirmEnrollment(ctx, record.UserID, code)
+	} else {
+		err = s.Verify(ctx, record.UserID, code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 登录挑战只能使用一次
+	consumed, err := s.challenges.Delete(ctx, tokenHash)
+	if err != nil {
+		s.logger.Error("Failed to delete mfa challenge", zap.Error(err))
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrMFAChallengeInvalid
+	}
+
+	return result, nil
+}
+
+// getMFA 获取用户两步验证配置，不存在时返回 nil
+func (s *mfaService) getMFA(ctx context.Context, userID uint) (*model.UserMFA, error) {
+	mfa, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		s.logger.Error("Failed to get mfa", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return mfa, nil
+}
+
+// getChallenge 获取登录挑战，前台签发的挑战不能在后台使用，反之亦然
+func (s *mfaService) getChallenge(ctx context.Context, tokenHash string, admin bool) (*cache.MFAChallengeRecord, error) {
+	record, err := s.challenges.Get(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, cache.ErrMFAChallengeNotFound) {
+			return nil, ErrMFAChallengeInvalid
+		}
+		s.logger.Error("Failed to get mfa challenge", zap.Error(err))
+		return nil, err
+	}
+
+	if (record.Role != jwt.RoleUser) != admin {
+		return nil, ErrMFAChallengeInvalid
+	}
+	return record, nil
+}
+
+// generateRecoveryCodes 生成恢复码及其摘要，格式为 xxxxx-xxxxx
+func generateRecoveryCodes() ([]string, []string, error) {
+	// 32 个字符，去掉了易混淆的 l、o、0、1
+	const alphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		for j := range buf {
+			buf[j] = alphabet[buf[j]&31]
+		}
+		code := string(buf[:5]) + "-" + string(buf[5:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode 计算恢复码摘要，忽略大小写、空格和连字符
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return hashToken(code)
+}
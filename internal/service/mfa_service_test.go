@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/pkg/cache"
+	"trx-project/pkg/jwt"
+	"trx-project/pkg/totp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MockMFARepository 模拟两步验证仓库
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) GetByUserID(ctx context.Context, userID uint) (*model.UserMFA, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UserMFA), args.Error(1)
+}
+
+func (m *MockMFARepository) Save(ctx context.Context, mfa *model.UserMFA) error {
+	args := m.Called(ctx, mfa)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) Delete(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UpdateLastCounter(ctx context.Context, userID uint, counter int64) (bool, error) {
+	args := m.Called(ctx, userID, counter)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) Enable(ctx context.Context, userID uint, counter int64, codeHashes []string) error {
+	args := m.Called(ctx, userID, counter, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	args := m.Called(ctx, userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) CountUnusedRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// fakeMFAChallengeStore 内存中的登录挑战存储，过期的挑战和 Redis 中一样视为不存在
+type fakeMFAChallengeStore struct {
+	mu       sync.Mutex
+	records  map[string]*cache.MFAChallengeRecord
+	attempts map[string]int64
+}
+
+func newFakeMFAChallengeStore() *fakeMFAChallengeStore {
+	return &fakeMFAChallengeStore{
+		records:  map[string]*cache.MFAChallengeRecord{},
+		attempts: map[string]int64{},
+	}
+}
+
+func (s *fakeMFAChallengeStore) Save(ctx context.Context, tokenHash string, record *cache.MFAChallengeRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[tokenHash] = record
+	return nil
+}
+
+func (s *fakeMFAChallengeStore) Get(ctx context.Context, tokenHash string) (*cache.MFAChallengeRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[tokenHash]
+	if !ok || !time.Now().Before(record.ExpiresAt) {
+		return nil, cache.ErrMFAChallengeNotFound
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (s *fakeMFAChallengeStore) IncrementAttempts(ctx context.Context, tokenHash string, expiresAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[tokenHash]++
+	return s.attempts[tokenHash], nil
+}
+
+func (s *fakeMFAChallengeStore) Delete(ctx context.Context, tokenHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.records[tokenHash]
+	delete(s.records, tokenHash)
+	delete(s.attempts, tokenHash)
+	return ok, nil
+}
+
+// expire 让挑战立即过期
+func (s *fakeMFAChallengeStore) expire(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[hashToken(token)].ExpiresAt = time.Now().Add(-time.Second)
+}
+
+func newTestMFAService(repo *MockMFARepository, userRepo *MockUserRepository, rbac *MockRBACService, challenges *fakeMFAChallengeStore) *mfaService {
+	return newMFAService(repo, userRepo, rbac, new(MockAuditService), challenges, zap.NewNop(), "trx-project")
+}
+
+// currentTOTP 生成当前时间步的验证码
+func currentTOTP(t *testing.T, secret string) (string, int64) {
+	counter := totp.Counter(time.Now())
+	code, err := totp.GenerateCode(secret, counter)
+	require.NoError(t, err)
+	return code, counter
+}
+
+func enabledMFA(t *testing.T, userID uint) *model.UserMFA {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	return &model.UserMFA{UserID: userID, Secret: secret, Enabled: true}
+}
+
+func TestMFAService_EnrollConfirmDisable(t *testing.T) {
+	ctx := context.Background()
+	repo, userRepo, rbac := new(MockMFARepository), new(MockUserRepository), new(MockRBACService)
+	s := newTestMFAService(repo, userRepo, rbac, newFakeMFAChallengeStore())
+
+	// 开始绑定：生成密钥并保存，此时尚未启用
+	var saved *model.UserMFA
+	repo.On("GetByUserID", ctx, uint(1)).Return(nil, gorm.ErrRecordNotFound).Once()
+	userRepo.On("GetByID", ctx, uint(1)).Return(&model.User{ID: 1, Username: "alice"}, nil)
+	repo.On("Save", ctx, mock.AnythingOfType("*model.UserMFA")).Run(func(args mock.Arguments) {
+		saved = args.Get(1).(*model.UserMFA)
+	}).Return(nil).Once()
+
+	enrollment, err := s.BeginEnrollment(ctx, 1)
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.False(t, saved.Enabled)
+	assert.Equal(t, saved.Secret, enrollment.Secret)
+	assert.Contains(t, enrollment.URI, "alice")
+
+	// 确认绑定：验证码错误时不启用
+	pending := &model.UserMFA{UserID: 1, Secret: enrollment.Secret}
+	repo.On("GetByUserID", ctx, uint(1)).Return(pending, nil).Twice()
+	_, err = s.ConfirmEnrollment(ctx, 1, "000000x")
+	assert.ErrorIs(t, err, ErrMFAInvalidCode)
+
+	code, counter := currentTOTP(t, enrollment.Secret)
+	var hashes []string
+	repo.On("Enable", ctx, uint(1), counter, mock.Anything).Run(func(args mock.Arguments) {
+		hashes = args.Get(3).([]string)
+	}).Return(nil).Once()
+
+	codes, err := s.ConfirmEnrollment(ctx, 1, code)
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	// 只保存恢复码的摘要
+	require.Len(t, hashes, recoveryCodeCount)
+	assert.Equal(t, hashRecoveryCode(codes[0]), hashes[0])
+	assert.NotContains(t, hashes, codes[0])
+
+	// 已启用后不能重新开始绑定
+	enabled := &model.UserMFA{UserID: 1, Secret: enrollment.Secret, Enabled: true, LastCounter: counter}
+	repo.On("GetByUserID", ctx, uint(1)).Return(enabled, nil)
+	_, err = s.BeginEnrollment(ctx, 1)
+	assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+
+	// 关闭：角色要求两步验证时不能关闭
+	rbac.On("GetUserRoles", ctx, uint(1)).Return([]*model.Role{{Name: model.RoleAdmin, Status: 1, MFARequired: true}}, nil).Once()
+	err = s.Disable(ctx, 1, code)
+	assert.ErrorIs(t, err, ErrMFARequiredByRole)
+
+	// 关闭需要校验恢复码或验证码
+	rbac.On("GetUserRoles", ctx, uint(1)).Return([]*model.Role{}, nil)
+	repo.On("UseRecoveryCode", ctx, uint(1), hashRecoveryCode(codes[1])).Return(true, nil).Once()
+	repo.On("Delete", ctx, uint(1)).Return(nil).Once()
+	require.NoError(t, s.Disable(ctx, 1, codes[1]))
+
+	repo.AssertExpectations(t)
+}
+
+func TestMFAService_RecoveryCodeSingleUse(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockMFARepository)
+	s := newTestMFAService(repo, new(MockUserRepository), new(MockRBACService), newFakeMFAChallengeStore())
+
+	repo.On("GetByUserID", ctx, uint(1)).Return(enabledMFA(t, 1), nil)
+	// 恢复码忽略大小写、空格和连字符
+	hash := hashRecoveryCode("abcde-fghij")
+	repo.On("UseRecoveryCode", ctx, uint(1), hash).Return(true, nil).Once()
+	repo.On("UseRecoveryCode", ctx, uint(1), hash).Return(false, nil).Once()
+
+	require.NoError(t, s.Verify(ctx, 1, " ABCDE FGHIJ "))
+	err := s.Verify(ctx, 1, "abcde-fghij")
+	assert.ErrorIs(t, err, ErrMFAInvalidCode)
+	repo.AssertExpectations(t)
+}
+
+func TestMFAService_RejectsReplayedCode(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockMFARepository)
+	s := newTestMFAService(repo, new(MockUserRepository), new(MockRBACService), newFakeMFAChallengeStore())
+
+	mfa := enabledMFA(t, 1)
+	repo.On("GetByUserID", ctx, uint(1)).Return(mfa, nil)
+	code, counter := currentTOTP(t, mfa.Secret)
+	// 同一时间步第二次使用时 UpdateLastCounter 返回 false
+	repo.On("UpdateLastCounter", ctx, uint(1), counter).Return(true, nil).Once()
+	repo.On("UpdateLastCounter", ctx, uint(1), counter).Return(false, nil).Once()
+
+	require.NoError(t, s.Verify(ctx, 1, code))
+	err := s.Verify(ctx, 1, code)
+	assert.ErrorIs(t, err, ErrMFAInvalidCode)
+	// 六位数字按验证码校验，不会当作恢复码
+	repo.AssertNotCalled(t, "UseRecoveryCode", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestMFAService_CompleteLogin(t *testing.T) {
+	ctx := context.Background()
+
+	start := func(t *testing.T, role string) (*mfaService, *MockMFARepository, *fakeMFAChallengeStore, *model.UserMFA, string) {
+		repo, challenges := new(MockMFARepository), newFakeMFAChallengeStore()
+		s := newTestMFAService(repo, new(MockUserRepository), new(MockRBACService), challenges)
+		mfa := enabledMFA(t, 1)
+		repo.On("GetByUserID", ctx, uint(1)).Return(mfa, nil)
+
+		challenge, err := s.StartLogin(ctx, 1, role)
+		require.NoError(t, err)
+		require.NotNil(t, challenge)
+		assert.False(t, challenge.EnrollmentRequired)
+		assert.Equal(t, int64(mfaChallengeTTL.Seconds()), challenge.ExpiresIn)
+		return s, repo, challenges, mfa, challenge.Token
+	}
+
+	t.Run("Success consumes challenge", func(t *testing.T) {
+		s, repo, _, mfa, token := start(t, jwt.RoleUser)
+		code, counter := currentTOTP(t, mfa.Secret)
+		repo.On("UpdateLastCounter", ctx, uint(1), counter).Return(true, nil).Once()
+
+		result, err := s.CompleteLogin(ctx, token, code, false)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), result.UserID)
+		assert.Equal(t, jwt.RoleUser, result.Role)
+
+		_, err = s.CompleteLogin(ctx, token, code, false)
+		assert.ErrorIs(t, err, ErrMFAChallengeInvalid)
+	})
+
+	t.Run("Expired challenge", func(t *testing.T) {
+		s, repo, challenges, mfa, token := start(t, jwt.RoleUser)
+		challenges.expire(token)
+		code, _ := currentTOTP(t, mfa.Secret)
+
+		_, err := s.CompleteLogin(ctx, token, code, false)
+		assert.ErrorIs(t, err, ErrMFAChallengeInvalid)
+		repo.AssertNotCalled(t, "UpdateLastCounter", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Challenge bound to frontend or backend", func(t *testing.T) {
+		s, _, _, mfa, token := start(t, jwt.RoleUser)
+		code, _ := currentTOTP(t, mfa.Secret)
+
+		_, err := s.CompleteLogin(ctx, token, code, true)
+		assert.ErrorIs(t, err, ErrMFAChallengeInvalid)
+	})
+
+	t.Run("Attempts exhausted", func(t *testing.T) {
+		s, repo, _, mfa, token := start(t, jwt.RoleAdmin)
+		repo.On("UseRecoveryCode", ctx, uint(1), mock.Anything).Return(false, nil)
+		for i := 0; i < mfaChallengeMaxAttempts; i++ {
+			_, err := s.CompleteLogin(ctx, token, "abcde-fghij", true)
+			require.ErrorIs(t, err, ErrMFAInvalidCode)
+		}
+
+		// 用完尝试次数后挑战被删除，正确的验证码也不能再使用
+		code, _ := currentTOTP(t, mfa.Secret)
+		_, err := s.CompleteLogin(ctx, token, code, true)
+		assert.ErrorIs(t, err, ErrMFAChallengeInvalid)
+		_, err = s.CompleteLogin(ctx, token, code, true)
+		assert.ErrorIs(t, err, ErrMFAChallengeInvalid)
+	})
+}
+
+func TestMFAService_Reset(t *testing.T) {
+	ctx := context.Background()
+	ip := "192.0.2.1"
+
+	setup := func() (*mfaService, *MockMFARepository, *MockRBACService, *MockAuditService) {
+		repo, userRepo, rbac, audit := new(MockMFARepository), new(MockUserRepository), new(MockRBACService), new(MockAuditService)
+		userRepo.On("GetByID", ctx, uint(2)).Return(&model.User{ID: 2, Username: "bob", Status: 1}, nil)
+		s := newMFAService(repo, userRepo, rbac, audit, newFakeMFAChallengeStore(), zap.NewNop(), "trx-project")
+		return s, repo, rbac, audit
+	}
+
+	t.Run("Admin target requires superadmin", func(t *testing.T) {
+		s, repo, rbac, audit := setup()
+		rbac.On("GetUserRoles", ctx, uint(2)).Return([]*model.Role{{Name: model.RoleAdmin, Status: 1}}, nil)
+		rbac.On("GetUserRoles", ctx, uint(1)).Return([]*model.Role{{Name: model.RoleEditor, Status: 1}}, nil)
+
+		// 清除两步验证后只凭密码即可登录，同级管理员不能互相清除
+		assert.ErrorIs(t, s.Reset(ctx, 1, 2, ip), ErrTargetPrivileged)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		audit.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Regular user reset and audited", func(t *testing.T) {
+		s, repo, rbac, audit := setup()
+		rbac.On("GetUserRoles", ctx, uint(2)).Return([]*model.Role{}, nil)
+		repo.On("Delete", ctx, uint(2)).Return(nil)
+		audit.On("Record", ctx, mock.MatchedBy(func(log *model.AuditLog) bool {
+			return log.ActorID == 1 && log.Action == model.AuditActionMFAReset && log.TargetID == 2 && log.IP == ip
+		})).Return(nil)
+
+		require.NoError(t, s.Reset(ctx, 1, 2, ip))
+		repo.AssertExpectations(t)
+		audit.AssertExpectations(t)
+	})
+}
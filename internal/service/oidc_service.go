@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/cache"
+	"trx-project/pkg/config"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOIDCDisabled 未启用单点登录
+	ErrOIDCDisabled = errors.New("single sign-on is not enabled")
+	// ErrOIDCStateInvalid state 不存在、已过期、已被使用或不是由当前浏览器发起
+	ErrOIDCStateInvalid = errors.New("sso login request is invalid or expired")
+	// ErrOIDCLoginFailed 授权码兑换失败或 ID Token 校验失败
+	ErrOIDCLoginFailed = errors.New("sso login failed")
+	// ErrOIDCAccountConflict 邮箱或用户名已被本地账号使用，且未开启按邮箱关联
+	ErrOIDCAccountConflict = errors.New("an account with this email already exists")
+)
+
+// OIDCAuthorization 发往身份提供方的授权请求
+type OIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"` // 浏览器跳转的授权地址
+	State            string `json:"state"`             // 回调时原样带回，前端可以保存后与回调地址中的 state 比对
+	ExpiresIn        int64  `json:"expires_in"`        // 授权请求有效期（秒）
+	Binding          string `json:"-"`                 // 浏览器绑定值，由处理器写入 HttpOnly Cookie，回调时一并提交
+}
+
+// OIDCService 后台 OpenID Connect 单点登录服务
+// 使用授权码模式 + PKCE：state、nonce 和 code_verifier 保存在 Redis 中，每个授权请求只能使用一次；
+// state 与发起登录的浏览器绑定，他人的授权回调无法在当前浏览器完成登录（login CSRF）；
+// 首次登录时自动创建账号（JIT）并关联 subject，每次登录按配置的组映射同步角色
+type OIDCService interface {
+	// BeginLogin 生成授权地址和浏览器绑定值
+	BeginLogin(ctx context.Context) (*OIDCAuthorization, error)
+	// CompleteLogin 校验浏览器绑定值，兑换授权码并校验 ID Token，返回关联或新建的本地用户
+	// 账号未启用时返回 ErrUserInactive，不会同步角色
+	CompleteLogin(ctx context.Context, code, state, binding, ip string) (*model.User, error)
+}
+
+// oidcStateStore 授权请求存储，由 *cache.OIDCStateStore 实现
+type oidcStateStore interface {
+	Save(ctx context.Context, stateHash string, record *cache.OIDCStateRecord) error
+	Take(ctx context.Context, stateHash string) (*cache.OIDCStateRecord, error)
+}
+
+type oidcService struct {
+	repo        repository.IdentityRepository
+	userRepo    repository.UserRepository
+	rbacService RBACService
+	store       oidcStateStore
+	audit       AuditService
+	logger      *zap.Logger
+	cfg         config.OIDCConfig
+
+	// 身份提供方在第一次使用时发现，失败时下次重试
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// NewOIDCService 创建后台单点登录服务
+func NewOIDCService(
+	repo repository.IdentityRepository,
+	userRepo repository.UserRepository,
+	rbacService RBACService,
+	store *cache.OIDCStateStore,
+	audit AuditService,
+	logger *zap.Logger,
+	cfg *config.Config,
+) OIDCService {
+	return newOIDCService(repo, userRepo, rbacService, store, audit, logger, cfg)
+}
+
+func newOIDCService(
+	repo repository.IdentityRepository,
+	userRepo repository.UserRepository,
+	rbacService RBACService,
+	store oidcStateStore,
+	audit AuditService,
+	logger *zap.Logger,
+	cfg *config.Config,
+) OIDCService {
+	return &oidcService{
+		repo:        repo,
+		userRepo:    userRepo,
+		rbacService: rbacService,
+		store:       store,
+		audit:       audit,
+		logger:      logger,
+		cfg:         cfg.Auth.OIDC,
+	}
+}
+
+func (s *oidcService) BeginLogin(ctx context.Context) (*OIDCAuthorization, error) {
+	oauth, _, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	binding, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	ttl := s.cfg.StateTTL()
+	err = s.store.Save(ctx, hashToken(state), &cache.OIDCStateRecord{
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		BindingHash:  hashToken(binding),
+		ExpiresAt:    time.Now().Add(ttl),
+	})
+	if err != nil {
+		s.logger.Error("Failed to save oidc state", zap.Error(err))
+		return nil, err
+	}
+
+	return &OIDCAuthorization{
+		AuthorizationURL: oauth.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce)),
+		State:            state,
+		ExpiresIn:        int64(ttl.Seconds()),
+		Binding:          binding,
+	}, nil
+}
+
+func (s *oidcService) CompleteLogin(ctx context.Context, code, state, binding, ip string) (*model.User, error) {
+	oauth, verifier, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if code == "" || state == "" || binding == "" {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	record, err := s.store.Take(ctx, hashToken(state))
+	if err != nil {
+		if errors.Is(err, cache.ErrOIDCStateNotFound) {
+			return nil, ErrOIDCStateInvalid
+		}
+		s.logger.Error("Failed to get oidc state", zap.Error(err))
+		return nil, err
+	}
+	// 攻击者可以把自己的授权回调发给受害者，绑定值不一致说明不是当前浏览器发起的登录
+	if subtle.ConstantTimeCompare([]byte(hashToken(binding)), []byte(record.BindingHash)) != 1 {
+		s.logger.Warn("OIDC state is not bound to this browser")
+		return nil, ErrOIDCStateInvalid
+	}
+
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(record.CodeVerifier))
+	if err != nil {
+		s.logger.Warn("Failed to exchange oidc authorization code", zap.Error(err))
+		return nil, ErrOIDCLoginFailed
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		s.logger.Warn("Token response does not contain id_token")
+		return nil, ErrOIDCLoginFailed
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		s.logger.Warn("Failed to verify id token", zap.Error(err))
+		return nil, ErrOIDCLoginFailed
+	}
+	if idToken.Nonce != record.Nonce {
+		s.logger.Warn("ID token nonce mismatch", zap.String("subject", idToken.Subject))
+		return nil, ErrOIDCLoginFailed
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		s.logger.Warn("Failed to decode id token claims", zap.Error(err))
+		return nil, ErrOIDCLoginFailed
+	}
+	profile := s.parseProfile(idToken, claims)
+
+	user, err := s.resolveUser(ctx, profile, ip)
+	if err != nil {
+		return nil, err
+	}
+	// 被禁用的账号不能通过单点登录改变角色
+	if user.Status != 1 {
+		s.logger.Warn("SSO login rejected: user inactive", zap.Uint("user_id", user.ID))
+		return nil, ErrUserInactive
+	}
+
+	if err := s.syncRoles(ctx, user, profile, ip); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("SSO login verified",
+		zap.Uint("user_id", user.ID),
+		zap.String("subject", profile.subject),
+		zap.Strings("groups", profile.groups))
+	return user, nil
+}
+
+// client 发现身份提供方并返回 OAuth2 配置和 ID Token 校验器
+func (s *oidcService) client(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	if !s.cfg.Enabled || s.cfg.Issuer == "" || s.cfg.ClientID == "" {
+		return nil, nil, ErrOIDCDisabled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.provider == nil {
+		// 公钥集合会在之后的请求中按需刷新，不能绑定到当前请求的生命周期
+		provider, err := oidc.NewProvider(context.WithoutCancel(ctx), s.cfg.Issuer)
+		if err != nil {
+			s.logger.Error("Failed to discover oidc provider", zap.String("issuer", s.cfg.Issuer), zap.Error(err))
+			return nil, nil, fmt.Errorf("failed to discover oidc provider: %w", err)
+		}
+		s.provider = provider
+	}
+
+	oauth := &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		Endpoint:     s.provider.Endpoint(),
+		RedirectURL:  s.cfg.RedirectURL,
+		Scopes:       s.cfg.RequestScopes(),
+	}
+	return oauth, s.provider.Verifier(&oidc.Config{ClientID: s.cfg.ClientID}), nil
+}
+
+// oidcProfile ID Token 中与账号相关的信息
+type oidcProfile struct {
+	issuer        string
+	subject       string
+	email         string
+	emailVerified bool
+	username      string
+	groups        []string
+}
+
+func (s *oidcService) parseProfile(idToken *oidc.IDToken, claims map[string]interface{}) *oidcProfile {
+	profile := &oidcProfile{
+		issuer:  idToken.Issuer,
+		subject: idToken.Subject,
+	}
+	profile.email, _ = claims["email"].(string)
+	profile.emailVerified, _ = claims["email_verified"].(bool)
+	profile.username, _ = claims[s.cfg.UsernameClaimName()].(string)
+
+	// 组可能是字符串数组，也可能是单个字符串
+	switch groups := claims[s.cfg.GroupsClaimName()].(type) {
+	case []interface{}:
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				profile.groups = append(profile.groups, name)
+			}
+		}
+	case string:
+		profile.groups = []string{groups}
+	}
+	return profile
+}
+
+// resolveUser 找到 subject 关联的用户，首次登录时按邮箱关联已有账号或创建新账号
+func (s *oidcService) resolveUser(ctx context.Context, profile *oidcProfile, ip string) (*model.User, error) {
+	identity, err := s.repo.GetByProviderSubject(ctx, profile.issuer, profile.subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrUserInactive
+			}
+			s.logger.Error("Failed to get user", zap.Uint("user_id", identity.UserID), zap.Error(err))
+			return nil, err
+		}
+		if err := s.repo.TouchLogin(ctx, identity.ID, profile.email, time.Now()); err != nil {
+			s.logger.Error("Failed to update identity", zap.Uint("identity_id", identity.ID), zap.Error(err))
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("Failed to get identity", zap.Error(err))
+		return nil, err
+	}
+
+	if profile.email == "" {
+		s.logger.Warn("ID token does not contain email", zap.String("subject", profile.subject))
+		return nil, ErrOIDCLoginFailed
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, profile.email)
+	switch {
+	case err == nil:
+		// 只有身份提供方确认过邮箱时才关联已有账号，否则任何人都能通过修改邮箱接管账号
+		if !s.cfg.LinkByEmail || !profile.emailVerified || user.IsServiceAccount() {
+			s.logger.Warn("SSO login conflicts with local account",
+				zap.String("subject", profile.subject),
+				zap.Uint("user_id", user.ID))
+			return nil, ErrOIDCAccountConflict
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// 不会获得任何后台角色的用户不创建账号
+		if len(mappedGroupRoles(s.cfg.GroupRoles, profile.groups)) == 0 {
+			s.logger.Warn("SSO login rejected: no mapped role",
+				zap.String("subject", profile.subject),
+				zap.Strings("groups", profile.groups))
+			return nil, ErrAdminRoleRequired
+		}
+		if user, err = s.provisionUser(ctx, profile, ip); err != nil {
+			return nil, err
+		}
+	default:
+		s.logger.Error("Failed to get user", zap.Error(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	identity = &model.UserIdentity{
+		UserID:      user.ID,
+		Provider:    profile.issuer,
+		Subject:     profile.subject,
+		Email:       profile.email,
+		LastLoginAt: &now,
+	}
+	if err := s.repo.Create(ctx, identity); err != nil {
+		s.logger.Error("Failed to create identity", zap.Uint("user_id", user.ID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("SSO identity linked", zap.Uint("user_id", user.ID), zap.String("subject", profile.subject))
+	return user, nil
+}
+
+// provisionUser 首次登录时创建账号，账号没有本地密码，只能通过单点登录（或之后重置密码）登录
+func (s *oidcService) provisionUser(ctx context.Context, profile *oidcProfile, ip string) (*model.User, error) {
+	username, err := s.availableUsername(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Username:    username,
+		Email:       profile.email,
+		Status:      1,
+		AccountType: model.AccountTypeUser,
+	}
+	if profile.emailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		s.logger.Error("Failed to provision sso user", zap.String("username", username), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("SSO user provisioned", zap.Uint("user_id", user.ID), zap.String("username", username))
+	s.recordAudit(ctx, user.ID, model.AuditActionSSOUserProvisioned,
+		fmt.Sprintf("issuer=%s subject=%s username=%s", profile.issuer, profile.subject, username), ip)
+	return user, nil
+}
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// availableUsername 根据 claim 生成用户名，已被占用时追加 subject 的摘要
+func (s *oidcService) availableUsername(ctx context.Context, profile *oidcProfile) (string, error) {
+	base := profile.username
+	if base == "" {
+		base, _, _ = strings.Cut(profile.email, "@")
+	}
+	base = usernameInvalidChars.ReplaceAllString(base, "_")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if base == "" {
+		base = "sso"
+	}
+
+	sum := sha256.Sum256([]byte(profile.issuer + "|" + profile.subject))
+	for _, candidate := range []string{base, base + "_" + hex.EncodeToString(sum[:3])} {
+		_, err := s.userRepo.GetByUsername(ctx, candidate)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			s.logger.Error("Failed to get user", zap.Error(err))
+			return "", err
+		}
+	}
+	return "", ErrOIDCAccountConflict
+}
+
+// syncRoles 按组映射同步角色
+func (s *oidcService) syncRoles(ctx context.Context, user *model.User, profile *oidcProfile, ip string) error {
+	added, removed, err := syncGroupRoles(ctx, s.rbacService, s.logger, user.ID, s.cfg.GroupRoles, profile.groups)
+	if err != nil {
+		return err
+	}
+
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	s.logger.Info("SSO roles synced",
+		zap.Uint("user_id", user.ID),
+		zap.Strings("added", added),
+		zap.Strings("removed", removed))
+	s.recordAudit(ctx, user.ID, model.AuditActionSSORolesSynced,
+		fmt.Sprintf("added=%s removed=%s", strings.Join(added, ","), strings.Join(removed, ",")), ip)
+	return nil
+}
+
+func (s *oidcService) recordAudit(ctx context.Context, userID uint, action, detail, ip string) {
+	err := s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    userID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   userID,
+		Detail:     detail,
+		IP:         ip,
+	})
+	if err != nil {
+		s.logger.Error("Failed to record sso audit log", zap.String("action", action), zap.Error(err))
+	}
+}
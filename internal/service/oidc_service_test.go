@@ -0,0 +1,398 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/pkg/cache"
+	"trx-project/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MockIdentityRepository 模拟外部身份仓库
+type MockIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdentityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	args := m.Called(ctx, provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UserIdentity), args.Error(1)
+}
+
+func (m *MockIdentityRepository) TouchLogin(ctx context.Context, id uint, email string, loginAt time.Time) error {
+	args := m.Called(ctx, id, email, loginAt)
+	return args.Error(0)
+}
+
+// memoryOIDCStateStore 内存中的授权请求存储
+type memoryOIDCStateStore struct {
+	mu      sync.Mutex
+	records map[string]*cache.OIDCStateRecord
+}
+
+func (s *memoryOIDCStateStore) Save(ctx context.Context, stateHash string, record *cache.OIDCStateRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[stateHash] = record
+	return nil
+}
+
+func (s *memoryOIDCStateStore) Take(ctx context.Context, stateHash string) (*cache.OIDCStateRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[stateHash]
+	if !ok {
+		return nil, cache.ErrOIDCStateNotFound
+	}
+	delete(s.records, stateHash)
+	return record, nil
+}
+
+// mockIdP 本地模拟的 OpenID Connect 身份提供方，只实现发现、JWKS 和 token 端点
+// 浏览器在授权端点完成登录的过程由 authorize 模拟
+type mockIdP struct {
+	t        *testing.T
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+
+	mu    sync.Mutex
+	codes map[string]*mockAuthorization
+}
+
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+	claims    map[string]interface{}
+}
+
+func newMockIdP(t *testing.T, clientID string) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &mockIdP{t: t, key: key, clientID: clientID, codes: make(map[string]*mockAuthorization)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (p *mockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"issuer":                                p.server.URL,
+		"authorization_endpoint":                p.server.URL + "/authorize",
+		"token_endpoint":                        p.server.URL + "/token",
+		"jwks_uri":                              p.server.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *mockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize 模拟用户在身份提供方登录后的跳转，返回回调地址中的 code 和 state
+func (p *mockIdP) authorize(authorizationURL string, claims map[string]interface{}) (string, string) {
+	u, err := url.Parse(authorizationURL)
+	require.NoError(p.t, err)
+	query := u.Query()
+	require.Equal(p.t, p.server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	require.Equal(p.t, p.clientID, query.Get("client_id"))
+	require.Equal(p.t, "code", query.Get("response_type"))
+	require.Equal(p.t, "S256", query.Get("code_challenge_method"))
+	require.Contains(p.t, query.Get("scope"), "openid")
+
+	code := generateTestCode(p.t)
+	p.mu.Lock()
+	p.codes[code] = &mockAuthorization{
+		challenge: query.Get("code_challenge"),
+		nonce:     query.Get("nonce"),
+		claims:    claims,
+	}
+	p.mu.Unlock()
+	return code, query.Get("state")
+}
+
+func (p *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	authorization, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	// 校验 PKCE：code_verifier 的 S256 摘要必须与授权请求中的 code_challenge 一致
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != authorization.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"aud":   p.clientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": authorization.nonce,
+	}
+	for key, value := range authorization.claims {
+		claims[key] = value
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = "test-key"
+	signed, err := idToken.SignedString(p.key)
+	require.NoError(p.t, err)
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": "idp-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func generateTestCode(t *testing.T) string {
+	code, err := generateOpaqueToken()
+	require.NoError(t, err)
+	return code
+}
+
+func newTestOIDCService(idp *mockIdP, repo *MockIdentityRepository, userRepo *MockUserRepository, rbac *MockRBACService, audit *MockAuditService) OIDCService {
+	cfg := &config.Config{Auth: config.AuthConfig{OIDC: config.OIDCConfig{
+		Enabled:     true,
+		Issuer:      idp.server.URL,
+		ClientID:    idp.clientID,
+		RedirectURL: "http://localhost:3001/sso/callback",
+		GroupRoles: map[string][]string{
+			"trx-admins":   {"admin"},
+			"trx-auditors": {"auditor"},
+		},
+	}}}
+	store := &memoryOIDCStateStore{records: make(map[string]*cache.OIDCStateRecord)}
+	return newOIDCService(repo, userRepo, rbac, store, audit, zap.NewNop(), cfg)
+}
+
+func TestOIDCService_ProvisionAndSyncRoles(t *testing.T) {
+	ctx := context.Background()
+	idp := newMockIdP(t, "trx-backend")
+	repo := new(MockIdentityRepository)
+	userRepo := new(MockUserRepository)
+	rbac := new(MockRBACService)
+	audit := new(MockAuditService)
+	service := newTestOIDCService(idp, repo, userRepo, rbac, audit)
+
+	adminRole := &model.Role{ID: 2, Name: "admin", Status: 1}
+	auditorRole := &model.Role{ID: 3, Name: "auditor", Status: 1}
+	editorRole := &model.Role{ID: 4, Name: "editor", Status: 1}
+	audit.On("Record", ctx, mock.AnythingOfType("*model.AuditLog")).Return(nil)
+
+	// 首次登录：创建账号、关联 subject 并分配映射的角色
+	repo.On("GetByProviderSubject", ctx, idp.server.URL, "alice-sub").Return(nil, gorm.ErrRecordNotFound).Once()
+	userRepo.On("GetByEmail", ctx, "alice@example.com").Return(nil, gorm.ErrRecordNotFound)
+	userRepo.On("GetByUsername", ctx, "alice").Return(nil, gorm.ErrRecordNotFound)
+	var created *model.User
+	userRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*model.User)
+		created.ID = 7
+	}).Return(nil)
+	var identity *model.UserIdentity
+	repo.On("Create", ctx, mock.AnythingOfType("*model.UserIdentity")).Run(func(args mock.Arguments) {
+		identity = args.Get(1).(*model.UserIdentity)
+		identity.ID = 11
+	}).Return(nil)
+	rbac.On("GetUserRoles", ctx, uint(7)).Return([]*model.Role{}, nil).Once()
+	rbac.On("GetRoleByName", ctx, "admin").Return(adminRole, nil)
+	rbac.On("GetRoleByName", ctx, "auditor").Return(auditorRole, nil)
+	rbac.On("AssignRoleToUser", ctx, uint(7), uint(2)).Return(nil)
+	rbac.On("AssignRoleToUser", ctx, uint(7), uint(3)).Return(nil)
+
+	authorization, err := service.BeginLogin(ctx)
+	require.NoError(t, err)
+	code, state := idp.authorize(authorization.AuthorizationURL, map[string]interface{}{
+		"sub":                "alice-sub",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"preferred_username": "alice",
+		"groups":             []string{"trx-admins", "trx-auditors", "engineering"},
+	})
+	assert.Equal(t, authorization.State, state)
+
+	user, err := service.CompleteLogin(ctx, code, state, authorization.Binding, "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), user.ID)
+	assert.Equal(t, "alice", created.Username)
+	assert.Empty(t, created.Password)
+	assert.True(t, created.EmailVerified())
+	assert.Equal(t, idp.server.URL, identity.Provider)
+	assert.Equal(t, "alice-sub", identity.Subject)
+	rbac.AssertNumberOfCalls(t, "AssignRoleToUser", 2)
+
+	// 再次登录：按 subject 找到账号，移除不再映射的角色，手动分配的角色保持不变
+	identity.UserID = 7
+	repo.On("GetByProviderSubject", ctx, idp.server.URL, "alice-sub").Return(identity, nil).Once()
+	repo.On("TouchLogin", ctx, uint(11), "alice@example.com", mock.AnythingOfType("time.Time")).Return(nil)
+	userRepo.On("GetByID", ctx, uint(7)).Return(created, nil)
+	rbac.On("GetUserRoles", ctx, uint(7)).Return([]*model.Role{adminRole, auditorRole, editorRole}, nil).Once()
+	rbac.On("RemoveRoleFromUser", ctx, uint(7), uint(3)).Return(nil)
+
+	authorization, err = service.BeginLogin(ctx)
+	require.NoError(t, err)
+	code, state = idp.authorize(authorization.AuthorizationURL, map[string]interface{}{
+		"sub":    "alice-sub",
+		"email":  "alice@example.com",
+		"groups": "trx-admins",
+	})
+
+	user, err = service.CompleteLogin(ctx, code, state, authorization.Binding, "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), user.ID)
+	rbac.AssertNumberOfCalls(t, "RemoveRoleFromUser", 1)
+	rbac.AssertNumberOfCalls(t, "AssignRoleToUser", 2)
+	userRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestOIDCService_RejectsLogin(t *testing.T) {
+	ctx := context.Background()
+	idp := newMockIdP(t, "trx-backend")
+	repo := new(MockIdentityRepository)
+	userRepo := new(MockUserRepository)
+	service := newTestOIDCService(idp, repo, userRepo, new(MockRBACService), new(MockAuditService))
+
+	// 没有映射角色的用户不创建账号
+	repo.On("GetByProviderSubject", ctx, idp.server.URL, "bob-sub").Return(nil, gorm.ErrRecordNotFound)
+	userRepo.On("GetByEmail", ctx, "bob@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	authorization, err := service.BeginLogin(ctx)
+	require.NoError(t, err)
+	code, state := idp.authorize(authorization.AuthorizationURL, map[string]interface{}{
+		"sub":    "bob-sub",
+		"email":  "bob@example.com",
+		"groups": []string{"engineering"},
+	})
+	_, err = service.CompleteLogin(ctx, code, state, authorization.Binding, "")
+	assert.ErrorIs(t, err, ErrAdminRoleRequired)
+	userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	// state 只能使用一次
+	_, err = service.CompleteLogin(ctx, code, state, authorization.Binding, "")
+	assert.ErrorIs(t, err, ErrOIDCStateInvalid)
+
+	// 未开启按邮箱关联时，与本地账号邮箱冲突的登录被拒绝
+	local := &model.User{ID: 3, Username: "carol", Email: "carol@example.com", Status: 1}
+	repo.On("GetByProviderSubject", ctx, idp.server.URL, "carol-sub").Return(nil, gorm.ErrRecordNotFound)
+	userRepo.On("GetByEmail", ctx, local.Email).Return(local, nil)
+
+	authorization, err = service.BeginLogin(ctx)
+	require.NoError(t, err)
+	code, state = idp.authorize(authorization.AuthorizationURL, map[string]interface{}{
+		"sub":            "carol-sub",
+		"email":          local.Email,
+		"email_verified": true,
+		"groups":         []string{"trx-admins"},
+	})
+	_, err = service.CompleteLogin(ctx, code, state, authorization.Binding, "")
+	assert.ErrorIs(t, err, ErrOIDCAccountConflict)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestOIDCService_StateBoundToBrowser(t *testing.T) {
+	ctx := context.Background()
+	idp := newMockIdP(t, "trx-backend")
+	repo := new(MockIdentityRepository)
+	service := newTestOIDCService(idp, repo, new(MockUserRepository), new(MockRBACService), new(MockAuditService))
+
+	// 攻击者发起登录后把回调发给受害者，受害者的浏览器没有攻击者的绑定 Cookie
+	attacker, err := service.BeginLogin(ctx)
+	require.NoError(t, err)
+	victim, err := service.BeginLogin(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, attacker.Binding, victim.Binding)
+	code, state := idp.authorize(attacker.AuthorizationURL, map[string]interface{}{
+		"sub":    "mallory-sub",
+		"email":  "mallory@example.com",
+		"groups": []string{"trx-admins"},
+	})
+
+	_, err = service.CompleteLogin(ctx, code, state, victim.Binding, "")
+	assert.ErrorIs(t, err, ErrOIDCStateInvalid)
+	_, err = service.CompleteLogin(ctx, code, state, "", "")
+	assert.ErrorIs(t, err, ErrOIDCStateInvalid)
+	repo.AssertNotCalled(t, "GetByProviderSubject", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOIDCService_InactiveUserRolesNotSynced(t *testing.T) {
+	ctx := context.Background()
+	idp := newMockIdP(t, "trx-backend")
+	repo := new(MockIdentityRepository)
+	userRepo := new(MockUserRepository)
+	rbac := new(MockRBACService)
+	service := newTestOIDCService(idp, repo, userRepo, rbac, new(MockAuditService))
+
+	disabled := &model.User{ID: 5, Username: "dave", Email: "dave@example.com", Status: 0}
+	repo.On("GetByProviderSubject", ctx, idp.server.URL, "dave-sub").Return(&model.UserIdentity{ID: 12, UserID: disabled.ID}, nil)
+	repo.On("TouchLogin", ctx, uint(12), disabled.Email, mock.AnythingOfType("time.Time")).Return(nil)
+	userRepo.On("GetByID", ctx, disabled.ID).Return(disabled, nil)
+
+	authorization, err := service.BeginLogin(ctx)
+	require.NoError(t, err)
+	code, state := idp.authorize(authorization.AuthorizationURL, map[string]interface{}{
+		"sub":    "dave-sub",
+		"email":  disabled.Email,
+		"groups": []string{"trx-admins", "trx-auditors"},
+	})
+
+	_, err = service.CompleteLogin(ctx, code, state, authorization.Binding, "")
+	assert.ErrorIs(t, err, ErrUserInactive)
+	rbac.AssertNotCalled(t, "GetUserRoles", mock.Anything, mock.Anything)
+	rbac.AssertNotCalled(t, "AssignRoleToUser", mock.Anything, mock.Anything, mock.Anything)
+}
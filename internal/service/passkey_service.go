@@ -0,0 +1,537 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/cache"
+	"trx-project/pkg/config"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPasskeyDisabled 未配置 WebAuthn 依赖方
+	ErrPasskeyDisabled = errors.New("passkey login is not configured")
+	// ErrPasskeyChallengeInvalid 挑战不存在、已过期、已使用或不属于当前用户
+	ErrPasskeyChallengeInvalid = errors.New("passkey challenge is invalid or expired")
+	// ErrPasskeyVerificationFailed 验证器返回的数据校验失败
+	ErrPasskeyVerificationFailed = errors.New("passkey verification failed")
+	// ErrPasskeyAlreadyRegistered 该通行密钥已经注册过
+	ErrPasskeyAlreadyRegistered = errors.New("passkey already registered")
+	// ErrPasskeyNotFound 通行密钥不存在或不属于该用户
+	ErrPasskeyNotFound = errors.New("passkey not found")
+)
+
+// PasskeyCeremony 进行中的注册或登录仪式，Options 原样传给浏览器的 navigator.credentials.create / get
+type PasskeyCeremony struct {
+	ChallengeID string      `json:"challenge_id"`
+	Options     interface{} `json:"options"`
+	ExpiresIn   int64       `json:"expires_in"` // 挑战有效期（秒）
+}
+
+// PasskeyService 管理员通行密钥（WebAuthn）服务
+// 通行密钥必须是可发现凭证并要求用户验证（PIN 或生物识别），登录时不需要输入用户名；
+// 挑战保存在 Redis 中，每个挑战只能使用一次
+type PasskeyService interface {
+	// BeginRegistration 为用户发起注册仪式，已注册的通行密钥会被排除
+	BeginRegistration(ctx context.Context, userID uint) (*PasskeyCeremony, error)
+	// FinishRegistration 校验验证器返回的注册数据并保存通行密钥
+	FinishRegistration(ctx context.Context, userID uint, challengeID, name string, response []byte, ip string) (*model.WebAuthnCredential, error)
+	// ListPasskeys 列出用户的通行密钥
+	ListPasskeys(ctx context.Context, userID uint) ([]*model.WebAuthnCredential, error)
+	// DeletePasskey 删除通行密钥并记录审计日志，actorID 为操作人，管理员删除其他用户的通行密钥时与 userID 不同
+	// 目标用户拥有管理员不具备的后台角色或权限时返回 ErrTargetPrivileged
+	DeletePasskey(ctx context.Context, actorID, userID, id uint, ip string) error
+	// BeginLogin 发起登录仪式
+	BeginLogin(ctx context.Context) (*PasskeyCeremony, error)
+	// FinishLogin 校验验证器返回的签名，返回通行密钥所属的用户
+	FinishLogin(ctx context.Context, challengeID string, response []byte) (*model.User, error)
+}
+
+// webAuthnChallengeStore 挑战存储，由 *cache.WebAuthnChallengeStore 实现
+type webAuthnChallengeStore interface {
+	Save(ctx context.Context, challengeID string, record *cache.WebAuthnChallengeRecord) error
+	Take(ctx context.Context, challengeID string) (*cache.WebAuthnChallengeRecord, error)
+}
+
+type passkeyService struct {
+	repo        repository.WebAuthnRepository
+	userRepo    repository.UserRepository
+	rbacService RBACService
+	store       webAuthnChallengeStore
+	audit       AuditService
+	logger      *zap.Logger
+	webauthn    *webauthn.WebAuthn
+	ttl         time.Duration
+}
+
+// NewPasskeyService 创建管理员通行密钥服务，未配置 rp_id 时所有操作返回 ErrPasskeyDisabled
+func NewPasskeyService(
+	repo repository.WebAuthnRepository,
+	userRepo repository.UserRepository,
+	rbacService RBACService,
+	store *cache.WebAuthnChallengeStore,
+	audit AuditService,
+	logger *zap.Logger,
+	cfg *config.Config,
+) (PasskeyService, error) {
+	return newPasskeyService(repo, userRepo, rbacService, store, audit, logger, cfg)
+}
+
+func newPasskeyService(
+	repo repository.WebAuthnRepository,
+	userRepo repository.UserRepository,
+	rbacService RBACService,
+	store webAuthnChallengeStore,
+	audit AuditService,
+	logger *zap.Logger,
+	cfg *config.Config,
+) (PasskeyService, error) {
+	s := &passkeyService{
+		repo:        repo,
+		userRepo:    userRepo,
+		rbacService: rbacService,
+		store:       store,
+		audit:       audit,
+		logger:      logger,
+		ttl:         cfg.Auth.WebAuthn.ChallengeTTL(),
+	}
+
+	rp := cfg.Auth.WebAuthn
+	if rp.RPID == "" {
+		logger.Info("WebAuthn relying party is not configured, passkey login disabled")
+		return s, nil
+	}
+
+	displayName := rp.RPDisplayName
+	if displayName == "" {
+		displayName = "trx-project"
+	}
+	timeout := webauthn.TimeoutConfig{Enforce: true, Timeout: s.ttl, TimeoutUVD: s.ttl}
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          rp.RPID,
+		RPDisplayName: displayName,
+		RPOrigins:     rp.RPOrigins,
+		Timeouts:      webauthn.TimeoutsConfig{Login: timeout, Registration: timeout},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid webauthn config: %w", err)
+	}
+	s.webauthn = wa
+	return s, nil
+}
+
+func (s *passkeyService) BeginRegistration(ctx context.Context, userID uint) (*PasskeyCeremony, error) {
+	if s.webauthn == nil {
+		return nil, ErrPasskeyDisabled
+	}
+
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	creation, session, err := s.webauthn.BeginRegistration(user,
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			UserVerification:   protocol.VerificationRequired,
+		}),
+		webauthn.WithExclusions(webauthn.Credentials(user.credentials).CredentialDescriptors()),
+	)
+	if err != nil {
+		s.logger.Error("Failed to begin passkey registration", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	return s.saveCeremony(ctx, userID, cache.WebAuthnCeremonyRegistration, session, creation)
+}
+
+func (s *passkeyService) FinishRegistration(ctx context.Context, userID uint, challengeID, name string, response []byte, ip string) (*model.WebAuthnCredential, error) {
+	if s.webauthn == nil {
+		return nil, ErrPasskeyDisabled
+	}
+
+	session, err := s.takeCeremony(ctx, challengeID, cache.WebAuthnCeremonyRegistration, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		s.logger.Warn("Invalid passkey registration response", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, ErrPasskeyVerificationFailed
+	}
+	credential, err := s.webauthn.CreateCredential(user, *session, parsed)
+	if err != nil {
+		s.logger.Warn("Passkey registration verification failed", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, ErrPasskeyVerificationFailed
+	}
+
+	credentialID := encodeCredentialID(credential.ID)
+	if _, err := s.repo.GetByCredentialID(ctx, credentialID); err == nil {
+		return nil, ErrPasskeyAlreadyRegistered
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("Failed to get passkey", zap.Error(err))
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+	aaguid := ""
+	if id, err := uuid.FromBytes(credential.Authenticator.AAGUID); err == nil {
+		aaguid = id.String()
+	}
+
+	record := &model.WebAuthnCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    credentialID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          aaguid,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+	if err := s.repo.Create(ctx, record); err != nil {
+		s.logger.Error("Failed to create passkey", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Passkey registered", zap.Uint("user_id", userID), zap.Uint("passkey_id", record.ID))
+	s.recordAudit(ctx, userID, model.AuditActionPasskeyRegistered, userID, record, ip)
+	return record, nil
+}
+
+func (s *passkeyService) ListPasskeys(ctx context.Context, userID uint) ([]*model.WebAuthnCredential, error) {
+	credentials, err := s.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to list passkeys", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func (s *passkeyService) DeletePasskey(ctx context.Context, actorID, userID, id uint, ip string) error {
+	// 与重置密码和两步验证相同，管理员不能操作权限更高的用户的凭证
+	if actorID != userID {
+		if err := checkTargetPrivileges(ctx, s.rbacService, actorID, userID); err != nil {
+			if errors.Is(err, ErrTargetPrivileged) {
+				s.logger.Warn("Admin passkey removal rejected: target is more privileged",
+					zap.Uint("admin_id", actorID),
+					zap.Uint("user_id", userID))
+			} else {
+				s.logger.Error("Failed to check target privileges", zap.Uint("user_id", userID), zap.Error(err))
+			}
+			return err
+		}
+	}
+
+	credentials, err := s.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to list passkeys", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+	var credential *model.WebAuthnCredential
+	for _, item := range credentials {
+		if item.ID == id {
+			credential = item
+			break
+		}
+	}
+	if credential == nil {
+		return ErrPasskeyNotFound
+	}
+
+	deleted, err := s.repo.Delete(ctx, userID, id)
+	if err != nil {
+		s.logger.Error("Failed to delete passkey", zap.Uint("user_id", userID), zap.Uint("passkey_id", id), zap.Error(err))
+		return err
+	}
+	if !deleted {
+		return ErrPasskeyNotFound
+	}
+
+	s.logger.Info("Passkey removed",
+		zap.Uint("actor_id", actorID),
+		zap.Uint("user_id", userID),
+		zap.Uint("passkey_id", id))
+	s.recordAudit(ctx, actorID, model.AuditActionPasskeyRemoved, userID, credential, ip)
+	return nil
+}
+
+func (s *passkeyService) BeginLogin(ctx context.Context) (*PasskeyCeremony, error) {
+	if s.webauthn == nil {
+		return nil, ErrPasskeyDisabled
+	}
+
+	assertion, session, err := s.webauthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		s.logger.Error("Failed to begin passkey login", zap.Error(err))
+		return nil, err
+	}
+
+	return s.saveCeremony(ctx, 0, cache.WebAuthnCeremonyLogin, session, assertion)
+}
+
+func (s *passkeyService) FinishLogin(ctx context.Context, challengeID string, response []byte) (*model.User, error) {
+	if s.webauthn == nil {
+		return nil, ErrPasskeyDisabled
+	}
+
+	session, err := s.takeCeremony(ctx, challengeID, cache.WebAuthnCeremonyLogin, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		s.logger.Warn("Invalid passkey login response", zap.Error(err))
+		return nil, ErrPasskeyVerificationFailed
+	}
+
+	// 通过凭证 ID 找到所属用户，并确认 user handle 与之一致
+	var owner *passkeyUser
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		record, err := s.repo.GetByCredentialID(ctx, encodeCredentialID(rawID))
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(userHandle, passkeyUserHandle(record.UserID)) {
+			return nil, errors.New("user handle mismatch")
+		}
+		owner, err = s.loadUser(ctx, record.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return owner, nil
+	}
+
+	_, credential, err := s.webauthn.ValidatePasskeyLogin(handler, *session, parsed)
+	if err != nil {
+		s.logger.Warn("Passkey login verification failed", zap.Error(err))
+		return nil, ErrPasskeyVerificationFailed
+	}
+
+	// 签名计数器没有增长说明凭证可能被复制
+	if credential.Authenticator.CloneWarning {
+		s.logger.Warn("Passkey sign count did not increase, possible cloned authenticator",
+			zap.Uint("user_id", owner.user.ID),
+			zap.String("credential_id", encodeCredentialID(credential.ID)))
+		return nil, ErrPasskeyVerificationFailed
+	}
+
+	record := owner.record(credential.ID)
+	if err := s.repo.UpdateUsage(ctx, record.ID, credential.Authenticator.SignCount, credential.Flags.BackupState, time.Now()); err != nil {
+		s.logger.Error("Failed to update passkey usage", zap.Uint("passkey_id", record.ID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Passkey assertion verified", zap.Uint("user_id", owner.user.ID), zap.Uint("passkey_id", record.ID))
+	return owner.user, nil
+}
+
+// saveCeremony 保存仪式的会话数据并返回挑战
+func (s *passkeyService) saveCeremony(ctx context.Context, userID uint, ceremony string, session *webauthn.SessionData, options interface{}) (*PasskeyCeremony, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webauthn session: %w", err)
+	}
+
+	challengeID, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	err = s.store.Save(ctx, hashToken(challengeID), &cache.WebAuthnChallengeRecord{
+		UserID:    userID,
+		Ceremony:  ceremony,
+		Session:   data,
+		ExpiresAt: time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		s.logger.Error("Failed to save webauthn challenge", zap.String("ceremony", ceremony), zap.Error(err))
+		return nil, err
+	}
+
+	return &PasskeyCeremony{
+		ChallengeID: challengeID,
+		Options:     options,
+		ExpiresIn:   int64(s.ttl.Seconds()),
+	}, nil
+}
+
+// takeCeremony 取出挑战，挑战只能由发起的用户在同一类仪式中使用一次
+func (s *passkeyService) takeCeremony(ctx context.Context, challengeID, ceremony string, userID uint) (*webauthn.SessionData, error) {
+	if challengeID == "" {
+		return nil, ErrPasskeyChallengeInvalid
+	}
+
+	record, err := s.store.Take(ctx, hashToken(challengeID))
+	if err != nil {
+		if errors.Is(err, cache.ErrWebAuthnChallengeNotFound) {
+			return nil, ErrPasskeyChallengeInvalid
+		}
+		s.logger.Error("Failed to get webauthn challenge", zap.Error(err))
+		return nil, err
+	}
+	if record.Ceremony != ceremony || record.UserID != userID {
+		return nil, ErrPasskeyChallengeInvalid
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(record.Session, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webauthn session: %w", err)
+	}
+	return &session, nil
+}
+
+// loadUser 加载用户及其通行密钥
+func (s *passkeyService) loadUser(ctx context.Context, userID uint) (*passkeyUser, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		s.logger.Error("Failed to get user", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	records, err := s.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to list passkeys", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	owner := &passkeyUser{user: user, records: records}
+	for _, record := range records {
+		credential, err := toWebAuthnCredential(record)
+		if err != nil {
+			s.logger.Warn("Skipping invalid passkey", zap.Uint("passkey_id", record.ID), zap.Error(err))
+			continue
+		}
+		owner.credentials = append(owner.credentials, credential)
+	}
+	return owner, nil
+}
+
+func (s *passkeyService) recordAudit(ctx context.Context, actorID uint, action string, userID uint, credential *model.WebAuthnCredential, ip string) {
+	err := s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   userID,
+		Detail:     fmt.Sprintf("passkey_id=%d name=%s", credential.ID, credential.Name),
+		IP:         ip,
+	})
+	if err != nil {
+		s.logger.Error("Failed to record passkey audit log", zap.String("action", action), zap.Error(err))
+	}
+}
+
+// passkeyUser 实现 webauthn.User
+type passkeyUser struct {
+	user        *model.User
+	records     []*model.WebAuthnCredential
+	credentials []webauthn.Credential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return passkeyUserHandle(u.user.ID)
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Username
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	return u.user.Username
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// record 根据凭证 ID 找到数据库记录
+func (u *passkeyUser) record(credentialID []byte) *model.WebAuthnCredential {
+	id := encodeCredentialID(credentialID)
+	for _, record := range u.records {
+		if record.CredentialID == id {
+			return record
+		}
+	}
+	return nil
+}
+
+// passkeyUserHandle 用户在验证器中的 user handle，由用户 ID 生成，不包含用户名等个人信息
+func passkeyUserHandle(userID uint) []byte {
+	handle := make([]byte, 8)
+	binary.BigEndian.PutUint64(handle, uint64(userID))
+	return handle
+}
+
+// encodeCredentialID 凭证 ID 以 base64url 保存
+func encodeCredentialID(id []byte) string {
+	return base64.RawURLEncoding.EncodeToString(id)
+}
+
+// toWebAuthnCredential 将数据库记录转换为 WebAuthn 库的凭证
+func toWebAuthnCredential(record *model.WebAuthnCredential) (webauthn.Credential, error) {
+	id, err := base64.RawURLEncoding.DecodeString(record.CredentialID)
+	if err != nil {
+		return webauthn.Credential{}, err
+	}
+
+	var transports []protocol.AuthenticatorTransport
+	for _, transport := range strings.Split(record.Transports, ",") {
+		if transport != "" {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+	}
+	var aaguid []byte
+	if parsed, err := uuid.Parse(record.AAGUID); err == nil {
+		aaguid = parsed[:]
+	}
+
+	return webauthn.Credential{
+		ID:              id,
+		PublicKey:       record.PublicKey,
+		AttestationType: record.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			UserPresent:    true,
+			UserVerified:   true,
+			BackupEligible: record.BackupEligible,
+			BackupState:    record.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    aaguid,
+			SignCount: record.SignCount,
+		},
+	}, nil
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/pkg/cache"
+	"trx-project/pkg/config"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MockWebAuthnRepository 模拟通行密钥仓库
+type MockWebAuthnRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebAuthnRepository) Create(ctx context.Context, credential *model.WebAuthnCredential) error {
+	args := m.Called(ctx, credential)
+	return args.Error(0)
+}
+
+func (m *MockWebAuthnRepository) ListByUserID(ctx context.Context, userID uint) ([]*model.WebAuthnCredential, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*model.WebAuthnCredential), args.Error(1)
+}
+
+func (m *MockWebAuthnRepository) GetByCredentialID(ctx context.Context, credentialID string) (*model.WebAuthnCredential, error) {
+	args := m.Called(ctx, credentialID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebAuthnCredential), args.Error(1)
+}
+
+func (m *MockWebAuthnRepository) UpdateUsage(ctx context.Context, id uint, signCount uint32, backupState bool, usedAt time.Time) error {
+	args := m.Called(ctx, id, signCount, backupState, usedAt)
+	return args.Error(0)
+}
+
+func (m *MockWebAuthnRepository) Delete(ctx context.Context, userID, id uint) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// memoryChallengeStore 内存中的挑战存储
+type memoryChallengeStore struct {
+	mu      sync.Mutex
+	records map[string]*cache.WebAuthnChallengeRecord
+}
+
+func (s *memoryChallengeStore) Save(ctx context.Context, challengeID string, record *cache.WebAuthnChallengeRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[challengeID] = record
+	return nil
+}
+
+func (s *memoryChallengeStore) Take(ctx context.Context, challengeID string) (*cache.WebAuthnChallengeRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[challengeID]
+	if !ok {
+		return nil, cache.ErrWebAuthnChallengeNotFound
+	}
+	delete(s.records, challengeID)
+	return record, nil
+}
+
+// softAuthenticator 软件实现的验证器，使用 P-256 密钥和 none 证明
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	rpID         string
+	origin       string
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T, rpID, origin string) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+	return &softAuthenticator{key: key, credentialID: credentialID, rpID: rpID, origin: origin}
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremony string, challenge protocol.URLEncodedBase64) []byte {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.origin,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+// authData 生成验证器数据，flags 固定为 UP|UV
+func (a *softAuthenticator) authData(attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	flags := byte(protocol.FlagUserPresent | protocol.FlagUserVerified)
+	if attested != nil {
+		flags |= byte(protocol.FlagAttestedCredentialData)
+	}
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+// create 模拟 navigator.credentials.create
+func (a *softAuthenticator) create(t *testing.T, ceremony *PasskeyCeremony) []byte {
+	options := ceremony.Options.(*protocol.CredentialCreation).Response
+	a.userHandle = options.User.ID.(protocol.URLEncodedBase64)
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(t, err)
+
+	attested := make([]byte, 16) // AAGUID 全零
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(attested),
+	})
+	require.NoError(t, err)
+
+	return a.credential(t, map[string]interface{}{
+		"clientDataJSON":    a.clientData(t, "webauthn.create", options.Challenge),
+		"attestationObject": attestation,
+		"transports":        []string{"internal"},
+	})
+}
+
+// get 模拟 navigator.credentials.get，每次调用签名计数器加一
+func (a *softAuthenticator) get(t *testing.T, ceremony *PasskeyCeremony) []byte {
+	a.signCount++
+	return a.sign(t, ceremony)
+}
+
+// sign 使用当前签名计数器生成断言
+func (a *softAuthenticator) sign(t *testing.T, ceremony *PasskeyCeremony) []byte {
+	options := ceremony.Options.(*protocol.CredentialAssertion).Response
+	clientData := a.clientData(t, "webauthn.get", options.Challenge)
+	authData := a.authData(nil)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	return a.credential(t, map[string]interface{}{
+		"clientDataJSON":    clientData,
+		"authenticatorData": authData,
+		"signature":         signature,
+		"userHandle":        a.userHandle,
+	})
+}
+
+func (a *softAuthenticator) credential(t *testing.T, response map[string]interface{}) []byte {
+	encoded := make(map[string]interface{}, len(response))
+	for key, value := range response {
+		if raw, ok := value.([]byte); ok {
+			value = base64.RawURLEncoding.EncodeToString(raw)
+		}
+		encoded[key] = value
+	}
+
+	id := base64.RawURLEncoding.EncodeToString(a.credentialID)
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       id,
+		"rawId":    id,
+		"type":     "public-key",
+		"response": encoded,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func newTestPasskeyService(t *testing.T, repo *MockWebAuthnRepository, userRepo *MockUserRepository, rbac *MockRBACService, audit *MockAuditService) PasskeyService {
+	cfg := &config.Config{Auth: config.AuthConfig{WebAuthn: config.WebAuthnConfig{
+		RPID:      "localhost",
+		RPOrigins: []string{"http://localhost:3001"},
+	}}}
+	store := &memoryChallengeStore{records: map[string]*cache.WebAuthnChallengeRecord{}}
+	service, err := newPasskeyService(repo, userRepo, rbac, store, audit, zap.NewNop(), cfg)
+	require.NoError(t, err)
+	return service
+}
+
+func TestPasskeyService_RegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockWebAuthnRepository)
+	userRepo := new(MockUserRepository)
+	audit := new(MockAuditService)
+	service := newTestPasskeyService(t, repo, userRepo, new(MockRBACService), audit)
+	authenticator := newSoftAuthenticator(t, "localhost", "http://localhost:3001")
+
+	user := &model.User{ID: 1, Username: "admin", Status: 1}
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+	// 注册
+	repo.On("ListByUserID", ctx, user.ID).Return([]*model.WebAuthnCredential{}, nil).Twice()
+	repo.On("GetByCredentialID", ctx, encodeCredentialID(authenticator.credentialID)).Return(nil, gorm.ErrRecordNotFound).Once()
+	var stored *model.WebAuthnCredential
+	repo.On("Create", ctx, mock.AnythingOfType("*model.WebAuthnCredential")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*model.WebAuthnCredential)
+		stored.ID = 7
+	}).Return(nil)
+	audit.On("Record", ctx, mock.MatchedBy(func(log *model.AuditLog) bool {
+		return log.Action == model.AuditActionPasskeyRegistered && log.ActorID == 1
+	})).Return(nil)
+
+	ceremony, err := service.BeginRegistration(ctx, user.ID)
+	require.NoError(t, err)
+	response := authenticator.create(t, ceremony)
+
+	passkey, err := service.FinishRegistration(ctx, user.ID, ceremony.ChallengeID, "YubiKey", response, "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "YubiKey", passkey.Name)
+	assert.Equal(t, "internal", passkey.Transports)
+	assert.Equal(t, encodeCredentialID(authenticator.credentialID), stored.CredentialID)
+	assert.NotEmpty(t, stored.PublicKey)
+
+	// 挑战只能使用一次
+	_, err = service.FinishRegistration(ctx, user.ID, ceremony.ChallengeID, "YubiKey", response, "")
+	assert.ErrorIs(t, err, ErrPasskeyChallengeInvalid)
+
+	// 登录
+	repo.On("GetByCredentialID", ctx, stored.CredentialID).Return(stored, nil)
+	repo.On("ListByUserID", ctx, user.ID).Return([]*model.WebAuthnCredential{stored}, nil)
+	repo.On("UpdateUsage", ctx, uint(7), uint32(1), false, mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		stored.SignCount = args.Get(2).(uint32)
+	}).Return(nil).Once()
+
+	ceremony, err = service.BeginLogin(ctx)
+	require.NoError(t, err)
+	loggedIn, err := service.FinishLogin(ctx, ceremony.ChallengeID, authenticator.get(t, ceremony))
+	require.NoError(t, err)
+	assert.Equal(t, user, loggedIn)
+
+	// 签名计数器没有增长，视为凭证被复制
+	ceremony, err = service.BeginLogin(ctx)
+	require.NoError(t, err)
+	_, err = service.FinishLogin(ctx, ceremony.ChallengeID, authenticator.sign(t, ceremony))
+	assert.ErrorIs(t, err, ErrPasskeyVerificationFailed)
+
+	// 其他密钥签名的断言无效
+	ceremony, err = service.BeginLogin(ctx)
+	require.NoError(t, err)
+	other := newSoftAuthenticator(t, "localhost", "http://localhost:3001")
+	other.credentialID, other.userHandle = authenticator.credentialID, authenticator.userHandle
+	_, err = service.FinishLogin(ctx, ceremony.ChallengeID, other.get(t, ceremony))
+	assert.ErrorIs(t, err, ErrPasskeyVerificationFailed)
+
+	repo.AssertNumberOfCalls(t, "UpdateUsage", 1)
+	audit.AssertExpectations(t)
+}
+
+func TestPasskeyService_DeletePasskey(t *testing.T) {
+	ctx := context.Background()
+	ip := "192.0.2.1"
+	passkey := &model.WebAuthnCredential{ID: 7, UserID: 2, Name: "YubiKey"}
+
+	setup := func(t *testing.T) (PasskeyService, *MockWebAuthnRepository, *MockRBACService, *MockAuditService) {
+		repo, rbac, audit := new(MockWebAuthnRepository), new(MockRBACService), new(MockAuditService)
+		return newTestPasskeyService(t, repo, new(MockUserRepository), rbac, audit), repo, rbac, audit
+	}
+
+	t.Run("Admin target requires superadmin", func(t *testing.T) {
+		service, repo, rbac, audit := setup(t)
+		rbac.On("GetUserRoles", ctx, uint(2)).Return([]*model.Role{{Name: model.RoleAdmin, Status: 1}}, nil)
+		rbac.On("GetUserRoles", ctx, uint(1)).Return([]*model.Role{{Name: model.RoleEditor, Status: 1}}, nil)
+
+		assert.ErrorIs(t, service.DeletePasskey(ctx, 1, 2, 7, ip), ErrTargetPrivileged)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+		audit.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Regular user removed and audited", func(t *testing.T) {
+		service, repo, rbac, audit := setup(t)
+		rbac.On("GetUserRoles", ctx, uint(2)).Return([]*model.Role{}, nil)
+		repo.On("ListByUserID", ctx, uint(2)).Return([]*model.WebAuthnCredential{passkey}, nil)
+		repo.On("Delete", ctx, uint(2), uint(7)).Return(true, nil)
+		audit.On("Record", ctx, mock.MatchedBy(func(log *model.AuditLog) bool {
+			return log.Action == model.AuditActionPasskeyRemoved && log.ActorID == 1 && log.TargetID == 2 && log.IP == ip
+		})).Return(nil)
+
+		// 不属于该用户的通行密钥
+		assert.ErrorIs(t, service.DeletePasskey(ctx, 1, 2, 8, ip), ErrPasskeyNotFound)
+
+		require.NoError(t, service.DeletePasskey(ctx, 1, 2, 7, ip))
+		repo.AssertNumberOfCalls(t, "Delete", 1)
+		audit.AssertExpectations(t)
+	})
+
+	t.Run("Own passkey skips privilege check", func(t *testing.T) {
+		service, repo, rbac, audit := setup(t)
+		repo.On("ListByUserID", ctx, uint(2)).Return([]*model.WebAuthnCredential{passkey}, nil)
+		repo.On("Delete", ctx, uint(2), uint(7)).Return(true, nil)
+		audit.On("Record", ctx, mock.Anything).Return(nil)
+
+		require.NoError(t, service.DeletePasskey(ctx, 2, 2, 7, ip))
+		rbac.AssertNotCalled(t, "GetUserRoles", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/config"
+	"trx-project/pkg/passhash"
+	"trx-project/pkg/pwned"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// 密码策略违规码
+const (
+	PasswordTooShort         = "password_too_short"
+	PasswordTooLong          = "password_too_long"
+	PasswordMissingUppercase = "password_missing_uppercase"
+	PasswordMissingLowercase = "password_missing_lowercase"
+	PasswordMissingDigit     = "password_missing_digit"
+	PasswordMissingSymbol    = "password_missing_symbol"
+	PasswordContainsUserInfo = "password_contains_user_info"
+	PasswordReused           = "password_reused"
+	PasswordBreached         = "password_breached"
+)
+
+// 用户名、邮箱名至少这么长时才检查密码是否包含它们，避免过短的片段误伤
+const minUserInfoLength = 3
+
+var (
+	// ErrPasswordPolicy 密码不符合密码策略，具体违规项见 PasswordPolicyError
+	ErrPasswordPolicy = errors.New("password does not meet the password policy")
+)
+
+// PasswordViolation 一项密码策略违规
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError 密码不符合策略，包含全部违规项，errors.Is(err, ErrPasswordPolicy) 为 true
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrPasswordPolicy.Error(), strings.Join(messages, "; "))
+}
+
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrPasswordPolicy
+}
+
+// PasswordPolicyService 密码策略服务，注册和设置新密码前校验长度、字符类型、是否包含用户信息、
+// 是否与最近使用过的密码相同以及是否出现在泄露密码列表中
+type PasswordPolicyService interface {
+	// Validate 校验新密码，不符合时返回 *PasswordPolicyError；user 为注册中的用户时 ID 为 0，不检查历史密码
+	Validate(ctx context.Context, user *model.User, password string) error
+	// Remember 密码修改后保存被替换的密码哈希，oldHash 为修改前的哈希
+	Remember(ctx context.Context, userID uint, oldHash string) error
+}
+
+type passwordPolicyService struct {
+	historyRepo repository.PasswordHistoryRepository
+	hasher      *passhash.Hasher
+	breached    *pwned.List
+	logger      *zap.Logger
+	policy      config.PasswordPolicyConfig
+}
+
+// NewPasswordPolicyService 创建密码策略服务，配置了泄露密码列表时在启动时加载
+func NewPasswordPolicyService(historyRepo repository.PasswordHistoryRepository, hasher *passhash.Hasher, logger *zap.Logger, cfg *config.Config) (PasswordPolicyService, error) {
+	policy := cfg.Auth.PasswordPolicy
+	if policy.MinimumLength() > policy.MaximumLength() {
+		return nil, fmt.Errorf("password policy min_length %d exceeds max_length %d", policy.MinimumLength(), policy.MaximumLength())
+	}
+
+	s := &passwordPolicyService{
+		historyRepo: historyRepo,
+		hasher:      hasher,
+		logger:      logger,
+		policy:      policy,
+	}
+	if policy.BreachedPasswordsFile != "" {
+		list, err := pwned.LoadFile(policy.BreachedPasswordsFile)
+		if err != nil {
+			return nil, err
+		}
+		s.breached = list
+		logger.Info("Breached password list loaded",
+			zap.String("file", policy.BreachedPasswordsFile),
+			zap.Int("passwords", list.Len()))
+	}
+	return s, nil
+}
+
+func (s *passwordPolicyService) Validate(ctx context.Context, user *model.User, password string) error {
+	var violations []PasswordViolation
+	violate := func(code, format string, args ...interface{}) {
+		violations = append(violations, PasswordViolation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if minLength := s.policy.MinimumLength(); length < minLength {
+		violate(PasswordTooShort, "password must be at least %d characters", minLength)
+	}
+	if maxLength := s.policy.MaximumLength(); length > maxLength {
+		violate(PasswordTooLong, "password must be at most %d characters", maxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSymbol = true
+		}
+	}
+	if s.policy.RequireUppercase && !hasUpper {
+		violate(PasswordMissingUppercase, "password must contain an uppercase letter")
+	}
+	if s.policy.RequireLowercase && !hasLower {
+		violate(PasswordMissingLowercase, "password must contain a lowercase letter")
+	}
+	if s.policy.RequireDigit && !hasDigit {
+		violate(PasswordMissingDigit, "password must contain a digit")
+	}
+	if s.policy.RequireSymbol && !hasSymbol {
+		violate(PasswordMissingSymbol, "password must contain a symbol")
+	}
+
+	if s.policy.DisallowUserInfo && containsUserInfo(user, password) {
+		violate(PasswordContainsUserInfo, "password must not contain the username or email")
+	}
+
+	if s.breached != nil && s.breached.Count(password) > 0 {
+		violate(PasswordBreached, "password has appeared in a data breach, choose a different one")
+	}
+
+	// 前面的检查都通过后再比较历史密码，避免对明显不合格的密码做多次哈希计算
+	if len(violations) == 0 && user.ID != 0 {
+		reused, err := s.reused(ctx, user, password)
+		if err != nil {
+			return err
+		}
+		if reused {
+			violate(PasswordReused, "password must not match any of the last %d passwords", s.policy.HistorySize)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// reused 检查新密码是否与当前密码或最近的 history_size - 1 个历史密码相同
+func (s *passwordPolicyService) reused(ctx context.Context, user *model.User, password string) (bool, error) {
+	if s.policy.HistorySize <= 0 {
+		return false, nil
+	}
+	if s.hasher.Verify(user.Password, password) == nil {
+		return true, nil
+	}
+	if s.policy.HistorySize == 1 {
+		return false, nil
+	}
+
+	histories, err := s.historyRepo.ListRecent(ctx, user.ID, s.policy.HistorySize-1)
+	if err != nil {
+		s.logger.Error("Failed to list password history", zap.Uint("user_id", user.ID), zap.Error(err))
+		return false, err
+	}
+	for _, history := range histories {
+		if s.hasher.Verify(history.PasswordHash, password) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *passwordPolicyService) Remember(ctx context.Context, userID uint, oldHash string) error {
+	// 当前密码直接和 users 表比较，只需要保存 history_size - 1 个历史密码；没有本地密码的账号不需要保存
+	keep := s.policy.HistorySize - 1
+	if keep <= 0 || oldHash == "" {
+		return nil
+	}
+
+	if err := s.historyRepo.Create(ctx, &model.PasswordHistory{UserID: userID, PasswordHash: oldHash}); err != nil {
+		s.logger.Error("Failed to save password history", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+	if err := s.historyRepo.Prune(ctx, userID, keep); err != nil {
+		s.logger.Error("Failed to prune password history", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// containsUserInfo 密码（不区分大小写）是否包含用户名或邮箱 @ 前的部分
+func containsUserInfo(user *model.User, password string) bool {
+	lower := strings.ToLower(password)
+	local, _, _ := strings.Cut(user.Email, "@")
+	for _, info := range []string{user.Username, local} {
+		info = strings.ToLower(info)
+		if utf8.RuneCountInString(info) >= minUserInfoLength && strings.Contains(lower, info) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"trx-project/internal/model"
+	"trx-project/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockPasswordHistoryRepository 是 PasswordHistoryRepository 的 mock 实现
+type MockPasswordHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordHistoryRepository) Create(ctx context.Context, history *model.PasswordHistory) error {
+	args := m.Called(ctx, history)
+	return args.Error(0)
+}
+
+func (m *MockPasswordHistoryRepository) ListRecent(ctx context.Context, userID uint, limit int) ([]*model.PasswordHistory, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.PasswordHistory), args.Error(1)
+}
+
+func (m *MockPasswordHistoryRepository) Prune(ctx context.Context, userID uint, keep int) error {
+	args := m.Called(ctx, userID, keep)
+	return args.Error(0)
+}
+
+// violationCodes 返回密码策略错误中的违规码
+func violationCodes(t *testing.T, err error) []string {
+	var policyErr *PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.ErrorIs(t, err, ErrPasswordPolicy)
+
+	codes := make([]string, len(policyErr.Violations))
+	for i, violation := range policyErr.Violations {
+		codes[i] = violation.Code
+	}
+	return codes
+}
+
+func TestPasswordPolicyService_Validate(t *testing.T) {
+	ctx := context.Background()
+
+	// password1 的 SHA-1
+	breached := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(breached, []byte("E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D:2418984\n"), 0o600))
+
+	policy, err := NewPasswordPolicyService(nil, testPasswordHasher, zap.NewNop(), &config.Config{Auth: config.AuthConfig{
+		PasswordPolicy: config.PasswordPolicyConfig{
+			MinLength:             10,
+			MaxLength:             20,
+			RequireUppercase:      true,
+			RequireLowercase:      true,
+			RequireDigit:          true,
+			RequireSymbol:         true,
+			DisallowUserInfo:      true,
+			BreachedPasswordsFile: breached,
+		},
+	}})
+	require.NoError(t, err)
+	newUser := &model.User{Username: "alice", Email: "wonderland@example.com"}
+
+	// 一次返回所有违规项
+	assert.Equal(t, []string{PasswordTooShort, PasswordMissingUppercase, PasswordMissingDigit, PasswordMissingSymbol},
+		violationCodes(t, policy.Validate(ctx, newUser, "secret")))
+	assert.Equal(t, []string{PasswordTooLong},
+		violationCodes(t, policy.Validate(ctx, newUser, "Correct-Horse-Battery-Staple-1")))
+
+	// 包含用户名或邮箱名（不区分大小写）
+	assert.Equal(t, []string{PasswordContainsUserInfo},
+		violationCodes(t, policy.Validate(ctx, newUser, "My-ALICE-pass1")))
+	assert.Equal(t, []string{PasswordContainsUserInfo},
+		violationCodes(t, policy.Validate(ctx, newUser, "Wonderland-42")))
+
+	// 出现在泄露密码列表中
+	assert.Equal(t, []string{PasswordTooShort, PasswordMissingUppercase, PasswordMissingSymbol, PasswordBreached},
+		violationCodes(t, policy.Validate(ctx, newUser, "password1")))
+
+	assert.NoError(t, policy.Validate(ctx, newUser, "Tr0ub4dor&3x"))
+
+	// 最小长度大于最大长度、列表文件不存在时启动失败
+	_, err = NewPasswordPolicyService(nil, testPasswordHasher, zap.NewNop(), &config.Config{Auth: config.AuthConfig{
+		PasswordPolicy: config.PasswordPolicyConfig{MinLength: 30, MaxLength: 20},
+	}})
+	assert.Error(t, err)
+	_, err = NewPasswordPolicyService(nil, testPasswordHasher, zap.NewNop(), &config.Config{Auth: config.AuthConfig{
+		PasswordPolicy: config.PasswordPolicyConfig{BreachedPasswordsFile: filepath.Join(t.TempDir(), "missing.txt")},
+	}})
+	assert.Error(t, err)
+}
+
+func TestPasswordPolicyService_History(t *testing.T) {
+	ctx := context.Background()
+	historyRepo := new(MockPasswordHistoryRepository)
+	policy, err := NewPasswordPolicyService(historyRepo, testPasswordHasher, zap.NewNop(), &config.Config{Auth: config.AuthConfig{
+		PasswordPolicy: config.PasswordPolicyConfig{HistorySize: 3},
+	}})
+	require.NoError(t, err)
+
+	current, _ := testPasswordHasher.Hash("current-password")
+	previous, _ := testPasswordHasher.Hash("previous-password")
+	user := &model.User{ID: 1, Username: "bob", Password: current}
+
+	// 当前密码直接比较，不查询历史
+	assert.Equal(t, []string{PasswordReused}, violationCodes(t, policy.Validate(ctx, user, "current-password")))
+	historyRepo.AssertNotCalled(t, "ListRecent", mock.Anything, mock.Anything, mock.Anything)
+
+	// 另外检查最近 history_size - 1 个历史密码
+	historyRepo.On("ListRecent", ctx, uint(1), 2).Return([]*model.PasswordHistory{{PasswordHash: previous}}, nil)
+	assert.Equal(t, []string{PasswordReused}, violationCodes(t, policy.Validate(ctx, user, "previous-password")))
+	assert.NoError(t, policy.Validate(ctx, user, "brand-new-password"))
+
+	// 注册时没有历史
+	assert.NoError(t, policy.Validate(ctx, &model.User{Username: "carol"}, "current-password"))
+
+	// 保存被替换的哈希并只保留 history_size - 1 个，没有本地密码的账号不保存
+	historyRepo.On("Create", ctx, mock.MatchedBy(func(history *model.PasswordHistory) bool {
+		return history.UserID == 1 && history.PasswordHash == current
+	})).Return(nil).Once()
+	historyRepo.On("Prune", ctx, uint(1), 2).Return(nil).Once()
+	require.NoError(t, policy.Remember(ctx, 1, current))
+	require.NoError(t, policy.Remember(ctx, 1, ""))
+	historyRepo.AssertExpectations(t)
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/config"
+	"trx-project/pkg/jwt"
+	"trx-project/pkg/notifier"
+	"trx-project/pkg/passhash"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPasswordIncorrect 原密码错误
+	ErrPasswordIncorrect = errors.New("old password is incorrect")
+	// ErrPasswordResetTokenInvalid 重置密码令牌不存在、已过期或已使用
+	ErrPasswordResetTokenInvalid = errors.New("password reset token is invalid or expired")
+)
+
+// PasswordService 密码管理服务：修改密码、找回密码和管理员重置密码
+// 密码变更后该用户之前签发的所有 Token 立即失效，未使用的重置密码令牌全部作废
+type PasswordService interface {
+	// ChangePassword 校验原密码后修改密码，返回当前设备使用的新 Token 对
+	ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) (*TokenPair, error)
+	// RequestPasswordReset 发送重置密码链接，邮箱不存在时同样返回成功，避免泄露账号是否存在
+	RequestPasswordReset(ctx context.Context, email string) error
+	// ResetPasswordWithToken 使用重置密码令牌设置新密码，令牌只能使用一次
+	ResetPasswordWithToken(ctx context.Context, token, newPassword string) error
+	// AdminResetPassword 管理员重置用户密码、通知用户并记录审计日志
+	// 目标用户拥有管理员不具备的后台角色或权限时返回 ErrTargetPrivileged
+	AdminResetPassword(ctx context.Context, adminID, userID uint, newPassword, ip string) error
+}
+
+type passwordService struct {
+	userRepo     repository.UserRepository
+	resetRepo    repository.PasswordResetRepository
+	userService  UserService
+	tokenService TokenService
+	rbacService  RBACService
+	audit        AuditService
+	notifier     notifier.Notifier
+	hasher       *passhash.Hasher
+	policy       PasswordPolicyService
+	logger       *zap.Logger
+	resetURL     string
+	resetTTL     time.Duration
+	resetLimit   int64
+}
+
+// NewPasswordService 创建密码管理服务
+func NewPasswordService(
+	userRepo repository.UserRepository,
+	resetRepo repository.PasswordResetRepository,
+	userService UserService,
+	tokenService TokenService,
+	rbacService RBACService,
+	audit AuditService,
+	notifier notifier.Notifier,
+	hasher *passhash.Hasher,
+	policy PasswordPolicyService,
+	logger *zap.Logger,
+	cfg *config.Config,
+) PasswordService {
+	return &passwordService{
+		userRepo:     userRepo,
+		resetRepo:    resetRepo,
+		userService:  userService,
+		tokenService: tokenService,
+		rbacService:  rbacService,
+		audit:        audit,
+		notifier:     notifier,
+		hasher:       hasher,
+		policy:       policy,
+		logger:       logger,
+		resetURL:     cfg.Auth.PasswordResetURL,
+		resetTTL:     cfg.Auth.PasswordResetTTL(),
+		resetLimit:   cfg.Auth.PasswordResetLimit(),
+	}
+}
+
+func (s *passwordService) ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) (*TokenPair, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.hasher.Verify(user.Password, oldPassword); err != nil {
+		return nil, ErrPasswordIncorrect
+	}
+
+	if err := s.policy.Validate(ctx, user, newPassword); err != nil {
+		return nil, err
+	}
+
+	if err := s.setPassword(ctx, user, newPassword, "Your password has been changed."); err != nil {
+		return nil, err
+	}
+
+	// 修改密码会吊销所有 Token（包括当前的），为当前设备重新签发，需要读取递增后的 Token 版本
+	user, err = s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.tokenService.IssueTokenPair(ctx, user, jwt.RoleUser, "")
+}
+
+func (s *passwordService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Info("Password reset requested for unknown email")
+			return nil
+		}
+		s.logger.Error("Failed to get user", zap.Error(err))
+		return err
+	}
+	if user.Status != 1 {
+		s.logger.Info("Password reset requested for inactive user", zap.Uint("user_id", user.ID))
+		return nil
+	}
+
+	count, err := s.resetRepo.CountSince(ctx, user.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		s.logger.Error("Failed to count password reset tokens", zap.Uint("user_id", user.ID), zap.Error(err))
+		return err
+	}
+	if count >= s.resetLimit {
+		// 与邮箱不存在时的响应相同，避免泄露账号是否存在
+		s.logger.Warn("Password reset requests throttled", zap.Uint("user_id", user.ID))
+		return nil
+	}
+
+	// 每次只有最新的链接有效
+	if err := s.resetRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		s.logger.Error("Failed to invalidate password reset tokens", zap.Uint("user_id", user.ID), zap.Error(err))
+		return err
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	record := &model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.resetTTL),
+	}
+	if err := s.resetRepo.Create(ctx, record); err != nil {
+		s.logger.Error("Failed to create password reset token", zap.Uint("user_id", user.ID), zap.Error(err))
+		return err
+	}
+
+	link := s.resetLink(token)
+	err = s.notifier.Send(ctx, &notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to reset your password. The link expires in %d minutes and can only be used once.\n\n%s\n\nIf you did not request a password reset, you can ignore this message.",
+			user.Username, int(s.resetTTL.Minutes()), link),
+		Data: map[string]string{
+			"token": token,
+			"link":  link,
+		},
+	})
+	if err != nil {
+		// 不向调用方返回错误，避免通过响应差异判断邮箱是否存在
+		s.logger.Error("Failed to send password reset message", zap.Uint("user_id", user.ID), zap.Error(err))
+		return nil
+	}
+
+	s.logger.Info("Password reset requested", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+func (s *passwordService) ResetPasswordWithToken(ctx context.Context, token, newPassword string) error {
+	tokenHash := hashToken(token)
+	record, err := s.resetRepo.GetValid(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPasswordResetTokenInvalid
+		}
+		s.logger.Error("Failed to get password reset token", zap.Error(err))
+		return err
+	}
+
+	user, err := s.getUser(ctx, record.UserID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return ErrPasswordResetTokenInvalid
+		}
+		return err
+	}
+	if user.Status != 1 {
+		return ErrPasswordResetTokenInvalid
+	}
+
+	// 新密码不符合策略时不使用令牌，用户可以换一个密码重试
+	if err := s.policy.Validate(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	if _, err := s.resetRepo.Consume(ctx, tokenHash); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPasswordResetTokenInvalid
+		}
+		s.logger.Error("Failed to consume password reset token", zap.Error(err))
+		return err
+	}
+
+	return s.setPassword(ctx, user, newPassword, "Your password has been reset.")
+}
+
+func (s *passwordService) AdminResetPassword(ctx context.Context, adminID, userID uint, newPassword, ip string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkTargetPrivileges(ctx, s.rbacService, adminID, userID); err != nil {
+		if errors.Is(err, ErrTargetPrivileged) {
+			s.logger.Warn("Admin password reset rejected: target is more privileged",
+				zap.Uint("admin_id", adminID),
+				zap.Uint("user_id", userID))
+		} else {
+			s.logger.Error("Failed to check target privileges", zap.Uint("user_id", userID), zap.Error(err))
+		}
+		return err
+	}
+
+	if err := s.policy.Validate(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, user, newPassword, "Your password has been reset by an administrator."); err != nil {
+		return err
+	}
+
+	s.logger.Info("Admin reset user password",
+		zap.Uint("admin_id", adminID),
+		zap.Uint("user_id", userID))
+
+	return s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    adminID,
+		Action:     model.AuditActionPasswordReset,
+		TargetType: "user",
+		TargetID:   userID,
+		Detail:     fmt.Sprintf("username=%s", user.Username),
+		IP:         ip,
+	})
+}
+
+// setPassword 设置新密码、保存被替换的密码、作废未使用的重置密码令牌并通知用户
+// user 为修改前读取的用户，新密码已通过密码策略校验
+func (s *passwordService) setPassword(ctx context.Context, user *model.User, newPassword, notice string) error {
+	oldHash := user.Password
+	if err := s.userService.ResetPassword(ctx, user.ID, newPassword); err != nil {
+		return err
+	}
+
+	// 密码已经修改，保存历史密码失败只记录日志
+	_ = s.policy.Remember(ctx, user.ID, oldHash)
+
+	if err := s.resetRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		s.logger.Error("Failed to invalidate password reset tokens", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+
+	err := s.notifier.Send(ctx, &notifier.Message{
+		To:      user.Email,
+		Subject: "Your password has been changed",
+		Body: fmt.Sprintf("Hi %s,\n\n%s You have been signed out on all devices.\n\nIf you did not make this change, please contact support immediately.",
+			user.Username, notice),
+	})
+	if err != nil {
+		s.logger.Error("Failed to send password changed message", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+	return nil
+}
+
+func (s *passwordService) getUser(ctx context.Context, userID uint) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		s.logger.Error("Failed to get user", zap.Error(err))
+		return nil, err
+	}
+	return user, nil
+}
+
+// resetLink 生成重置密码链接，未配置页面地址时直接返回令牌
+func (s *passwordService) resetLink(token string) string {
+	if s.resetURL == "" {
+		return token
+	}
+
+	u, err := url.Parse(s.resetURL)
+	if err != nil {
+		s.logger.Warn("Invalid password reset url", zap.String("url", s.resetURL), zap.Error(err))
+		return token
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
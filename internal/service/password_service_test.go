@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/pkg/config"
+	"trx-project/pkg/notifier"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// MockPasswordResetRepository 是 PasswordResetRepository 的 mock 实现
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) Create(ctx context.Context, token *model.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) GetValid(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) Consume(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) CountSince(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	args := m.Called(ctx, userID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) InvalidateByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func newTestPasswordService(mockRepo *MockUserRepository, mockReset *MockPasswordResetRepository, mockTokens *MockTokenService, mockRBAC *MockRBACService, mockAudit *MockAuditService, notify notifier.Notifier) PasswordService {
+	logger := zap.NewNop()
+	mockStatus := new(MockUserStatusService)
+	mockStatus.On("InvalidateUserStatus", mock.Anything, mock.Anything).Return()
+	userService := NewUserService(mockRepo, nil, logger, mockTokens, mockStatus, nil, nil, nil, NewLocalAuthenticator(mockRepo, testPasswordHasher, logger), testPasswordHasher, testPasswordPolicy)
+
+	cfg := &config.Config{Auth: config.AuthConfig{PasswordResetURL: "https://example.com/reset-password"}}
+	return NewPasswordService(mockRepo, mockReset, userService, mockTokens, mockRBAC, mockAudit, notify, testPasswordHasher, testPasswordPolicy, logger, cfg)
+}
+
+func TestPasswordService_ResetFlow(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	mockReset := new(MockPasswordResetRepository)
+	mockTokens := new(MockTokenService)
+	notify := notifier.NewMemoryNotifier()
+	service := newTestPasswordService(mockRepo, mockReset, mockTokens, new(MockRBACService), new(MockAuditService), notify)
+
+	user := &model.User{ID: 1, Username: "testuser", Email: "test@example.com", Status: 1}
+	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("Update", ctx, user).Return(nil)
+	mockReset.On("CountSince", ctx, user.ID, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	mockReset.On("InvalidateByUserID", ctx, user.ID).Return(nil)
+	var created *model.PasswordResetToken
+	mockReset.On("Create", ctx, mock.AnythingOfType("*model.PasswordResetToken")).
+		Run(func(args mock.Arguments) { created = args.Get(1).(*model.PasswordResetToken) }).
+		Return(nil)
+	mockTokens.On("RevokeUserTokens", ctx, user.ID).Return(nil)
+
+	// 申请重置：通过通知发送一次性链接
+	require.NoError(t, service.RequestPasswordReset(ctx, user.Email))
+	message := notify.Last()
+	require.NotNil(t, message)
+	assert.Equal(t, user.Email, message.To)
+	token := message.Data["token"]
+	require.NotEmpty(t, token)
+	assert.Contains(t, message.Data["link"], "https://example.com/reset-password?token=")
+
+	// 数据库只保存令牌摘要
+	require.NotNil(t, created)
+	assert.Equal(t, hashToken(token), created.TokenHash)
+	assert.NotEqual(t, token, created.TokenHash)
+
+	// 新密码不符合策略时返回全部违规项，令牌不会被使用
+	mockReset.On("GetValid", ctx, hashToken(token)).Return(&model.PasswordResetToken{UserID: user.ID}, nil).Twice()
+	err := service.ResetPasswordWithToken(ctx, token, "short")
+	var policyErr *PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, PasswordTooShort, policyErr.Violations[0].Code)
+	mockReset.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+
+	// 使用令牌设置新密码，已签发的 Token 全部吊销
+	mockReset.On("Consume", ctx, hashToken(token)).Return(&model.PasswordResetToken{UserID: user.ID}, nil).Once()
+	require.NoError(t, service.ResetPasswordWithToken(ctx, token, "newpassword"))
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("newpassword")))
+	mockTokens.AssertCalled(t, "RevokeUserTokens", ctx, user.ID)
+	assert.Len(t, notify.Messages(), 2)
+
+	// 令牌只能使用一次
+	mockReset.On("GetValid", ctx, hashToken(token)).Return(nil, gorm.ErrRecordNotFound).Once()
+	assert.ErrorIs(t, service.ResetPasswordWithToken(ctx, token, "another-password"), ErrPasswordResetTokenInvalid)
+}
+
+func TestPasswordService_RequestPasswordReset_UnknownEmail(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	mockReset := new(MockPasswordResetRepository)
+	notify := notifier.NewMemoryNotifier()
+	service := newTestPasswordService(mockRepo, mockReset, new(MockTokenService), new(MockRBACService), new(MockAuditService), notify)
+
+	mockRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	assert.NoError(t, service.RequestPasswordReset(ctx, "nobody@example.com"))
+	assert.Empty(t, notify.Messages())
+	mockReset.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestPasswordService_RequestPasswordReset_Throttled(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	mockReset := new(MockPasswordResetRepository)
+	notify := notifier.NewMemoryNotifier()
+	service := newTestPasswordService(mockRepo, mockReset, new(MockTokenService), new(MockRBACService), new(MockAuditService), notify)
+
+	user := &model.User{ID: 1, Username: "testuser", Email: "test@example.com", Status: 1}
+	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockReset.On("CountSince", ctx, user.ID, mock.AnythingOfType("time.Time")).Return(int64(5), nil)
+
+	// 超过上限时与邮箱不存在时的响应相同，旧链接仍然有效
+	assert.NoError(t, service.RequestPasswordReset(ctx, user.Email))
+	assert.Empty(t, notify.Messages())
+	mockReset.AssertNotCalled(t, "InvalidateByUserID", mock.Anything, mock.Anything)
+	mockReset.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestPasswordService_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+	hashed, err := bcrypt.GenerateFromPassword([]byte("oldpassword"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	t.Run("原密码错误", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenService)
+		service := newTestPasswordService(mockRepo, new(MockPasswordResetRepository), mockTokens, new(MockRBACService), new(MockAuditService), notifier.NewMemoryNotifier())
+
+		mockRepo.On("GetByID", ctx, uint(1)).Return(&model.User{ID: 1, Password: string(hashed), Status: 1}, nil)
+
+		_, err := service.ChangePassword(ctx, 1, "wrong", "newpassword")
+		assert.ErrorIs(t, err, ErrPasswordIncorrect)
+		mockTokens.AssertNotCalled(t, "RevokeUserTokens", mock.Anything, mock.Anything)
+	})
+
+	t.Run("修改成功后重新签发 Token", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockReset := new(MockPasswordResetRepository)
+		mockTokens := new(MockTokenService)
+		notify := notifier.NewMemoryNotifier()
+		service := newTestPasswordService(mockRepo, mockReset, mockTokens, new(MockRBACService), new(MockAuditService), notify)
+
+		user := &model.User{ID: 1, Email: "test@example.com", Password: string(hashed), Status: 1}
+		mockRepo.On("GetByID", ctx, uint(1)).Return(user, nil)
+		mockRepo.On("Update", ctx, user).Return(nil)
+		mockReset.On("InvalidateByUserID", ctx, uint(1)).Return(nil)
+		mockTokens.On("RevokeUserTokens", ctx, uint(1)).Return(nil)
+		mockTokens.On("IssueTokenPair", ctx, user, "user", "").Return(&TokenPair{AccessToken: "access"}, nil)
+
+		tokens, err := service.ChangePassword(ctx, 1, "oldpassword", "newpassword")
+		require.NoError(t, err)
+		assert.Equal(t, "access", tokens.AccessToken)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("newpassword")))
+		assert.Len(t, notify.Messages(), 1)
+		mockTokens.AssertExpectations(t)
+	})
+}
+
+func TestPasswordService_AdminResetPassword(t *testing.T) {
+	ctx := context.Background()
+	ip := "192.0.2.1"
+	editor := []*model.Role{{Name: model.RoleEditor, Status: 1}}
+	superadmin := []*model.Role{{Name: model.RoleSuperAdmin, Status: 1}}
+
+	t.Run("目标拥有后台角色时只有超级管理员可以重置", func(t *testing.T) {
+		mockRepo, mockRBAC, mockAudit := new(MockUserRepository), new(MockRBACService), new(MockAuditService)
+		service := newTestPasswordService(mockRepo, new(MockPasswordResetRepository), new(MockTokenService), mockRBAC, mockAudit, notifier.NewMemoryNotifier())
+
+		mockRepo.On("GetByID", ctx, uint(2)).Return(&model.User{ID: 2, Username: "bob", Status: 1}, nil)
+		mockRBAC.On("GetUserRoles", ctx, uint(2)).Return(editor, nil)
+		mockRBAC.On("GetUserRoles", ctx, uint(1)).Return(editor, nil)
+
+		err := service.AdminResetPassword(ctx, 1, 2, "newpassword", ip)
+		assert.ErrorIs(t, err, ErrTargetPrivileged)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		mockAudit.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+	})
+
+	t.Run("超级管理员缺少目标的权限时拒绝", func(t *testing.T) {
+		mockRepo, mockRBAC := new(MockUserRepository), new(MockRBACService)
+		service := newTestPasswordService(mockRepo, new(MockPasswordResetRepository), new(MockTokenService), mockRBAC, new(MockAuditService), notifier.NewMemoryNotifier())
+
+		mockRepo.On("GetByID", ctx, uint(2)).Return(&model.User{ID: 2, Username: "bob", Status: 1}, nil)
+		mockRBAC.On("GetUserRoles", ctx, uint(2)).Return(editor, nil)
+		mockRBAC.On("GetUserRoles", ctx, uint(1)).Return(superadmin, nil)
+		mockRBAC.On("GetUserPermissions", ctx, uint(2)).Return([]*model.Permission{{Code: "user:write"}, {Code: "rbac:manage"}}, nil)
+		mockRBAC.On("HasPermission", ctx, uint(1), "user:write").Return(true, nil)
+		mockRBAC.On("HasPermission", ctx, uint(1), "rbac:manage").Return(false, nil)
+
+		err := service.AdminResetPassword(ctx, 1, 2, "newpassword", ip)
+		assert.ErrorIs(t, err, ErrTargetPrivileged)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("重置普通用户密码并记录审计日志", func(t *testing.T) {
+		mockRepo, mockReset, mockTokens := new(MockUserRepository), new(MockPasswordResetRepository), new(MockTokenService)
+		mockRBAC, mockAudit := new(MockRBACService), new(MockAuditService)
+		notify := notifier.NewMemoryNotifier()
+		service := newTestPasswordService(mockRepo, mockReset, mockTokens, mockRBAC, mockAudit, notify)
+
+		user := &model.User{ID: 2, Username: "bob", Email: "bob@example.com", Status: 1}
+		mockRepo.On("GetByID", ctx, uint(2)).Return(user, nil)
+		mockRepo.On("Update", ctx, user).Return(nil)
+		mockRBAC.On("GetUserRoles", ctx, uint(2)).Return([]*model.Role{}, nil)
+		mockReset.On("InvalidateByUserID", ctx, uint(2)).Return(nil)
+		mockTokens.On("RevokeUserTokens", ctx, uint(2)).Return(nil)
+		mockAudit.On("Record", ctx, mock.MatchedBy(func(log *model.AuditLog) bool {
+			return log.ActorID == 1 && log.Action == model.AuditActionPasswordReset && log.TargetID == 2 && log.IP == ip
+		})).Return(nil)
+
+		require.NoError(t, service.AdminResetPassword(ctx, 1, 2, "newpassword", ip))
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("newpassword")))
+		assert.Len(t, notify.Messages(), 1)
+		mockAudit.AssertExpectations(t)
+	})
+}
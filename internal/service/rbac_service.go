@@ -3,11 +3,41 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"trx-project/internal/model"
 	"trx-project/internal/repository"
 	"trx-project/pkg/cache"
+	"trx-project/pkg/permission"
+	"trx-project/pkg/policy"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRoleNotFound 角色不存在
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleNameExists 角色名已存在
+	ErrRoleNameExists = errors.New("role name already exists")
+	// ErrParentRoleNotFound 上级角色不存在
+	ErrParentRoleNotFound = errors.New("parent role not found")
+	// ErrRoleHierarchyCycle 上级角色是角色本身或它的下级角色
+	ErrRoleHierarchyCycle = errors.New("role hierarchy cannot contain a cycle")
+	// ErrBuiltinRoleProtected 内置角色不能删除、改名或修改上级角色，超级管理员角色不能禁用
+	ErrBuiltinRoleProtected = errors.New("built-in role cannot be changed")
+	// ErrPermissionNotFound 权限不存在
+	ErrPermissionNotFound = errors.New("permission not found")
+	// ErrPermissionCodeExists 权限编码已存在
+	ErrPermissionCodeExists = errors.New("permission code already exists")
+	// ErrPermissionCodeInvalid 权限编码或通配符不合法
+	ErrPermissionCodeInvalid = errors.New("permission code is invalid")
+	// ErrGrantNotFound 角色没有直接授予该权限
+	ErrGrantNotFound = errors.New("role does not grant this permission")
+	// ErrGrantConditionInvalid 授权条件表达式不合法
+	ErrGrantConditionInvalid = errors.New("grant condition is invalid")
+	// ErrRoleUserNotFound 被分配角色的用户不存在或不属于当前租户
+	ErrRoleUserNotFound = errors.New("user not found")
 )
 
 // RBACService RBAC 服务接口
@@ -18,39 +48,82 @@ type RBACService interface {
 	GetRoleWithPermissions(ctx context.Context, roleID uint) (*model.Role, error)
 	ListRoles(ctx context.Context) ([]*model.Role, error)
 	CreateRole(ctx context.Context, role *model.Role) error
+	// UpdateRole 保存角色，内置角色不能改名或修改上级角色，超级管理员角色不能禁用
 	UpdateRole(ctx context.Context, role *model.Role) error
+	// UpdateRoleStatus 启用或禁用角色，禁用的角色不再授予直接拥有它的用户权限
+	UpdateRoleStatus(ctx context.Context, id uint, status int) (*model.Role, error)
+	// SetRoleParent 设置上级角色，parentID 为 nil 时取消继承
+	SetRoleParent(ctx context.Context, id uint, parentID *uint) (*model.Role, error)
+	// DeleteRole 删除角色及其权限和用户分配，下级角色改为继承它的上级角色，内置角色不能删除
 	DeleteRole(ctx context.Context, id uint) error
 
 	// Permission 相关
+	GetPermissionByID(ctx context.Context, id uint) (*model.Permission, error)
 	ListPermissions(ctx context.Context) ([]*model.Permission, error)
 	CreatePermission(ctx context.Context, permission *model.Permission) error
+	UpdatePermission(ctx context.Context, permission *model.Permission) error
+	// UpdatePermissionStatus 启用或禁用权限，禁用的权限不再授予任何用户
+	UpdatePermissionStatus(ctx context.Context, id uint, status int) (*model.Permission, error)
+	// DeletePermission 删除权限并从所有角色中移除
+	DeletePermission(ctx context.Context, id uint) error
 
 	// RolePermission 相关
 	AssignPermissionsToRole(ctx context.Context, roleID uint, permissionIDs []uint) error
 	RemovePermissionsFromRole(ctx context.Context, roleID uint, permissionIDs []uint) error
+	// GetRolePermissions 获取角色的有效权限，包括从上级角色继承的权限
 	GetRolePermissions(ctx context.Context, roleID uint) ([]*model.Permission, error)
+	// GetRoleGrants 获取角色的有效授权及条件，包括从上级角色继承的授权
+	GetRoleGrants(ctx context.Context, roleID uint) ([]*model.PermissionGrant, error)
+	// SetGrantCondition 设置角色直接授予的权限的条件，condition 为空时取消条件
+	SetGrantCondition(ctx context.Context, roleID, permissionID uint, condition string) error
 
 	// UserRole 相关
 	AssignRoleToUser(ctx context.Context, userID, roleID uint) error
 	RemoveRoleFromUser(ctx context.Context, userID, roleID uint) error
 	GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error)
+	// GetUserPermissions 获取用户被授予的权限，编码可能包含通配符，不展开蕴含的权限
 	GetUserPermissions(ctx context.Context, userID uint) ([]*model.Permission, error)
+	// HasPermission 按通配符和蕴含规则检查用户是否拥有权限，结果会被缓存
 	HasPermission(ctx context.Context, userID uint, permissionCode string) (bool, error)
+	// CheckPermission 同 HasPermission，没有权限时返回错误
 	CheckPermission(ctx context.Context, userID uint, permissionCode string) error
+	// GrantsAllow 按与 HasPermission 相同的规则检查授权列表（如 API Key 的 scopes）是否包含权限
+	GrantsAllow(grants []string, permissionCode string) bool
+}
+
+// permissionCache RBAC 服务使用的权限缓存，由 cache.RBACCache 实现
+type permissionCache interface {
+	GetRolePermissions(ctx context.Context, roleID uint) ([]string, bool)
+	SetRolePermissions(ctx context.Context, roleID uint, permissions []string) error
+	GetUserPermissions(ctx context.Context, userID uint) ([]string, bool)
+	SetUserPermissions(ctx context.Context, userID uint, permissions []string) error
+	CheckPermissionCached(ctx context.Context, userID uint, permission string) (bool, bool)
+	SetPermissionCheck(ctx context.Context, userID uint, permission string, hasPermission bool) error
+	InvalidateUserCache(ctx context.Context, userID uint) error
+	InvalidateRoleCache(ctx context.Context, roleID uint) error
 }
 
 type rbacService struct {
 	repo        repository.RBACRepository
-	cache       *cache.RBACCache
+	cache       permissionCache
+	matcher     *permission.Matcher
 	logger      *zap.Logger
 	enableCache bool // 是否启用缓存
 }
 
 // NewRBACService 创建 RBAC 服务
-func NewRBACService(repo repository.RBACRepository, rbacCache *cache.RBACCache, logger *zap.Logger) RBACService {
+func NewRBACService(repo repository.RBACRepository, rbacCache *cache.RBACCache, matcher *permission.Matcher, logger *zap.Logger) RBACService {
+	if rbacCache == nil {
+		return newRBACService(repo, nil, matcher, logger)
+	}
+	return newRBACService(repo, rbacCache, matcher, logger)
+}
+
+func newRBACService(repo repository.RBACRepository, rbacCache permissionCache, matcher *permission.Matcher, logger *zap.Logger) *rbacService {
 	return &rbacService{
 		repo:        repo,
 		cache:       rbacCache,
+		matcher:     matcher,
 		logger:      logger,
 		enableCache: rbacCache != nil, // 如果提供了缓存，则启用
 	}
@@ -78,65 +151,313 @@ func (s *rbacService) CreateRole(ctx context.Context, role *model.Role) error {
 	// 检查角色名是否已存在
 	existingRole, err := s.repo.GetRoleByName(ctx, role.Name)
 	if err == nil && existingRole.ID > 0 {
-		return errors.New("role name already exists")
+		return ErrRoleNameExists
+	}
+
+	// 新角色没有下级角色，不会形成环
+	if role.ParentID != nil {
+		if _, err := s.getParentRole(ctx, *role.ParentID); err != nil {
+			return err
+		}
 	}
 
 	return s.repo.CreateRole(ctx, role)
 }
 
 func (s *rbacService) UpdateRole(ctx context.Context, role *model.Role) error {
-	return s.repo.UpdateRole(ctx, role)
+	current, err := s.getRole(ctx, role.ID)
+	if err != nil {
+		return err
+	}
+
+	if role.Name != current.Name {
+		if isBuiltinRole(current.Name) {
+			return ErrBuiltinRoleProtected
+		}
+		if existing, err := s.repo.GetRoleByName(ctx, role.Name); err == nil && existing.ID != role.ID {
+			return ErrRoleNameExists
+		}
+	}
+	if current.Name == model.RoleSuperAdmin && role.Status != 1 {
+		return ErrBuiltinRoleProtected
+	}
+	if !sameParent(role.ParentID, current.ParentID) {
+		if isBuiltinRole(current.Name) {
+			return ErrBuiltinRoleProtected
+		}
+		if err := s.checkParent(ctx, role.ID, role.ParentID); err != nil {
+			return err
+		}
+	}
+
+	// 先查出该角色、它的下级角色和拥有这些角色的用户，保存后使他们的权限缓存失效（状态和上级角色变化会影响权限）
+	roleIDs, userIDs, err := s.roleTree(ctx, role.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdateRole(ctx, role); err != nil {
+		return err
+	}
+	s.invalidateRoles(ctx, roleIDs, userIDs)
+	return nil
+}
+
+func (s *rbacService) UpdateRoleStatus(ctx context.Context, id uint, status int) (*model.Role, error) {
+	role, err := s.getRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if role.Status == status {
+		return role, nil
+	}
+
+	role.Status = status
+	if err := s.UpdateRole(ctx, role); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Role status updated", zap.Uint("role_id", id), zap.Int("status", status))
+	return role, nil
+}
+
+func (s *rbacService) SetRoleParent(ctx context.Context, id uint, parentID *uint) (*model.Role, error) {
+	role, err := s.getRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sameParent(role.ParentID, parentID) {
+		return role, nil
+	}
+
+	role.ParentID = parentID
+	if err := s.UpdateRole(ctx, role); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Role parent updated", zap.Uint("role_id", id), zap.Uintp("parent_id", parentID))
+	return role, nil
 }
 
 func (s *rbacService) DeleteRole(ctx context.Context, id uint) error {
-	return s.repo.DeleteRole(ctx, id)
+	role, err := s.getRole(ctx, id)
+	if err != nil {
+		return err
+	}
+	if isBuiltinRole(role.Name) {
+		return ErrBuiltinRoleProtected
+	}
+
+	// 删除会移除用户分配并改变下级角色的继承，需要在删除前查出受影响的角色和用户
+	roleIDs, userIDs, err := s.roleTree(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteRole(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateRoles(ctx, roleIDs, userIDs)
+
+	s.logger.Info("Role deleted", zap.Uint("role_id", id), zap.String("name", role.Name))
+	return nil
+}
+
+// getRole 获取角色，不存在时返回 ErrRoleNotFound
+func (s *rbacService) getRole(ctx context.Context, id uint) (*model.Role, error) {
+	role, err := s.repo.GetRoleByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// getParentRole 获取上级角色，不存在时返回 ErrParentRoleNotFound
+func (s *rbacService) getParentRole(ctx context.Context, parentID uint) (*model.Role, error) {
+	parent, err := s.getRole(ctx, parentID)
+	if errors.Is(err, ErrRoleNotFound) {
+		return nil, ErrParentRoleNotFound
+	}
+	return parent, err
+}
+
+// checkParent 检查上级角色存在且不是角色本身或它的下级角色
+func (s *rbacService) checkParent(ctx context.Context, roleID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	if *parentID == roleID {
+		return ErrRoleHierarchyCycle
+	}
+	if _, err := s.getParentRole(ctx, *parentID); err != nil {
+		return err
+	}
+
+	ancestorIDs, err := s.repo.GetRoleAncestorIDs(ctx, *parentID)
+	if err != nil {
+		s.logger.Error("Failed to get role ancestors", zap.Uint("role_id", *parentID), zap.Error(err))
+		return err
+	}
+	for _, ancestorID := range ancestorIDs {
+		if ancestorID == roleID {
+			return ErrRoleHierarchyCycle
+		}
+	}
+	return nil
+}
+
+// sameParent 两个上级角色 ID 是否相同
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// isBuiltinRole 是否为代码中引用的内置角色
+func isBuiltinRole(name string) bool {
+	switch name {
+	case model.RoleSuperAdmin, model.RoleAdmin, model.RoleEditor, model.RoleViewer:
+		return true
+	}
+	return false
 }
 
 // Permission 相关实现
 
+func (s *rbacService) GetPermissionByID(ctx context.Context, id uint) (*model.Permission, error) {
+	permission, err := s.repo.GetPermissionByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPermissionNotFound
+		}
+		return nil, err
+	}
+	return permission, nil
+}
+
 func (s *rbacService) ListPermissions(ctx context.Context) ([]*model.Permission, error) {
 	return s.repo.ListPermissions(ctx)
 }
 
 func (s *rbacService) CreatePermission(ctx context.Context, permission *model.Permission) error {
+	if err := validatePermissionCode(permission.Code); err != nil {
+		return err
+	}
+	if existing, err := s.repo.GetPermissionByCode(ctx, permission.Code); err == nil && existing.ID > 0 {
+		return ErrPermissionCodeExists
+	}
+
 	return s.repo.CreatePermission(ctx, permission)
 }
 
+func (s *rbacService) UpdatePermission(ctx context.Context, permission *model.Permission) error {
+	current, err := s.GetPermissionByID(ctx, permission.ID)
+	if err != nil {
+		return err
+	}
+	if permission.Code != current.Code {
+		if err := validatePermissionCode(permission.Code); err != nil {
+			return err
+		}
+		if existing, err := s.repo.GetPermissionByCode(ctx, permission.Code); err == nil && existing.ID != permission.ID {
+			return ErrPermissionCodeExists
+		}
+	}
+
+	// 编码和状态变化会影响所有拥有该权限的角色和用户
+	roleIDs, userIDs, err := s.permissionHolders(ctx, permission.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePermission(ctx, permission); err != nil {
+		return err
+	}
+	s.invalidateRoles(ctx, roleIDs, userIDs)
+	return nil
+}
+
+func (s *rbacService) UpdatePermissionStatus(ctx context.Context, id uint, status int) (*model.Permission, error) {
+	permission, err := s.GetPermissionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if permission.Status == status {
+		return permission, nil
+	}
+
+	permission.Status = status
+	if err := s.UpdatePermission(ctx, permission); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Permission status updated", zap.Uint("permission_id", id), zap.Int("status", status))
+	return permission, nil
+}
+
+func (s *rbacService) DeletePermission(ctx context.Context, id uint) error {
+	permission, err := s.GetPermissionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	roleIDs, userIDs, err := s.permissionHolders(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeletePermission(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateRoles(ctx, roleIDs, userIDs)
+
+	s.logger.Info("Permission deleted", zap.Uint("permission_id", id), zap.String("code", permission.Code))
+	return nil
+}
+
+// validatePermissionCode 检查权限编码，编码可以是 user:*、*:read 这样的通配符授权
+func validatePermissionCode(code string) error {
+	if err := permission.Validate(code); err != nil {
+		return fmt.Errorf("%w: %s", ErrPermissionCodeInvalid, code)
+	}
+	return nil
+}
+
 // RolePermission 相关实现
 
 func (s *rbacService) AssignPermissionsToRole(ctx context.Context, roleID uint, permissionIDs []uint) error {
-	err := s.repo.AssignPermissionsToRole(ctx, roleID, permissionIDs)
-	if err != nil {
+	if _, err := s.getRole(ctx, roleID); err != nil {
 		return err
 	}
 
-	// 使角色权限缓存失效
-	if s.enableCache {
-		if err := s.cache.InvalidateRoleCache(ctx, roleID); err != nil {
-			s.logger.Error("Failed to invalidate role cache",
-				zap.Uint("role_id", roleID),
-				zap.Error(err))
-		}
+	roleIDs, userIDs, err := s.roleTree(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.AssignPermissionsToRole(ctx, roleID, permissionIDs); err != nil {
+		return err
 	}
 
+	// 使角色及其下级角色的权限缓存和拥有这些角色的用户的缓存失效
+	s.invalidateRoles(ctx, roleIDs, userIDs)
 	return nil
 }
 
 func (s *rbacService) RemovePermissionsFromRole(ctx context.Context, roleID uint, permissionIDs []uint) error {
-	err := s.repo.RemovePermissionsFromRole(ctx, roleID, permissionIDs)
-	if err != nil {
+	if _, err := s.getRole(ctx, roleID); err != nil {
 		return err
 	}
 
-	// 使角色权限缓存失效
-	if s.enableCache {
-		if err := s.cache.InvalidateRoleCache(ctx, roleID); err != nil {
-			s.logger.Error("Failed to invalidate role cache",
-				zap.Uint("role_id", roleID),
-				zap.Error(err))
-		}
+	roleIDs, userIDs, err := s.roleTree(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.RemovePermissionsFromRole(ctx, roleID, permissionIDs); err != nil {
+		return err
 	}
 
+	// 使角色及其下级角色的权限缓存和拥有这些角色的用户的缓存失效
+	s.invalidateRoles(ctx, roleIDs, userIDs)
 	return nil
 }
 
@@ -175,17 +496,56 @@ func (s *rbacService) GetRolePermissions(ctx context.Context, roleID uint) ([]*m
 	return permissions, nil
 }
 
+func (s *rbacService) GetRoleGrants(ctx context.Context, roleID uint) ([]*model.PermissionGrant, error) {
+	if _, err := s.getRole(ctx, roleID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRoleGrants(ctx, roleID)
+}
+
+func (s *rbacService) SetGrantCondition(ctx context.Context, roleID, permissionID uint, condition string) error {
+	condition = strings.TrimSpace(condition)
+	if condition != "" {
+		if _, err := policy.Compile(condition); err != nil {
+			return fmt.Errorf("%w: %v", ErrGrantConditionInvalid, err)
+		}
+	}
+
+	if _, err := s.getRole(ctx, roleID); err != nil {
+		return err
+	}
+	if _, err := s.GetPermissionByID(ctx, permissionID); err != nil {
+		return err
+	}
+
+	// 条件在每次授权时从数据库读取，权限编码缓存不受影响
+	if err := s.repo.SetGrantCondition(ctx, roleID, permissionID, condition); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrGrantNotFound
+		}
+		return err
+	}
+
+	s.logger.Info("Grant condition updated",
+		zap.Uint("role_id", roleID),
+		zap.Uint("permission_id", permissionID),
+		zap.String("condition", condition))
+	return nil
+}
+
 // UserRole 相关实现
 
 func (s *rbacService) AssignRoleToUser(ctx context.Context, userID, roleID uint) error {
 	// 检查角色是否存在
-	_, err := s.repo.GetRoleByID(ctx, roleID)
-	if err != nil {
-		return errors.New("role not found")
+	if _, err := s.getRole(ctx, roleID); err != nil {
+		return err
 	}
 
-	err = s.repo.AssignRoleToUser(ctx, userID, roleID)
+	err := s.repo.AssignRoleToUser(ctx, userID, roleID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleUserNotFound
+		}
 		return err
 	}
 
@@ -260,7 +620,7 @@ func (s *rbacService) GetUserPermissions(ctx context.Context, userID uint) ([]*m
 }
 
 func (s *rbacService) HasPermission(ctx context.Context, userID uint, permissionCode string) (bool, error) {
-	// 尝试从缓存获取权限检查结果
+	// 尝试从缓存获取权限检查结果，缓存的是按通配符和蕴含规则计算后的结果
 	if s.enableCache {
 		if hasPermission, ok := s.cache.CheckPermissionCached(ctx, userID, permissionCode); ok {
 			// 缓存命中
@@ -272,11 +632,16 @@ func (s *rbacService) HasPermission(ctx context.Context, userID uint, permission
 		}
 	}
 
-	// 缓存未命中，从数据库查询
-	hasPermission, err := s.repo.HasPermission(ctx, userID, permissionCode)
+	// 缓存未命中，用用户被授予的权限计算（授予的权限本身也有缓存）
+	permissions, err := s.GetUserPermissions(ctx, userID)
 	if err != nil {
 		return false, err
 	}
+	grants := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		grants = append(grants, p.Code)
+	}
+	hasPermission := s.GrantsAllow(grants, permissionCode)
 
 	// 存入缓存
 	if s.enableCache {
@@ -293,7 +658,7 @@ func (s *rbacService) HasPermission(ctx context.Context, userID uint, permission
 
 // CheckPermission 检查用户是否有指定权限，没有则返回错误
 func (s *rbacService) CheckPermission(ctx context.Context, userID uint, permissionCode string) error {
-	has, err := s.repo.HasPermission(ctx, userID, permissionCode)
+	has, err := s.HasPermission(ctx, userID, permissionCode)
 	if err != nil {
 		s.logger.Error("Failed to check permission",
 			zap.Uint("user_id", userID),
@@ -311,3 +676,65 @@ func (s *rbacService) CheckPermission(ctx context.Context, userID uint, permissi
 
 	return nil
 }
+
+func (s *rbacService) GrantsAllow(grants []string, permissionCode string) bool {
+	return s.matcher.Allows(grants, permissionCode)
+}
+
+// roleTree 在修改角色前查询角色、它们的所有下级角色（继承了被修改的角色）以及拥有这些角色的用户，
+// 修改后使它们的缓存失效；查询失败时不做修改，避免继续使用缓存中的旧权限
+func (s *rbacService) roleTree(ctx context.Context, roleIDs ...uint) ([]uint, []uint, error) {
+	if !s.enableCache || len(roleIDs) == 0 {
+		return nil, nil, nil
+	}
+
+	descendantIDs, err := s.repo.GetRoleDescendantIDs(ctx, roleIDs)
+	if err != nil {
+		s.logger.Error("Failed to get role descendants", zap.Uints("role_ids", roleIDs), zap.Error(err))
+		return nil, nil, err
+	}
+	treeIDs := append(append(make([]uint, 0, len(roleIDs)+len(descendantIDs)), roleIDs...), descendantIDs...)
+
+	userIDs, err := s.repo.GetRoleUserIDs(ctx, treeIDs)
+	if err != nil {
+		s.logger.Error("Failed to get role users", zap.Uints("role_ids", treeIDs), zap.Error(err))
+		return nil, nil, err
+	}
+	return treeIDs, userIDs, nil
+}
+
+// permissionHolders 在修改权限前查询拥有权限的角色、它们的下级角色及这些角色的用户
+func (s *rbacService) permissionHolders(ctx context.Context, permissionID uint) ([]uint, []uint, error) {
+	if !s.enableCache {
+		return nil, nil, nil
+	}
+
+	roleIDs, err := s.repo.GetPermissionRoleIDs(ctx, permissionID)
+	if err != nil {
+		s.logger.Error("Failed to get permission roles", zap.Uint("permission_id", permissionID), zap.Error(err))
+		return nil, nil, err
+	}
+	return s.roleTree(ctx, roleIDs...)
+}
+
+// invalidateRoles 使角色权限缓存及相关用户的权限缓存失效
+func (s *rbacService) invalidateRoles(ctx context.Context, roleIDs, userIDs []uint) {
+	if !s.enableCache {
+		return
+	}
+
+	for _, roleID := range roleIDs {
+		if err := s.cache.InvalidateRoleCache(ctx, roleID); err != nil {
+			s.logger.Error("Failed to invalidate role cache",
+				zap.Uint("role_id", roleID),
+				zap.Error(err))
+		}
+	}
+	for _, userID := range userIDs {
+		if err := s.cache.InvalidateUserCache(ctx, userID); err != nil {
+			s.logger.Error("Failed to invalidate user cache",
+				zap.Uint("user_id", userID),
+				zap.Error(err))
+		}
+	}
+}
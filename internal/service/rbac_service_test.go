@@ -0,0 +1,495 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"trx-project/internal/model"
+	"trx-project/pkg/permission"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MockRBACRepository 是 RBACRepository 的 mock 实现
+type MockRBACRepository struct {
+	mock.Mock
+}
+
+func (m *MockRBACRepository) GetRoleByName(ctx context.Context, name string) (*model.Role, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+// GetRoleByID 返回副本，和每次从数据库读取一样，服务修改返回值不会影响之后的读取
+func (m *MockRBACRepository) GetRoleByID(ctx context.Context, id uint) (*model.Role, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	role := *args.Get(0).(*model.Role)
+	return &role, args.Error(1)
+}
+
+func (m *MockRBACRepository) GetRoleWithPermissions(ctx context.Context, roleID uint) (*model.Role, error) {
+	args := m.Called(ctx, roleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+func (m *MockRBACRepository) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.Role), args.Error(1)
+}
+
+func (m *MockRBACRepository) CreateRole(ctx context.Context, role *model.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) UpdateRole(ctx context.Context, role *model.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) DeleteRole(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) GetRoleAncestorIDs(ctx context.Context, roleID uint) ([]uint, error) {
+	args := m.Called(ctx, roleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockRBACRepository) GetRoleDescendantIDs(ctx context.Context, roleIDs []uint) ([]uint, error) {
+	args := m.Called(ctx, roleIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockRBACRepository) GetPermissionByCode(ctx context.Context, code string) (*model.Permission, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Permission), args.Error(1)
+}
+
+// GetPermissionByID 返回副本，原因同 GetRoleByID
+func (m *MockRBACRepository) GetPermissionByID(ctx context.Context, id uint) (*model.Permission, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	permission := *args.Get(0).(*model.Permission)
+	return &permission, args.Error(1)
+}
+
+func (m *MockRBACRepository) ListPermissions(ctx context.Context) ([]*model.Permission, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.Permission), args.Error(1)
+}
+
+func (m *MockRBACRepository) CreatePermission(ctx context.Context, permission *model.Permission) error {
+	args := m.Called(ctx, permission)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) UpdatePermission(ctx context.Context, permission *model.Permission) error {
+	args := m.Called(ctx, permission)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) DeletePermission(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) GetPermissionRoleIDs(ctx context.Context, permissionID uint) ([]uint, error) {
+	args := m.Called(ctx, permissionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockRBACRepository) AssignPermissionsToRole(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	args := m.Called(ctx, roleID, permissionIDs)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) RemovePermissionsFromRole(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	args := m.Called(ctx, roleID, permissionIDs)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) GetRolePermissions(ctx context.Context, roleID uint) ([]*model.Permission, error) {
+	args := m.Called(ctx, roleID)
+	return args.Get(0).([]*model.Permission), args.Error(1)
+}
+
+func (m *MockRBACRepository) GetRoleGrants(ctx context.Context, roleID uint) ([]*model.PermissionGrant, error) {
+	args := m.Called(ctx, roleID)
+	return args.Get(0).([]*model.PermissionGrant), args.Error(1)
+}
+
+func (m *MockRBACRepository) SetGrantCondition(ctx context.Context, roleID, permissionID uint, condition string) error {
+	args := m.Called(ctx, roleID, permissionID, condition)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) AssignRoleToUser(ctx context.Context, userID, roleID uint) error {
+	args := m.Called(ctx, userID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) RemoveRoleFromUser(ctx context.Context, userID, roleID uint) error {
+	args := m.Called(ctx, userID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockRBACRepository) GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*model.Role), args.Error(1)
+}
+
+func (m *MockRBACRepository) GetRoleUserIDs(ctx context.Context, roleIDs []uint) ([]uint, error) {
+	args := m.Called(ctx, roleIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockRBACRepository) GetUserPermissions(ctx context.Context, userID uint) ([]*model.Permission, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*model.Permission), args.Error(1)
+}
+
+func (m *MockRBACRepository) GetUserGrants(ctx context.Context, userID uint) ([]*model.PermissionGrant, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*model.PermissionGrant), args.Error(1)
+}
+
+// fakePermissionCache 缓存权限检查结果，记录失效的角色和用户
+type fakePermissionCache struct {
+	checks map[string]bool
+	roles  []uint
+	users  []uint
+}
+
+func (c *fakePermissionCache) GetRolePermissions(ctx context.Context, roleID uint) ([]string, bool) {
+	return nil, false
+}
+
+func (c *fakePermissionCache) SetRolePermissions(ctx context.Context, roleID uint, permissions []string) error {
+	return nil
+}
+
+func (c *fakePermissionCache) GetUserPermissions(ctx context.Context, userID uint) ([]string, bool) {
+	return nil, false
+}
+
+func (c *fakePermissionCache) SetUserPermissions(ctx context.Context, userID uint, permissions []string) error {
+	return nil
+}
+
+func (c *fakePermissionCache) CheckPermissionCached(ctx context.Context, userID uint, permission string) (bool, bool) {
+	hasPermission, ok := c.checks[fmt.Sprintf("%d:%s", userID, permission)]
+	return hasPermission, ok
+}
+
+func (c *fakePermissionCache) SetPermissionCheck(ctx context.Context, userID uint, permission string, hasPermission bool) error {
+	c.checks[fmt.Sprintf("%d:%s", userID, permission)] = hasPermission
+	return nil
+}
+
+func (c *fakePermissionCache) InvalidateUserCache(ctx context.Context, userID uint) error {
+	prefix := fmt.Sprintf("%d:", userID)
+	for key := range c.checks {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.checks, key)
+		}
+	}
+	c.users = append(c.users, userID)
+	return nil
+}
+
+func (c *fakePermissionCache) InvalidateRoleCache(ctx context.Context, roleID uint) error {
+	c.roles = append(c.roles, roleID)
+	return nil
+}
+
+func newTestRBACService() (*rbacService, *MockRBACRepository, *fakePermissionCache) {
+	repo := new(MockRBACRepository)
+	rbacCache := &fakePermissionCache{checks: map[string]bool{}}
+	matcher, err := permission.NewMatcher(map[string][]string{
+		"user:write":  {"user:read"},
+		"user:delete": {"user:write"},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return newRBACService(repo, rbacCache, matcher, zap.NewNop()), repo, rbacCache
+}
+
+func TestRBACService_HasPermission(t *testing.T) {
+	ctx := context.Background()
+	s, repo, rbacCache := newTestRBACService()
+
+	repo.On("GetUserPermissions", ctx, uint(10)).Return([]*model.Permission{{Code: "user:delete"}, {Code: "*:read"}}, nil)
+
+	// 通配符授权和传递的蕴含
+	for _, code := range []string{"user:delete", "user:write", "user:read", "statistics:read"} {
+		has, err := s.HasPermission(ctx, 10, code)
+		require.NoError(t, err)
+		assert.True(t, has, code)
+	}
+	require.Error(t, s.CheckPermission(ctx, 10, "rbac:manage"))
+
+	// 缓存的是计算后的结果，命中时不再查询
+	assert.True(t, rbacCache.checks["10:user:read"])
+	assert.False(t, rbacCache.checks["10:rbac:manage"])
+	repo.AssertNumberOfCalls(t, "GetUserPermissions", 5)
+	require.NoError(t, s.CheckPermission(ctx, 10, "user:read"))
+	repo.AssertNumberOfCalls(t, "GetUserPermissions", 5)
+
+	// 授权变化后缓存失效，重新计算
+	repo.On("RemoveRoleFromUser", ctx, uint(10), uint(5)).Return(nil).Once()
+	require.NoError(t, s.RemoveRoleFromUser(ctx, 10, 5))
+	assert.Empty(t, rbacCache.checks)
+
+	// API Key 的 scopes 使用同样的规则
+	assert.True(t, s.GrantsAllow([]string{"user:write"}, "user:read"))
+	assert.True(t, s.GrantsAllow([]string{"user:*"}, "user:delete"))
+	assert.False(t, s.GrantsAllow([]string{"user:read"}, "user:write"))
+}
+
+func TestRBACService_CreatePermission_InvalidCode(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newTestRBACService()
+
+	err := s.CreatePermission(ctx, &model.Permission{Code: "user:[read"})
+	assert.ErrorIs(t, err, ErrPermissionCodeInvalid)
+}
+
+func TestRBACService_SetGrantCondition(t *testing.T) {
+	ctx := context.Background()
+	s, repo, _ := newTestRBACService()
+
+	repo.On("GetRoleByID", ctx, uint(3)).Return(&model.Role{ID: 3, Name: model.RoleEditor, Status: 1}, nil)
+	repo.On("GetPermissionByID", ctx, uint(2)).Return(&model.Permission{ID: 2, Code: "user:write"}, nil)
+	repo.On("GetPermissionByID", ctx, uint(4)).Return(&model.Permission{ID: 4, Code: "user:delete"}, nil)
+	repo.On("SetGrantCondition", ctx, uint(3), uint(2), "resource.created_by == subject.id").Return(nil).Once()
+	repo.On("SetGrantCondition", ctx, uint(3), uint(4), "").Return(gorm.ErrRecordNotFound).Once()
+
+	// 条件表达式在保存前编译
+	err := s.SetGrantCondition(ctx, 3, 2, "resource.created_by ==")
+	assert.ErrorIs(t, err, ErrGrantConditionInvalid)
+	err = s.SetGrantCondition(ctx, 3, 2, "owner == subject.id")
+	assert.ErrorIs(t, err, ErrGrantConditionInvalid)
+
+	require.NoError(t, s.SetGrantCondition(ctx, 3, 2, "  resource.created_by == subject.id "))
+
+	// 角色没有直接授予该权限
+	err = s.SetGrantCondition(ctx, 3, 4, "")
+	assert.ErrorIs(t, err, ErrGrantNotFound)
+	repo.AssertExpectations(t)
+}
+
+func TestRBACService_UpdateRoleStatus(t *testing.T) {
+	ctx := context.Background()
+	s, repo, rbacCache := newTestRBACService()
+
+	repo.On("GetRoleByID", ctx, uint(5)).Return(&model.Role{ID: 5, Name: "auditor", Status: 1}, nil)
+	repo.On("GetRoleDescendantIDs", ctx, []uint{5}).Return([]uint{}, nil)
+	repo.On("GetRoleUserIDs", ctx, []uint{5}).Return([]uint{10, 11}, nil)
+	repo.On("UpdateRole", ctx, mock.MatchedBy(func(role *model.Role) bool {
+		return role.ID == 5 && role.Status == 0
+	})).Return(nil).Once()
+
+	role, err := s.UpdateRoleStatus(ctx, 5, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, role.Status)
+	assert.Equal(t, []uint{5}, rbacCache.roles)
+	assert.Equal(t, []uint{10, 11}, rbacCache.users)
+	repo.AssertExpectations(t)
+
+	// 超级管理员角色不能禁用
+	repo.On("GetRoleByID", ctx, uint(1)).Return(&model.Role{ID: 1, Name: model.RoleSuperAdmin, Status: 1}, nil)
+	_, err = s.UpdateRoleStatus(ctx, 1, 0)
+	assert.ErrorIs(t, err, ErrBuiltinRoleProtected)
+
+	repo.On("GetRoleByID", ctx, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+	_, err = s.UpdateRoleStatus(ctx, 9, 0)
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+}
+
+func TestRBACService_UpdateRole(t *testing.T) {
+	ctx := context.Background()
+	s, repo, _ := newTestRBACService()
+
+	repo.On("GetRoleByID", ctx, uint(2)).Return(&model.Role{ID: 2, Name: model.RoleAdmin, Status: 1}, nil)
+	repo.On("GetRoleByID", ctx, uint(5)).Return(&model.Role{ID: 5, Name: "auditor", Status: 1}, nil)
+	repo.On("GetRoleByName", ctx, "support").Return(&model.Role{ID: 6, Name: "support"}, nil)
+
+	// 内置角色不能改名，新名称不能与其他角色重复
+	err := s.UpdateRole(ctx, &model.Role{ID: 2, Name: "administrators", Status: 1})
+	assert.ErrorIs(t, err, ErrBuiltinRoleProtected)
+	err = s.UpdateRole(ctx, &model.Role{ID: 5, Name: "support", Status: 1})
+	assert.ErrorIs(t, err, ErrRoleNameExists)
+	repo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything)
+}
+
+func TestRBACService_SetRoleParent(t *testing.T) {
+	ctx := context.Background()
+	s, repo, rbacCache := newTestRBACService()
+	parentID := func(id uint) *uint { return &id }
+
+	// 层级：4 <- 5 <- 8，修改 5 的上级角色影响 5、8 以及拥有它们的用户
+	repo.On("GetRoleByID", ctx, uint(4)).Return(&model.Role{ID: 4, Name: model.RoleViewer, Status: 1}, nil)
+	repo.On("GetRoleByID", ctx, uint(5)).Return(&model.Role{ID: 5, Name: "auditor", ParentID: parentID(4), Status: 1}, nil)
+	repo.On("GetRoleByID", ctx, uint(6)).Return(&model.Role{ID: 6, Name: "support", Status: 1}, nil)
+	repo.On("GetRoleByID", ctx, uint(8)).Return(&model.Role{ID: 8, Name: "senior-auditor", ParentID: parentID(5), Status: 1}, nil)
+	repo.On("GetRoleAncestorIDs", ctx, uint(6)).Return([]uint{}, nil)
+	repo.On("GetRoleAncestorIDs", ctx, uint(8)).Return([]uint{5, 4}, nil)
+	repo.On("GetRoleDescendantIDs", ctx, []uint{5}).Return([]uint{8}, nil)
+	repo.On("GetRoleUserIDs", ctx, []uint{5, 8}).Return([]uint{10}, nil)
+	repo.On("UpdateRole", ctx, mock.MatchedBy(func(role *model.Role) bool {
+		return role.ID == 5 && role.ParentID != nil && *role.ParentID == 6
+	})).Return(nil).Once()
+
+	role, err := s.SetRoleParent(ctx, 5, parentID(6))
+	require.NoError(t, err)
+	assert.Equal(t, uint(6), *role.ParentID)
+	assert.Equal(t, []uint{5, 8}, rbacCache.roles)
+	assert.Equal(t, []uint{10}, rbacCache.users)
+
+	// 上级角色不能是角色本身或它的下级角色
+	_, err = s.SetRoleParent(ctx, 5, parentID(5))
+	assert.ErrorIs(t, err, ErrRoleHierarchyCycle)
+	_, err = s.SetRoleParent(ctx, 5, parentID(8))
+	assert.ErrorIs(t, err, ErrRoleHierarchyCycle)
+
+	repo.On("GetRoleByID", ctx, uint(99)).Return(nil, gorm.ErrRecordNotFound)
+	_, err = s.SetRoleParent(ctx, 5, parentID(99))
+	assert.ErrorIs(t, err, ErrParentRoleNotFound)
+
+	// 内置角色的层级不能修改
+	_, err = s.SetRoleParent(ctx, 4, parentID(6))
+	assert.ErrorIs(t, err, ErrBuiltinRoleProtected)
+
+	// 创建时检查上级角色存在
+	repo.On("GetRoleByName", ctx, "new-role").Return(nil, gorm.ErrRecordNotFound)
+	err = s.CreateRole(ctx, &model.Role{Name: "new-role", ParentID: parentID(99)})
+	assert.ErrorIs(t, err, ErrParentRoleNotFound)
+	repo.AssertExpectations(t)
+}
+
+func TestRBACService_DeleteRole(t *testing.T) {
+	ctx := context.Background()
+	s, repo, rbacCache := newTestRBACService()
+
+	// 下级角色改为继承被删除角色的上级角色，同样需要失效
+	repo.On("GetRoleByID", ctx, uint(5)).Return(&model.Role{ID: 5, Name: "auditor"}, nil)
+	repo.On("GetRoleDescendantIDs", ctx, []uint{5}).Return([]uint{8}, nil)
+	repo.On("GetRoleUserIDs", ctx, []uint{5, 8}).Return([]uint{10, 13}, nil)
+	repo.On("DeleteRole", ctx, uint(5)).Return(nil).Once()
+
+	require.NoError(t, s.DeleteRole(ctx, 5))
+	assert.Equal(t, []uint{5, 8}, rbacCache.roles)
+	assert.Equal(t, []uint{10, 13}, rbacCache.users)
+
+	// 内置角色不能删除
+	repo.On("GetRoleByID", ctx, uint(3)).Return(&model.Role{ID: 3, Name: model.RoleEditor}, nil)
+	assert.ErrorIs(t, s.DeleteRole(ctx, 3), ErrBuiltinRoleProtected)
+
+	// 查询受影响用户失败时不删除，避免缓存中留下旧权限
+	repo.On("GetRoleByID", ctx, uint(7)).Return(&model.Role{ID: 7, Name: "temp"}, nil)
+	repo.On("GetRoleDescendantIDs", ctx, []uint{7}).Return(nil, errors.New("db down"))
+	assert.Error(t, s.DeleteRole(ctx, 7))
+	repo.AssertNotCalled(t, "DeleteRole", ctx, uint(7))
+	repo.AssertExpectations(t)
+}
+
+func TestRBACService_RemovePermissionsFromRole(t *testing.T) {
+	ctx := context.Background()
+	s, repo, rbacCache := newTestRBACService()
+
+	// 下级角色继承了被移除的权限
+	repo.On("GetRoleByID", ctx, uint(5)).Return(&model.Role{ID: 5, Name: "auditor"}, nil)
+	repo.On("GetRoleDescendantIDs", ctx, []uint{5}).Return([]uint{8, 9}, nil)
+	repo.On("GetRoleUserIDs", ctx, []uint{5, 8, 9}).Return([]uint{10, 12}, nil)
+	repo.On("RemovePermissionsFromRole", ctx, uint(5), []uint{3, 4}).Return(nil).Once()
+
+	require.NoError(t, s.RemovePermissionsFromRole(ctx, 5, []uint{3, 4}))
+	assert.Equal(t, []uint{5, 8, 9}, rbacCache.roles)
+	assert.Equal(t, []uint{10, 12}, rbacCache.users)
+
+	repo.On("GetRoleByID", ctx, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, s.AssignPermissionsToRole(ctx, 9, []uint{3}), ErrRoleNotFound)
+	repo.AssertExpectations(t)
+}
+
+func TestRBACService_PermissionChanges(t *testing.T) {
+	ctx := context.Background()
+	s, repo, rbacCache := newTestRBACService()
+
+	repo.On("GetPermissionByID", ctx, uint(3)).Return(&model.Permission{ID: 3, Code: "report:read", Status: 1}, nil)
+	repo.On("GetPermissionRoleIDs", ctx, uint(3)).Return([]uint{5, 6}, nil)
+	repo.On("GetRoleDescendantIDs", ctx, []uint{5, 6}).Return([]uint{}, nil)
+	repo.On("GetRoleUserIDs", ctx, []uint{5, 6}).Return([]uint{10, 11}, nil)
+	repo.On("GetPermissionByCode", ctx, "user:read").Return(&model.Permission{ID: 4, Code: "user:read"}, nil)
+
+	// 禁用权限使拥有它的角色及这些角色的用户的缓存失效
+	repo.On("UpdatePermission", ctx, mock.MatchedBy(func(permission *model.Permission) bool {
+		return permission.ID == 3 && permission.Status == 0
+	})).Return(nil).Once()
+	permission, err := s.UpdatePermissionStatus(ctx, 3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, permission.Status)
+	assert.Equal(t, []uint{5, 6}, rbacCache.roles)
+	assert.Equal(t, []uint{10, 11}, rbacCache.users)
+
+	// 编码不能与其他权限重复
+	err = s.UpdatePermission(ctx, &model.Permission{ID: 3, Code: "user:read", Status: 1})
+	assert.ErrorIs(t, err, ErrPermissionCodeExists)
+
+	// 删除同样使相关缓存失效
+	rbacCache.roles, rbacCache.users = nil, nil
+	repo.On("DeletePermission", ctx, uint(3)).Return(nil).Once()
+	require.NoError(t, s.DeletePermission(ctx, 3))
+	assert.Equal(t, []uint{5, 6}, rbacCache.roles)
+	assert.Equal(t, []uint{10, 11}, rbacCache.users)
+
+	repo.On("GetPermissionByID", ctx, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, s.DeletePermission(ctx, 9), ErrPermissionNotFound)
+	repo.AssertExpectations(t)
+}
+
+func TestRBACService_RemoveRoleFromUser(t *testing.T) {
+	ctx := context.Background()
+	s, repo, rbacCache := newTestRBACService()
+
+	repo.On("RemoveRoleFromUser", ctx, uint(10), uint(5)).Return(nil).Once()
+	require.NoError(t, s.RemoveRoleFromUser(ctx, 10, 5))
+	assert.Empty(t, rbacCache.roles)
+	assert.Equal(t, []uint{10}, rbacCache.users)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrServiceAccountNotFound 服务账号不存在
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	// ErrServiceAccountExists 用户名已被占用
+	ErrServiceAccountExists = errors.New("username already exists")
+)
+
+// serviceAccountEmailDomain 服务账号的占位邮箱域名，保留域名，不会真正收到邮件
+const serviceAccountEmailDomain = "service-account.invalid"
+
+// ServiceAccountService 服务账号管理服务
+// 服务账号没有密码，不能登录，只能通过 API Key 访问，角色通过 RBAC 接口分配
+type ServiceAccountService interface {
+	CreateServiceAccount(ctx context.Context, actorID uint, username, ip string) (*model.User, error)
+	ListServiceAccounts(ctx context.Context) ([]*model.User, error)
+	GetServiceAccount(ctx context.Context, id uint) (*model.User, error)
+	// DeleteServiceAccount 删除服务账号并吊销其所有 API Key
+	DeleteServiceAccount(ctx context.Context, actorID, id uint, ip string) error
+
+	// CreateKey 为服务账号创建 API Key
+	CreateKey(ctx context.Context, actorID, accountID uint, input CreateAPIKeyInput, ip string) (*CreatedAPIKey, error)
+	ListKeys(ctx context.Context, accountID uint) ([]*model.APIKey, error)
+	RevokeKey(ctx context.Context, actorID, accountID, keyID uint, ip string) error
+}
+
+type serviceAccountService struct {
+	userRepo      repository.UserRepository
+	apiKeyService APIKeyService
+	statusService UserStatusService
+	audit         AuditService
+	logger        *zap.Logger
+}
+
+// NewServiceAccountService 创建服务账号管理服务
+func NewServiceAccountService(userRepo repository.UserRepository, apiKeyService APIKeyService, statusService UserStatusService, audit AuditService, logger *zap.Logger) ServiceAccountService {
+	return &serviceAccountService{
+		userRepo:      userRepo,
+		apiKeyService: apiKeyService,
+		statusService: statusService,
+		audit:         audit,
+		logger:        logger,
+	}
+}
+
+func (s *serviceAccountService) CreateServiceAccount(ctx context.Context, actorID uint, username, ip string) (*model.User, error) {
+	existing, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("Failed to check username", zap.Error(err))
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrServiceAccountExists
+	}
+
+	now := time.Now()
+	account := &model.User{
+		Username:        username,
+		Email:           fmt.Sprintf("%s@%s", username, serviceAccountEmailDomain),
+		Status:          1,
+		AccountType:     model.AccountTypeService,
+		CreatedBy:       &actorID,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.userRepo.Create(ctx, account); err != nil {
+		s.logger.Error("Failed to create service account", zap.String("username", username), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Service account created",
+		zap.Uint("actor_id", actorID),
+		zap.Uint("account_id", account.ID),
+		zap.String("username", username))
+
+	if err := s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    actorID,
+		Action:     model.AuditActionServiceAccountCreated,
+		TargetType: "user",
+		TargetID:   account.ID,
+		Detail:     fmt.Sprintf("username=%s", username),
+		IP:         ip,
+	}); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *serviceAccountService) ListServiceAccounts(ctx context.Context) ([]*model.User, error) {
+	accounts, err := s.userRepo.ListByAccountType(ctx, model.AccountTypeService)
+	if err != nil {
+		s.logger.Error("Failed to list service accounts", zap.Error(err))
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (s *serviceAccountService) GetServiceAccount(ctx context.Context, id uint) (*model.User, error) {
+	account, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrServiceAccountNotFound
+		}
+		s.logger.Error("Failed to get service account", zap.Uint("account_id", id), zap.Error(err))
+		return nil, err
+	}
+	if !account.IsServiceAccount() {
+		return nil, ErrServiceAccountNotFound
+	}
+	return account, nil
+}
+
+func (s *serviceAccountService) DeleteServiceAccount(ctx context.Context, actorID, id uint, ip string) error {
+	account, err := s.GetServiceAccount(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.apiKeyService.RevokeUserKeys(ctx, id); err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(ctx, id); err != nil {
+		s.logger.Error("Failed to delete service account", zap.Uint("account_id", id), zap.Error(err))
+		return err
+	}
+	s.statusService.InvalidateUserStatus(ctx, id)
+
+	s.logger.Info("Service account deleted",
+		zap.Uint("actor_id", actorID),
+		zap.Uint("account_id", id))
+
+	return s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    actorID,
+		Action:     model.AuditActionServiceAccountDeleted,
+		TargetType: "user",
+		TargetID:   id,
+		Detail:     fmt.Sprintf("username=%s", account.Username),
+		IP:         ip,
+	})
+}
+
+func (s *serviceAccountService) CreateKey(ctx context.Context, actorID, accountID uint, input CreateAPIKeyInput, ip string) (*CreatedAPIKey, error) {
+	if _, err := s.GetServiceAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	return s.apiKeyService.CreateKey(ctx, actorID, accountID, input, ip)
+}
+
+func (s *serviceAccountService) ListKeys(ctx context.Context, accountID uint) ([]*model.APIKey, error) {
+	if _, err := s.GetServiceAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	return s.apiKeyService.ListKeys(ctx, accountID)
+}
+
+func (s *serviceAccountService) RevokeKey(ctx context.Context, actorID, accountID, keyID uint, ip string) error {
+	if _, err := s.GetServiceAccount(ctx, accountID); err != nil {
+		return err
+	}
+	return s.apiKeyService.RevokeKey(ctx, actorID, accountID, keyID, ip)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ErrSessionNotFound 会话不存在、已失效或不属于该用户
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionService 登录会话（设备）管理服务
+type SessionService interface {
+	// ListSessions 列出用户当前有效的会话，currentID 对应的会话标记为当前会话
+	ListSessions(ctx context.Context, userID uint, currentID string) ([]*model.UserSession, error)
+	// RevokeSession 用户移除自己的某个会话，该设备立即退出登录
+	RevokeSession(ctx context.Context, userID uint, sessionID string) error
+	// RevokeOtherSessions 用户退出除当前会话外的所有会话，返回移除的数量
+	RevokeOtherSessions(ctx context.Context, userID uint, currentID string) (int, error)
+	// AdminRevokeSession 管理员强制用户的某个会话退出登录并记录审计日志
+	AdminRevokeSession(ctx context.Context, adminID, userID uint, sessionID, ip string) error
+}
+
+type sessionService struct {
+	repo         repository.SessionRepository
+	tokenService TokenService
+	audit        AuditService
+	logger       *zap.Logger
+}
+
+// NewSessionService 创建登录会话管理服务
+func NewSessionService(repo repository.SessionRepository, tokenService TokenService, audit AuditService, logger *zap.Logger) SessionService {
+	return &sessionService{
+		repo:         repo,
+		tokenService: tokenService,
+		audit:        audit,
+		logger:       logger,
+	}
+}
+
+func (s *sessionService) ListSessions(ctx context.Context, userID uint, currentID string) ([]*model.UserSession, error) {
+	sessions, err := s.repo.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to list sessions", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+	return sessions, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	if _, err := s.getActiveSession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	if err := s.tokenService.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+
+	s.logger.Info("User revoked session", zap.Uint("user_id", userID), zap.String("session_id", sessionID))
+	return nil
+}
+
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, userID uint, currentID string) (int, error) {
+	sessions, err := s.repo.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to list sessions", zap.Uint("user_id", userID), zap.Error(err))
+		return 0, err
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		if session.ID == currentID {
+			continue
+		}
+		if err := s.tokenService.RevokeSession(ctx, session.ID); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	s.logger.Info("User revoked other sessions", zap.Uint("user_id", userID), zap.Int("count", revoked))
+	return revoked, nil
+}
+
+func (s *sessionService) AdminRevokeSession(ctx context.Context, adminID, userID uint, sessionID, ip string) error {
+	session, err := s.getActiveSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenService.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Admin revoked user session",
+		zap.Uint("admin_id", adminID),
+		zap.Uint("user_id", userID),
+		zap.String("session_id", sessionID))
+
+	return s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    adminID,
+		Action:     model.AuditActionSessionRevoked,
+		TargetType: "user",
+		TargetID:   userID,
+		Detail:     fmt.Sprintf("session_id=%s device=%s ip=%s", session.ID, session.Device, session.IP),
+		IP:         ip,
+	})
+}
+
+// getActiveSession 获取用户的有效会话，会话属于其他用户时同样返回 ErrSessionNotFound
+func (s *sessionService) getActiveSession(ctx context.Context, userID uint, sessionID string) (*model.UserSession, error) {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		s.logger.Error("Failed to get session", zap.String("session_id", sessionID), zap.Error(err))
+		return nil, err
+	}
+
+	if session.UserID != userID || session.RevokedAt != nil {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
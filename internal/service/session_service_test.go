@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"trx-project/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MockSessionRepository 模拟登录会话仓库
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(ctx context.Context, session *model.UserSession) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetByID(ctx context.Context, id string) (*model.UserSession, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UserSession), args.Error(1)
+}
+
+func (m *MockSessionRepository) ListActiveByUserID(ctx context.Context, userID uint) ([]*model.UserSession, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.UserSession), args.Error(1)
+}
+
+func (m *MockSessionRepository) Touch(ctx context.Context, id string, seenAt, expiresAt time.Time) error {
+	args := m.Called(ctx, id, seenAt, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Revoke(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) RevokeByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestSessionService_RevokeSession(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockSessionRepository)
+	mockTokens := new(MockTokenService)
+	service := NewSessionService(mockRepo, mockTokens, nil, zap.NewNop())
+
+	mockRepo.On("GetByID", ctx, "own").Return(&model.UserSession{ID: "own", UserID: 1}, nil)
+	mockRepo.On("GetByID", ctx, "other").Return(&model.UserSession{ID: "other", UserID: 2}, nil)
+	mockRepo.On("GetByID", ctx, "missing").Return(nil, gorm.ErrRecordNotFound)
+	mockTokens.On("RevokeSession", ctx, "own").Return(nil).Once()
+
+	require.NoError(t, service.RevokeSession(ctx, 1, "own"))
+
+	// 其他用户的会话和不存在的会话都按不存在处理
+	assert.ErrorIs(t, service.RevokeSession(ctx, 1, "other"), ErrSessionNotFound)
+	assert.ErrorIs(t, service.RevokeSession(ctx, 1, "missing"), ErrSessionNotFound)
+
+	mockTokens.AssertNumberOfCalls(t, "RevokeSession", 1)
+}
+
+func TestSessionService_RevokeOtherSessions(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockSessionRepository)
+	mockTokens := new(MockTokenService)
+	service := NewSessionService(mockRepo, mockTokens, nil, zap.NewNop())
+
+	mockRepo.On("ListActiveByUserID", ctx, uint(1)).Return([]*model.UserSession{
+		{ID: "current", UserID: 1},
+		{ID: "laptop", UserID: 1},
+		{ID: "phone", UserID: 1},
+	}, nil)
+	mockTokens.On("RevokeSession", ctx, "laptop").Return(nil).Once()
+	mockTokens.On("RevokeSession", ctx, "phone").Return(nil).Once()
+
+	revoked, err := service.RevokeOtherSessions(ctx, 1, "current")
+	require.NoError(t, err)
+	assert.Equal(t, 2, revoked)
+	mockTokens.AssertNotCalled(t, "RevokeSession", ctx, "current")
+
+	sessions, err := service.ListSessions(ctx, 1, "current")
+	require.NoError(t, err)
+	assert.True(t, sessions[0].Current)
+	assert.False(t, sessions[1].Current)
+}
+
+func TestDeviceName(t *testing.T) {
+	tests := map[string]string{
+		"":           "Unknown device",
+		"curl/8.4.0": "curl",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36":                   "Chrome on macOS",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0":           "Edge on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1": "Safari on iOS",
+	}
+	for userAgent, expected := range tests {
+		assert.Equal(t, expected, DeviceName(userAgent), userAgent)
+	}
+}
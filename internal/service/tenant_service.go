@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/tenant"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTenantNotFound 租户不存在
+	ErrTenantNotFound = errors.New("tenant not found")
+	// ErrTenantNameExists 租户标识已被占用
+	ErrTenantNameExists = errors.New("tenant name already exists")
+	// ErrPlatformTenantProtected 平台租户不能删除
+	ErrPlatformTenantProtected = errors.New("platform tenant cannot be deleted")
+	// ErrTenantNotEmpty 租户下还有用户，不能删除
+	ErrTenantNotEmpty = errors.New("tenant still has users")
+	// ErrTenantUserNotFound 要移动的用户不存在
+	ErrTenantUserNotFound = errors.New("user not found")
+)
+
+// TenantService 租户管理服务，只有平台管理员可以使用
+type TenantService interface {
+	ListTenants(ctx context.Context) ([]*model.Tenant, error)
+	GetTenant(ctx context.Context, id uint) (*model.Tenant, error)
+	CreateTenant(ctx context.Context, name, displayName string) (*model.Tenant, error)
+	// UpdateTenant 修改显示名称，租户标识创建后不能修改
+	UpdateTenant(ctx context.Context, id uint, displayName string) (*model.Tenant, error)
+	// DeleteTenant 删除没有用户的租户，平台租户不能删除
+	DeleteTenant(ctx context.Context, id uint) error
+	// MoveUser 将用户移到另一个租户，移除用户在原租户的所有角色并吊销其所有 Token
+	MoveUser(ctx context.Context, actorID, userID, tenantID uint, ip string) error
+}
+
+type tenantService struct {
+	repo         repository.TenantRepository
+	userRepo     repository.UserRepository
+	rbacService  RBACService
+	tokenService TokenService
+	audit        AuditService
+	logger       *zap.Logger
+}
+
+// NewTenantService 创建租户管理服务
+func NewTenantService(repo repository.TenantRepository, userRepo repository.UserRepository, rbacService RBACService, tokenService TokenService, audit AuditService, logger *zap.Logger) TenantService {
+	return &tenantService{
+		repo:         repo,
+		userRepo:     userRepo,
+		rbacService:  rbacService,
+		tokenService: tokenService,
+		audit:        audit,
+		logger:       logger,
+	}
+}
+
+func (s *tenantService) ListTenants(ctx context.Context) ([]*model.Tenant, error) {
+	tenants, err := s.repo.List(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list tenants", zap.Error(err))
+		return nil, err
+	}
+	return tenants, nil
+}
+
+func (s *tenantService) GetTenant(ctx context.Context, id uint) (*model.Tenant, error) {
+	t, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		s.logger.Error("Failed to get tenant", zap.Uint("tenant_id", id), zap.Error(err))
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *tenantService) CreateTenant(ctx context.Context, name, displayName string) (*model.Tenant, error) {
+	existing, err := s.repo.GetByName(ctx, name)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("Failed to check tenant name", zap.Error(err))
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrTenantNameExists
+	}
+
+	t := &model.Tenant{Name: name, DisplayName: displayName}
+	if err := s.repo.Create(ctx, t); err != nil {
+		s.logger.Error("Failed to create tenant", zap.String("name", name), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Tenant created", zap.Uint("tenant_id", t.ID), zap.String("name", name))
+	return t, nil
+}
+
+func (s *tenantService) UpdateTenant(ctx context.Context, id uint, displayName string) (*model.Tenant, error) {
+	t, err := s.GetTenant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	t.DisplayName = displayName
+	if err := s.repo.Update(ctx, t); err != nil {
+		s.logger.Error("Failed to update tenant", zap.Uint("tenant_id", id), zap.Error(err))
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *tenantService) DeleteTenant(ctx context.Context, id uint) error {
+	if id == model.PlatformTenantID {
+		return ErrPlatformTenantProtected
+	}
+	if _, err := s.GetTenant(ctx, id); err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountUsers(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to count tenant users", zap.Uint("tenant_id", id), zap.Error(err))
+		return err
+	}
+	if count > 0 {
+		return ErrTenantNotEmpty
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.logger.Error("Failed to delete tenant", zap.Uint("tenant_id", id), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Tenant deleted", zap.Uint("tenant_id", id))
+	return nil
+}
+
+func (s *tenantService) MoveUser(ctx context.Context, actorID, userID, tenantID uint, ip string) error {
+	target, err := s.GetTenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	// 用户可能属于任何租户，不受当前请求限定的租户影响
+	ctx = tenant.WithAllTenants(ctx)
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTenantUserNotFound
+		}
+		s.logger.Error("Failed to get user", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+	if user.TenantID == tenantID {
+		return nil
+	}
+
+	// 通过 RBACService 移除角色，使用户的权限缓存失效；MoveUser 会删除剩余的角色分配
+	roles, err := s.rbacService.GetUserRoles(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if err := s.rbacService.RemoveRoleFromUser(ctx, userID, role.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.MoveUser(ctx, userID, tenantID); err != nil {
+		s.logger.Error("Failed to move user", zap.Uint("user_id", userID), zap.Uint("tenant_id", tenantID), zap.Error(err))
+		return err
+	}
+
+	// 已签发的 Token 带有原租户，必须重新登录
+	if err := s.tokenService.RevokeUserTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	s.logger.Info("User moved to tenant",
+		zap.Uint("actor_id", actorID),
+		zap.Uint("user_id", userID),
+		zap.Uint("from_tenant_id", user.TenantID),
+		zap.Uint("tenant_id", tenantID))
+
+	return s.audit.Record(ctx, &model.AuditLog{
+		ActorID:    actorID,
+		Action:     model.AuditActionUserTenantChanged,
+		TargetType: "user",
+		TargetID:   userID,
+		Detail:     fmt.Sprintf("from_tenant_id=%d to_tenant=%s", user.TenantID, target.Name),
+		IP:         ip,
+	})
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"trx-project/internal/model"
+	"trx-project/pkg/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MockTenantRepository 模拟租户 repository
+type MockTenantRepository struct {
+	mock.Mock
+}
+
+func (m *MockTenantRepository) Create(ctx context.Context, t *model.Tenant) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
+func (m *MockTenantRepository) GetByID(ctx context.Context, id uint) (*model.Tenant, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepository) GetByName(ctx context.Context, name string) (*model.Tenant, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepository) List(ctx context.Context) ([]*model.Tenant, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepository) Update(ctx context.Context, t *model.Tenant) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
+func (m *MockTenantRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTenantRepository) CountUsers(ctx context.Context, id uint) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTenantRepository) MoveUser(ctx context.Context, userID, tenantID uint) error {
+	args := m.Called(ctx, userID, tenantID)
+	return args.Error(0)
+}
+
+func newTestTenantService() (TenantService, *MockTenantRepository, *MockUserRepository, *MockRBACService, *MockTokenService, *MockAuditService) {
+	repo := new(MockTenantRepository)
+	userRepo := new(MockUserRepository)
+	rbac := new(MockRBACService)
+	tokens := new(MockTokenService)
+	audit := new(MockAuditService)
+	return NewTenantService(repo, userRepo, rbac, tokens, audit, zap.NewNop()), repo, userRepo, rbac, tokens, audit
+}
+
+func TestTenantService_CreateTenant(t *testing.T) {
+	ctx := context.Background()
+	s, repo, _, _, _, _ := newTestTenantService()
+
+	repo.On("GetByName", ctx, "acme").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetByName", ctx, "platform").Return(&model.Tenant{ID: model.PlatformTenantID, Name: "platform"}, nil)
+	repo.On("Create", ctx, mock.AnythingOfType("*model.Tenant")).Return(nil)
+
+	created, err := s.CreateTenant(ctx, "acme", "Acme")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", created.Name)
+	assert.Equal(t, "Acme", created.DisplayName)
+
+	_, err = s.CreateTenant(ctx, "platform", "Platform")
+	assert.ErrorIs(t, err, ErrTenantNameExists)
+	repo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestTenantService_DeleteTenant(t *testing.T) {
+	ctx := context.Background()
+	s, repo, _, _, _, _ := newTestTenantService()
+
+	repo.On("GetByID", ctx, uint(2)).Return(&model.Tenant{ID: 2, Name: "acme"}, nil)
+	repo.On("GetByID", ctx, uint(3)).Return(&model.Tenant{ID: 3, Name: "empty"}, nil)
+	repo.On("GetByID", ctx, uint(4)).Return(nil, gorm.ErrRecordNotFound)
+	repo.On("CountUsers", ctx, uint(2)).Return(int64(5), nil)
+	repo.On("CountUsers", ctx, uint(3)).Return(int64(0), nil)
+	repo.On("Delete", ctx, uint(3)).Return(nil)
+
+	// 平台租户和还有用户的租户不能删除
+	assert.ErrorIs(t, s.DeleteTenant(ctx, model.PlatformTenantID), ErrPlatformTenantProtected)
+	assert.ErrorIs(t, s.DeleteTenant(ctx, 2), ErrTenantNotEmpty)
+	assert.ErrorIs(t, s.DeleteTenant(ctx, 4), ErrTenantNotFound)
+	require.NoError(t, s.DeleteTenant(ctx, 3))
+	repo.AssertNumberOfCalls(t, "Delete", 1)
+}
+
+func TestTenantService_MoveUser(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), model.PlatformTenantID)
+	s, repo, userRepo, rbac, tokens, audit := newTestTenantService()
+	allTenants := mock.MatchedBy(tenant.IsAllTenants)
+
+	repo.On("GetByID", ctx, uint(2)).Return(&model.Tenant{ID: 2, Name: "acme"}, nil)
+	// 用户可能不在当前请求限定的租户中，必须跨租户查找
+	userRepo.On("GetByID", allTenants, uint(42)).Return(&model.User{ID: 42, TenantID: model.PlatformTenantID}, nil)
+	rbac.On("GetUserRoles", allTenants, uint(42)).Return([]*model.Role{{ID: 3}, {ID: 5}}, nil)
+	rbac.On("RemoveRoleFromUser", allTenants, uint(42), uint(3)).Return(nil)
+	rbac.On("RemoveRoleFromUser", allTenants, uint(42), uint(5)).Return(nil)
+	repo.On("MoveUser", allTenants, uint(42), uint(2)).Return(nil)
+	tokens.On("RevokeUserTokens", allTenants, uint(42)).Return(nil)
+	audit.On("Record", allTenants, mock.MatchedBy(func(log *model.AuditLog) bool {
+		return log.Action == model.AuditActionUserTenantChanged && log.ActorID == 1 && log.TargetID == 42
+	})).Return(nil)
+
+	require.NoError(t, s.MoveUser(ctx, 1, 42, 2, "127.0.0.1"))
+	rbac.AssertNumberOfCalls(t, "RemoveRoleFromUser", 2)
+	tokens.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestTenantService_MoveUserNoop(t *testing.T) {
+	ctx := context.Background()
+	s, repo, userRepo, rbac, tokens, _ := newTestTenantService()
+	allTenants := mock.MatchedBy(tenant.IsAllTenants)
+
+	repo.On("GetByID", ctx, uint(2)).Return(&model.Tenant{ID: 2, Name: "acme"}, nil)
+	repo.On("GetByID", ctx, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+	userRepo.On("GetByID", allTenants, uint(42)).Return(&model.User{ID: 42, TenantID: 2}, nil)
+	userRepo.On("GetByID", allTenants, uint(43)).Return(nil, gorm.ErrRecordNotFound)
+
+	// 已在目标租户中时不做任何修改
+	require.NoError(t, s.MoveUser(ctx, 1, 42, 2, ""))
+	assert.ErrorIs(t, s.MoveUser(ctx, 1, 43, 2, ""), ErrTenantUserNotFound)
+	assert.ErrorIs(t, s.MoveUser(ctx, 1, 42, 9, ""), ErrTenantNotFound)
+
+	rbac.AssertNotCalled(t, "RemoveRoleFromUser", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "MoveUser", mock.Anything, mock.Anything, mock.Anything)
+	tokens.AssertNotCalled(t, "RevokeUserTokens", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,519 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/internal/repository"
+	"trx-project/pkg/cache"
+	"trx-project/pkg/config"
+	"trx-project/pkg/jwt"
+	"trx-project/pkg/tenant"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRefreshTokenInvalid Refresh Token 无效、已过期或已被吊销
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid")
+	// ErrRefreshTokenReused Refresh Token 被重复使用，整个家族已被吊销
+	ErrRefreshTokenReused = errors.New("refresh token has been reused")
+	// ErrTokenRevoked Access Token 已被吊销
+	ErrTokenRevoked = errors.New("token has been revoked")
+)
+
+// TokenPair Access Token 与 Refresh Token 对
+type TokenPair struct {
+	AccessToken      string `json:"token"`              // Access Token（JWT）
+	RefreshToken     string `json:"refresh_token"`      // Refresh Token（不透明随机串）
+	ExpiresIn        int64  `json:"expires_in"`         // Access Token 有效期（秒）
+	RefreshExpiresIn int64  `json:"refresh_expires_in"` // Refresh Token 有效期（秒）
+}
+
+// RefreshSession 被消费的 Refresh Token 所属的会话信息
+type RefreshSession struct {
+	UserID   uint
+	Username string
+	Role     string
+	FamilyID string
+}
+
+// TokenService Token 签发与轮换服务
+type TokenService interface {
+	// IssueTokenPair 签发 Token 对，familyID 为空时开启新的 Token 家族
+	IssueTokenPair(ctx context.Context, user *model.User, role, familyID string) (*TokenPair, error)
+	// IssueImpersonationToken 签发管理员模拟用户的前台 Access Token，Token 带有 act 声明
+	// 不签发 Refresh Token，但会创建会话，可以像普通登录一样单独吊销
+	IssueImpersonationToken(ctx context.Context, user, actor *model.User, ttl time.Duration) (string, error)
+	// ConsumeRefreshToken 校验并消费 Refresh Token（单次使用）
+	// 已使用过的 Token 再次出现时会吊销整个家族并返回 ErrRefreshTokenReused
+	ConsumeRefreshToken(ctx context.Context, refreshToken string) (*RefreshSession, error)
+	// RevokeRefreshFamily 吊销 Token 家族
+	RevokeRefreshFamily(ctx context.Context, familyID string) error
+
+	// ParseAccessToken 解析 Access Token 并检查是否已被吊销、用户是否仍可访问
+	ParseAccessToken(ctx context.Context, tokenString string) (*jwt.Claims, error)
+	// Logout 吊销当前 Access Token，refreshToken 不为空时一并吊销其所属的登录会话
+	Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error
+	// RevokeUserTokens 递增用户 Token 版本并吊销所有 Refresh Token 和 API Key，用户之前签发的所有凭证立即失效
+	// 修改或重置密码、退出所有设备、管理员吊销、修改状态和移动租户都通过它吊销
+	RevokeUserTokens(ctx context.Context, userID uint) error
+	// RevokeSession 吊销登录会话，该会话的 Refresh Token 和 Access Token 立即失效
+	RevokeSession(ctx context.Context, sessionID string) error
+}
+
+// sessionSeenInterval 会话最近活跃时间的最小更新间隔
+const sessionSeenInterval = time.Minute
+
+type tokenService struct {
+	repo       repository.UserRepository
+	sessions   repository.SessionRepository
+	store      *cache.RefreshTokenStore
+	revocation *cache.TokenRevocationStore
+	versions   *cache.TokenVersionCache
+	status     UserStatusService
+	rbac       RBACService
+	apiKeys    APIKeyService
+	logger     *zap.Logger
+	jwtConfig  jwt.Config
+	failOpen   bool
+}
+
+// NewTokenService 创建 Token 服务
+func NewTokenService(
+	repo repository.UserRepository,
+	sessions repository.SessionRepository,
+	store *cache.RefreshTokenStore,
+	revocation *cache.TokenRevocationStore,
+	versions *cache.TokenVersionCache,
+	status UserStatusService,
+	rbac RBACService,
+	apiKeys APIKeyService,
+	logger *zap.Logger,
+	jwtConfig jwt.Config,
+	cfg *config.Config,
+) TokenService {
+	return &tokenService{
+		repo:       repo,
+		sessions:   sessions,
+		store:      store,
+		revocation: revocation,
+		versions:   versions,
+		status:     status,
+		rbac:       rbac,
+		apiKeys:    apiKeys,
+		logger:     logger,
+		jwtConfig:  jwtConfig,
+		failOpen:   cfg.Auth.StatusCheckFailOpen,
+	}
+}
+
+func (s *tokenService) IssueTokenPair(ctx context.Context, user *model.User, role, familyID string) (*TokenPair, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.jwtConfig.RefreshExpireTime)
+
+	// 新的 Token 家族即一次新的登录，创建会话记录；刷新时顺延会话有效期
+	if familyID == "" {
+		familyID = uuid.NewString()
+		if err := s.createSession(ctx, familyID, user.ID, role, now, expiresAt); err != nil {
+			return nil, err
+		}
+	} else if err := s.sessions.Touch(ctx, familyID, now, expiresAt); err != nil {
+		s.logger.Warn("Failed to update session", zap.String("session_id", familyID), zap.Error(err))
+	}
+
+	accessToken, err := jwt.IssueToken(&jwt.Claims{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         role,
+		TenantID:     user.TenantID,
+		TokenVersion: user.TokenVersion,
+		SessionID:    familyID,
+	}, s.jwtConfig)
+	if err != nil {
+		s.logger.Error("Failed to generate access token", zap.Error(err))
+		return nil, err
+	}
+
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		s.logger.Error("Failed to generate refresh token", zap.Error(err))
+		return nil, err
+	}
+
+	record := &cache.RefreshTokenRecord{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      role,
+		FamilyID:  familyID,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.store.Save(ctx, hashToken(refreshToken), record); err != nil {
+		s.logger.Error("Failed to save refresh token", zap.Uint("user_id", user.ID), zap.Error(err))
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        int64(s.jwtConfig.ExpireTime.Seconds()),
+		RefreshExpiresIn: int64(s.jwtConfig.RefreshExpireTime.Seconds()),
+	}, nil
+}
+
+func (s *tokenService) IssueImpersonationToken(ctx context.Context, user, actor *model.User, ttl time.Duration) (string, error) {
+	config := s.jwtConfig
+	config.ExpireTime = ttl
+
+	// 模拟登录也创建会话，用户和管理员都可以在会话管理中单独结束
+	now := time.Now()
+	sessionID := uuid.NewString()
+	if err := s.createSession(ctx, sessionID, user.ID, jwt.RoleUser, now, now.Add(ttl)); err != nil {
+		return "", err
+	}
+	if err := s.store.SaveFamily(ctx, user.ID, sessionID, now.Add(ttl)); err != nil {
+		s.logger.Error("Failed to save impersonation session", zap.Uint("user_id", user.ID), zap.Error(err))
+		return "", err
+	}
+
+	token, err := jwt.IssueToken(&jwt.Claims{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         jwt.RoleUser,
+		TenantID:     user.TenantID,
+		TokenVersion: user.TokenVersion,
+		SessionID:    sessionID,
+		Actor: &jwt.Actor{
+			UserID:   actor.ID,
+			Username: actor.Username,
+		},
+	}, config)
+	if err != nil {
+		s.logger.Error("Failed to generate impersonation token", zap.Error(err))
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *tokenService) ConsumeRefreshToken(ctx context.Context, refreshToken string) (*RefreshSession, error) {
+	tokenHash := hashToken(refreshToken)
+
+	record, err := s.store.Get(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, cache.ErrRefreshTokenNotFound) {
+			return nil, ErrRefreshTokenInvalid
+		}
+		s.logger.Error("Failed to get refresh token", zap.Error(err))
+		return nil, err
+	}
+
+	active, err := s.store.IsFamilyActive(ctx, record.FamilyID)
+	if err != nil {
+		s.logger.Error("Failed to check refresh token family", zap.Error(err))
+		return nil, err
+	}
+	if !active {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	// 单次使用：已使用过的 Token 再次出现说明可能被盗用，吊销整个家族
+	firstUse, err := s.store.MarkUsed(ctx, tokenHash, record.ExpiresAt)
+	if err != nil {
+		s.logger.Error("Failed to mark refresh token used", zap.Error(err))
+		return nil, err
+	}
+	if !firstUse {
+		s.logger.Warn("Refresh token reuse detected, revoking family",
+			zap.Uint("user_id", record.UserID),
+			zap.String("family_id", record.FamilyID))
+		if err := s.RevokeSession(ctx, record.FamilyID); err != nil {
+			s.logger.Error("Failed to revoke refresh token family", zap.Error(err))
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	return &RefreshSession{
+		UserID:   record.UserID,
+		Username: record.Username,
+		Role:     record.Role,
+		FamilyID: record.FamilyID,
+	}, nil
+}
+
+func (s *tokenService) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	return s.RevokeSession(ctx, familyID)
+}
+
+func (s *tokenService) ParseAccessToken(ctx context.Context, tokenString string) (*jwt.Claims, error) {
+	claims, err := jwt.ParseTokenWithKeys(tokenString, s.jwtConfig.KeySet())
+	if err != nil {
+		return nil, err
+	}
+
+	// 单个 Token 吊销（退出登录）
+	if claims.ID != "" {
+		revoked, err := s.revocation.IsTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			if err := s.checkUnavailable("revocation", claims.UserID, err); err != nil {
+				return nil, err
+			}
+		} else if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	// 会话吊销（退出登录、在设备管理中移除）：会话与 Refresh Token 家族同生命周期
+	if claims.SessionID != "" {
+		active, err := s.store.IsFamilyActive(ctx, claims.SessionID)
+		if err != nil {
+			if err := s.checkUnavailable("session", claims.UserID, err); err != nil {
+				return nil, err
+			}
+		} else if !active {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	// 用户已被禁用或删除
+	if err := s.status.CheckUserStatus(ctx, claims.UserID); err != nil {
+		return nil, err
+	}
+
+	// 用户级吊销：Token 版本落后于用户当前版本
+	version, err := s.currentTokenVersion(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenRevoked
+		}
+		if err := s.checkUnavailable("token_version", claims.UserID, err); err != nil {
+			return nil, err
+		}
+	} else if claims.TokenVersion != version {
+		return nil, ErrTokenRevoked
+	}
+
+	// 模拟登录：发起模拟的管理员被禁用或失去模拟权限后 Token 立即失效
+	if claims.Impersonated() {
+		if err := s.checkActor(ctx, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	s.touchSession(ctx, claims.SessionID)
+	return claims, nil
+}
+
+func (s *tokenService) Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error {
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := s.revocation.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			s.logger.Error("Failed to revoke access token", zap.Uint("user_id", claims.UserID), zap.Error(err))
+			return err
+		}
+	}
+
+	// 吊销当前 Access Token 所属的会话
+	if claims.SessionID != "" {
+		if err := s.RevokeSession(ctx, claims.SessionID); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	record, err := s.store.Get(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, cache.ErrRefreshTokenNotFound) {
+			return nil
+		}
+		s.logger.Error("Failed to get refresh token", zap.Error(err))
+		return err
+	}
+
+	// 只允许吊销自己的登录会话
+	if record.UserID != claims.UserID {
+		s.logger.Warn("Refresh token does not belong to current user",
+			zap.Uint("user_id", claims.UserID),
+			zap.Uint("owner_id", record.UserID))
+		return nil
+	}
+
+	return s.RevokeSession(ctx, record.FamilyID)
+}
+
+func (s *tokenService) RevokeUserTokens(ctx context.Context, userID uint) error {
+	version, err := s.repo.IncrementTokenVersion(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to increment token version", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	// API Key 不受 Token 版本约束，需要单独吊销；保存在数据库中，先于 Redis 吊销
+	if err := s.apiKeys.RevokeUserKeys(ctx, userID); err != nil {
+		return err
+	}
+
+	// 立即写入缓存，所有实例下一次请求即可看到新版本
+	if err := s.versions.Set(ctx, userID, version); err != nil {
+		s.logger.Error("Failed to cache token version", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	if err := s.store.RevokeUserFamilies(ctx, userID); err != nil {
+		s.logger.Error("Failed to revoke user refresh tokens", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	if err := s.sessions.RevokeByUserID(ctx, userID); err != nil {
+		s.logger.Error("Failed to revoke user sessions", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("User tokens revoked", zap.Uint("user_id", userID), zap.Uint("token_version", version))
+	return nil
+}
+
+func (s *tokenService) RevokeSession(ctx context.Context, sessionID string) error {
+	if err := s.store.RevokeFamily(ctx, sessionID); err != nil {
+		return err
+	}
+
+	if _, err := s.sessions.Revoke(ctx, sessionID); err != nil {
+		s.logger.Error("Failed to revoke session", zap.String("session_id", sessionID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// createSession 记录登录会话，客户端信息来自请求 context
+func (s *tokenService) createSession(ctx context.Context, sessionID string, userID uint, role string, now, expiresAt time.Time) error {
+	client := ClientInfoFromContext(ctx)
+	session := &model.UserSession{
+		ID:         sessionID,
+		UserID:     userID,
+		Role:       role,
+		Device:     DeviceName(client.UserAgent),
+		IP:         client.IP,
+		UserAgent:  truncateString(client.UserAgent, 500),
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		s.logger.Error("Failed to create session", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// touchSession 更新会话最近活跃时间，每个会话每分钟最多写一次库
+func (s *tokenService) touchSession(ctx context.Context, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+
+	first, err := s.store.MarkSessionSeen(ctx, sessionID, sessionSeenInterval)
+	if err != nil {
+		s.logger.Warn("Failed to mark session seen", zap.String("session_id", sessionID), zap.Error(err))
+		return
+	}
+	if !first {
+		return
+	}
+
+	if err := s.sessions.Touch(ctx, sessionID, time.Now(), time.Time{}); err != nil {
+		s.logger.Warn("Failed to update session last seen", zap.String("session_id", sessionID), zap.Error(err))
+	}
+}
+
+// checkActor 检查模拟登录的管理员是否仍然可用且拥有 user:impersonate 权限
+func (s *tokenService) checkActor(ctx context.Context, claims *jwt.Claims) error {
+	// 管理员可能与被模拟用户不在同一租户
+	ctx = tenant.WithAllTenants(ctx)
+	actorID := claims.Actor.UserID
+
+	if err := s.status.CheckUserStatus(ctx, actorID); err != nil {
+		if errors.Is(err, ErrUserDisabled) {
+			return ErrTokenRevoked
+		}
+		return err
+	}
+
+	allowed, err := s.rbac.HasPermission(ctx, actorID, PermissionUserImpersonate)
+	if err != nil {
+		return s.checkUnavailable("impersonator", claims.UserID, err)
+	}
+	if !allowed {
+		s.logger.Warn("Impersonator lost permission, rejecting token",
+			zap.Uint("impersonator_id", actorID),
+			zap.Uint("user_id", claims.UserID))
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// checkUnavailable 吊销检查依赖的 Redis 或数据库不可用时按 auth.status_check_fail_open 处理，
+// 放行时返回 nil，拒绝时返回原错误
+func (s *tokenService) checkUnavailable(check string, userID uint, err error) error {
+	if s.failOpen {
+		s.logger.Warn("Token check unavailable, allowing request (fail open)",
+			zap.String("check", check),
+			zap.Uint("user_id", userID),
+			zap.Error(err))
+		return nil
+	}
+	s.logger.Error("Token check unavailable, rejecting request (fail closed)",
+		zap.String("check", check),
+		zap.Uint("user_id", userID),
+		zap.Error(err))
+	return err
+}
+
+// currentTokenVersion 获取用户当前 Token 版本，优先读缓存
+func (s *tokenService) currentTokenVersion(ctx context.Context, userID uint) (uint, error) {
+	if version, ok := s.versions.Get(ctx, userID); ok {
+		return version, nil
+	}
+
+	version, err := s.repo.GetTokenVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.versions.Set(ctx, userID, version); err != nil {
+		s.logger.Warn("Failed to cache token version", zap.Uint("user_id", userID), zap.Error(err))
+	}
+	return version, nil
+}
+
+// generateOpaqueToken 生成 URL 安全的随机 Token
+func generateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken 计算 Token 的 SHA-256 摘要，用于存储和查找
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncateString 按字符截断字符串
+func truncateString(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"trx-project/internal/model"
+	"trx-project/pkg/cache"
+	"trx-project/pkg/config"
+	"trx-project/pkg/jwt"
+	"trx-project/pkg/tenant"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var testJWTConfig = jwt.Config{Secret: "test-secret", Issuer: "test", ExpireTime: time.Minute}
+
+// newUnavailableTokenService 创建 Redis 不可用的 Token 服务
+func newUnavailableTokenService(t *testing.T, repo *MockUserRepository, status UserStatusService, failOpen bool) TokenService {
+	return newUnavailableTokenServiceWithRBAC(t, repo, status, new(MockRBACService), failOpen)
+}
+
+func newUnavailableTokenServiceWithRBAC(t *testing.T, repo *MockUserRepository, status UserStatusService, rbac RBACService, failOpen bool) TokenService {
+	logger := zap.NewNop()
+	client := newUnavailableRedis(t)
+	cfg := &config.Config{Auth: config.AuthConfig{StatusCheckFailOpen: failOpen}}
+	return NewTokenService(repo, nil,
+		cache.NewRefreshTokenStore(client, logger),
+		cache.NewTokenRevocationStore(client, logger),
+		cache.NewTokenVersionCache(client, logger),
+		status, rbac, nil, logger, testJWTConfig, cfg)
+}
+
+// newUnavailableRedis 创建连接不上的 Redis 客户端，所有命令都会立即失败
+func newUnavailableRedis(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// newTestTokenService 创建使用内存 Redis 的 Token 服务
+func newTestTokenService(t *testing.T, repo *MockUserRepository, status UserStatusService) (TokenService, *miniredis.Miniredis) {
+	logger := zap.NewNop()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewTokenService(repo, nil,
+		cache.NewRefreshTokenStore(client, logger),
+		cache.NewTokenRevocationStore(client, logger),
+		cache.NewTokenVersionCache(client, logger),
+		status, new(MockRBACService), nil, logger, testJWTConfig, &config.Config{}), mr
+}
+
+func issueTestAccessToken(t *testing.T, version uint) string {
+	token, err := jwt.IssueToken(&jwt.Claims{
+		UserID:       7,
+		Username:     "alice",
+		Role:         jwt.RoleUser,
+		TokenVersion: version,
+		SessionID:    "session-1",
+	}, testJWTConfig)
+	require.NoError(t, err)
+	return token
+}
+
+func TestTokenService_LogoutRevokesAccessToken(t *testing.T) {
+	ctx := context.Background()
+	repo, status := new(MockUserRepository), new(MockUserStatusService)
+	s, mr := newTestTokenService(t, repo, status)
+
+	repo.On("GetTokenVersion", ctx, uint(7)).Return(uint(0), nil)
+	status.On("CheckUserStatus", ctx, uint(7)).Return(nil)
+
+	issue := func() string {
+		token, err := jwt.IssueToken(&jwt.Claims{UserID: 7, Username: "alice", Role: jwt.RoleUser}, testJWTConfig)
+		require.NoError(t, err)
+		return token
+	}
+	token, other := issue(), issue()
+
+	claims, err := s.ParseAccessToken(ctx, token)
+	require.NoError(t, err)
+	require.NoError(t, s.Logout(ctx, claims, ""))
+
+	// 退出后同一个 Token 立即失效，同一用户的其他 Token 不受影响
+	_, err = s.ParseAccessToken(ctx, token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = s.ParseAccessToken(ctx, other)
+	assert.NoError(t, err)
+
+	// 吊销记录与 Token 同时过期
+	key := "auth:revoked:" + claims.ID
+	assert.InDelta(t, time.Until(claims.ExpiresAt.Time).Seconds(), mr.TTL(key).Seconds(), 2)
+	mr.FastForward(testJWTConfig.ExpireTime + time.Second)
+	assert.False(t, mr.Exists(key))
+}
+
+func TestTokenService_ParseAccessTokenUnavailable(t *testing.T) {
+	ctx := context.Background()
+	dbErr := errors.New("database unavailable")
+
+	t.Run("Fail closed rejects request", func(t *testing.T) {
+		repo, status := new(MockUserRepository), new(MockUserStatusService)
+		s := newUnavailableTokenService(t, repo, status, false)
+
+		claims, err := s.ParseAccessToken(ctx, issueTestAccessToken(t, 0))
+		assert.Error(t, err)
+		assert.Nil(t, claims)
+		// 吊销检查失败时立即拒绝，不再继续后面的检查
+		status.AssertNotCalled(t, "CheckUserStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fail open allows request", func(t *testing.T) {
+		repo, status := new(MockUserRepository), new(MockUserStatusService)
+		s := newUnavailableTokenService(t, repo, status, true)
+
+		status.On("CheckUserStatus", ctx, uint(7)).Return(nil)
+		repo.On("GetTokenVersion", ctx, uint(7)).Return(uint(0), dbErr)
+
+		claims, err := s.ParseAccessToken(ctx, issueTestAccessToken(t, 0))
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), claims.UserID)
+		status.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Fail open still rejects known revocation", func(t *testing.T) {
+		repo, status := new(MockUserRepository), new(MockUserStatusService)
+		s := newUnavailableTokenService(t, repo, status, true)
+
+		status.On("CheckUserStatus", ctx, uint(7)).Return(nil)
+		repo.On("GetTokenVersion", ctx, uint(7)).Return(uint(2), nil).Once()
+		repo.On("GetTokenVersion", ctx, uint(7)).Return(uint(0), gorm.ErrRecordNotFound).Once()
+
+		// 数据库能给出结论时不受放行策略影响：版本落后或用户已删除
+		_, err := s.ParseAccessToken(ctx, issueTestAccessToken(t, 1))
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+		_, err = s.ParseAccessToken(ctx, issueTestAccessToken(t, 1))
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	})
+
+	t.Run("Fail open still rejects disabled user", func(t *testing.T) {
+		repo, status := new(MockUserRepository), new(MockUserStatusService)
+		s := newUnavailableTokenService(t, repo, status, true)
+
+		status.On("CheckUserStatus", ctx, uint(7)).Return(ErrUserDisabled)
+
+		_, err := s.ParseAccessToken(ctx, issueTestAccessToken(t, 0))
+		assert.ErrorIs(t, err, ErrUserDisabled)
+		repo.AssertNotCalled(t, "GetTokenVersion", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTokenService_ParseAccessTokenWholeChainUnavailable(t *testing.T) {
+	ctx := context.Background()
+	dbErr := errors.New("database unavailable")
+
+	for _, failOpen := range []bool{false, true} {
+		repo := new(MockUserRepository)
+		repo.On("GetStatus", ctx, uint(7)).Return(0, false, dbErr)
+		repo.On("GetTokenVersion", ctx, uint(7)).Return(uint(0), dbErr)
+
+		// 吊销、会话、用户状态和 Token 版本检查共用同一个放行策略
+		cfg := &config.Config{Auth: config.AuthConfig{StatusCheckFailOpen: failOpen}}
+		status := NewUserStatusService(repo, cache.NewUserStatusCache(newUnavailableRedis(t), zap.NewNop()), zap.NewNop(), cfg)
+		s := newUnavailableTokenService(t, repo, status, failOpen)
+
+		claims, err := s.ParseAccessToken(ctx, issueTestAccessToken(t, 0))
+		if failOpen {
+			require.NoError(t, err)
+			assert.Equal(t, uint(7), claims.UserID)
+		} else {
+			assert.Error(t, err)
+			assert.Nil(t, claims)
+		}
+	}
+}
+
+func TestTokenService_ParseImpersonationToken(t *testing.T) {
+	ctx := context.Background()
+	allTenants := mock.MatchedBy(tenant.IsAllTenants)
+	issue := func(t *testing.T) string {
+		token, err := jwt.IssueToken(&jwt.Claims{
+			UserID:    7,
+			Username:  "alice",
+			Role:      jwt.RoleUser,
+			SessionID: "session-1",
+			Actor:     &jwt.Actor{UserID: 1, Username: "admin"},
+		}, testJWTConfig)
+		require.NoError(t, err)
+		return token
+	}
+	// Redis 不可用时放行，只验证模拟人的检查
+	setup := func(t *testing.T) (TokenService, *MockUserStatusService, *MockRBACService) {
+		repo, status, rbac := new(MockUserRepository), new(MockUserStatusService), new(MockRBACService)
+		repo.On("GetTokenVersion", ctx, uint(7)).Return(uint(0), nil)
+		status.On("CheckUserStatus", ctx, uint(7)).Return(nil)
+		return newUnavailableTokenServiceWithRBAC(t, repo, status, rbac, true), status, rbac
+	}
+
+	t.Run("Active impersonator", func(t *testing.T) {
+		s, status, rbac := setup(t)
+		status.On("CheckUserStatus", allTenants, uint(1)).Return(nil)
+		rbac.On("HasPermission", allTenants, uint(1), PermissionUserImpersonate).Return(true, nil)
+
+		claims, err := s.ParseAccessToken(ctx, issue(t))
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), claims.Actor.UserID)
+	})
+
+	t.Run("Impersonator disabled", func(t *testing.T) {
+		s, status, rbac := setup(t)
+		status.On("CheckUserStatus", allTenants, uint(1)).Return(ErrUserDisabled)
+
+		_, err := s.ParseAccessToken(ctx, issue(t))
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+		rbac.AssertNotCalled(t, "HasPermission", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Impersonator lost permission", func(t *testing.T) {
+		s, status, rbac := setup(t)
+		status.On("CheckUserStatus", allTenants, uint(1)).Return(nil)
+		rbac.On("HasPermission", allTenants, uint(1), PermissionUserImpersonate).Return(false, nil)
+
+		_, err := s.ParseAccessToken(ctx, issue(t))
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	})
+}
+
+func TestTokenService_IssueImpersonationTokenCreatesSession(t *testing.T) {
+	ctx := context.Background()
+	sessions := new(MockSessionRepository)
+	s := newUnavailableTokenService(t, new(MockUserRepository), new(MockUserStatusService), false).(*tokenService)
+	s.sessions = sessions
+
+	sessions.On("Create", ctx, mock.MatchedBy(func(session *model.UserSession) bool {
+		return session.UserID == 7 && session.ID != ""
+	})).Return(nil)
+
+	// 会话登记失败时不签发 Token，否则该 Token 无法被单独吊销
+	token, err := s.IssueImpersonationToken(ctx, &model.User{ID: 7, Username: "alice"}, &model.User{ID: 1, Username: "admin"}, time.Minute)
+	assert.Error(t, err)
+	assert.Empty(t, token)
+	sessions.AssertExpectations(t)
+}
+
+func TestTokenService_RevokeUserTokensRevokesAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	repo, keyRepo := new(MockUserRepository), new(MockAPIKeyRepository)
+	s := newUnavailableTokenService(t, repo, new(MockUserStatusService), false).(*tokenService)
+	s.apiKeys = newTestAPIKeyService(keyRepo, repo, new(MockRBACService), new(MockAuditService))
+
+	repo.On("IncrementTokenVersion", ctx, uint(7)).Return(uint(3), nil)
+	keyRepo.On("RevokeByUserID", ctx, uint(7)).Return(nil)
+
+	// 修改密码、退出所有设备、管理员吊销和移动租户都通过 RevokeUserTokens 吊销，
+	// API Key 保存在数据库中，Redis 不可用时也已经被吊销
+	assert.Error(t, s.RevokeUserTokens(ctx, 7))
+	keyRepo.AssertCalled(t, "RevokeByUserID", ctx, uint(7))
+}
@@ -7,115 +7,265 @@ import (
 	"trx-project/internal/model"
 	"trx-project/internal/repository"
 	"trx-project/pkg/jwt"
+	"trx-project/pkg/passhash"
 
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// LoginResult 登录结果，需要两步验证时只返回 MFA 挑战，不签发 Token
+type LoginResult struct {
+	User          *model.User
+	Roles         []*model.Role // 仅后台登录返回
+	Tokens        *TokenPair
+	MFA           *MFAChallenge
+	RecoveryCodes []string // 登录过程中完成两步验证绑定时返回
+}
+
 type UserService interface {
-	Register(ctx context.Context, username, email, password string) (*model.User, string, error)
-	Login(ctx context.Context, username, password string) (*model.User, string, error)
+	// Register 注册新用户并发送邮箱验证链接，配置要求验证邮箱时不签发 Token
+	Register(ctx context.Context, username, email, password string) (*model.User, *TokenPair, error)
+	// Login 校验用户名密码并签发 Token，ip 用于登录失败计数
+	Login(ctx context.Context, username, password, ip string) (*LoginResult, error)
+	// BeginMFAEnrollment 登录过程中为角色要求两步验证但尚未绑定的用户生成密钥
+	BeginMFAEnrollment(ctx context.Context, mfaToken string) (*MFAEnrollment, error)
+	// CompleteMFALogin 校验两步验证码并签发 Token
+	CompleteMFALogin(ctx context.Context, mfaToken, code string) (*LoginResult, error)
+	Authenticate(ctx context.Context, username, password string) (*model.User, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error)
+	Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error
+	RevokeUserTokens(ctx context.Context, userID uint) error
 	GetUserByID(ctx context.Context, id uint) (*model.User, error)
 	UpdateUser(ctx context.Context, user *model.User) error
+	UpdateStatus(ctx context.Context, id uint, status int) (*model.User, error)
+	// UpdateAttributes 整体替换用户的自定义属性，授权条件中使用
+	UpdateAttributes(ctx context.Context, id uint, attributes map[string]string) (*model.User, error)
+	ResetPassword(ctx context.Context, id uint, newPassword string) error
 	DeleteUser(ctx context.Context, id uint) error
 	ListUsers(ctx context.Context, page, pageSize int) ([]*model.User, int64, error)
 }
 
 type userService struct {
-	repo      repository.UserRepository
-	redis     *redis.Client
-	logger    *zap.Logger
-	jwtConfig jwt.Config
+	repo          repository.UserRepository
+	redis         *redis.Client
+	logger        *zap.Logger
+	tokenService  TokenService
+	statusService UserStatusService
+	mfaService    MFAService
+	emailVerifier EmailVerificationService
+	loginGuard    LoginProtectionService
+	authenticator Authenticator
+	hasher        *passhash.Hasher
+	policy        PasswordPolicyService
 }
 
 // NewUserService 创建新的用户服务
-func NewUserService(repo repository.UserRepository, redis *redis.Client, logger *zap.Logger, jwtConfig jwt.Config) UserService {
+func NewUserService(repo repository.UserRepository, redis *redis.Client, logger *zap.Logger, tokenService TokenService, statusService UserStatusService, mfaService MFAService, emailVerifier EmailVerificationService, loginGuard LoginProtectionService, authenticator Authenticator, hasher *passhash.Hasher, policy PasswordPolicyService) UserService {
 	return &userService{
-		repo:      repo,
-		redis:     redis,
-		logger:    logger,
-		jwtConfig: jwtConfig,
+		repo:          repo,
+		redis:         redis,
+		logger:        logger,
+		tokenService:  tokenService,
+		statusService: statusService,
+		mfaService:    mfaService,
+		emailVerifier: emailVerifier,
+		loginGuard:    loginGuard,
+		authenticator: authenticator,
+		hasher:        hasher,
+		policy:        policy,
 	}
 }
 
-func (s *userService) Register(ctx context.Context, username, email, password string) (*model.User, string, error) {
+func (s *userService) Register(ctx context.Context, username, email, password string) (*model.User, *TokenPair, error) {
 	// 检查用户是否已存在
 	if _, err := s.repo.GetByUsername(ctx, username); err == nil {
-		return nil, "", errors.New("username already exists")
+		return nil, nil, errors.New("username already exists")
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		s.logger.Error("Failed to check username", zap.Error(err))
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	if _, err := s.repo.GetByEmail(ctx, email); err == nil {
-		return nil, "", errors.New("email already exists")
+		return nil, nil, errors.New("email already exists")
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		s.logger.Error("Failed to check email", zap.Error(err))
-		return nil, "", err
-	}
-
-	// 加密密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		s.logger.Error("Failed to hash password", zap.Error(err))
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// 创建用户
+	// 创建用户，邮箱等待验证
 	user := &model.User{
 		Username: username,
 		Email:    email,
-		Password: string(hashedPassword),
 		Status:   1,
 	}
 
+	if err := s.policy.Validate(ctx, user, password); err != nil {
+		return nil, nil, err
+	}
+
+	// 加密密码
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		s.logger.Error("Failed to hash password", zap.Error(err))
+		return nil, nil, err
+	}
+	user.Password = hashedPassword
+
 	if err := s.repo.Create(ctx, user); err != nil {
 		s.logger.Error("Failed to create user", zap.Error(err))
-		return nil, "", err
+		return nil, nil, err
+	}
+
+	// 发送失败不影响注册，用户可以稍后重新发送
+	if err := s.emailVerifier.SendVerification(ctx, user); err != nil {
+		s.logger.Warn("Failed to send email verification", zap.Uint("user_id", user.ID), zap.Error(err))
 	}
 
-	// 生成 JWT Token
-	token, err := jwt.GenerateToken(user.ID, user.Username, "user", s.jwtConfig)
+	if s.emailVerifier.Required() {
+		s.logger.Info("User registered, email verification pending", zap.String("username", username))
+		return user, nil, nil
+	}
+
+	// 签发 Token 对
+	tokens, err := s.tokenService.IssueTokenPair(ctx, user, jwt.RoleUser, "")
 	if err != nil {
-		s.logger.Error("Failed to generate token", zap.Error(err))
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	s.logger.Info("User registered successfully", zap.String("username", username))
-	return user, token, nil
+	return user, tokens, nil
 }
 
-func (s *userService) Login(ctx context.Context, username, password string) (*model.User, string, error) {
-	user, err := s.repo.GetByUsername(ctx, username)
+func (s *userService) Login(ctx context.Context, username, password, ip string) (*LoginResult, error) {
+	if err := s.loginGuard.Check(ctx, loginServiceFrontend, username, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.Authenticate(ctx, username, password)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, "", errors.New("invalid username or password")
-		}
+		s.loginGuard.RecordFailure(ctx, loginServiceFrontend, username, ip, err)
+		return nil, err
+	}
+	s.loginGuard.RecordSuccess(ctx, username, ip)
+
+	// 启用了两步验证或角色要求两步验证时，先返回挑战
+	challenge, err := s.mfaService.StartLogin(ctx, user.ID, jwt.RoleUser)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		s.logger.Info("User login requires mfa", zap.String("username", username))
+		return &LoginResult{User: user, MFA: challenge}, nil
+	}
+
+	// 签发 Token 对
+	tokens, err := s.tokenService.IssueTokenPair(ctx, user, jwt.RoleUser, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("User logged in successfully", zap.String("username", username))
+	return &LoginResult{User: user, Tokens: tokens}, nil
+}
+
+func (s *userService) BeginMFAEnrollment(ctx context.Context, mfaToken string) (*MFAEnrollment, error) {
+	return s.mfaService.BeginLoginEnrollment(ctx, mfaToken, false)
+}
+
+func (s *userService) CompleteMFALogin(ctx context.Context, mfaToken, code string) (*LoginResult, error) {
+	result, err := s.mfaService.CompleteLogin(ctx, mfaToken, code, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// 密码校验后账号可能已被禁用
+	user, err := s.repo.GetByID(ctx, result.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		s.logger.Error("Failed to get user", zap.Error(err))
-		return nil, "", err
+		return nil, err
+	}
+	if err != nil || user.Status != 1 {
+		return nil, ErrUserInactive
 	}
 
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, "", errors.New("invalid username or password")
+	tokens, err := s.tokenService.IssueTokenPair(ctx, user, jwt.RoleUser, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("User logged in successfully with mfa", zap.String("username", user.Username))
+	return &LoginResult{User: user, Tokens: tokens, RecoveryCodes: result.RecoveryCodes}, nil
+}
+
+// Authenticate 按配置的凭证校验器顺序校验用户名密码，再检查账号状态及邮箱验证状态，不签发 Token
+func (s *userService) Authenticate(ctx context.Context, username, password string) (*model.User, error) {
+	user, err := s.authenticator.Authenticate(ctx, username, password)
+	if err != nil {
+		return nil, err
 	}
 
 	// 检查用户是否活跃
 	if user.Status != 1 {
-		return nil, "", errors.New("user account is inactive")
+		return nil, ErrUserInactive
+	}
+
+	if s.emailVerifier.Required() && !user.EmailVerified() {
+		return nil, ErrEmailNotVerified
 	}
 
-	// 生成 JWT Token
-	token, err := jwt.GenerateToken(user.ID, user.Username, "user", s.jwtConfig)
+	return user, nil
+}
+
+// RefreshToken 使用 Refresh Token 轮换出新的 Token 对
+func (s *userService) RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	session, err := s.tokenService.ConsumeRefreshToken(ctx, refreshToken)
 	if err != nil {
-		s.logger.Error("Failed to generate token", zap.Error(err))
-		return nil, "", err
+		return nil, err
 	}
 
-	s.logger.Info("User logged in successfully", zap.String("username", username))
-	return user, token, nil
+	// 后台签发的 Refresh Token 不能在前台使用
+	if session.Role != jwt.RoleUser {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	user, err := s.repo.GetByID(ctx, session.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("Failed to get user", zap.Error(err))
+		return nil, err
+	}
+	if err != nil || user.Status != 1 {
+		// 用户已删除或被禁用，终止整个会话
+		if err := s.tokenService.RevokeRefreshFamily(ctx, session.FamilyID); err != nil {
+			s.logger.Error("Failed to revoke refresh token family", zap.Error(err))
+		}
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	return s.tokenService.IssueTokenPair(ctx, user, jwt.RoleUser, session.FamilyID)
+}
+
+// Logout 退出登录，吊销当前 Access Token 及其 Refresh Token 会话
+func (s *userService) Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error {
+	if err := s.tokenService.Logout(ctx, claims, refreshToken); err != nil {
+		return err
+	}
+
+	s.logger.Info("User logged out", zap.Uint("user_id", claims.UserID))
+	return nil
+}
+
+// RevokeUserTokens 吊销用户的所有 Token，强制其在所有设备上重新登录
+func (s *userService) RevokeUserTokens(ctx context.Context, userID uint) error {
+	if _, err := s.repo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		s.logger.Error("Failed to get user", zap.Error(err))
+		return err
+	}
+
+	return s.tokenService.RevokeUserTokens(ctx, userID)
 }
 
 func (s *userService) GetUserByID(ctx context.Context, id uint) (*model.User, error) {
@@ -145,13 +295,75 @@ func (s *userService) UpdateUser(ctx context.Context, user *model.User) error {
 	}
 
 	// 使缓存失效
-	cacheKey := fmt.Sprintf("user:%d", user.ID)
-	_ = cacheKey // TODO: 实现缓存失效
+	s.statusService.InvalidateUserStatus(ctx, user.ID)
 
 	s.logger.Info("User updated successfully", zap.Uint("user_id", user.ID))
 	return nil
 }
 
+// UpdateStatus 更新用户状态，状态变化时该用户之前签发的所有 Token 立即失效
+func (s *userService) UpdateStatus(ctx context.Context, id uint, status int) (*model.User, error) {
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status == status {
+		return user, nil
+	}
+
+	user.Status = status
+	if err := s.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenService.RevokeUserTokens(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *userService) UpdateAttributes(ctx context.Context, id uint, attributes map[string]string) (*model.User, error) {
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Attributes = attributes
+	if err := s.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ResetPassword 重置用户密码，该用户之前签发的所有 Token 立即失效
+// 不校验密码策略，由调用方（PasswordService）在设置前校验
+func (s *userService) ResetPassword(ctx context.Context, id uint, newPassword string) error {
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		s.logger.Error("Failed to hash password", zap.Error(err))
+		return err
+	}
+
+	user.Password = hashedPassword
+	if err := s.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+
+	if err := s.tokenService.RevokeUserTokens(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("User password reset successfully", zap.Uint("user_id", id))
+	return nil
+}
+
 func (s *userService) DeleteUser(ctx context.Context, id uint) error {
 	if err := s.repo.Delete(ctx, id); err != nil {
 		s.logger.Error("Failed to delete user", zap.Error(err))
@@ -159,8 +371,7 @@ func (s *userService) DeleteUser(ctx context.Context, id uint) error {
 	}
 
 	// 使缓存失效
-	cacheKey := fmt.Sprintf("user:%d", id)
-	_ = cacheKey // TODO: 实现缓存失效
+	s.statusService.InvalidateUserStatus(ctx, id)
 
 	s.logger.Info("User deleted successfully", zap.Uint("user_id", id))
 	return nil
@@ -3,17 +3,29 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 	"trx-project/internal/model"
+	"trx-project/pkg/cache"
+	"trx-project/pkg/config"
 	"trx-project/pkg/jwt"
+	"trx-project/pkg/notifier"
+	"trx-project/pkg/passhash"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// testPasswordHasher 测试使用的密码哈希器，使用最低成本的 bcrypt 加快测试
+var testPasswordHasher, _ = passhash.NewHasher(passhash.Params{Algorithm: passhash.AlgorithmBcrypt, BcryptCost: bcrypt.MinCost})
+
+// testPasswordPolicy 测试使用的默认密码策略，只检查长度
+var testPasswordPolicy, _ = NewPasswordPolicyService(nil, testPasswordHasher, zap.NewNop(), &config.Config{})
+
 // MockUserRepository 是 UserRepository 的 mock 实现
 type MockUserRepository struct {
 	mock.Mock
@@ -63,16 +75,157 @@ func (m *MockUserRepository) List(ctx context.Context, offset, limit int) ([]*mo
 	return args.Get(0).([]*model.User), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockUserRepository) ListByAccountType(ctx context.Context, accountType string) ([]*model.User, error) {
+	args := m.Called(ctx, accountType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetStatus(ctx context.Context, id uint) (int, bool, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUserRepository) GetTokenVersion(ctx context.Context, id uint) (uint, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockUserRepository) IncrementTokenVersion(ctx context.Context, id uint) (uint, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, id uint, email string) (bool, error) {
+	args := m.Called(ctx, id, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) TouchEmailVerificationSentAt(ctx context.Context, id uint, interval time.Duration) (bool, error) {
+	args := m.Called(ctx, id, interval)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePasswordHash(ctx context.Context, id uint, oldHash, newHash string) (bool, error) {
+	args := m.Called(ctx, id, oldHash, newHash)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockUserStatusService 是 UserStatusService 的 mock 实现
+type MockUserStatusService struct {
+	mock.Mock
+}
+
+func (m *MockUserStatusService) CheckUserStatus(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserStatusService) InvalidateUserStatus(ctx context.Context, userID uint) {
+	m.Called(ctx, userID)
+}
+
+// MockTokenService 是 TokenService 的 mock 实现
+type MockTokenService struct {
+	mock.Mock
+}
+
+func (m *MockTokenService) IssueTokenPair(ctx context.Context, user *model.User, role, familyID string) (*TokenPair, error) {
+	args := m.Called(ctx, user, role, familyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) ConsumeRefreshToken(ctx context.Context, refreshToken string) (*RefreshSession, error) {
+	args := m.Called(ctx, refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefreshSession), args.Error(1)
+}
+
+func (m *MockTokenService) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) IssueImpersonationToken(ctx context.Context, user, actor *model.User, ttl time.Duration) (string, error) {
+	args := m.Called(ctx, user, actor, ttl)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTokenService) RevokeSession(ctx context.Context, sessionID string) error {
+	args := m.Called(ctx, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) ParseAccessToken(ctx context.Context, tokenString string) (*jwt.Claims, error) {
+	args := m.Called(ctx, tokenString)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*jwt.Claims), args.Error(1)
+}
+
+func (m *MockTokenService) Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error {
+	args := m.Called(ctx, claims, refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) RevokeUserTokens(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockLoginProtectionService 是 LoginProtectionService 的 mock 实现
+type MockLoginProtectionService struct {
+	mock.Mock
+}
+
+func (m *MockLoginProtectionService) Check(ctx context.Context, service, username, ip string) error {
+	args := m.Called(ctx, service, username, ip)
+	return args.Error(0)
+}
+
+func (m *MockLoginProtectionService) RecordFailure(ctx context.Context, service, username, ip string, err error) {
+	m.Called(ctx, service, username, ip, err)
+}
+
+func (m *MockLoginProtectionService) RecordSuccess(ctx context.Context, username, ip string) {
+	m.Called(ctx, username, ip)
+}
+
+func (m *MockLoginProtectionService) ListLockouts(ctx context.Context) ([]*cache.LoginAttemptState, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*cache.LoginAttemptState), args.Error(1)
+}
+
+func (m *MockLoginProtectionService) GetLockout(ctx context.Context, scope, value string) (*cache.LoginAttemptState, error) {
+	args := m.Called(ctx, scope, value)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cache.LoginAttemptState), args.Error(1)
+}
+
+func (m *MockLoginProtectionService) ClearLockout(ctx context.Context, adminID uint, scope, value, ip string) error {
+	args := m.Called(ctx, adminID, scope, value, ip)
+	return args.Error(0)
+}
+
 func TestUserService_Register(t *testing.T) {
 	// 配置
 	mockRepo := new(MockUserRepository)
 	logger, _ := zap.NewDevelopment()
-	jwtConfig := jwt.Config{
-		Secret:     "test-secret",
-		Issuer:     "test",
-		ExpireTime: 24 * time.Hour,
-	}
-	service := NewUserService(mockRepo, nil, logger, jwtConfig)
+	mockTokens := new(MockTokenService)
+	mockStatus := new(MockUserStatusService)
+	notify := notifier.NewMemoryNotifier()
+	verifier := newTestEmailVerificationService(mockRepo, notify, false)
+	service := NewUserService(mockRepo, nil, logger, mockTokens, mockStatus, nil, verifier, nil, NewLocalAuthenticator(mockRepo, testPasswordHasher, logger), testPasswordHasher, testPasswordPolicy)
 
 	ctx := context.Background()
 	username := "testuser"
@@ -84,6 +237,9 @@ func TestUserService_Register(t *testing.T) {
 		mockRepo.On("GetByUsername", ctx, username).Return(nil, gorm.ErrRecordNotFound).Once()
 		mockRepo.On("GetByEmail", ctx, email).Return(nil, gorm.ErrRecordNotFound).Once()
 		mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil).Once()
+		mockRepo.On("TouchEmailVerificationSentAt", ctx, uint(0), time.Minute).Return(true, nil).Once()
+		mockTokens.On("IssueTokenPair", ctx, mock.AnythingOfType("*model.User"), jwt.RoleUser, "").
+			Return(&TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil).Once()
 
 		user, token, err := service.Register(ctx, username, email, password)
 
@@ -92,6 +248,11 @@ func TestUserService_Register(t *testing.T) {
 		assert.NotEmpty(t, token)
 		assert.Equal(t, username, user.Username)
 		assert.Equal(t, email, user.Email)
+		assert.False(t, user.EmailVerified())
+		// 注册后发送邮箱验证链接
+		if assert.NotNil(t, notify.Last()) {
+			assert.Equal(t, email, notify.Last().To)
+		}
 		mockRepo.AssertExpectations(t)
 	})
 
@@ -145,41 +306,79 @@ func TestUserService_Login(t *testing.T) {
 	// 配置
 	mockRepo := new(MockUserRepository)
 	logger, _ := zap.NewDevelopment()
-	jwtConfig := jwt.Config{
-		Secret:     "test-secret",
-		Issuer:     "test",
-		ExpireTime: 24 * time.Hour,
-	}
-	service := NewUserService(mockRepo, nil, logger, jwtConfig)
+	mockTokens := new(MockTokenService)
+	mockStatus := new(MockUserStatusService)
+	mockGuard := new(MockLoginProtectionService)
+	service := NewUserService(mockRepo, nil, logger, mockTokens, mockStatus, nil, nil, mockGuard, NewLocalAuthenticator(mockRepo, testPasswordHasher, logger), testPasswordHasher, testPasswordPolicy)
 
 	ctx := context.Background()
 	username := "testuser"
 	password := "password123"
+	ip := "192.0.2.1"
 
 	// 测试用例 1: 用户不存在
 	t.Run("User not found", func(t *testing.T) {
+		mockGuard.On("Check", ctx, "frontend", username, ip).Return(nil).Once()
 		mockRepo.On("GetByUsername", ctx, username).Return(nil, gorm.ErrRecordNotFound).Once()
+		mockGuard.On("RecordFailure", ctx, "frontend", username, ip, ErrInvalidCredentials).Return().Once()
 
-		user, token, err := service.Login(ctx, username, password)
+		result, err := service.Login(ctx, username, password, ip)
 
 		assert.Error(t, err)
-		assert.Nil(t, user)
-		assert.Empty(t, token)
+		assert.Nil(t, result)
 		assert.Equal(t, "invalid username or password", err.Error())
 		mockRepo.AssertExpectations(t)
+		mockGuard.AssertExpectations(t)
+	})
+
+	// 测试用例 2: 登录已被锁定，不校验密码
+	t.Run("Login locked", func(t *testing.T) {
+		mockGuard.On("Check", ctx, "frontend", username, ip).Return(&LoginLockedError{RetryAfter: time.Minute}).Once()
+
+		result, err := service.Login(ctx, username, password, ip)
+
+		assert.ErrorIs(t, err, ErrLoginLocked)
+		assert.Nil(t, result)
+		mockRepo.AssertNumberOfCalls(t, "GetByUsername", 1)
 	})
 }
 
+func TestLocalAuthenticator_RehashOnLogin(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	argon, err := passhash.NewHasher(passhash.Params{Argon2MemoryKiB: 1024, Argon2Iterations: 1, Argon2Parallelism: 1})
+	assert.NoError(t, err)
+	authenticator := NewLocalAuthenticator(mockRepo, argon, zap.NewNop())
+
+	// 旧的 bcrypt 哈希登录成功后升级为 argon2id，只在哈希未被并发修改时写入
+	legacy, _ := testPasswordHasher.Hash("password123")
+	user := &model.User{ID: 1, Username: "legacy", Password: legacy, Status: 1}
+	mockRepo.On("GetByUsername", ctx, "legacy").Return(user, nil).Once()
+	mockRepo.On("UpdatePasswordHash", ctx, uint(1), legacy, mock.MatchedBy(func(hash string) bool {
+		return strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$")
+	})).Return(true, nil).Once()
+
+	result, err := authenticator.Authenticate(ctx, "legacy", "password123")
+	assert.NoError(t, err)
+	assert.NoError(t, argon.Verify(result.Password, "password123"))
+
+	// 参数已是最新时不再写入；密码错误时不升级
+	mockRepo.On("GetByUsername", ctx, "legacy").Return(user, nil).Twice()
+	_, err = authenticator.Authenticate(ctx, "legacy", "password123")
+	assert.NoError(t, err)
+	_, err = authenticator.Authenticate(ctx, "legacy", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	mockRepo.AssertNumberOfCalls(t, "UpdatePasswordHash", 1)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUserService_GetUserByID(t *testing.T) {
 	// 配置
 	mockRepo := new(MockUserRepository)
 	logger, _ := zap.NewDevelopment()
-	jwtConfig := jwt.Config{
-		Secret:     "test-secret",
-		Issuer:     "test",
-		ExpireTime: 24 * time.Hour,
-	}
-	service := NewUserService(mockRepo, nil, logger, jwtConfig)
+	mockTokens := new(MockTokenService)
+	mockStatus := new(MockUserStatusService)
+	service := NewUserService(mockRepo, nil, logger, mockTokens, mockStatus, nil, nil, nil, NewLocalAuthenticator(mockRepo, testPasswordHasher, logger), testPasswordHasher, testPasswordPolicy)
 
 	ctx := context.Background()
 	userID := uint(1)
@@ -218,12 +417,9 @@ func TestUserService_ListUsers(t *testing.T) {
 	// 配置
 	mockRepo := new(MockUserRepository)
 	logger, _ := zap.NewDevelopment()
-	jwtConfig := jwt.Config{
-		Secret:     "test-secret",
-		Issuer:     "test",
-		ExpireTime: 24 * time.Hour,
-	}
-	service := NewUserService(mockRepo, nil, logger, jwtConfig)
+	mockTokens := new(MockTokenService)
+	mockStatus := new(MockUserStatusService)
+	service := NewUserService(mockRepo, nil, logger, mockTokens, mockStatus, nil, nil, nil, NewLocalAuthenticator(mockRepo, testPasswordHasher, logger), testPasswordHasher, testPasswordPolicy)
 
 	ctx := context.Background()
 
@@ -256,3 +452,112 @@ func TestUserService_ListUsers(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestUserService_RefreshToken(t *testing.T) {
+	// 配置
+	mockRepo := new(MockUserRepository)
+	logger, _ := zap.NewDevelopment()
+	mockTokens := new(MockTokenService)
+	mockStatus := new(MockUserStatusService)
+	service := NewUserService(mockRepo, nil, logger, mockTokens, mockStatus, nil, nil, nil, NewLocalAuthenticator(mockRepo, testPasswordHasher, logger), testPasswordHasher, testPasswordPolicy)
+
+	ctx := context.Background()
+	refreshToken := "refresh-token"
+
+	// 测试用例 1: 成功轮换，沿用原 Token 家族
+	t.Run("Successful rotation", func(t *testing.T) {
+		session := &RefreshSession{UserID: 1, Username: "testuser", Role: jwt.RoleUser, FamilyID: "family-1"}
+		user := &model.User{ID: 1, Username: "testuser", Status: 1}
+		mockTokens.On("ConsumeRefreshToken", ctx, refreshToken).Return(session, nil).Once()
+		mockRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		mockTokens.On("IssueTokenPair", ctx, user, jwt.RoleUser, "family-1").
+			Return(&TokenPair{AccessToken: "access", RefreshToken: "refresh-2"}, nil).Once()
+
+		tokens, err := service.RefreshToken(ctx, refreshToken)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "refresh-2", tokens.RefreshToken)
+		mockRepo.AssertExpectations(t)
+		mockTokens.AssertExpectations(t)
+	})
+
+	// 测试用例 2: 重复使用的 Refresh Token
+	t.Run("Reused refresh token", func(t *testing.T) {
+		mockTokens.On("ConsumeRefreshToken", ctx, refreshToken).Return(nil, ErrRefreshTokenReused).Once()
+
+		tokens, err := service.RefreshToken(ctx, refreshToken)
+
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+		assert.Nil(t, tokens)
+		mockTokens.AssertExpectations(t)
+	})
+
+	// 测试用例 3: 用户已被禁用 - 应吊销整个家族
+	t.Run("Disabled user revokes family", func(t *testing.T) {
+		session := &RefreshSession{UserID: 1, Username: "testuser", Role: jwt.RoleUser, FamilyID: "family-1"}
+		mockTokens.On("ConsumeRefreshToken", ctx, refreshToken).Return(session, nil).Once()
+		mockRepo.On("GetByID", ctx, uint(1)).Return(&model.User{ID: 1, Status: 0}, nil).Once()
+		mockTokens.On("RevokeRefreshFamily", ctx, "family-1").Return(nil).Once()
+
+		tokens, err := service.RefreshToken(ctx, refreshToken)
+
+		assert.ErrorIs(t, err, ErrRefreshTokenInvalid)
+		assert.Nil(t, tokens)
+		mockRepo.AssertExpectations(t)
+		mockTokens.AssertExpectations(t)
+	})
+
+	// 测试用例 4: 后台签发的 Refresh Token 不能在前台使用
+	t.Run("Admin refresh token rejected", func(t *testing.T) {
+		session := &RefreshSession{UserID: 1, Username: "admin", Role: jwt.RoleAdmin, FamilyID: "family-2"}
+		mockTokens.On("ConsumeRefreshToken", ctx, refreshToken).Return(session, nil).Once()
+
+		tokens, err := service.RefreshToken(ctx, refreshToken)
+
+		assert.ErrorIs(t, err, ErrRefreshTokenInvalid)
+		assert.Nil(t, tokens)
+		mockTokens.AssertExpectations(t)
+	})
+}
+
+func TestUserService_UpdateStatus(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	t.Run("状态变化时吊销所有 Token", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenService)
+		mockStatus := new(MockUserStatusService)
+		service := NewUserService(mockRepo, nil, logger, mockTokens, mockStatus, nil, nil, nil, NewLocalAuthenticator(mockRepo, testPasswordHasher, logger), testPasswordHasher, testPasswordPolicy)
+
+		user := &model.User{ID: 1, Username: "testuser", Status: 1}
+		mockRepo.On("GetByID", ctx, uint(1)).Return(user, nil)
+		mockRepo.On("Update", ctx, user).Return(nil)
+		mockStatus.On("InvalidateUserStatus", ctx, uint(1)).Return()
+		mockTokens.On("RevokeUserTokens", ctx, uint(1)).Return(nil)
+
+		updated, err := service.UpdateStatus(ctx, 1, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, updated.Status)
+		mockRepo.AssertExpectations(t)
+		mockStatus.AssertExpectations(t)
+		mockTokens.AssertExpectations(t)
+	})
+
+	t.Run("状态未变化时不吊销", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenService)
+		mockStatus := new(MockUserStatusService)
+		service := NewUserService(mockRepo, nil, logger, mockTokens, mockStatus, nil, nil, nil, NewLocalAuthenticator(mockRepo, testPasswordHasher, logger), testPasswordHasher, testPasswordPolicy)
+
+		user := &model.User{ID: 1, Username: "testuser", Status: 1}
+		mockRepo.On("GetByID", ctx, uint(1)).Return(user, nil)
+
+		_, err := service.UpdateStatus(ctx, 1, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		mockTokens.AssertNotCalled(t, "RevokeUserTokens", mock.Anything, mock.Anything)
+	})
+}
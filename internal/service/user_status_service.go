@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"trx-project/internal/repository"
+	"trx-project/pkg/cache"
+	"trx-project/pkg/config"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUserDisabled 用户已被禁用或删除
+	ErrUserDisabled = errors.New("user account is disabled")
+	// ErrUserInactive 登录时账号未启用
+	ErrUserInactive = errors.New("user account is inactive")
+)
+
+// UserStatusService 用户状态检查服务，认证中间件每次请求都会调用
+type UserStatusService interface {
+	// CheckUserStatus 检查用户是否仍可访问，已禁用或已删除时返回 ErrUserDisabled
+	CheckUserStatus(ctx context.Context, userID uint) error
+	// InvalidateUserStatus 用户状态变化后清除缓存
+	InvalidateUserStatus(ctx context.Context, userID uint)
+}
+
+type userStatusService struct {
+	repo     repository.UserRepository
+	cache    *cache.UserStatusCache
+	logger   *zap.Logger
+	failOpen bool
+}
+
+// NewUserStatusService 创建用户状态检查服务
+func NewUserStatusService(repo repository.UserRepository, cache *cache.UserStatusCache, logger *zap.Logger, cfg *config.Config) UserStatusService {
+	return &userStatusService{
+		repo:     repo,
+		cache:    cache,
+		logger:   logger,
+		failOpen: cfg.Auth.StatusCheckFailOpen,
+	}
+}
+
+func (s *userStatusService) CheckUserStatus(ctx context.Context, userID uint) error {
+	status, ok := s.cache.Get(ctx, userID)
+	if !ok {
+		var err error
+		status, err = s.loadUserStatus(ctx, userID)
+		if err != nil {
+			// 缓存和数据库都不可用
+			if s.failOpen {
+				s.logger.Warn("User status unavailable, allowing request (fail open)",
+					zap.Uint("user_id", userID),
+					zap.Error(err))
+				return nil
+			}
+			s.logger.Error("User status unavailable, rejecting request (fail closed)",
+				zap.Uint("user_id", userID),
+				zap.Error(err))
+			return err
+		}
+		s.cache.Set(ctx, userID, status)
+	}
+
+	if status.Deleted || status.Status != 1 {
+		s.logger.Warn("Rejected request from inactive user",
+			zap.Uint("user_id", userID),
+			zap.Int("status", status.Status),
+			zap.Bool("deleted", status.Deleted))
+		return ErrUserDisabled
+	}
+
+	return nil
+}
+
+func (s *userStatusService) InvalidateUserStatus(ctx context.Context, userID uint) {
+	if err := s.cache.Invalidate(ctx, userID); err != nil {
+		s.logger.Error("Failed to invalidate user status cache", zap.Uint("user_id", userID), zap.Error(err))
+	}
+}
+
+// loadUserStatus 从数据库加载用户状态，用户不存在时视为已删除
+func (s *userStatusService) loadUserStatus(ctx context.Context, userID uint) (*cache.UserStatus, error) {
+	status, deleted, err := s.repo.GetStatus(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &cache.UserStatus{Deleted: true}, nil
+		}
+		return nil, err
+	}
+
+	return &cache.UserStatus{Status: status, Deleted: deleted}, nil
+}
@@ -0,0 +1,2 @@
+-- 删除用户表 Token 版本
+ALTER TABLE `users` DROP COLUMN `token_version`;
@@ -0,0 +1,3 @@
+-- 用户表增加 Token 版本，递增后该用户之前签发的所有 Token 立即失效
+ALTER TABLE `users`
+    ADD COLUMN `token_version` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT 'Token 版本' AFTER `status`;
@@ -0,0 +1,6 @@
+-- 删除角色表两步验证要求
+ALTER TABLE `roles` DROP COLUMN `mfa_required`;
+
+-- 删除两步验证相关表
+DROP TABLE IF EXISTS `mfa_recovery_codes`;
+DROP TABLE IF EXISTS `user_mfa`;
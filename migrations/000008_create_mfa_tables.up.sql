@@ -0,0 +1,29 @@
+-- 创建用户两步验证表
+CREATE TABLE IF NOT EXISTS `user_mfa` (
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `secret` VARCHAR(64) NOT NULL COMMENT 'TOTP 密钥（Base32）',
+    `enabled` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否已完成绑定确认',
+    `last_counter` BIGINT NOT NULL DEFAULT 0 COMMENT '最近一次使用的 TOTP 时间步',
+    `confirmed_at` DATETIME(3) NULL DEFAULT NULL COMMENT '绑定确认时间',
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+    PRIMARY KEY (`user_id`),
+    CONSTRAINT `fk_user_mfa_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户两步验证表';
+
+-- 创建两步验证恢复码表
+CREATE TABLE IF NOT EXISTS `mfa_recovery_codes` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `code_hash` VARCHAR(64) NOT NULL COMMENT '恢复码 SHA-256 摘要',
+    `used_at` DATETIME(3) NULL DEFAULT NULL COMMENT '使用时间',
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_mfa_recovery_codes_code_hash` (`code_hash`),
+    INDEX `idx_mfa_recovery_codes_user_id` (`user_id`),
+    CONSTRAINT `fk_mfa_recovery_codes_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='两步验证恢复码表';
+
+-- 角色表增加两步验证要求
+ALTER TABLE `roles`
+    ADD COLUMN `mfa_required` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否要求两步验证' AFTER `status`;
@@ -0,0 +1,15 @@
+-- 创建审计日志表
+CREATE TABLE IF NOT EXISTS `audit_logs` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `actor_id` BIGINT UNSIGNED NOT NULL COMMENT '操作人用户ID',
+    `action` VARCHAR(100) NOT NULL COMMENT '操作类型',
+    `target_type` VARCHAR(50) NOT NULL COMMENT '操作对象类型',
+    `target_id` BIGINT UNSIGNED NOT NULL COMMENT '操作对象ID',
+    `detail` VARCHAR(1000) NULL COMMENT '操作详情',
+    `ip` VARCHAR(64) NULL COMMENT '操作人IP',
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_audit_logs_actor_id` (`actor_id`),
+    INDEX `idx_audit_logs_action` (`action`),
+    INDEX `idx_audit_logs_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='审计日志表';
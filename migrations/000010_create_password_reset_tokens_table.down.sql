@@ -0,0 +1,2 @@
+-- 删除重置密码令牌表
+DROP TABLE IF EXISTS `password_reset_tokens`;
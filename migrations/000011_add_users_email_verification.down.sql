@@ -0,0 +1,4 @@
+-- 删除用户表邮箱验证状态
+ALTER TABLE `users`
+    DROP COLUMN `email_verification_sent_at`,
+    DROP COLUMN `email_verified_at`;
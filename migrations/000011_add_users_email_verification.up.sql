@@ -0,0 +1,7 @@
+-- 用户表增加邮箱验证状态，email_verified_at 为空表示等待验证
+ALTER TABLE `users`
+    ADD COLUMN `email_verified_at` DATETIME(3) NULL DEFAULT NULL COMMENT '邮箱验证时间' AFTER `token_version`,
+    ADD COLUMN `email_verification_sent_at` DATETIME(3) NULL DEFAULT NULL COMMENT '最近一次发送验证邮件的时间' AFTER `email_verified_at`;
+
+-- 已有用户视为已验证
+UPDATE `users` SET `email_verified_at` = `created_at` WHERE `email_verified_at` IS NULL;
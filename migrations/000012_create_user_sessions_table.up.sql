@@ -0,0 +1,16 @@
+-- 创建登录会话表，会话 ID 与 Refresh Token 家族 ID 相同
+CREATE TABLE IF NOT EXISTS `user_sessions` (
+    `id` VARCHAR(36) NOT NULL COMMENT '会话ID',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `role` VARCHAR(50) NOT NULL COMMENT '会话签发的 Token 角色',
+    `device` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '设备',
+    `ip` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '登录 IP',
+    `user_agent` VARCHAR(500) NOT NULL DEFAULT '' COMMENT 'User-Agent',
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `last_seen_at` DATETIME(3) NOT NULL COMMENT '最近活跃时间',
+    `expires_at` DATETIME(3) NOT NULL COMMENT '过期时间',
+    `revoked_at` DATETIME(3) NULL DEFAULT NULL COMMENT '退出登录或被吊销的时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_sessions_user_id` (`user_id`),
+    CONSTRAINT `fk_user_sessions_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='登录会话表';
@@ -0,0 +1,10 @@
+-- 删除服务账号和 API Key 管理权限
+DELETE FROM `role_permissions`
+WHERE `permission_id` IN (SELECT `id` FROM (SELECT `id` FROM `permissions` WHERE `code` = 'apikey:manage') AS p);
+DELETE FROM `permissions` WHERE `code` = 'apikey:manage';
+
+-- 删除 API Key 表
+DROP TABLE IF EXISTS `api_keys`;
+
+-- 删除用户表账号类型
+ALTER TABLE `users` DROP COLUMN `account_type`;
@@ -0,0 +1,34 @@
+-- 用户表增加账号类型，服务账号没有密码，只能通过 API Key 访问
+ALTER TABLE `users`
+    ADD COLUMN `account_type` VARCHAR(20) NOT NULL DEFAULT 'user' COMMENT '账号类型：user-普通账号 service-服务账号' AFTER `status`;
+
+-- 创建 API Key 表，只保存明文的 SHA-256 哈希
+CREATE TABLE IF NOT EXISTS `api_keys` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '所有者用户ID',
+    `name` VARCHAR(100) NOT NULL COMMENT '名称',
+    `prefix` VARCHAR(16) NOT NULL COMMENT '明文前缀，用于辨认',
+    `key_hash` CHAR(64) NOT NULL COMMENT '明文的 SHA-256 哈希',
+    `scopes` JSON NULL COMMENT '允许使用的权限编码',
+    `created_by` BIGINT UNSIGNED NOT NULL COMMENT '创建人用户ID',
+    `expires_at` DATETIME(3) NOT NULL COMMENT '过期时间',
+    `last_used_at` DATETIME(3) NULL DEFAULT NULL COMMENT '最近使用时间',
+    `revoked_at` DATETIME(3) NULL DEFAULT NULL COMMENT '吊销时间',
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_api_keys_key_hash` (`key_hash`),
+    INDEX `idx_api_keys_user_id` (`user_id`),
+    CONSTRAINT `fk_api_keys_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='API Key 表';
+
+-- 服务账号和 API Key 管理权限
+INSERT INTO `permissions` (`code`, `name`, `resource`, `action`, `description`, `status`, `created_at`, `updated_at`) VALUES
+('apikey:manage', 'API Key管理', 'apikey', 'manage', '管理服务账号及其 API Key', 1, NOW(), NOW());
+
+INSERT INTO `role_permissions` (`role_id`, `permission_id`, `created_at`)
+SELECT
+    (SELECT `id` FROM `roles` WHERE `name` = 'superadmin'),
+    `id`,
+    NOW()
+FROM `permissions`
+WHERE `code` = 'apikey:manage';
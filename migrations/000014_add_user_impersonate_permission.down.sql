@@ -0,0 +1,4 @@
+-- 删除管理员模拟用户权限
+DELETE FROM `role_permissions`
+WHERE `permission_id` IN (SELECT `id` FROM (SELECT `id` FROM `permissions` WHERE `code` = 'user:impersonate') AS p);
+DELETE FROM `permissions` WHERE `code` = 'user:impersonate';
@@ -0,0 +1,11 @@
+-- 管理员模拟用户权限
+INSERT INTO `permissions` (`code`, `name`, `resource`, `action`, `description`, `status`, `created_at`, `updated_at`) VALUES
+('user:impersonate', '模拟用户', 'user', 'impersonate', '以用户身份访问前台接口，用于复现用户问题', 1, NOW(), NOW());
+
+INSERT INTO `role_permissions` (`role_id`, `permission_id`, `created_at`)
+SELECT
+    (SELECT `id` FROM `roles` WHERE `name` = 'superadmin'),
+    `id`,
+    NOW()
+FROM `permissions`
+WHERE `code` = 'user:impersonate';
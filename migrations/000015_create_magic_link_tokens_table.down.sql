@@ -0,0 +1,2 @@
+-- 删除免密登录链接令牌表
+DROP TABLE IF EXISTS `magic_link_tokens`;
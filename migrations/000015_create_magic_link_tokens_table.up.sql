@@ -0,0 +1,15 @@
+-- 创建免密登录链接令牌表
+CREATE TABLE IF NOT EXISTS `magic_link_tokens` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `token_hash` VARCHAR(64) NOT NULL COMMENT '令牌 SHA-256 摘要',
+    `nonce_hash` VARCHAR(64) NOT NULL COMMENT '发起请求的浏览器持有的 nonce 的 SHA-256 摘要',
+    `ip` VARCHAR(45) NOT NULL DEFAULT '' COMMENT '发起请求的 IP',
+    `expires_at` DATETIME(3) NOT NULL COMMENT '过期时间',
+    `used_at` DATETIME(3) NULL DEFAULT NULL COMMENT '使用（或作废）时间',
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_magic_link_tokens_token_hash` (`token_hash`),
+    INDEX `idx_magic_link_tokens_user_id` (`user_id`),
+    CONSTRAINT `fk_magic_link_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='免密登录链接令牌表';
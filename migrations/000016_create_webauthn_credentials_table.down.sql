@@ -0,0 +1,2 @@
+-- 删除管理员通行密钥表
+DROP TABLE IF EXISTS `webauthn_credentials`;
@@ -0,0 +1,20 @@
+-- 创建管理员通行密钥（WebAuthn 凭证）表
+CREATE TABLE IF NOT EXISTS `webauthn_credentials` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `name` VARCHAR(100) NOT NULL COMMENT '名称',
+    `credential_id` VARCHAR(255) NOT NULL COMMENT '凭证 ID（base64url）',
+    `public_key` BLOB NOT NULL COMMENT 'COSE 编码的公钥',
+    `attestation_type` VARCHAR(32) NOT NULL DEFAULT '' COMMENT '证明类型',
+    `transports` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '传输方式，逗号分隔',
+    `aaguid` VARCHAR(36) NOT NULL DEFAULT '' COMMENT '验证器型号',
+    `sign_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '签名计数器',
+    `backup_eligible` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否为可同步的通行密钥',
+    `backup_state` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否已同步到其他设备',
+    `last_used_at` DATETIME(3) NULL DEFAULT NULL COMMENT '最后使用时间',
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_webauthn_credentials_credential_id` (`credential_id`),
+    INDEX `idx_webauthn_credentials_user_id` (`user_id`),
+    CONSTRAINT `fk_webauthn_credentials_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='管理员通行密钥表';
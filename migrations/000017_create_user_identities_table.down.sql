@@ -0,0 +1,2 @@
+-- 删除外部身份表
+DROP TABLE IF EXISTS `user_identities`;
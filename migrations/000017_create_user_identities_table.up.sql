@@ -0,0 +1,14 @@
+-- 创建外部身份（OpenID Connect 单点登录）表
+CREATE TABLE IF NOT EXISTS `user_identities` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `provider` VARCHAR(255) NOT NULL COMMENT '身份提供方 issuer',
+    `subject` VARCHAR(255) NOT NULL COMMENT '身份提供方中的用户 ID（sub）',
+    `email` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '最近一次登录时的邮箱',
+    `last_login_at` DATETIME(3) NULL DEFAULT NULL COMMENT '最后登录时间',
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_user_identities_provider_subject` (`provider`, `subject`),
+    INDEX `idx_user_identities_user_id` (`user_id`),
+    CONSTRAINT `fk_user_identities_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='外部身份表';
@@ -0,0 +1,2 @@
+-- 删除历史密码表
+DROP TABLE IF EXISTS `password_histories`;
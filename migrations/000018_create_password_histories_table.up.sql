@@ -0,0 +1,10 @@
+-- 创建历史密码表
+CREATE TABLE IF NOT EXISTS `password_histories` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `password_hash` VARCHAR(255) NOT NULL COMMENT '被替换的密码哈希',
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_password_histories_user_id` (`user_id`),
+    CONSTRAINT `fk_password_histories_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='历史密码表';
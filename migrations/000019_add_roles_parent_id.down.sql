@@ -0,0 +1,15 @@
+-- 把内置角色继承的权限复制回角色本身
+INSERT IGNORE INTO `role_permissions` (`role_id`, `permission_id`, `created_at`)
+SELECT `child`.`id`, `inherited`.`permission_id`, NOW()
+FROM `roles` AS `child`
+JOIN `roles` AS `ancestor` ON
+    (`child`.`name` = 'superadmin' AND `ancestor`.`name` IN ('admin', 'editor', 'viewer')) OR
+    (`child`.`name` = 'admin' AND `ancestor`.`name` IN ('editor', 'viewer')) OR
+    (`child`.`name` = 'editor' AND `ancestor`.`name` = 'viewer')
+JOIN `role_permissions` AS `inherited` ON `inherited`.`role_id` = `ancestor`.`id`;
+
+-- 删除角色表的上级角色
+ALTER TABLE `roles`
+    DROP FOREIGN KEY `fk_roles_parent`,
+    DROP INDEX `idx_roles_parent_id`,
+    DROP COLUMN `parent_id`;
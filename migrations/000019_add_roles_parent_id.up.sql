@@ -0,0 +1,23 @@
+-- 角色表增加上级角色，角色继承所有上级角色的权限
+ALTER TABLE `roles`
+    ADD COLUMN `parent_id` BIGINT UNSIGNED NULL DEFAULT NULL COMMENT '上级角色ID，继承其全部权限' AFTER `description`,
+    ADD INDEX `idx_roles_parent_id` (`parent_id`),
+    ADD CONSTRAINT `fk_roles_parent` FOREIGN KEY (`parent_id`) REFERENCES `roles`(`id`) ON DELETE SET NULL;
+
+-- 内置角色层级：superadmin 继承 admin，admin 继承 editor，editor 继承 viewer
+UPDATE `roles` AS `child`
+JOIN `roles` AS `parent` ON
+    (`child`.`name` = 'superadmin' AND `parent`.`name` = 'admin') OR
+    (`child`.`name` = 'admin' AND `parent`.`name` = 'editor') OR
+    (`child`.`name` = 'editor' AND `parent`.`name` = 'viewer')
+SET `child`.`parent_id` = `parent`.`id`;
+
+-- 删除 000006 中复制的、现在可以从上级角色继承的权限
+DELETE `granted` FROM `role_permissions` AS `granted`
+JOIN `roles` AS `child` ON `child`.`id` = `granted`.`role_id`
+JOIN `roles` AS `ancestor` ON
+    (`child`.`name` = 'superadmin' AND `ancestor`.`name` IN ('admin', 'editor', 'viewer')) OR
+    (`child`.`name` = 'admin' AND `ancestor`.`name` IN ('editor', 'viewer')) OR
+    (`child`.`name` = 'editor' AND `ancestor`.`name` = 'viewer')
+JOIN `role_permissions` AS `inherited` ON
+    `inherited`.`role_id` = `ancestor`.`id` AND `inherited`.`permission_id` = `granted`.`permission_id`;
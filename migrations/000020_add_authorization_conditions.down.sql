@@ -0,0 +1,8 @@
+-- 删除用户表的创建人和自定义属性
+ALTER TABLE `users`
+    DROP INDEX `idx_users_created_by`,
+    DROP COLUMN `attributes`,
+    DROP COLUMN `created_by`;
+
+-- 删除角色授权的条件表达式
+ALTER TABLE `role_permissions` DROP COLUMN `condition`;
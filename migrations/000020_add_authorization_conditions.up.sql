@@ -0,0 +1,9 @@
+-- 角色授权增加条件表达式，为空表示无条件授权
+ALTER TABLE `role_permissions`
+    ADD COLUMN `condition` VARCHAR(1000) NOT NULL DEFAULT '' COMMENT '授权条件表达式，为空表示无条件' AFTER `permission_id`;
+
+-- 用户表增加创建人和自定义属性，供授权条件使用
+ALTER TABLE `users`
+    ADD COLUMN `created_by` BIGINT UNSIGNED NULL DEFAULT NULL COMMENT '创建该账号的管理员ID' AFTER `account_type`,
+    ADD COLUMN `attributes` JSON NULL COMMENT '自定义属性，例如 region' AFTER `created_by`,
+    ADD INDEX `idx_users_created_by` (`created_by`);
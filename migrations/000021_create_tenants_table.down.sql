@@ -0,0 +1,24 @@
+-- 删除租户管理权限
+DELETE FROM `role_permissions`
+WHERE `permission_id` IN (SELECT `id` FROM (SELECT `id` FROM `permissions` WHERE `code` = 'tenant:manage') AS p);
+DELETE FROM `permissions` WHERE `code` = 'tenant:manage';
+
+-- 恢复全局的用户角色分配，只保留用户所属租户中的分配
+DELETE `user_roles` FROM `user_roles`
+JOIN `users` ON `users`.`id` = `user_roles`.`user_id`
+WHERE `user_roles`.`tenant_id` <> `users`.`tenant_id`;
+
+ALTER TABLE `user_roles`
+    DROP FOREIGN KEY `fk_user_roles_tenant`,
+    DROP PRIMARY KEY,
+    DROP COLUMN `tenant_id`,
+    ADD PRIMARY KEY (`user_id`, `role_id`);
+
+-- 删除用户表的所属租户
+ALTER TABLE `users`
+    DROP FOREIGN KEY `fk_users_tenant`,
+    DROP INDEX `idx_users_tenant_id`,
+    DROP COLUMN `tenant_id`;
+
+-- 删除租户表
+DROP TABLE IF EXISTS `tenants`;
@@ -0,0 +1,41 @@
+-- 创建租户表
+CREATE TABLE IF NOT EXISTS `tenants` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `name` VARCHAR(50) NOT NULL COMMENT '租户标识',
+    `display_name` VARCHAR(100) NOT NULL COMMENT '显示名称',
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+    `deleted_at` DATETIME(3) NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_tenants_name` (`name`),
+    INDEX `idx_tenants_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='租户表';
+
+-- 平台租户，已有的用户和角色分配都属于平台租户
+INSERT INTO `tenants` (`id`, `name`, `display_name`, `created_at`, `updated_at`) VALUES
+(1, 'platform', '平台', NOW(), NOW());
+
+-- 用户表增加所属租户
+ALTER TABLE `users`
+    ADD COLUMN `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '所属租户ID' AFTER `id`,
+    ADD INDEX `idx_users_tenant_id` (`tenant_id`),
+    ADD CONSTRAINT `fk_users_tenant` FOREIGN KEY (`tenant_id`) REFERENCES `tenants`(`id`);
+
+-- 用户角色按租户分配
+ALTER TABLE `user_roles`
+    ADD COLUMN `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '租户ID' FIRST,
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (`tenant_id`, `user_id`, `role_id`),
+    ADD CONSTRAINT `fk_user_roles_tenant` FOREIGN KEY (`tenant_id`) REFERENCES `tenants`(`id`) ON DELETE CASCADE;
+
+-- 租户管理权限，平台租户中拥有该权限的管理员是平台管理员
+INSERT INTO `permissions` (`code`, `name`, `resource`, `action`, `description`, `status`, `created_at`, `updated_at`) VALUES
+('tenant:manage', '管理租户', 'tenant', 'manage', '管理租户，跨租户访问数据，修改角色和权限定义', 1, NOW(), NOW());
+
+INSERT INTO `role_permissions` (`role_id`, `permission_id`, `created_at`)
+SELECT
+    (SELECT `id` FROM `roles` WHERE `name` = 'superadmin'),
+    `id`,
+    NOW()
+FROM `permissions`
+WHERE `code` = 'tenant:manage';
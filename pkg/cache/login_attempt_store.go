@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 登录失败计数的维度
+const (
+	LoginAttemptScopeUsername = "username"
+	LoginAttemptScopeIP       = "ip"
+)
+
+// LoginAttemptState 某个用户名或 IP 的登录失败状态
+type LoginAttemptState struct {
+	Scope      string `json:"scope"` // username 或 ip
+	Value      string `json:"value"`
+	Failures   int64  `json:"failures"`    // 窗口期内的失败次数
+	RetryAfter int64  `json:"retry_after"` // 剩余锁定时间（秒），0 表示未锁定
+}
+
+// LoginAttemptStore 登录失败计数和锁定状态存储
+// 所有实例共享同一份 Redis 数据，锁定在所有实例上立即生效
+type LoginAttemptStore struct {
+	redis  *redis.Client
+	logger *zap.Logger
+}
+
+// NewLoginAttemptStore 创建登录失败计数存储
+func NewLoginAttemptStore(redis *redis.Client, logger *zap.Logger) *LoginAttemptStore {
+	return &LoginAttemptStore{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+// Cache Keys 定义
+const (
+	// 失败次数: auth:login_failures:<scope>:<value>
+	loginFailuresKeyPrefix = "auth:login_failures:"
+	// 锁定标记，过期即解锁: auth:login_lock:<scope>:<value>
+	loginLockKeyPrefix = "auth:login_lock:"
+	// 正在校验密码的登录次数: auth:login_pending:<scope>:<value>
+	loginPendingKeyPrefix = "auth:login_pending:"
+
+	// loginPendingTTL 登录占用的名额在请求异常退出、没有释放时的过期时间
+	loginPendingTTL = time.Minute
+)
+
+// reserveLoginAttemptScript 校验密码前占用一次登录名额，检查锁定和占用在同一个脚本中完成
+// 失败次数加上正在校验的次数达到上限时拒绝，避免并发请求在失败计数更新前绕过上限；
+// 没有正在校验的请求时总是允许，锁定过期后仍然可以尝试一次
+// 返回剩余锁定时间（毫秒），-1 表示名额已被正在校验的请求占满，0 表示占用成功
+var reserveLoginAttemptScript = redis.NewScript(`
+local ttl = redis.call('PTTL', KEYS[2])
+if ttl > 0 then
+	return ttl
+end
+local failures = tonumber(redis.call('GET', KEYS[1]) or '0')
+local pending = tonumber(redis.call('GET', KEYS[3]) or '0')
+if pending > 0 and failures + pending >= tonumber(ARGV[1]) then
+	return -1
+end
+redis.call('INCR', KEYS[3])
+redis.call('PEXPIRE', KEYS[3], ARGV[2])
+return 0
+`)
+
+// releaseLoginAttemptScript 释放占用的登录名额，名额已过期时不会减成负数
+var releaseLoginAttemptScript = redis.NewScript(`
+local pending = tonumber(redis.call('GET', KEYS[1]) or '0')
+if pending > 0 then
+	redis.call('DECR', KEYS[1])
+end
+return 0
+`)
+
+func loginAttemptKey(prefix, scope, value string) string {
+	return prefix + scope + ":" + value
+}
+
+// Reserve 校验密码前占用一次登录名额，limit 为失败次数上限
+// 返回是否占用成功和剩余锁定时间；未锁定但名额被正在校验的请求占满时返回 false 和 0
+func (s *LoginAttemptStore) Reserve(ctx context.Context, scope, value string, limit int64) (bool, time.Duration, error) {
+	keys := []string{
+		loginAttemptKey(loginFailuresKeyPrefix, scope, value),
+		loginAttemptKey(loginLockKeyPrefix, scope, value),
+		loginAttemptKey(loginPendingKeyPrefix, scope, value),
+	}
+	result, err := reserveLoginAttemptScript.Run(ctx, s.redis, keys, limit, loginPendingTTL.Milliseconds()).Int64()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to reserve login attempt: %w", err)
+	}
+
+	switch {
+	case result > 0:
+		return false, time.Duration(result) * time.Millisecond, nil
+	case result < 0:
+		return false, 0, nil
+	default:
+		return true, 0, nil
+	}
+}
+
+// Release 释放 Reserve 占用的登录名额，登录失败时需要先记录失败和锁定再释放
+func (s *LoginAttemptStore) Release(ctx context.Context, scope, value string) error {
+	key := loginAttemptKey(loginPendingKeyPrefix, scope, value)
+	if err := releaseLoginAttemptScript.Run(ctx, s.redis, []string{key}).Err(); err != nil {
+		return fmt.Errorf("failed to release login attempt: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure 记录一次登录失败，返回窗口期内的累计次数
+// 每次失败都会刷新过期时间，持续失败的计数不会被窗口截断
+func (s *LoginAttemptStore) RecordFailure(ctx context.Context, scope, value string, window time.Duration) (int64, error) {
+	key := loginAttemptKey(loginFailuresKeyPrefix, scope, value)
+
+	pipe := s.redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	return incr.Val(), nil
+}
+
+// Lock 锁定用户名或 IP，已有更长的锁定时保留原锁定
+func (s *LoginAttemptStore) Lock(ctx context.Context, scope, value string, duration time.Duration) error {
+	key := loginAttemptKey(loginLockKeyPrefix, scope, value)
+
+	ttl, err := s.redis.PTTL(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get login lock: %w", err)
+	}
+	if ttl >= duration {
+		return nil
+	}
+
+	if err := s.redis.Set(ctx, key, 1, duration).Err(); err != nil {
+		return fmt.Errorf("failed to lock login: %w", err)
+	}
+	return nil
+}
+
+// LockedFor 返回剩余锁定时间，未锁定时返回 0
+func (s *LoginAttemptStore) LockedFor(ctx context.Context, scope, value string) (time.Duration, error) {
+	ttl, err := s.redis.PTTL(ctx, loginAttemptKey(loginLockKeyPrefix, scope, value)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get login lock: %w", err)
+	}
+	// key 不存在时 PTTL 返回负数
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// Get 获取用户名或 IP 的登录失败状态
+func (s *LoginAttemptStore) Get(ctx context.Context, scope, value string) (*LoginAttemptState, error) {
+	failures, err := s.redis.Get(ctx, loginAttemptKey(loginFailuresKeyPrefix, scope, value)).Int64()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get login failures: %w", err)
+	}
+
+	lockedFor, err := s.LockedFor(ctx, scope, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginAttemptState{
+		Scope:      scope,
+		Value:      value,
+		Failures:   failures,
+		RetryAfter: int64((lockedFor + time.Second - 1) / time.Second),
+	}, nil
+}
+
+// ListLocked 列出当前被锁定的所有用户名和 IP
+func (s *LoginAttemptStore) ListLocked(ctx context.Context) ([]*LoginAttemptState, error) {
+	var states []*LoginAttemptState
+	var cursor uint64
+	for {
+		keys, next, err := s.redis.Scan(ctx, cursor, loginLockKeyPrefix+"*", 100).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan login locks: %w", err)
+		}
+
+		for _, key := range keys {
+			scope, value, ok := strings.Cut(strings.TrimPrefix(key, loginLockKeyPrefix), ":")
+			if !ok {
+				continue
+			}
+			state, err := s.Get(ctx, scope, value)
+			if err != nil {
+				return nil, err
+			}
+			// 扫描过程中锁定可能已过期
+			if state.RetryAfter > 0 {
+				states = append(states, state)
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return states, nil
+}
+
+// Clear 清除用户名或 IP 的失败次数、锁定和占用的名额
+func (s *LoginAttemptStore) Clear(ctx context.Context, scope, value string) error {
+	err := s.redis.Del(ctx,
+		loginAttemptKey(loginFailuresKeyPrefix, scope, value),
+		loginAttemptKey(loginLockKeyPrefix, scope, value),
+		loginAttemptKey(loginPendingKeyPrefix, scope, value),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to clear login failures: %w", err)
+	}
+	return nil
+}
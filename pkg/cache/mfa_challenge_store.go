@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// ErrMFAChallengeNotFound 两步验证挑战不存在或已过期
+var ErrMFAChallengeNotFound = errors.New("mfa challenge not found")
+
+// MFAChallengeRecord 密码校验通过、等待两步验证的登录挑战
+type MFAChallengeRecord struct {
+	UserID    uint      `json:"user_id"`
+	Role      string    `json:"role"`   // 验证通过后签发的 Token 角色
+	Enroll    bool      `json:"enroll"` // 用户尚未绑定，需要先完成绑定
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MFAChallengeStore 两步验证登录挑战存储
+// Redis 中只保存挑战 Token 的 SHA-256 摘要，不保存明文
+type MFAChallengeStore struct {
+	redis  *redis.Client
+	logger *zap.Logger
+}
+
+// NewMFAChallengeStore 创建两步验证登录挑战存储
+func NewMFAChallengeStore(redis *redis.Client, logger *zap.Logger) *MFAChallengeStore {
+	return &MFAChallengeStore{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+// Cache Keys 定义
+const (
+	// 登录挑战: auth:mfa_pending:<token_hash>
+	mfaChallengeKeyPrefix = "auth:mfa_pending:"
+	// 登录挑战的验证次数: auth:mfa_attempts:<token_hash>
+	mfaChallengeAttemptsKeyPrefix = "auth:mfa_attempts:"
+)
+
+// Save 保存登录挑战
+func (s *MFAChallengeStore) Save(ctx context.Context, tokenHash string, record *MFAChallengeRecord) error {
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("mfa challenge already expired")
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mfa challenge: %w", err)
+	}
+
+	if err := s.redis.Set(ctx, mfaChallengeKeyPrefix+tokenHash, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save mfa challenge: %w", err)
+	}
+
+	return nil
+}
+
+// Get 获取登录挑战，不存在时返回 ErrMFAChallengeNotFound
+func (s *MFAChallengeStore) Get(ctx context.Context, tokenHash string) (*MFAChallengeRecord, error) {
+	data, err := s.redis.Get(ctx, mfaChallengeKeyPrefix+tokenHash).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrMFAChallengeNotFound
+		}
+		return nil, fmt.Errorf("failed to get mfa challenge: %w", err)
+	}
+
+	var record MFAChallengeRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mfa challenge: %w", err)
+	}
+
+	return &record, nil
+}
+
+// IncrementAttempts 记录一次验证尝试，返回累计次数
+func (s *MFAChallengeStore) IncrementAttempts(ctx context.Context, tokenHash string, expiresAt time.Time) (int64, error) {
+	key := mfaChallengeAttemptsKeyPrefix + tokenHash
+
+	pipe := s.redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireAt(ctx, key, expiresAt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment mfa challenge attempts: %w", err)
+	}
+
+	return incr.Val(), nil
+}
+
+// Delete 删除登录挑战，返回 false 表示挑战已被其他请求使用
+func (s *MFAChallengeStore) Delete(ctx context.Context, tokenHash string) (bool, error) {
+	deleted, err := s.redis.Del(ctx, mfaChallengeKeyPrefix+tokenHash, mfaChallengeAttemptsKeyPrefix+tokenHash).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete mfa challenge: %w", err)
+	}
+
+	// 并发完成同一挑战时只有一个请求能删除成功
+	return deleted > 0, nil
+}
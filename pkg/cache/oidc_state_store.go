@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// ErrOIDCStateNotFound 授权请求不存在、已过期或已被使用
+var ErrOIDCStateNotFound = errors.New("oidc state not found")
+
+// OIDCStateRecord 发往身份提供方、等待回调的授权请求
+type OIDCStateRecord struct {
+	CodeVerifier string    `json:"code_verifier"` // PKCE code_verifier，兑换授权码时提交
+	Nonce        string    `json:"nonce"`         // 写入 ID Token 的 nonce，防止重放
+	BindingHash  string    `json:"binding_hash"`  // 发起登录的浏览器 Cookie 中绑定值的摘要
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// OIDCStateStore 单点登录授权请求存储
+// Redis 中以 state 的 SHA-256 摘要为 key，每个 state 只能使用一次
+type OIDCStateStore struct {
+	redis  *redis.Client
+	logger *zap.Logger
+}
+
+// NewOIDCStateStore 创建单点登录授权请求存储
+func NewOIDCStateStore(redis *redis.Client, logger *zap.Logger) *OIDCStateStore {
+	return &OIDCStateStore{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+// Cache Keys 定义
+const (
+	// 授权请求: auth:oidc_state:<state_hash>
+	oidcStateKeyPrefix = "auth:oidc_state:"
+)
+
+// Save 保存授权请求，过期后自动删除
+func (s *OIDCStateStore) Save(ctx context.Context, stateHash string, record *OIDCStateRecord) error {
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("oidc state already expired")
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal oidc state: %w", err)
+	}
+
+	if err := s.redis.Set(ctx, oidcStateKeyPrefix+stateHash, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save oidc state: %w", err)
+	}
+
+	return nil
+}
+
+// Take 取出并删除授权请求，不存在时返回 ErrOIDCStateNotFound
+func (s *OIDCStateStore) Take(ctx context.Context, stateHash string) (*OIDCStateRecord, error) {
+	data, err := s.redis.GetDel(ctx, oidcStateKeyPrefix+stateHash).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrOIDCStateNotFound
+		}
+		return nil, fmt.Errorf("failed to get oidc state: %w", err)
+	}
+
+	var record OIDCStateRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal oidc state: %w", err)
+	}
+
+	return &record, nil
+}
@@ -354,9 +354,8 @@ func (c *RBACCache) InvalidateRoleCache(ctx context.Context, roleID uint) error
 
 	c.logger.Info("Role cache invalidated", zap.Uint("role_id", roleID))
 
-	// 注意：这里不删除用户缓存，因为我们不知道哪些用户拥有这个角色
-	// 用户缓存会在 TTL 过期后自动更新
-	// 如果需要立即生效，可以在分配/移除角色时手动调用 InvalidateUserCache
+	// 注意：这里不删除下级角色和用户的缓存，因为缓存中没有角色层级和角色对应的用户
+	// 由调用方（RBACService）查出继承该角色的下级角色和拥有这些角色的用户后逐个使它们失效
 
 	return nil
 }
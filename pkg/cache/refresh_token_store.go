@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// ErrRefreshTokenNotFound Refresh Token 不存在或已过期
+var ErrRefreshTokenNotFound = errors.New("refresh token not found")
+
+// RefreshTokenRecord Refresh Token 在 Redis 中保存的信息
+type RefreshTokenRecord struct {
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	FamilyID  string    `json:"family_id"` // Token 家族 ID，同一次登录轮换出的 Refresh Token 属于同一家族
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RefreshTokenStore Refresh Token 存储
+// Redis 中只保存 Token 的 SHA-256 摘要，不保存明文
+type RefreshTokenStore struct {
+	redis  *redis.Client
+	logger *zap.Logger
+}
+
+// NewRefreshTokenStore 创建 Refresh Token 存储
+func NewRefreshTokenStore(redis *redis.Client, logger *zap.Logger) *RefreshTokenStore {
+	return &RefreshTokenStore{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+// Cache Keys 定义
+const (
+	// Refresh Token 记录: auth:refresh:<token_hash>
+	refreshTokenKeyPrefix = "auth:refresh:"
+	// Refresh Token 已使用标记: auth:refresh_used:<token_hash>
+	refreshTokenUsedKeyPrefix = "auth:refresh_used:"
+	// Token 家族: auth:refresh_family:<family_id>
+	refreshFamilyKeyPrefix = "auth:refresh_family:"
+	// 用户的 Token 家族集合: auth:refresh_user:<user_id>
+	refreshUserFamiliesKeyPrefix = "auth:refresh_user:"
+	// 会话最近活跃时间的写入节流标记: auth:session_seen:<family_id>
+	sessionSeenKeyPrefix = "auth:session_seen:"
+)
+
+// Save 保存 Refresh Token 记录，并延长其家族的有效期
+func (s *RefreshTokenStore) Save(ctx context.Context, tokenHash string, record *RefreshTokenRecord) error {
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("refresh token already expired")
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal refresh token: %w", err)
+	}
+
+	userFamiliesKey := fmt.Sprintf("%s%d", refreshUserFamiliesKeyPrefix, record.UserID)
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, refreshTokenKeyPrefix+tokenHash, data, ttl)
+	pipe.Set(ctx, refreshFamilyKeyPrefix+record.FamilyID, record.UserID, ttl)
+	pipe.SAdd(ctx, userFamiliesKey, record.FamilyID)
+	// 集合的有效期取其中最晚过期的 Token 家族（需要 Redis 7+）
+	pipe.ExpireNX(ctx, userFamiliesKey, ttl)
+	pipe.ExpireGT(ctx, userFamiliesKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	s.logger.Debug("Refresh token saved",
+		zap.Uint("user_id", record.UserID),
+		zap.String("family_id", record.FamilyID),
+		zap.Duration("ttl", ttl))
+
+	return nil
+}
+
+// SaveFamily 只登记 Token 家族而不保存 Refresh Token，用于不能刷新的会话（如管理员模拟登录）
+func (s *RefreshTokenStore) SaveFamily(ctx context.Context, userID uint, familyID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("token family already expired")
+	}
+
+	userFamiliesKey := fmt.Sprintf("%s%d", refreshUserFamiliesKeyPrefix, userID)
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, refreshFamilyKeyPrefix+familyID, userID, ttl)
+	pipe.SAdd(ctx, userFamiliesKey, familyID)
+	pipe.ExpireNX(ctx, userFamiliesKey, ttl)
+	pipe.ExpireGT(ctx, userFamiliesKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save token family: %w", err)
+	}
+
+	return nil
+}
+
+// Get 获取 Refresh Token 记录
+func (s *RefreshTokenStore) Get(ctx context.Context, tokenHash string) (*RefreshTokenRecord, error) {
+	data, err := s.redis.Get(ctx, refreshTokenKeyPrefix+tokenHash).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	var record RefreshTokenRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal refresh token: %w", err)
+	}
+
+	return &record, nil
+}
+
+// MarkUsed 将 Refresh Token 标记为已使用
+// 返回 false 表示该 Token 之前已被使用过（重放）
+func (s *RefreshTokenStore) MarkUsed(ctx context.Context, tokenHash string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+
+	ok, err := s.redis.SetNX(ctx, refreshTokenUsedKeyPrefix+tokenHash, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+
+	return ok, nil
+}
+
+// IsFamilyActive 检查 Token 家族是否仍然有效
+func (s *RefreshTokenStore) IsFamilyActive(ctx context.Context, familyID string) (bool, error) {
+	n, err := s.redis.Exists(ctx, refreshFamilyKeyPrefix+familyID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check refresh token family: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// RevokeFamily 吊销整个 Token 家族，家族内所有 Refresh Token 立即失效
+func (s *RefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	if err := s.redis.Del(ctx, refreshFamilyKeyPrefix+familyID).Err(); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	s.logger.Info("Refresh token family revoked", zap.String("family_id", familyID))
+	return nil
+}
+
+// RevokeUserFamilies 吊销用户的所有 Token 家族
+func (s *RefreshTokenStore) RevokeUserFamilies(ctx context.Context, userID uint) error {
+	userFamiliesKey := fmt.Sprintf("%s%d", refreshUserFamiliesKeyPrefix, userID)
+
+	familyIDs, err := s.redis.SMembers(ctx, userFamiliesKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get user refresh token families: %w", err)
+	}
+
+	keys := make([]string, 0, len(familyIDs)+1)
+	for _, familyID := range familyIDs {
+		keys = append(keys, refreshFamilyKeyPrefix+familyID)
+	}
+	keys = append(keys, userFamiliesKey)
+
+	if err := s.redis.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to revoke user refresh token families: %w", err)
+	}
+
+	s.logger.Info("User refresh token families revoked",
+		zap.Uint("user_id", userID),
+		zap.Int("count", len(familyIDs)))
+	return nil
+}
+
+// MarkSessionSeen 标记会话活跃，interval 内只有第一次调用返回 true，用于限制写库频率
+func (s *RefreshTokenStore) MarkSessionSeen(ctx context.Context, familyID string, interval time.Duration) (bool, error) {
+	ok, err := s.redis.SetNX(ctx, sessionSeenKeyPrefix+familyID, 1, interval).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark session seen: %w", err)
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// TokenRevocationStore Access Token 吊销列表
+// 所有实例共享同一份 Redis 数据，吊销后在所有实例上立即生效
+type TokenRevocationStore struct {
+	redis  *redis.Client
+	logger *zap.Logger
+}
+
+// NewTokenRevocationStore 创建 Access Token 吊销列表
+func NewTokenRevocationStore(redis *redis.Client, logger *zap.Logger) *TokenRevocationStore {
+	return &TokenRevocationStore{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+// Cache Keys 定义
+const (
+	// 已吊销的 Token: auth:revoked:<jti>
+	revokedTokenKeyPrefix = "auth:revoked:"
+)
+
+// RevokeToken 吊销单个 Token，记录保留到 Token 自然过期为止
+func (s *TokenRevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		// Token 已过期，无需吊销
+		return nil
+	}
+
+	if err := s.redis.Set(ctx, revokedTokenKeyPrefix+jti, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	s.logger.Debug("Token revoked", zap.String("jti", jti), zap.Duration("ttl", ttl))
+	return nil
+}
+
+// IsTokenRevoked 检查 Token 是否已被吊销
+func (s *TokenRevocationStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.redis.Exists(ctx, revokedTokenKeyPrefix+jti).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check revoked token: %w", err)
+	}
+
+	return n > 0, nil
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestTokenRevocationStore(t *testing.T) (*TokenRevocationStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewTokenRevocationStore(client, zap.NewNop()), mr
+}
+
+func TestTokenRevocationStore_RevokeToken(t *testing.T) {
+	ctx := context.Background()
+	store, mr := newTestTokenRevocationStore(t)
+
+	require.NoError(t, store.RevokeToken(ctx, "jti-1", time.Now().Add(10*time.Minute)))
+
+	revoked, err := store.IsTokenRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// 其他 Token 不受影响
+	revoked, err = store.IsTokenRevoked(ctx, "jti-2")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// 记录只保留到 Token 自然过期为止
+	assert.InDelta(t, (10 * time.Minute).Seconds(), mr.TTL(revokedTokenKeyPrefix+"jti-1").Seconds(), 2)
+	mr.FastForward(10*time.Minute + time.Second)
+	revoked, err = store.IsTokenRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestTokenRevocationStore_RevokeExpiredToken(t *testing.T) {
+	ctx := context.Background()
+	store, mr := newTestTokenRevocationStore(t)
+
+	// 已过期的 Token 不需要写入吊销列表
+	require.NoError(t, store.RevokeToken(ctx, "jti-1", time.Now().Add(-time.Second)))
+	assert.False(t, mr.Exists(revokedTokenKeyPrefix+"jti-1"))
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// TokenVersionCache 用户当前 Token 版本缓存
+type TokenVersionCache struct {
+	redis  *redis.Client
+	logger *zap.Logger
+	ttl    time.Duration
+}
+
+// NewTokenVersionCache 创建 Token 版本缓存
+func NewTokenVersionCache(redis *redis.Client, logger *zap.Logger) *TokenVersionCache {
+	return &TokenVersionCache{
+		redis:  redis,
+		logger: logger,
+		ttl:    24 * time.Hour, // 版本只增不减，可以长时间缓存
+	}
+}
+
+// Cache Keys 定义
+const (
+	// 用户 Token 版本: auth:token_version:<user_id>
+	tokenVersionKeyPrefix = "auth:token_version:"
+)
+
+// setIfGreaterScript 只有新版本大于缓存中的版本时才写入
+// 避免并发时读到旧版本的请求把刚递增的版本覆盖回去
+var setIfGreaterScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current and tonumber(current) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'EX', ARGV[2])
+return 1
+`)
+
+// Get 获取用户当前 Token 版本
+func (c *TokenVersionCache) Get(ctx context.Context, userID uint) (uint, bool) {
+	key := fmt.Sprintf("%s%d", tokenVersionKeyPrefix, userID)
+
+	data, err := c.redis.Get(ctx, key).Result()
+	if err != nil {
+		if err != redis.Nil {
+			c.logger.Error("Failed to get token version from cache",
+				zap.Uint("user_id", userID),
+				zap.Error(err))
+		}
+		return 0, false
+	}
+
+	version, err := strconv.ParseUint(data, 10, 64)
+	if err != nil {
+		c.logger.Error("Failed to parse token version from cache",
+			zap.Uint("user_id", userID),
+			zap.Error(err))
+		return 0, false
+	}
+
+	return uint(version), true
+}
+
+// Set 缓存用户 Token 版本（只会增大）
+func (c *TokenVersionCache) Set(ctx context.Context, userID uint, version uint) error {
+	key := fmt.Sprintf("%s%d", tokenVersionKeyPrefix, userID)
+
+	if err := setIfGreaterScript.Run(ctx, c.redis, []string{key}, version, int(c.ttl.Seconds())).Err(); err != nil {
+		return fmt.Errorf("failed to set token version cache: %w", err)
+	}
+
+	return nil
+}
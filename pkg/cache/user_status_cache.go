@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// UserStatus 认证时需要检查的用户状态
+type UserStatus struct {
+	Status  int  `json:"status"`  // 1: 活跃, 0: 禁用
+	Deleted bool `json:"deleted"` // 是否已删除（软删除）
+}
+
+// UserStatusCache 用户状态缓存
+type UserStatusCache struct {
+	redis  *redis.Client
+	logger *zap.Logger
+	ttl    time.Duration
+}
+
+// NewUserStatusCache 创建用户状态缓存
+func NewUserStatusCache(redis *redis.Client, logger *zap.Logger) *UserStatusCache {
+	return &UserStatusCache{
+		redis:  redis,
+		logger: logger,
+		ttl:    5 * time.Minute, // 用户状态缓存 5 分钟
+	}
+}
+
+// Cache Keys 定义
+const (
+	// 用户状态: auth:user_status:<user_id>
+	userStatusKeyPrefix = "auth:user_status:"
+)
+
+// Get 获取用户状态
+func (c *UserStatusCache) Get(ctx context.Context, userID uint) (*UserStatus, bool) {
+	key := fmt.Sprintf("%s%d", userStatusKeyPrefix, userID)
+
+	data, err := c.redis.Get(ctx, key).Result()
+	if err != nil {
+		if err != redis.Nil {
+			c.logger.Error("Failed to get user status from cache",
+				zap.Uint("user_id", userID),
+				zap.Error(err))
+		}
+		return nil, false
+	}
+
+	var status UserStatus
+	if err := json.Unmarshal([]byte(data), &status); err != nil {
+		c.logger.Error("Failed to unmarshal user status",
+			zap.Uint("user_id", userID),
+			zap.Error(err))
+		return nil, false
+	}
+
+	return &status, true
+}
+
+// Set 缓存用户状态
+func (c *UserStatusCache) Set(ctx context.Context, userID uint, status *UserStatus) {
+	key := fmt.Sprintf("%s%d", userStatusKeyPrefix, userID)
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		c.logger.Error("Failed to marshal user status",
+			zap.Uint("user_id", userID),
+			zap.Error(err))
+		return
+	}
+
+	if err := c.redis.Set(ctx, key, data, c.ttl).Err(); err != nil {
+		c.logger.Error("Failed to set user status cache",
+			zap.Uint("user_id", userID),
+			zap.Error(err))
+	}
+}
+
+// Invalidate 清除用户状态缓存
+func (c *UserStatusCache) Invalidate(ctx context.Context, userID uint) error {
+	key := fmt.Sprintf("%s%d", userStatusKeyPrefix, userID)
+
+	if err := c.redis.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to invalidate user status cache: %w", err)
+	}
+
+	c.logger.Debug("User status cache invalidated", zap.Uint("user_id", userID))
+	return nil
+}
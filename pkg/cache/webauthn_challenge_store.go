@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// ErrWebAuthnChallengeNotFound WebAuthn 挑战不存在、已过期或已被使用
+var ErrWebAuthnChallengeNotFound = errors.New("webauthn challenge not found")
+
+// WebAuthn 仪式类型
+const (
+	WebAuthnCeremonyRegistration = "registration"
+	WebAuthnCeremonyLogin        = "login"
+)
+
+// WebAuthnChallengeRecord 进行中的通行密钥注册或登录仪式
+type WebAuthnChallengeRecord struct {
+	UserID    uint            `json:"user_id"`  // 发起注册的用户，登录仪式为 0
+	Ceremony  string          `json:"ceremony"` // registration 或 login
+	Session   json.RawMessage `json:"session"`  // WebAuthn 库的 SessionData，包含挑战值
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// WebAuthnChallengeStore WebAuthn 挑战存储
+// 每个挑战只能使用一次，读取时即删除
+type WebAuthnChallengeStore struct {
+	redis  *redis.Client
+	logger *zap.Logger
+}
+
+// NewWebAuthnChallengeStore 创建 WebAuthn 挑战存储
+func NewWebAuthnChallengeStore(redis *redis.Client, logger *zap.Logger) *WebAuthnChallengeStore {
+	return &WebAuthnChallengeStore{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+// Cache Keys 定义
+const (
+	// 注册或登录挑战: auth:webauthn:<challenge_id>
+	webAuthnChallengeKeyPrefix = "auth:webauthn:"
+)
+
+// Save 保存挑战，过期后自动删除
+func (s *WebAuthnChallengeStore) Save(ctx context.Context, challengeID string, record *WebAuthnChallengeRecord) error {
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("webauthn challenge already expired")
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webauthn challenge: %w", err)
+	}
+
+	if err := s.redis.Set(ctx, webAuthnChallengeKeyPrefix+challengeID, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save webauthn challenge: %w", err)
+	}
+
+	return nil
+}
+
+// Take 取出并删除挑战，不存在时返回 ErrWebAuthnChallengeNotFound
+// 并发使用同一挑战时只有一个请求能取到
+func (s *WebAuthnChallengeStore) Take(ctx context.Context, challengeID string) (*WebAuthnChallengeRecord, error) {
+	data, err := s.redis.GetDel(ctx, webAuthnChallengeKeyPrefix+challengeID).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrWebAuthnChallengeNotFound
+		}
+		return nil, fmt.Errorf("failed to get webauthn challenge: %w", err)
+	}
+
+	var record WebAuthnChallengeRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webauthn challenge: %w", err)
+	}
+
+	return &record, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,6 +16,9 @@ type Config struct {
 	Kafka     KafkaConfig     `yaml:"kafka"`
 	Logger    LoggerConfig    `yaml:"logger"`
 	JWT       JWTConfig       `yaml:"jwt"`
+	Auth      AuthConfig      `yaml:"auth"`
+	RBAC      RBACConfig      `yaml:"rbac"`
+	Notifier  NotifierConfig  `yaml:"notifier"`
 	RateLimit RateLimitConfig `yaml:"rate_limit"`
 	Tracing   TracingConfig   `yaml:"tracing"`
 }
@@ -65,13 +69,202 @@ type LoggerConfig struct {
 }
 
 type JWTConfig struct {
-	Secret           string `yaml:"secret"`
-	Issuer           string `yaml:"issuer"`
-	ExpireHours      int    `yaml:"expire_hours"`       // 用户 Token 过期时间（小时）
-	AdminExpireHours int    `yaml:"admin_expire_hours"` // 管理员 Token 过期时间（小时）
+	Secret                   string `yaml:"secret"`
+	Issuer                   string `yaml:"issuer"`
+	ExpireHours              int    `yaml:"expire_hours"`                // 用户 Token 过期时间（小时）
+	AdminExpireHours         int    `yaml:"admin_expire_hours"`          // 管理员 Token 过期时间（小时）
+	AccessExpireMinutes      int    `yaml:"access_expire_minutes"`       // 用户 Access Token 过期时间（分钟），优先于 expire_hours
+	AdminAccessExpireMinutes int    `yaml:"admin_access_expire_minutes"` // 管理员 Access Token 过期时间（分钟），优先于 admin_expire_hours
+	RefreshExpireHours       int    `yaml:"refresh_expire_hours"`        // 用户 Refresh Token 过期时间（小时），默认 168
+	AdminRefreshExpireHours  int    `yaml:"admin_refresh_expire_hours"`  // 管理员 Refresh Token 过期时间（小时），默认 24
+
+	// 非对称签名密钥，配置后使用 keys 签名，secret 不再用于签发和验证
+	Keys                 []JWTKeyConfig `yaml:"keys"`
+	RotationOverlapHours int            `yaml:"rotation_overlap_hours"` // 新密钥启用后旧密钥继续用于验证的时长（小时），不小于 Access Token 有效期
+}
+
+// JWTKeyConfig JWT 签名密钥配置
+type JWTKeyConfig struct {
+	ID             string    `yaml:"id"`               // 密钥 ID，写入 Token 头部的 kid
+	Algorithm      string    `yaml:"algorithm"`        // 签名算法：RS256、ES256、EdDSA
+	PrivateKeyFile string    `yaml:"private_key_file"` // 私钥 PEM 文件，为空时该密钥只用于验证
+	PublicKeyFile  string    `yaml:"public_key_file"`  // 公钥 PEM 文件，为空时从私钥推导
+	ActiveFrom     time.Time `yaml:"active_from"`      // 开始用于签名的时间（RFC3339），为空表示立即生效
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	StatusCheckFailOpen bool   `yaml:"status_check_fail_open"` // Token 吊销、会话、用户状态和 Token 版本检查依赖的 Redis 或数据库不可用时是否放行请求，默认拒绝
+	MFAIssuer           string `yaml:"mfa_issuer"`             // 两步验证在验证器 App 中显示的发行方名称，为空时使用 JWT issuer
+
+	PasswordHash   PasswordHashConfig   `yaml:"password_hash"`
+	PasswordPolicy PasswordPolicyConfig `yaml:"password_policy"`
+
+	PasswordResetURL        string `yaml:"password_reset_url"`          // 重置密码页面地址，邮件中的链接为 <url>?token=<token>
+	PasswordResetTTLMinutes int    `yaml:"password_reset_ttl_minutes"`  // 重置密码链接有效期（分钟），默认 30
+	PasswordResetMaxPerHour int    `yaml:"password_reset_max_per_hour"` // 同一邮箱每小时最多申请的重置密码链接数，默认 5
+
+	RequireEmailVerification       bool   `yaml:"require_email_verification"`        // 邮箱验证前是否禁止登录
+	EmailVerificationURL           string `yaml:"email_verification_url"`            // 邮箱验证页面地址，邮件中的链接为 <url>?token=<token>
+	EmailVerificationSecret        string `yaml:"email_verification_secret"`         // 邮件链接（邮箱验证、免密登录）签名密钥，为空时使用 JWT secret
+	EmailVerificationTTLHours      int    `yaml:"email_verification_ttl_hours"`      // 验证链接有效期（小时），默认 24
+	EmailVerificationResendSeconds int    `yaml:"email_verification_resend_seconds"` // 两次发送验证邮件的最小间隔（秒），默认 60
+
+	MagicLinkURL        string `yaml:"magic_link_url"`          // 免密登录页面地址，邮件中的链接为 <url>?token=<token>
+	MagicLinkTTLMinutes int    `yaml:"magic_link_ttl_minutes"`  // 免密登录链接有效期（分钟），默认 10
+	MagicLinkMaxPerHour int    `yaml:"magic_link_max_per_hour"` // 同一邮箱每小时最多申请的免密登录链接数，默认 5
+
+	APIKeyMaxTTLDays int `yaml:"api_key_max_ttl_days"` // API Key 最长有效期（天），默认 365
+
+	ImpersonationTTLMinutes int `yaml:"impersonation_ttl_minutes"` // 管理员模拟用户签发的 Token 有效期（分钟），默认 15
+
+	// 浏览器 Cookie 会话，前台和后台分别配置
+	FrontendCookie SessionCookieConfig `yaml:"frontend_cookie"`
+	BackendCookie  SessionCookieConfig `yaml:"backend_cookie"`
+
+	WebAuthn WebAuthnConfig `yaml:"webauthn"`
+	OIDC     OIDCConfig     `yaml:"oidc"`
+
+	// 用户名密码登录的凭证校验器，按顺序尝试：local（本地密码）、ldap（LDAP / Active Directory），默认只使用 local
+	// 前一个校验器密码错误、找不到用户或连接失败时尝试下一个
+	Authenticators []string   `yaml:"authenticators"`
+	LDAP           LDAPConfig `yaml:"ldap"`
+
+	LoginProtection LoginProtectionConfig `yaml:"login_protection"`
+}
+
+// PasswordHashConfig 密码哈希配置
+// 哈希值自带算法和参数，修改配置后旧哈希仍然可以校验，并在用户下次登录成功时按新配置重新哈希
+type PasswordHashConfig struct {
+	Algorithm         string `yaml:"algorithm"`          // argon2id（默认）或 bcrypt
+	BcryptCost        int    `yaml:"bcrypt_cost"`        // bcrypt 成本，默认 10
+	Argon2MemoryKiB   uint32 `yaml:"argon2_memory_kib"`  // argon2id 内存（KiB），默认 65536
+	Argon2Iterations  uint32 `yaml:"argon2_iterations"`  // argon2id 迭代次数，默认 3
+	Argon2Parallelism uint8  `yaml:"argon2_parallelism"` // argon2id 并行度，默认 2
+}
+
+// PasswordPolicyConfig 密码策略配置，注册、修改密码、找回密码和管理员重置密码时校验
+type PasswordPolicyConfig struct {
+	MinLength        int  `yaml:"min_length"`         // 最小长度（按字符计），默认 8
+	MaxLength        int  `yaml:"max_length"`         // 最大长度（按字符计），默认 128
+	RequireUppercase bool `yaml:"require_uppercase"`  // 是否必须包含大写字母
+	RequireLowercase bool `yaml:"require_lowercase"`  // 是否必须包含小写字母
+	RequireDigit     bool `yaml:"require_digit"`      // 是否必须包含数字
+	RequireSymbol    bool `yaml:"require_symbol"`     // 是否必须包含字母和数字以外的字符
+	DisallowUserInfo bool `yaml:"disallow_user_info"` // 是否禁止包含用户名或邮箱名（不区分大小写）
+	HistorySize      int  `yaml:"history_size"`       // 不能与最近几次使用过的密码（包括当前密码）相同，0 表示不检查
+
+	// 泄露密码列表文件，每行为密码的 SHA-1（十六进制）加可选的 :<出现次数>，与 Have I Been Pwned 导出的格式相同；
+	// 按 SHA-1 前 5 位分段查找（k-anonymity），为空时不检查
+	BreachedPasswordsFile string `yaml:"breached_passwords_file"`
+}
+
+// MinimumLength 返回密码最小长度
+func (p *PasswordPolicyConfig) MinimumLength() int {
+	return positiveOr(p.MinLength, 8)
+}
+
+// MaximumLength 返回密码最大长度
+func (p *PasswordPolicyConfig) MaximumLength() int {
+	return positiveOr(p.MaxLength, 128)
+}
+
+// LoginProtectionConfig 登录暴力破解防护配置
+// 按用户名和 IP 分别统计窗口期内的失败次数：超过 delay_after 次后每次失败需要等待递增的时间才能重试，
+// 达到上限后锁定 lockout_minutes 分钟
+type LoginProtectionConfig struct {
+	Enabled              bool `yaml:"enabled"`                // 是否启用
+	MaxFailures          int  `yaml:"max_failures"`           // 同一用户名失败多少次后锁定，默认 5
+	IPMaxFailures        int  `yaml:"ip_max_failures"`        // 同一 IP 失败多少次后锁定，默认 20
+	FailureWindowMinutes int  `yaml:"failure_window_minutes"` // 失败次数统计窗口（分钟），默认 15
+	LockoutMinutes       int  `yaml:"lockout_minutes"`        // 锁定时长（分钟），默认 15
+	DelayAfter           int  `yaml:"delay_after"`            // 失败多少次后开始递增等待，默认 3
+	BaseDelaySeconds     int  `yaml:"base_delay_seconds"`     // 第一次等待时长（秒），之后每次翻倍，默认 1
+	MaxDelaySeconds      int  `yaml:"max_delay_seconds"`      // 等待时长上限（秒），默认 60
+}
+
+// SessionCookieConfig 浏览器 Cookie 会话配置
+// 启用后登录和刷新时通过 HttpOnly Cookie 下发 Token，认证中间件在没有 Authorization 头时读取 Cookie；
+// 使用 Cookie 认证的写请求必须在请求头中带上 CSRF Cookie 的值（double submit）
+type SessionCookieConfig struct {
+	Enabled     bool   `yaml:"enabled"`      // 是否启用
+	Name        string `yaml:"name"`         // Access Token Cookie 名称，前后台不能相同
+	RefreshName string `yaml:"refresh_name"` // Refresh Token Cookie 名称
+	RefreshPath string `yaml:"refresh_path"` // Refresh Token Cookie 的路径，建议设为刷新接口，为空时使用 path
+	Domain      string `yaml:"domain"`       // Cookie 域名，为空时只发送给当前域名
+	Path        string `yaml:"path"`         // Cookie 路径，默认 /
+	Secure      bool   `yaml:"secure"`       // 只通过 HTTPS 发送，生产环境必须开启
+	SameSite    string `yaml:"same_site"`    // strict、lax（默认）、none（必须同时开启 secure）
+
+	CSRFDisabled   bool   `yaml:"csrf_disabled"`    // 关闭 CSRF 校验，只能在开发环境使用
+	CSRFCookieName string `yaml:"csrf_cookie_name"` // CSRF Cookie 名称，前端脚本可读
+	CSRFHeaderName string `yaml:"csrf_header_name"` // 写请求携带 CSRF Token 的请求头，默认 X-CSRF-Token
+}
+
+// WebAuthnConfig 管理员通行密钥（WebAuthn）配置
+type WebAuthnConfig struct {
+	RPID                string   `yaml:"rp_id"`                 // 依赖方 ID，即后台页面的域名（不含协议和端口），通行密钥与该域名绑定
+	RPDisplayName       string   `yaml:"rp_display_name"`       // 在浏览器和验证器中显示的名称，默认 trx-project
+	RPOrigins           []string `yaml:"rp_origins"`            // 允许发起仪式的页面 origin，例如 https://admin.example.com
+	ChallengeTTLSeconds int      `yaml:"challenge_ttl_seconds"` // 注册和登录挑战的有效期（秒），默认 300
+}
+
+// OIDCConfig 后台 OpenID Connect 单点登录配置
+// 使用授权码模式 + PKCE，首次登录时自动创建账号（JIT），每次登录按 group_roles 同步角色
+type OIDCConfig struct {
+	Enabled         bool                `yaml:"enabled"`           // 是否启用
+	Issuer          string              `yaml:"issuer"`            // 身份提供方地址，通过 <issuer>/.well-known/openid-configuration 发现端点
+	ClientID        string              `yaml:"client_id"`         // 在身份提供方注册的客户端 ID
+	ClientSecret    string              `yaml:"client_secret"`     // 客户端密钥，公开客户端可以为空
+	RedirectURL     string              `yaml:"redirect_url"`      // 后台登录回调页面地址，页面把 code 和 state 提交到 /admin/auth/login/sso
+	Scopes          []string            `yaml:"scopes"`            // 申请的 scope，默认 openid profile email
+	UsernameClaim   string              `yaml:"username_claim"`    // 作为用户名的 claim，默认 preferred_username
+	GroupsClaim     string              `yaml:"groups_claim"`      // 用户所属组的 claim，默认 groups
+	GroupRoles      map[string][]string `yaml:"group_roles"`       // 身份提供方的组到本地角色名的映射
+	LinkByEmail     bool                `yaml:"link_by_email"`     // 首次登录时按已验证的邮箱关联已有账号，默认不关联
+	StateTTLSeconds int                 `yaml:"state_ttl_seconds"` // 授权请求的有效期（秒），默认 600
+}
+
+// LDAPConfig LDAP / Active Directory 认证配置
+// 先使用 bind_dn 查找用户条目，再以用户条目的 DN 和密码绑定校验密码；首次登录时自动创建账号，每次登录按 group_roles 同步角色
+type LDAPConfig struct {
+	URL                string              `yaml:"url"`                  // 服务器地址，ldap:// 或 ldaps://
+	StartTLS           bool                `yaml:"start_tls"`            // ldap:// 连接是否升级为 TLS
+	InsecureSkipVerify bool                `yaml:"insecure_skip_verify"` // 不校验服务器证书，仅用于测试环境
+	BindDN             string              `yaml:"bind_dn"`              // 查找用户使用的服务账号 DN，为空时匿名查找
+	BindPassword       string              `yaml:"bind_password"`        // 服务账号密码
+	BaseDN             string              `yaml:"base_dn"`              // 查找用户的起始 DN
+	UserFilter         string              `yaml:"user_filter"`          // 查找用户的过滤器，%s 替换为转义后的用户名，默认 (&(objectClass=person)(uid=%s))
+	UsernameAttribute  string              `yaml:"username_attribute"`   // 作为用户名的属性，默认 uid，Active Directory 使用 sAMAccountName
+	EmailAttribute     string              `yaml:"email_attribute"`      // 邮箱属性，默认 mail
+	GroupAttribute     string              `yaml:"group_attribute"`      // 用户条目上所属组 DN 的属性，默认 memberOf
+	GroupRoles         map[string][]string `yaml:"group_roles"`          // 组 DN（不区分大小写）到本地角色名的映射
+	TimeoutSeconds     int                 `yaml:"timeout_seconds"`      // 连接和请求超时（秒），默认 5
+}
+
+// NotifierConfig 通知配置
+type NotifierConfig struct {
+	Driver string     `yaml:"driver"` // 发送方式：smtp、file（写入目录，仅用于开发）、log（写入日志，仅用于开发）、memory（保存在内存中，仅用于测试）
+	SMTP   SMTPConfig `yaml:"smtp"`
+	Dir    string     `yaml:"dir"` // file 方式下保存邮件的目录
+}
+
+// SMTPConfig SMTP 邮件配置
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"` // 为空时不认证
+	Password string `yaml:"password"`
+	From     string `yaml:"from"` // 发件人地址
 }
 
 // RateLimitConfig 限流配置
+// RBACConfig 权限配置
+type RBACConfig struct {
+	// ImpliedPermissions 权限蕴含表：拥有键对应的权限即拥有值中的权限，可以传递，值可以使用通配符
+	ImpliedPermissions map[string][]string `yaml:"implied_permissions"`
+}
+
 type RateLimitConfig struct {
 	Enabled    bool   `yaml:"enabled"`     // 是否启用限流
 	GlobalRate string `yaml:"global_rate"` // 全局限流：例如 "1000-S" (每秒1000个请求)
@@ -149,6 +342,254 @@ func (m *MySQLConfig) GetDSN() string {
 		m.Username, m.Password, m.Host, m.Port, m.Database)
 }
 
+// AccessExpire 返回用户 Access Token 有效期
+func (j *JWTConfig) AccessExpire() time.Duration {
+	if j.AccessExpireMinutes > 0 {
+		return time.Duration(j.AccessExpireMinutes) * time.Minute
+	}
+	return time.Duration(j.ExpireHours) * time.Hour
+}
+
+// AdminAccessExpire 返回管理员 Access Token 有效期
+func (j *JWTConfig) AdminAccessExpire() time.Duration {
+	if j.AdminAccessExpireMinutes > 0 {
+		return time.Duration(j.AdminAccessExpireMinutes) * time.Minute
+	}
+	return time.Duration(j.AdminExpireHours) * time.Hour
+}
+
+// MaxAccessExpire 返回前后台 Access Token 有效期中较长的一个
+func (j *JWTConfig) MaxAccessExpire() time.Duration {
+	if j.AdminAccessExpire() > j.AccessExpire() {
+		return j.AdminAccessExpire()
+	}
+	return j.AccessExpire()
+}
+
+// RotationOverlap 返回旧签名密钥被取代后继续用于验证的时长
+// 至少覆盖 Access Token 的有效期，否则轮换时已签发的 Token 会提前失效
+func (j *JWTConfig) RotationOverlap() time.Duration {
+	overlap := time.Duration(j.RotationOverlapHours) * time.Hour
+	if overlap < j.MaxAccessExpire() {
+		overlap = j.MaxAccessExpire()
+	}
+	return overlap
+}
+
+// RefreshExpire 返回用户 Refresh Token 有效期
+func (j *JWTConfig) RefreshExpire() time.Duration {
+	return time.Duration(positiveOr(j.RefreshExpireHours, 168)) * time.Hour
+}
+
+// AdminRefreshExpire 返回管理员 Refresh Token 有效期
+func (j *JWTConfig) AdminRefreshExpire() time.Duration {
+	return time.Duration(positiveOr(j.AdminRefreshExpireHours, 24)) * time.Hour
+}
+
+// PasswordResetTTL 返回重置密码链接有效期
+func (a *AuthConfig) PasswordResetTTL() time.Duration {
+	if a.PasswordResetTTLMinutes > 0 {
+		return time.Duration(a.PasswordResetTTLMinutes) * time.Minute
+	}
+	return 30 * time.Minute
+}
+
+// PasswordResetLimit 返回同一邮箱每小时最多申请的重置密码链接数
+func (a *AuthConfig) PasswordResetLimit() int64 {
+	return int64(positiveOr(a.PasswordResetMaxPerHour, 5))
+}
+
+// EmailVerificationTTL 返回邮箱验证链接有效期
+func (a *AuthConfig) EmailVerificationTTL() time.Duration {
+	if a.EmailVerificationTTLHours > 0 {
+		return time.Duration(a.EmailVerificationTTLHours) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// EmailVerificationResendInterval 返回两次发送验证邮件的最小间隔
+func (a *AuthConfig) EmailVerificationResendInterval() time.Duration {
+	if a.EmailVerificationResendSeconds > 0 {
+		return time.Duration(a.EmailVerificationResendSeconds) * time.Second
+	}
+	return time.Minute
+}
+
+// MagicLinkTTL 返回免密登录链接有效期
+func (a *AuthConfig) MagicLinkTTL() time.Duration {
+	return time.Duration(positiveOr(a.MagicLinkTTLMinutes, 10)) * time.Minute
+}
+
+// MagicLinkLimit 返回同一邮箱每小时最多申请的免密登录链接数
+func (a *AuthConfig) MagicLinkLimit() int64 {
+	return int64(positiveOr(a.MagicLinkMaxPerHour, 5))
+}
+
+// APIKeyMaxTTL 返回 API Key 最长有效期
+func (a *AuthConfig) APIKeyMaxTTL() time.Duration {
+	return time.Duration(positiveOr(a.APIKeyMaxTTLDays, 365)) * 24 * time.Hour
+}
+
+// ImpersonationTTL 返回模拟用户 Token 的有效期
+func (a *AuthConfig) ImpersonationTTL() time.Duration {
+	return time.Duration(positiveOr(a.ImpersonationTTLMinutes, 15)) * time.Minute
+}
+
+// ChallengeTTL 返回 WebAuthn 挑战的有效期
+func (w *WebAuthnConfig) ChallengeTTL() time.Duration {
+	return time.Duration(positiveOr(w.ChallengeTTLSeconds, 300)) * time.Second
+}
+
+// RequestScopes 返回申请的 scope，始终包含 openid
+func (o *OIDCConfig) RequestScopes() []string {
+	if len(o.Scopes) == 0 {
+		return []string{"openid", "profile", "email"}
+	}
+	for _, scope := range o.Scopes {
+		if scope == "openid" {
+			return o.Scopes
+		}
+	}
+	return append([]string{"openid"}, o.Scopes...)
+}
+
+// UsernameClaimName 返回作为用户名的 claim
+func (o *OIDCConfig) UsernameClaimName() string {
+	if o.UsernameClaim == "" {
+		return "preferred_username"
+	}
+	return o.UsernameClaim
+}
+
+// GroupsClaimName 返回用户所属组的 claim
+func (o *OIDCConfig) GroupsClaimName() string {
+	if o.GroupsClaim == "" {
+		return "groups"
+	}
+	return o.GroupsClaim
+}
+
+// StateTTL 返回授权请求的有效期
+func (o *OIDCConfig) StateTTL() time.Duration {
+	return time.Duration(positiveOr(o.StateTTLSeconds, 600)) * time.Second
+}
+
+// AuthenticatorOrder 返回凭证校验器的尝试顺序
+func (a *AuthConfig) AuthenticatorOrder() []string {
+	if len(a.Authenticators) == 0 {
+		return []string{"local"}
+	}
+	return a.Authenticators
+}
+
+// UserFilterTemplate 返回查找用户的过滤器
+func (l *LDAPConfig) UserFilterTemplate() string {
+	if l.UserFilter == "" {
+		return "(&(objectClass=person)(uid=%s))"
+	}
+	return l.UserFilter
+}
+
+// UsernameAttributeName 返回作为用户名的属性
+func (l *LDAPConfig) UsernameAttributeName() string {
+	if l.UsernameAttribute == "" {
+		return "uid"
+	}
+	return l.UsernameAttribute
+}
+
+// EmailAttributeName 返回邮箱属性
+func (l *LDAPConfig) EmailAttributeName() string {
+	if l.EmailAttribute == "" {
+		return "mail"
+	}
+	return l.EmailAttribute
+}
+
+// GroupAttributeName 返回所属组属性
+func (l *LDAPConfig) GroupAttributeName() string {
+	if l.GroupAttribute == "" {
+		return "memberOf"
+	}
+	return l.GroupAttribute
+}
+
+// Timeout 返回连接和请求超时
+func (l *LDAPConfig) Timeout() time.Duration {
+	return time.Duration(positiveOr(l.TimeoutSeconds, 5)) * time.Second
+}
+
+// CookiePath 返回 Cookie 路径
+func (s *SessionCookieConfig) CookiePath() string {
+	if s.Path != "" {
+		return s.Path
+	}
+	return "/"
+}
+
+// RefreshCookiePath 返回 Refresh Token Cookie 路径
+func (s *SessionCookieConfig) RefreshCookiePath() string {
+	if s.RefreshPath != "" {
+		return s.RefreshPath
+	}
+	return s.CookiePath()
+}
+
+// CSRFHeader 返回携带 CSRF Token 的请求头名称
+func (s *SessionCookieConfig) CSRFHeader() string {
+	if s.CSRFHeaderName != "" {
+		return s.CSRFHeaderName
+	}
+	return "X-CSRF-Token"
+}
+
+// UsernameLimit 返回同一用户名的失败次数上限
+func (l *LoginProtectionConfig) UsernameLimit() int64 {
+	return int64(positiveOr(l.MaxFailures, 5))
+}
+
+// IPLimit 返回同一 IP 的失败次数上限
+func (l *LoginProtectionConfig) IPLimit() int64 {
+	return int64(positiveOr(l.IPMaxFailures, 20))
+}
+
+// FailureWindow 返回失败次数统计窗口
+func (l *LoginProtectionConfig) FailureWindow() time.Duration {
+	return time.Duration(positiveOr(l.FailureWindowMinutes, 15)) * time.Minute
+}
+
+// Lockout 返回锁定时长
+func (l *LoginProtectionConfig) Lockout() time.Duration {
+	return time.Duration(positiveOr(l.LockoutMinutes, 15)) * time.Minute
+}
+
+// FreeAttempts 返回开始递增等待前允许的失败次数
+func (l *LoginProtectionConfig) FreeAttempts() int64 {
+	return int64(positiveOr(l.DelayAfter, 3))
+}
+
+// BaseDelay 返回第一次等待时长
+func (l *LoginProtectionConfig) BaseDelay() time.Duration {
+	return time.Duration(positiveOr(l.BaseDelaySeconds, 1)) * time.Second
+}
+
+// MaxDelay 返回等待时长上限
+func (l *LoginProtectionConfig) MaxDelay() time.Duration {
+	return time.Duration(positiveOr(l.MaxDelaySeconds, 60)) * time.Second
+}
+
+func positiveOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// GetAddress 返回 SMTP 服务器地址
+func (s *SMTPConfig) GetAddress() string {
+	return fmt.Sprintf("%s:%d", s.Host, s.Port)
+}
+
 // GetAddress 返回 Redis 地址
 func (r *RedisConfig) GetAddress() string {
 	return fmt.Sprintf("%s:%d", r.Host, r.Port)
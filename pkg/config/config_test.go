@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTConfig_RefreshExpire(t *testing.T) {
+	// 未配置时使用默认值，避免 TTL 为 0 导致所有登录失败
+	var empty JWTConfig
+	assert.Equal(t, 168*time.Hour, empty.RefreshExpire())
+	assert.Equal(t, 24*time.Hour, empty.AdminRefreshExpire())
+
+	configured := JWTConfig{RefreshExpireHours: 72, AdminRefreshExpireHours: 8}
+	assert.Equal(t, 72*time.Hour, configured.RefreshExpire())
+	assert.Equal(t, 8*time.Hour, configured.AdminRefreshExpire())
+}
+
+func TestLoad_RefreshExpireDefault(t *testing.T) {
+	t.Setenv("GO_ENV", "missing")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("jwt:\n  secret: test\n  access_expire_minutes: 15\n"), 0o600))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 168*time.Hour, cfg.JWT.RefreshExpire())
+	assert.Equal(t, 24*time.Hour, cfg.JWT.AdminRefreshExpire())
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 	"trx-project/pkg/config"
+	"trx-project/pkg/tenant"
 
 	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
@@ -36,6 +37,11 @@ func InitMySQL(cfg *config.MySQLConfig, zapLogger *zap.Logger) (*gorm.DB, error)
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// 多租户数据隔离，按 context 中的租户自动过滤带 TenantID 字段的模型
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register tenant plugin: %w", err)
+	}
+
 	// 获取底层 SQL 数据库
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var (
@@ -14,48 +15,106 @@ var (
 	ErrTokenNotValidYet = errors.New("token is not valid yet")
 )
 
+// Token 角色
+const (
+	RoleUser       = "user"       // 前台用户
+	RoleAdmin      = "admin"      // 后台管理员
+	RoleSuperAdmin = "superadmin" // 超级管理员
+)
+
 // Claims JWT 声明
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
-	Role     string `json:"role"` // user, admin, superadmin
+	Role     string `json:"role"`                // user, admin, superadmin
+	TenantID uint   `json:"tenant_id,omitempty"` // 用户所属租户，为 0 表示迁移前签发的 Token，属于平台租户
+
+	TokenVersion uint   `json:"ver"`           // 用户 Token 版本，与用户当前版本不一致时 Token 失效
+	SessionID    string `json:"sid,omitempty"` // 登录会话 ID，会话被吊销后 Token 立即失效
+
+	// Actor 实际操作人（RFC 8693 act），不为空表示管理员正在模拟该用户
+	Actor *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Actor 模拟登录时的实际操作人
+type Actor struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// Impersonated 是否为管理员模拟登录签发的 Token
+func (c *Claims) Impersonated() bool {
+	return c.Actor != nil
+}
+
 // Config JWT 配置
 type Config struct {
-	Secret     string        // 密钥
-	Issuer     string        // 签发者
-	ExpireTime time.Duration // 过期时间
+	Secret            string        // 密钥
+	Issuer            string        // 签发者
+	ExpireTime        time.Duration // Access Token 过期时间
+	RefreshExpireTime time.Duration // Refresh Token 过期时间
+	Keys              *KeySet       // 签名密钥集合，为空时使用 Secret（HS256）
+}
+
+// KeySet 返回签发和验证使用的密钥集合
+func (c Config) KeySet() *KeySet {
+	if c.Keys != nil {
+		return c.Keys
+	}
+	return newHMACKeySet(c.Secret)
 }
 
 // GenerateToken 生成 JWT Token
 func GenerateToken(userID uint, username, role string, config Config) (string, error) {
-	now := time.Now()
-	claims := Claims{
+	return IssueToken(&Claims{
 		UserID:   userID,
 		Username: username,
 		Role:     role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    config.Issuer,
-			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(config.ExpireTime)),
-			NotBefore: jwt.NewNumericDate(now),
-		},
+	}, config)
+}
+
+// IssueToken 根据自定义 Claims 签发 JWT Token，签发时间、过期时间和 jti 由本函数填充
+func IssueToken(claims *Claims, config Config) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(), // jti，用于吊销单个 Token
+		Issuer:    config.Issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(config.ExpireTime)),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+
+	key, err := config.KeySet().SigningKey()
+	if err != nil {
+		return "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.Secret))
+	token := jwt.NewWithClaims(key.method(), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.signKey)
 }
 
-// ParseToken 解析 JWT Token
+// ParseToken 使用 HS256 共享密钥解析 JWT Token
 func ParseToken(tokenString string, secret string) (*Claims, error) {
+	return ParseTokenWithKeys(tokenString, newHMACKeySet(secret))
+}
+
+// ParseTokenWithKeys 使用密钥集合解析 JWT Token，根据头部的 kid 选择验证密钥
+func ParseTokenWithKeys(tokenString string, keys *KeySet) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// 验证签名方法
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := keys.lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		// 验证签名方法，防止算法混淆攻击
+		if token.Method.Alg() != key.Algorithm {
 			return nil, ErrTokenInvalid
 		}
-		return []byte(secret), nil
+		return key.verifyKey, nil
 	})
 
 	if err != nil {
@@ -78,18 +137,6 @@ func ParseToken(tokenString string, secret string) (*Claims, error) {
 	return nil, ErrTokenInvalid
 }
 
-// RefreshToken 刷新 Token
-func RefreshToken(tokenString string, secret string, config Config) (string, error) {
-	claims, err := ParseToken(tokenString, secret)
-	if err != nil && !errors.Is(err, ErrTokenExpired) {
-		return "", err
-	}
-
-	// 即使 token 过期，如果在允许刷新的时间范围内，也可以刷新
-	// 这里简化处理，直接生成新 token
-	return GenerateToken(claims.UserID, claims.Username, claims.Role, config)
-}
-
 // ValidateToken 验证 Token 是否有效
 func ValidateToken(tokenString string, secret string) error {
 	_, err := ParseToken(tokenString, secret)
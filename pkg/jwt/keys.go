@@ -0,0 +1,255 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no active signing key")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// Key JWT 签名密钥
+type Key struct {
+	ID         string    // 密钥 ID（kid），HS256 兼容密钥为空
+	Algorithm  string    // 签名算法
+	ActiveFrom time.Time // 开始用于签名的时间
+
+	signKey   interface{} // 签名用私钥，为 nil 时只能用于验证
+	verifyKey interface{} // 验证用公钥
+}
+
+// NewHMACKey 使用共享密钥创建 HS256 密钥
+func NewHMACKey(secret string) *Key {
+	return &Key{
+		Algorithm: AlgHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// ParseKey 从 PEM 数据创建非对称密钥
+// privatePEM 为空时只能用于验证；publicPEM 为空时从私钥推导
+func ParseKey(id, algorithm string, privatePEM, publicPEM []byte, activeFrom time.Time) (*Key, error) {
+	if id == "" {
+		return nil, errors.New("key id is required")
+	}
+	if len(privatePEM) == 0 && len(publicPEM) == 0 {
+		return nil, fmt.Errorf("key %s: private or public key is required", id)
+	}
+
+	key := &Key{
+		ID:         id,
+		Algorithm:  algorithm,
+		ActiveFrom: activeFrom,
+	}
+
+	var err error
+	switch algorithm {
+	case AlgRS256:
+		if len(privatePEM) > 0 {
+			var priv *rsa.PrivateKey
+			if priv, err = jwt.ParseRSAPrivateKeyFromPEM(privatePEM); err == nil {
+				key.signKey, key.verifyKey = priv, &priv.PublicKey
+			}
+		}
+		if err == nil && len(publicPEM) > 0 {
+			key.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+		}
+	case AlgES256:
+		if len(privatePEM) > 0 {
+			var priv *ecdsa.PrivateKey
+			if priv, err = jwt.ParseECPrivateKeyFromPEM(privatePEM); err == nil {
+				key.signKey, key.verifyKey = priv, &priv.PublicKey
+			}
+		}
+		if err == nil && len(publicPEM) > 0 {
+			key.verifyKey, err = jwt.ParseECPublicKeyFromPEM(publicPEM)
+		}
+		if err == nil && key.verifyKey.(*ecdsa.PublicKey).Curve != elliptic.P256() {
+			err = errors.New("ES256 requires a P-256 key")
+		}
+	case AlgEdDSA:
+		if len(privatePEM) > 0 {
+			var priv crypto.PrivateKey
+			if priv, err = jwt.ParseEdPrivateKeyFromPEM(privatePEM); err == nil {
+				edPriv := priv.(ed25519.PrivateKey)
+				key.signKey, key.verifyKey = edPriv, edPriv.Public()
+			}
+		}
+		if err == nil && len(publicPEM) > 0 {
+			key.verifyKey, err = jwt.ParseEdPublicKeyFromPEM(publicPEM)
+		}
+	default:
+		return nil, fmt.Errorf("key %s: unsupported algorithm %q", id, algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %s: failed to parse %s key: %w", id, algorithm, err)
+	}
+
+	return key, nil
+}
+
+// KeyFile 保存在 PEM 文件中的非对称密钥
+type KeyFile struct {
+	ID             string    // 密钥 ID（kid）
+	Algorithm      string    // 签名算法：RS256、ES256、EdDSA
+	PrivateKeyFile string    // 私钥 PEM 文件，为空时该密钥只用于验证
+	PublicKeyFile  string    // 公钥 PEM 文件，为空时从私钥推导
+	ActiveFrom     time.Time // 开始用于签名的时间，零值表示立即生效
+}
+
+// LoadKey 从 PEM 文件加载非对称密钥
+func LoadKey(file KeyFile) (*Key, error) {
+	var privatePEM, publicPEM []byte
+	var err error
+
+	if file.PrivateKeyFile != "" {
+		if privatePEM, err = os.ReadFile(file.PrivateKeyFile); err != nil {
+			return nil, fmt.Errorf("failed to read private key file: %w", err)
+		}
+	}
+	if file.PublicKeyFile != "" {
+		if publicPEM, err = os.ReadFile(file.PublicKeyFile); err != nil {
+			return nil, fmt.Errorf("failed to read public key file: %w", err)
+		}
+	}
+
+	return ParseKey(file.ID, file.Algorithm, privatePEM, publicPEM, file.ActiveFrom)
+}
+
+// CanSign 是否持有私钥
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// method 返回密钥对应的签名方法
+func (k *Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// KeySet JWT 密钥集合，支持多个密钥同时有效和按时间轮换
+//
+// 轮换规则：
+//   - 已到 ActiveFrom 的最新密钥用于签名
+//   - 旧密钥在被新密钥取代后的 overlap 时长内仍可用于验证，之后不再接受
+//   - 尚未启用的密钥提前发布到 JWKS，方便其他服务预先缓存
+type KeySet struct {
+	keys    []*Key // 按 ActiveFrom 升序排列
+	overlap time.Duration
+	now     func() time.Time
+}
+
+// NewKeySet 创建密钥集合
+func NewKeySet(keys []*Key, overlap time.Duration) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		seen[key.ID] = true
+	}
+
+	sorted := make([]*Key, len(keys))
+	copy(sorted, keys)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActiveFrom.Before(sorted[j].ActiveFrom)
+	})
+
+	return &KeySet{
+		keys:    sorted,
+		overlap: overlap,
+		now:     time.Now,
+	}, nil
+}
+
+// LoadKeySet 从 PEM 文件加载密钥集合，overlap 为旧密钥被取代后继续用于验证的时长
+// 没有密钥文件时使用 secret 的 HS256 密钥
+func LoadKeySet(secret string, files []KeyFile, overlap time.Duration) (*KeySet, error) {
+	if len(files) == 0 {
+		return newHMACKeySet(secret), nil
+	}
+
+	keys := make([]*Key, 0, len(files))
+	for _, file := range files {
+		key, err := LoadKey(file)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeySet(keys, overlap)
+}
+
+// newHMACKeySet 创建只包含一个 HS256 密钥的集合
+func newHMACKeySet(secret string) *KeySet {
+	return &KeySet{
+		keys: []*Key{NewHMACKey(secret)},
+		now:  time.Now,
+	}
+}
+
+// SigningKey 返回当前用于签名的密钥
+func (s *KeySet) SigningKey() (*Key, error) {
+	now := s.now()
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if !s.keys[i].ActiveFrom.After(now) {
+			if !s.keys[i].CanSign() {
+				return nil, ErrNoSigningKey
+			}
+			return s.keys[i], nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+// VerificationKeys 返回当前可用于验证的密钥（包括尚未启用和仍在重叠窗口内的密钥）
+func (s *KeySet) VerificationKeys() []*Key {
+	now := s.now()
+	keys := make([]*Key, 0, len(s.keys))
+	for i, key := range s.keys {
+		// 找到取代该密钥的下一个已启用密钥
+		if i+1 < len(s.keys) {
+			supersededAt := s.keys[i+1].ActiveFrom
+			if !supersededAt.After(now) && now.Sub(supersededAt) > s.overlap {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// lookup 根据 kid 查找验证密钥
+func (s *KeySet) lookup(kid string) (*Key, error) {
+	for _, key := range s.VerificationKeys() {
+		if key.ID == kid {
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
@@ -0,0 +1,130 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generatePrivatePEM(t *testing.T, algorithm string) []byte {
+	t.Helper()
+
+	var priv interface{}
+	var err error
+	switch algorithm {
+	case AlgRS256:
+		priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgES256:
+		priv, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	}
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func newTestKey(t *testing.T, id, algorithm string, activeFrom time.Time) *Key {
+	t.Helper()
+	key, err := ParseKey(id, algorithm, generatePrivatePEM(t, algorithm), nil, activeFrom)
+	require.NoError(t, err)
+	return key
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgES256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			keys, err := NewKeySet([]*Key{newTestKey(t, "k1", alg, time.Time{})}, time.Hour)
+			require.NoError(t, err)
+			cfg := Config{Issuer: "test", ExpireTime: time.Minute, Keys: keys}
+
+			token, err := GenerateToken(1, "alice", RoleUser, cfg)
+			require.NoError(t, err)
+
+			claims, err := ParseTokenWithKeys(token, keys)
+			require.NoError(t, err)
+			assert.Equal(t, uint(1), claims.UserID)
+
+			jwks := keys.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, "k1", jwks.Keys[0].Kid)
+			assert.Equal(t, alg, jwks.Keys[0].Alg)
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	start := time.Now()
+	oldKey := newTestKey(t, "old", AlgRS256, start.Add(-24*time.Hour))
+	newKey := newTestKey(t, "new", AlgES256, start.Add(time.Hour))
+
+	keys, err := NewKeySet([]*Key{newKey, oldKey}, 2*time.Hour)
+	require.NoError(t, err)
+	cfg := Config{ExpireTime: 24 * time.Hour, Keys: keys}
+
+	// 新密钥启用前：旧密钥签名，新密钥已提前发布
+	keys.now = func() time.Time { return start }
+	oldToken, err := GenerateToken(1, "alice", RoleUser, cfg)
+	require.NoError(t, err)
+	assert.Len(t, keys.JWKS().Keys, 2)
+
+	// 新密钥启用后：新密钥签名，旧 Token 在重叠窗口内仍然有效
+	keys.now = func() time.Time { return start.Add(2 * time.Hour) }
+	signing, err := keys.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "new", signing.ID)
+	_, err = ParseTokenWithKeys(oldToken, keys)
+	assert.NoError(t, err)
+
+	// 重叠窗口结束后：旧密钥下线
+	keys.now = func() time.Time { return start.Add(4 * time.Hour) }
+	_, err = ParseTokenWithKeys(oldToken, keys)
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+	require.Len(t, keys.JWKS().Keys, 1)
+	assert.Equal(t, "new", keys.JWKS().Keys[0].Kid)
+}
+
+func TestKeySet_RejectsHMACToken(t *testing.T) {
+	keys, err := NewKeySet([]*Key{newTestKey(t, "k1", AlgRS256, time.Time{})}, time.Hour)
+	require.NoError(t, err)
+
+	token, err := GenerateToken(1, "alice", RoleUser, Config{Secret: "secret", ExpireTime: time.Minute})
+	require.NoError(t, err)
+
+	_, err = ParseTokenWithKeys(token, keys)
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	// 没有密钥文件时使用 secret 的 HS256 密钥
+	hmac, err := LoadKeySet("secret", nil, time.Hour)
+	require.NoError(t, err)
+	key, err := hmac.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, AlgHS256, key.Algorithm)
+
+	path := filepath.Join(t.TempDir(), "k1.pem")
+	require.NoError(t, os.WriteFile(path, generatePrivatePEM(t, AlgES256), 0o600))
+
+	keys, err := LoadKeySet("secret", []KeyFile{{ID: "k1", Algorithm: AlgES256, PrivateKeyFile: path}}, time.Hour)
+	require.NoError(t, err)
+	key, err = keys.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "k1", key.ID)
+
+	_, err = LoadKeySet("secret", []KeyFile{{ID: "k2", Algorithm: AlgES256, PrivateKeyFile: path + ".missing"}}, time.Hour)
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// FileNotifier 把每条消息写入目录中的一个文件，仅用于开发环境
+type FileNotifier struct {
+	dir string
+}
+
+// NewFileNotifier 创建文件通知发送器，目录不存在时自动创建
+func NewFileNotifier(dir string) (*FileNotifier, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("file notifier requires dir")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create notifier dir: %w", err)
+	}
+	return &FileNotifier{dir: dir}, nil
+}
+
+func (n *FileNotifier) Send(ctx context.Context, msg *Message) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "To: %s\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\n\n", msg.Subject)
+	b.WriteString(msg.Body)
+	b.WriteString("\n")
+
+	f, err := os.CreateTemp(n.dir, time.Now().Format("20060102-150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(b.String())
+	return err
+}
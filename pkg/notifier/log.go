@@ -0,0 +1,25 @@
+package notifier
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// LogNotifier 把消息写入日志，仅用于开发环境（消息中可能包含重置密码链接等敏感信息）
+type LogNotifier struct {
+	logger *zap.Logger
+}
+
+// NewLogNotifier 创建日志通知发送器
+func NewLogNotifier(logger *zap.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Send(ctx context.Context, msg *Message) error {
+	n.logger.Info("Notification",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body))
+	return nil
+}
@@ -0,0 +1,45 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryNotifier 把消息保存在内存中，用于测试
+type MemoryNotifier struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// NewMemoryNotifier 创建内存通知发送器
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+func (n *MemoryNotifier) Send(ctx context.Context, msg *Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	copied := *msg
+	n.messages = append(n.messages, &copied)
+	return nil
+}
+
+// Messages 返回已发送的全部消息
+func (n *MemoryNotifier) Messages() []*Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]*Message(nil), n.messages...)
+}
+
+// Last 返回最后一条消息，没有消息时返回 nil
+func (n *MemoryNotifier) Last() *Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.messages) == 0 {
+		return nil
+	}
+	return n.messages[len(n.messages)-1]
+}
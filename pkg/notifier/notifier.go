@@ -0,0 +1,38 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"trx-project/pkg/config"
+
+	"go.uber.org/zap"
+)
+
+// Message 通知消息
+type Message struct {
+	To      string // 接收人邮箱
+	Subject string
+	Body    string
+	Data    map[string]string // 消息中的变量，如 token、link，便于模板渲染和测试
+}
+
+// Notifier 通知发送接口，用于发送重置密码链接、邮箱验证链接等消息
+type Notifier interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewNotifier 根据配置创建通知发送器
+func NewNotifier(cfg *config.Config, logger *zap.Logger) (Notifier, error) {
+	switch cfg.Notifier.Driver {
+	case "smtp":
+		return NewSMTPNotifier(&cfg.Notifier.SMTP)
+	case "file":
+		return NewFileNotifier(cfg.Notifier.Dir)
+	case "", "log":
+		return NewLogNotifier(logger), nil
+	case "memory":
+		return NewMemoryNotifier(), nil
+	default:
+		return nil, fmt.Errorf("unsupported notifier driver: %s", cfg.Notifier.Driver)
+	}
+}
@@ -0,0 +1,203 @@
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 支持的哈希算法
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var (
+	// ErrMismatch 密码与哈希不匹配
+	ErrMismatch = errors.New("password does not match")
+	// ErrUnsupportedHash 无法识别的哈希格式（包括没有本地密码的账号）
+	ErrUnsupportedHash = errors.New("unsupported password hash")
+)
+
+// argon2Params argon2id 参数
+type argon2Params struct {
+	memory      uint32 // KiB
+	iterations  uint32
+	parallelism uint8
+	keyLength   uint32
+}
+
+// Params 密码哈希参数，为零的字段使用默认值
+type Params struct {
+	Algorithm         string // argon2id（默认）或 bcrypt
+	BcryptCost        int    // bcrypt 成本，默认 10
+	Argon2MemoryKiB   uint32 // argon2id 内存（KiB），默认 65536
+	Argon2Iterations  uint32 // argon2id 迭代次数，默认 3
+	Argon2Parallelism uint8  // argon2id 并行度，默认 2
+}
+
+// Hasher 密码哈希器
+// 生成自描述的哈希：argon2id 使用 PHC 格式 $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>，bcrypt 使用 $2a$<cost>$...，
+// 校验时按哈希中记录的算法和参数进行，因此修改配置后旧哈希仍然有效
+type Hasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     argon2Params
+}
+
+// NewHasher 创建密码哈希器
+func NewHasher(c Params) (*Hasher, error) {
+	h := &Hasher{
+		algorithm:  c.Algorithm,
+		bcryptCost: c.BcryptCost,
+		argon2: argon2Params{
+			memory:      c.Argon2MemoryKiB,
+			iterations:  c.Argon2Iterations,
+			parallelism: c.Argon2Parallelism,
+			keyLength:   argon2KeyLength,
+		},
+	}
+	if h.algorithm == "" {
+		h.algorithm = AlgorithmArgon2id
+	}
+	if h.bcryptCost == 0 {
+		h.bcryptCost = bcrypt.DefaultCost
+	}
+	if h.argon2.memory == 0 {
+		h.argon2.memory = 64 * 1024
+	}
+	if h.argon2.iterations == 0 {
+		h.argon2.iterations = 3
+	}
+	if h.argon2.parallelism == 0 {
+		h.argon2.parallelism = 2
+	}
+
+	switch h.algorithm {
+	case AlgorithmArgon2id:
+		if h.argon2.memory < 8*uint32(h.argon2.parallelism) {
+			return nil, fmt.Errorf("argon2 memory must be at least %d KiB", 8*uint32(h.argon2.parallelism))
+		}
+	case AlgorithmBcrypt:
+		if h.bcryptCost < bcrypt.MinCost || h.bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm: %s", h.algorithm)
+	}
+	return h, nil
+}
+
+// Hash 使用当前配置的算法和参数哈希密码
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.algorithm == AlgorithmBcrypt {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashed), nil
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.argon2.iterations, h.argon2.memory, h.argon2.parallelism, h.argon2.keyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.argon2.memory, h.argon2.iterations, h.argon2.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify 校验密码，不匹配时返回 ErrMismatch，哈希格式无法识别时返回 ErrUnsupportedHash
+func (h *Hasher) Verify(encoded, password string) error {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2(encoded)
+		if err != nil {
+			return err
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, params.keyLength)
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return ErrMismatch
+		}
+		return nil
+	case isBcrypt(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUnsupportedHash, err)
+		}
+		return nil
+	default:
+		return ErrUnsupportedHash
+	}
+}
+
+// NeedsRehash 哈希的算法或参数与当前配置不一致时返回 true，应在校验通过后用明文密码重新哈希；无法识别的哈希返回 false
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		if h.algorithm != AlgorithmArgon2id {
+			return true
+		}
+		params, _, _, err := decodeArgon2(encoded)
+		return err == nil && params != h.argon2
+	case isBcrypt(encoded):
+		if h.algorithm != AlgorithmBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err == nil && cost != h.bcryptCost
+	default:
+		return false
+	}
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// decodeArgon2 解析 $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>
+func decodeArgon2(encoded string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	if params.memory == 0 || params.iterations == 0 || params.parallelism == 0 {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	params.keyLength = uint32(len(key))
+	return params, salt, key, nil
+}
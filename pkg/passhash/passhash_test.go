@@ -0,0 +1,69 @@
+package passhash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestHasher(t *testing.T, params Params) *Hasher {
+	hasher, err := NewHasher(params)
+	require.NoError(t, err)
+	return hasher
+}
+
+func TestHasher_Argon2id(t *testing.T) {
+	hasher := newTestHasher(t, Params{Argon2MemoryKiB: 1024, Argon2Iterations: 1, Argon2Parallelism: 1})
+
+	hashed, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hashed, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	assert.NoError(t, hasher.Verify(hashed, "correct horse"))
+	assert.ErrorIs(t, hasher.Verify(hashed, "wrong horse"), ErrMismatch)
+	assert.False(t, hasher.NeedsRehash(hashed))
+
+	// 相同密码每次使用不同的盐
+	again, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, hashed, again)
+
+	// 提高参数后旧哈希仍然有效，但需要重新哈希
+	stronger := newTestHasher(t, Params{Argon2MemoryKiB: 2048, Argon2Iterations: 2, Argon2Parallelism: 1})
+	assert.NoError(t, stronger.Verify(hashed, "correct horse"))
+	assert.True(t, stronger.NeedsRehash(hashed))
+}
+
+func TestHasher_Bcrypt(t *testing.T) {
+	hasher := newTestHasher(t, Params{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost})
+
+	hashed, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.NoError(t, hasher.Verify(hashed, "correct horse"))
+	assert.ErrorIs(t, hasher.Verify(hashed, "wrong horse"), ErrMismatch)
+	assert.False(t, hasher.NeedsRehash(hashed))
+
+	// 成本变化或切换到 argon2id 后需要重新哈希
+	assert.True(t, newTestHasher(t, Params{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1}).NeedsRehash(hashed))
+	argon := newTestHasher(t, Params{Argon2MemoryKiB: 1024, Argon2Iterations: 1, Argon2Parallelism: 1})
+	assert.NoError(t, argon.Verify(hashed, "correct horse"))
+	assert.True(t, argon.NeedsRehash(hashed))
+}
+
+func TestHasher_RejectsInvalidInput(t *testing.T) {
+	hasher := newTestHasher(t, Params{Argon2MemoryKiB: 1024, Argon2Iterations: 1, Argon2Parallelism: 1})
+
+	// 没有本地密码的账号（单点登录、服务账号）和被篡改的哈希都无法通过校验
+	for _, encoded := range []string{"", "plaintext", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5"} {
+		assert.ErrorIs(t, hasher.Verify(encoded, ""), ErrUnsupportedHash, encoded)
+		assert.False(t, hasher.NeedsRehash(encoded), encoded)
+	}
+
+	_, err := NewHasher(Params{Algorithm: "md5"})
+	assert.Error(t, err)
+	_, err = NewHasher(Params{Algorithm: AlgorithmBcrypt, BcryptCost: 40})
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+// Package permission 权限编码匹配，支持通配符授权和权限蕴含
+//
+// 权限编码格式为 资源:操作，授权的编码可以使用 glob 通配符：user:* 匹配 user 的所有操作，
+// *:read 匹配所有资源的 read 操作，* 匹配所有权限。
+// 蕴含表描述一个权限隐含的其他权限，例如 user:write 蕴含 user:read，蕴含可以传递。
+package permission
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Matcher 按通配符和蕴含规则判断授权是否包含某个权限，创建后只读，可以并发使用
+type Matcher struct {
+	// implied 蕴含表的传递闭包：权限编码 -> 直接或间接蕴含的权限（可以是通配符）
+	implied map[string][]string
+}
+
+// NewMatcher 根据蕴含表（权限编码 -> 蕴含的权限）创建权限匹配器
+// 蕴含表的键必须是具体的权限编码，值可以使用通配符，编码不合法时返回错误
+func NewMatcher(rules map[string][]string) (*Matcher, error) {
+	for code, implied := range rules {
+		if IsPattern(code) {
+			return nil, fmt.Errorf("implied permission key %q must not contain wildcards", code)
+		}
+		if err := Validate(code); err != nil {
+			return nil, err
+		}
+		for _, pattern := range implied {
+			if err := Validate(pattern); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	m := &Matcher{implied: make(map[string][]string, len(rules))}
+	for code := range rules {
+		m.implied[code] = closure(rules, code)
+	}
+	return m, nil
+}
+
+// closure 计算 code 直接或间接蕴含的所有权限，蕴含的通配符覆盖的键也会继续展开，出现环时同样能结束
+func closure(rules map[string][]string, code string) []string {
+	seen := map[string]bool{}
+	expanded := map[string]bool{code: true}
+	queue := []string{code}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, pattern := range rules[current] {
+			if seen[pattern] {
+				continue
+			}
+			seen[pattern] = true
+			for key := range rules {
+				if !expanded[key] && Match(pattern, key) {
+					expanded[key] = true
+					queue = append(queue, key)
+				}
+			}
+		}
+	}
+
+	result := make([]string, 0, len(seen))
+	for pattern := range seen {
+		result = append(result, pattern)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Allows 授权列表（可以包含通配符）是否直接、通过通配符或通过蕴含包含 required
+func (m *Matcher) Allows(grants []string, required string) bool {
+	for _, grant := range grants {
+		if Match(grant, required) {
+			return true
+		}
+	}
+
+	// 授权覆盖了蕴含表中的某个权限时，该权限蕴含的权限也被授予
+	for code, implied := range m.implied {
+		if !matchAny(grants, code) {
+			continue
+		}
+		if matchAny(implied, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match 授权编码 pattern 是否覆盖权限编码 code，pattern 不含通配符时要求完全相同
+func Match(pattern, code string) bool {
+	if !IsPattern(pattern) {
+		return pattern == code
+	}
+	ok, err := path.Match(pattern, code)
+	return err == nil && ok
+}
+
+// IsPattern 编码中是否包含通配符
+func IsPattern(code string) bool {
+	return strings.ContainsAny(code, "*?[")
+}
+
+// Validate 检查权限编码或通配符是否合法
+func Validate(code string) error {
+	if code == "" || strings.TrimSpace(code) != code {
+		return fmt.Errorf("invalid permission code %q", code)
+	}
+	if _, err := path.Match(code, ""); err != nil {
+		return fmt.Errorf("invalid permission pattern %q: %w", code, err)
+	}
+	return nil
+}
+
+func matchAny(patterns []string, code string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, code) {
+			return true
+		}
+	}
+	return false
+}
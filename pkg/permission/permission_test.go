@@ -0,0 +1,69 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMatcher(t *testing.T, rules map[string][]string) *Matcher {
+	m, err := NewMatcher(rules)
+	require.NoError(t, err)
+	return m
+}
+
+func TestMatch(t *testing.T) {
+	assert.True(t, Match("user:read", "user:read"))
+	assert.False(t, Match("user:read", "user:write"))
+	assert.True(t, Match("user:*", "user:delete"))
+	assert.False(t, Match("user:*", "rbac:manage"))
+	assert.True(t, Match("*:read", "statistics:read"))
+	assert.False(t, Match("*:read", "user:write"))
+	assert.True(t, Match("*", "rbac:manage"))
+}
+
+func TestMatcher_Allows(t *testing.T) {
+	m := newMatcher(t, map[string][]string{
+		"user:write":  {"user:read"},
+		"user:delete": {"user:write"},
+		"rbac:manage": {"*:read"},
+	})
+
+	// 直接授权和通配符授权
+	assert.True(t, m.Allows([]string{"user:read"}, "user:read"))
+	assert.True(t, m.Allows([]string{"user:*"}, "user:delete"))
+	assert.True(t, m.Allows([]string{"*:read"}, "statistics:read"))
+	assert.False(t, m.Allows([]string{"*:read"}, "user:write"))
+	assert.False(t, m.Allows(nil, "user:read"))
+
+	// 蕴含可以传递
+	assert.True(t, m.Allows([]string{"user:write"}, "user:read"))
+	assert.True(t, m.Allows([]string{"user:delete"}, "user:read"))
+	assert.False(t, m.Allows([]string{"user:read"}, "user:write"))
+
+	// 通配符授权覆盖的权限的蕴含同样生效，蕴含的值可以是通配符
+	assert.True(t, m.Allows([]string{"*:delete"}, "user:read"))
+	assert.True(t, m.Allows([]string{"rbac:manage"}, "statistics:read"))
+	assert.False(t, m.Allows([]string{"rbac:manage"}, "user:write"))
+}
+
+func TestNewMatcher_Cycle(t *testing.T) {
+	m := newMatcher(t, map[string][]string{
+		"a:write": {"a:read"},
+		"a:read":  {"a:write"},
+	})
+	assert.True(t, m.Allows([]string{"a:read"}, "a:write"))
+	assert.True(t, m.Allows([]string{"a:write"}, "a:read"))
+}
+
+func TestNewMatcher_Invalid(t *testing.T) {
+	for _, rules := range []map[string][]string{
+		{"user:*": {"user:read"}},
+		{"user:write": {"user:[read"}},
+		{"user:write": {""}},
+	} {
+		_, err := NewMatcher(rules)
+		assert.Error(t, err, rules)
+	}
+}
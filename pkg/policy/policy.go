@@ -0,0 +1,579 @@
+// Package policy 授权条件表达式，在进程内对 subject、resource、request 属性求值
+//
+// 语法示例：
+//
+//	resource.created_by == subject.id
+//	resource.region in subject.regions || subject.region == "global"
+//	!(resource.account_type == "service") && request.hour >= 9 && request.hour < 18
+//
+// 支持 ||、&&、!、==、!=、<、<=、>、>=、in 和括号；字面量为字符串（单引号或双引号）、数字、
+// true、false、null 和列表 [a, b]；属性路径必须以 subject、resource 或 request 开头。
+// 不存在的属性为 null，但两个不存在的属性互不相等；x in list 判断元素是否在列表中，x in string 判断子串。
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 属性根
+const (
+	RootSubject  = "subject"
+	RootResource = "resource"
+	RootRequest  = "request"
+)
+
+// maxExpressionLength 表达式的最大长度，避免过于复杂的条件
+const maxExpressionLength = 1000
+
+// Attributes 一组属性，值为字符串、数字、布尔、nil、列表或嵌套的 Attributes
+type Attributes map[string]interface{}
+
+// Env 求值环境
+type Env struct {
+	Subject  Attributes
+	Resource Attributes
+	Request  Attributes
+}
+
+// Expression 编译后的条件表达式，可以并发求值
+type Expression struct {
+	source string
+	root   node
+}
+
+// Compile 编译条件表达式，语法错误时返回错误
+func Compile(source string) (*Expression, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("policy: empty expression")
+	}
+	if len(source) > maxExpressionLength {
+		return nil, fmt.Errorf("policy: expression longer than %d characters", maxExpressionLength)
+	}
+
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("policy: unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// String 返回表达式源码
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval 对环境求值，结果不是布尔值或运算的类型不匹配时返回错误，调用方应视为不满足
+func (e *Expression) Eval(env Env) (bool, error) {
+	value, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("policy: expression %q does not evaluate to a boolean", e.source)
+	}
+	return result, nil
+}
+
+// ==================== 词法分析 ====================
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators 按长度从长到短匹配
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ",", "."}
+
+func lex(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		ch := source[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '"' || ch == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(source) && source[j] != ch; j++ {
+				if source[j] == '\\' && j+1 < len(source) {
+					j++
+				}
+				b.WriteByte(source[j])
+			}
+			if j >= len(source) {
+				return nil, fmt.Errorf("policy: unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: b.String(), pos: i})
+			i = j + 1
+		case isDigit(ch) || (ch == '-' && i+1 < len(source) && isDigit(source[i+1])):
+			j := i + 1
+			for j < len(source) && (isDigit(source[j]) || source[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: source[i:j], pos: i})
+			i = j
+		case isIdentStart(ch):
+			j := i + 1
+			for j < len(source) && (isIdentStart(source[j]) || isDigit(source[j])) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[i:j], pos: i})
+			i = j
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("policy: unexpected character %q at position %d", ch, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// ==================== 语法分析 ====================
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept 下一个记号是指定的运算符或关键字时消费它
+func (p *parser) accept(text string) bool {
+	tok := p.peek()
+	if (tok.kind == tokenOperator || tok.kind == tokenIdent) && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		tok := p.peek()
+		return fmt.Errorf("policy: expected %q at position %d", text, tok.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if p.accept(op) {
+			right, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return &compareNode{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString:
+		return &literalNode{value: tok.text}, nil
+	case tokenNumber:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("policy: invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return &literalNode{value: f}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		case RootSubject, RootResource, RootRequest:
+			path := []string{tok.text}
+			for p.accept(".") {
+				field := p.next()
+				if field.kind != tokenIdent {
+					return nil, fmt.Errorf("policy: expected attribute name at position %d", field.pos)
+				}
+				path = append(path, field.text)
+			}
+			if len(path) == 1 {
+				return nil, fmt.Errorf("policy: expected attribute of %s at position %d", tok.text, tok.pos)
+			}
+			return &pathNode{path: path}, nil
+		}
+		return nil, fmt.Errorf("policy: unknown identifier %q at position %d, attributes must start with subject, resource or request", tok.text, tok.pos)
+	case tokenOperator:
+		switch tok.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		case "[":
+			list := &listNode{}
+			if p.accept("]") {
+				return list, nil
+			}
+			for {
+				item, err := p.parsePrimary()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+				if p.accept("]") {
+					return list, nil
+				}
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if tok.kind == tokenEOF {
+		return nil, fmt.Errorf("policy: unexpected end of expression")
+	}
+	return nil, fmt.Errorf("policy: unexpected %q at position %d", tok.text, tok.pos)
+}
+
+// ==================== 求值 ====================
+
+type node interface {
+	eval(env Env) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(Env) (interface{}, error) {
+	return n.value, nil
+}
+
+type pathNode struct {
+	path []string
+}
+
+func (n *pathNode) eval(env Env) (interface{}, error) {
+	var current interface{}
+	switch n.path[0] {
+	case RootSubject:
+		current = env.Subject
+	case RootResource:
+		current = env.Resource
+	case RootRequest:
+		current = env.Request
+	}
+	for _, field := range n.path[1:] {
+		var attrs Attributes
+		switch v := normalize(current).(type) {
+		case Attributes:
+			attrs = v
+		case map[string]interface{}:
+			attrs = v
+		default:
+			return nil, nil
+		}
+		current = attrs[field]
+	}
+	return normalize(current), nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(env Env) (interface{}, error) {
+	values := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		value, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(env Env) (interface{}, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("policy: operand of ! is not a boolean")
+	}
+	return !b, nil
+}
+
+type logicalNode struct {
+	and         bool
+	left, right node
+}
+
+func (n *logicalNode) eval(env Env) (interface{}, error) {
+	left, err := evalBool(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+	// 短路求值
+	if n.and != left {
+		return left, nil
+	}
+	return evalBool(n.right, env)
+}
+
+func evalBool(n node, env Env) (bool, error) {
+	value, err := n.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("policy: operand of && or || is not a boolean")
+	}
+	return b, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(env Env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		// 两侧都是不存在的属性时视为不相等，避免主体和资源都缺少属性（如 region）时意外满足条件
+		eq := equal(left, right) && !(left == nil && n.attributeOnly())
+		return eq == (n.op == "=="), nil
+	case "in":
+		switch container := right.(type) {
+		case []interface{}:
+			for _, item := range container {
+				if equal(left, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case string:
+			s, ok := left.(string)
+			return ok && strings.Contains(container, s), nil
+		case nil:
+			return false, nil
+		}
+		return nil, fmt.Errorf("policy: right operand of in must be a list or string")
+	}
+
+	// 有序比较只支持两个数字或两个字符串，任一侧为 null 时不满足
+	if left == nil || right == nil {
+		return false, nil
+	}
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, fmt.Errorf("policy: cannot compare number with %T", right)
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("policy: cannot compare string with %T", right)
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return nil, fmt.Errorf("policy: cannot compare %T values", left)
+	}
+
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// attributeOnly 两侧是否都是属性路径
+func (n *compareNode) attributeOnly() bool {
+	_, leftPath := n.left.(*pathNode)
+	_, rightPath := n.right.(*pathNode)
+	return leftPath && rightPath
+}
+
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// normalize 把属性值转换为表达式使用的类型：整数转为 float64，切片转为 []interface{}
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool, float64, Attributes, map[string]interface{}:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case *uint:
+		if v == nil {
+			return nil
+		}
+		return float64(*v)
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items
+	case []uint:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = float64(item)
+		}
+		return items
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = normalize(item)
+		}
+		return items
+	case map[string]string:
+		attrs := make(Attributes, len(v))
+		for key, item := range v {
+			attrs[key] = item
+		}
+		return attrs
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,103 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eval(t *testing.T, source string, env Env) bool {
+	expr, err := Compile(source)
+	require.NoError(t, err, source)
+	result, err := expr.Eval(env)
+	require.NoError(t, err, source)
+	return result
+}
+
+func TestCompile_Errors(t *testing.T) {
+	for _, source := range []string{
+		"",
+		"   ",
+		"resource.created_by ==",
+		"user.id == 1",
+		"subject",
+		"subject.id == 'a",
+		"(subject.id == 1",
+		"subject.id == 1 subject.id",
+		"subject.id = 1",
+		"subject.id in [1, 2",
+		"subject.id == 1.2.3",
+		"subject.id == 1 && # 2",
+	} {
+		_, err := Compile(source)
+		assert.Error(t, err, source)
+	}
+}
+
+func TestEval_Ownership(t *testing.T) {
+	env := Env{
+		Subject:  Attributes{"id": uint(7), "roles": []string{"editor"}},
+		Resource: Attributes{"id": uint(42), "created_by": uint(7)},
+	}
+	assert.True(t, eval(t, "resource.created_by == subject.id", env))
+
+	env.Resource["created_by"] = uint(8)
+	assert.False(t, eval(t, "resource.created_by == subject.id", env))
+
+	// 不存在的属性为 null
+	env.Resource["created_by"] = (*uint)(nil)
+	assert.False(t, eval(t, "resource.created_by == subject.id", env))
+	assert.True(t, eval(t, "resource.created_by == null", env))
+	assert.True(t, eval(t, "resource.missing.nested == null", env))
+
+	// 两个不存在的属性不相等
+	assert.False(t, eval(t, "resource.region == subject.region", env))
+	assert.True(t, eval(t, "resource.region != subject.region", env))
+}
+
+func TestEval_Operators(t *testing.T) {
+	env := Env{
+		Subject: Attributes{
+			"regions":    []string{"eu", "us"},
+			"attributes": map[string]string{"region": "eu"},
+			"level":      3,
+		},
+		Resource: Attributes{"attributes": Attributes{"region": "eu"}, "account_type": "human"},
+		Request:  Attributes{"hour": 10, "ip": "10.0.0.5"},
+	}
+
+	assert.True(t, eval(t, "resource.attributes.region == subject.attributes.region", env))
+	assert.True(t, eval(t, "resource.attributes.region in subject.regions", env))
+	assert.False(t, eval(t, "resource.attributes.region in ['apac']", env))
+	assert.True(t, eval(t, `resource.account_type != "service"`, env))
+	assert.True(t, eval(t, "request.hour >= 9 && request.hour < 18", env))
+	assert.False(t, eval(t, "request.hour > 10", env))
+	assert.True(t, eval(t, "subject.level <= 3.0", env))
+	assert.True(t, eval(t, "'10.0.' in request.ip", env))
+	assert.True(t, eval(t, "!(subject.level == 1) || false", env))
+	assert.True(t, eval(t, "false || true && true", env))
+	assert.False(t, eval(t, "!true", env))
+}
+
+func TestEval_ShortCircuit(t *testing.T) {
+	// 左侧已经决定结果时不再对右侧求值，右侧的类型错误不会暴露
+	assert.True(t, eval(t, "true || subject.id", Env{}))
+	assert.False(t, eval(t, "false && subject.id", Env{}))
+}
+
+func TestEval_TypeErrors(t *testing.T) {
+	env := Env{Subject: Attributes{"id": 1, "name": "alice"}}
+	for _, source := range []string{
+		"subject.id",
+		"subject.id && true",
+		"!subject.name",
+		"subject.id < subject.name",
+		"subject.id in subject.id",
+	} {
+		expr, err := Compile(source)
+		require.NoError(t, err, source)
+		_, err = expr.Eval(env)
+		assert.Error(t, err, source)
+	}
+}
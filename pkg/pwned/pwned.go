@@ -0,0 +1,86 @@
+package pwned
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PrefixLength 按 SHA-1 前缀分段的长度，与 Have I Been Pwned 的 range API 相同
+const PrefixLength = 5
+
+// List 本地泄露密码列表
+// 按 SHA-1 前 5 位分段保存，查询时只用前缀取出一段后缀再在本地比较（k-anonymity），
+// 换成远程的 range API 时调用方不需要改动
+type List struct {
+	ranges map[string]map[string]int
+	size   int
+}
+
+// LoadFile 读取泄露密码列表文件
+// 每行为密码的 SHA-1（40 位十六进制，不区分大小写）加可选的 :<出现次数>，空行和 # 开头的行会被忽略
+func LoadFile(path string) (*List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	list := &List{ranges: make(map[string]map[string]int)}
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hash, countText, hasCount := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("invalid sha1 in breached password list at line %d", lineNo)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("invalid sha1 in breached password list at line %d", lineNo)
+		}
+		count := 1
+		if hasCount {
+			if count, err = strconv.Atoi(countText); err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid count in breached password list at line %d", lineNo)
+			}
+		}
+
+		prefix, suffix := hash[:PrefixLength], hash[PrefixLength:]
+		if list.ranges[prefix] == nil {
+			list.ranges[prefix] = make(map[string]int)
+		}
+		if _, ok := list.ranges[prefix][suffix]; !ok {
+			list.size++
+		}
+		list.ranges[prefix][suffix] += count
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	return list, nil
+}
+
+// Len 返回列表中的密码数
+func (l *List) Len() int {
+	return l.size
+}
+
+// Range 返回 SHA-1 前缀对应的后缀及出现次数
+func (l *List) Range(prefix string) map[string]int {
+	return l.ranges[strings.ToUpper(prefix)]
+}
+
+// Count 返回密码在列表中的出现次数，未出现时返回 0
+func (l *List) Count(password string) int {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return l.Range(hash[:PrefixLength])[hash[PrefixLength:]]
+}
@@ -0,0 +1,45 @@
+package pwned
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeList(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	// password 和 123456 的 SHA-1，大小写和有无次数都可以
+	path := writeList(t, "# breached passwords\n"+
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3730471\n"+
+		"\n"+
+		"7c4a8d09ca3762af61e59520943dc26494f8941b\n")
+
+	list, err := LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, list.Len())
+	assert.Equal(t, 3730471, list.Count("password"))
+	assert.Equal(t, 1, list.Count("123456"))
+	assert.Zero(t, list.Count("correct horse battery staple"))
+
+	// 按前缀只返回同一段的后缀
+	assert.Equal(t, map[string]int{"1E4C9B93F3F0682250B6CF8331B7EE68FD8": 3730471}, list.Range("5baa6"))
+	assert.Empty(t, list.Range("00000"))
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	_, err := LoadFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+
+	for _, content := range []string{"password\n", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:many\n", "ZZAA61E4C9B93F3F0682250B6CF8331B7EE68FD8\n"} {
+		_, err := LoadFile(writeList(t, content))
+		assert.Error(t, err, content)
+	}
+}
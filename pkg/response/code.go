@@ -13,13 +13,17 @@ const (
 	CodeValidateError   = 10001 // 参数验证错误
 
 	// 用户相关 (20xxx)
-	CodeUserNotFound       = 20001 // 用户不存在
-	CodeUserAlreadyExists  = 20002 // 用户已存在
-	CodeUserPasswordError  = 20003 // 密码错误
-	CodeUserDisabled       = 20004 // 用户已禁用
-	CodeUserTokenInvalid   = 20005 // Token 无效
-	CodeUserTokenExpired   = 20006 // Token 过期
-	CodeUserPermissionDeny = 20007 // 权限不足
+	CodeUserNotFound         = 20001 // 用户不存在
+	CodeUserAlreadyExists    = 20002 // 用户已存在
+	CodeUserPasswordError    = 20003 // 密码错误
+	CodeUserDisabled         = 20004 // 用户已禁用
+	CodeUserTokenInvalid     = 20005 // Token 无效
+	CodeUserTokenExpired     = 20006 // Token 过期
+	CodeUserPermissionDeny   = 20007 // 权限不足
+	CodeUserMFAInvalid       = 20008 // 两步验证码错误
+	CodeUserMFARequired      = 20009 // 角色要求启用两步验证
+	CodeUserEmailNotVerified = 20010 // 邮箱未验证
+	CodeUserLoginLocked      = 20011 // 登录失败次数过多，暂时锁定
 
 	// 数据库相关 (30xxx)
 	CodeDatabaseError  = 30001 // 数据库错误
@@ -47,13 +51,17 @@ var CodeMessage = map[int]string{
 	CodeInternalError:   "internal server error",
 	CodeValidateError:   "validate error",
 
-	CodeUserNotFound:       "user not found",
-	CodeUserAlreadyExists:  "user already exists",
-	CodeUserPasswordError:  "password error",
-	CodeUserDisabled:       "user disabled",
-	CodeUserTokenInvalid:   "token invalid",
-	CodeUserTokenExpired:   "token expired",
-	CodeUserPermissionDeny: "permission denied",
+	CodeUserNotFound:         "user not found",
+	CodeUserAlreadyExists:    "user already exists",
+	CodeUserPasswordError:    "password error",
+	CodeUserDisabled:         "user disabled",
+	CodeUserTokenInvalid:     "token invalid",
+	CodeUserTokenExpired:     "token expired",
+	CodeUserPermissionDeny:   "permission denied",
+	CodeUserMFAInvalid:       "invalid mfa code",
+	CodeUserMFARequired:      "mfa required",
+	CodeUserEmailNotVerified: "email not verified",
+	CodeUserLoginLocked:      "too many failed login attempts",
 
 	CodeDatabaseError:  "database error",
 	CodeRecordNotFound: "record not found",
@@ -22,6 +22,13 @@ type PageData struct {
 	PageSize int         `json:"page_size"` // 每页数量
 }
 
+// FieldError 字段级校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 请求字段
+	Code    string `json:"code"`    // 错误码，如 password_too_short
+	Message string `json:"message"` // 错误说明
+}
+
 // Success 成功响应
 func Success(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, Response{
@@ -120,12 +127,16 @@ func PageSuccess(c *gin.Context, list interface{}, total int64, page, pageSize i
 	})
 }
 
-// ValidateError 参数验证错误
-func ValidateError(c *gin.Context, message string) {
-	c.JSON(http.StatusBadRequest, Response{
+// ValidateError 参数验证错误，有字段级错误时放在 data 中
+func ValidateError(c *gin.Context, message string, fields ...FieldError) {
+	resp := Response{
 		Code:    CodeValidateError,
 		Message: message,
-	})
+	}
+	if len(fields) > 0 {
+		resp.Data = fields
+	}
+	c.JSON(http.StatusBadRequest, resp)
 }
 
 // BusinessError 业务错误
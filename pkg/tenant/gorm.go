@@ -0,0 +1,103 @@
+package tenant
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// fieldName 租户字段名，模型包含该字段时按租户过滤
+const fieldName = "TenantID"
+
+// skipKey 通过 SkipScope 关闭单次操作的租户过滤
+const skipKey = "tenant:skip"
+
+// Plugin GORM 租户隔离插件，通过 db.Use(tenant.Plugin{}) 注册
+type Plugin struct{}
+
+// Name 插件名称
+func (Plugin) Name() string {
+	return "tenant"
+}
+
+// Initialize 注册查询、更新、删除和创建回调
+func (Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", addCondition); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:row", addCondition); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", addCondition); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:delete", addCondition); err != nil {
+		return err
+	}
+	return callbacks.Create().Before("gorm:create").Register("tenant:create", setTenant)
+}
+
+// SkipScope 关闭本次操作的租户过滤，用于必须跨租户的查询（如全局唯一的用户名、角色变更后失效所有租户的缓存）
+//
+//	db.WithContext(ctx).Scopes(tenant.SkipScope).Where(...).Find(&users)
+func SkipScope(db *gorm.DB) *gorm.DB {
+	return db.Set(skipKey, true)
+}
+
+// tenantField 返回需要按租户处理的字段和当前租户
+func tenantField(db *gorm.DB) (*schema.Field, uint, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, 0, false
+	}
+	if skip, ok := db.Get(skipKey); ok && skip == true {
+		return nil, 0, false
+	}
+	field := db.Statement.Schema.LookUpField(fieldName)
+	if field == nil {
+		return nil, 0, false
+	}
+	id, ok := FromContext(db.Statement.Context)
+	if !ok {
+		return nil, 0, false
+	}
+	return field, id, true
+}
+
+func addCondition(db *gorm.DB) {
+	field, id, ok := tenantField(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: db.Statement.Table, Name: field.DBName}, Value: id},
+	}})
+}
+
+func setTenant(db *gorm.DB) {
+	field, id, ok := tenantField(db)
+	if !ok {
+		return
+	}
+
+	ctx := db.Statement.Context
+	setOne := func(value reflect.Value) {
+		if _, zero := field.ValueOf(ctx, value); zero {
+			if err := field.Set(ctx, value, id); err != nil {
+				_ = db.AddError(err)
+			}
+		}
+	}
+
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			setOne(reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		setOne(value)
+	}
+}
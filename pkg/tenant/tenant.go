@@ -0,0 +1,41 @@
+// Package tenant 多租户数据隔离：在 context 中记录当前租户，GORM 插件按租户自动过滤数据
+//
+// 模型包含 TenantID 字段时，查询、更新和删除自动加上 tenant_id = 当前租户的条件，
+// 创建时 TenantID 为零值则填入当前租户。context 中没有租户（如登录、后台任务）或
+// 标记为跨租户（平台管理员）时不过滤。Raw SQL 和 Joins 关联的表不会被自动过滤。
+package tenant
+
+import "context"
+
+type contextKey struct{}
+
+// scope 当前请求的租户范围
+type scope struct {
+	id  uint
+	all bool
+}
+
+// WithID 限定 context 只能访问指定租户的数据
+func WithID(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope{id: id})
+}
+
+// WithAllTenants 标记 context 可以访问所有租户的数据，用于平台管理员和跨租户的内部操作
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope{all: true})
+}
+
+// FromContext 返回 context 限定的租户，没有限定或可以访问所有租户时返回 false
+func FromContext(ctx context.Context) (uint, bool) {
+	s, ok := ctx.Value(contextKey{}).(scope)
+	if !ok || s.all {
+		return 0, false
+	}
+	return s.id, true
+}
+
+// IsAllTenants context 是否被标记为可以访问所有租户
+func IsAllTenants(ctx context.Context) bool {
+	s, ok := ctx.Value(contextKey{}).(scope)
+	return ok && s.all
+}
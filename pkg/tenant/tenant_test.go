@@ -0,0 +1,102 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type account struct {
+	ID       uint
+	TenantID uint
+	Name     string
+}
+
+type role struct {
+	ID   uint
+	Name string
+}
+
+func newDryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(Plugin{}))
+	return db
+}
+
+func TestFromContext(t *testing.T) {
+	ctx := context.Background()
+	_, ok := FromContext(ctx)
+	assert.False(t, ok)
+
+	id, ok := FromContext(WithID(ctx, 3))
+	assert.True(t, ok)
+	assert.Equal(t, uint(3), id)
+
+	all := WithAllTenants(WithID(ctx, 3))
+	_, ok = FromContext(all)
+	assert.False(t, ok)
+	assert.True(t, IsAllTenants(all))
+	assert.False(t, IsAllTenants(ctx))
+}
+
+func TestPlugin_Query(t *testing.T) {
+	db := newDryRunDB(t)
+	ctx := WithID(context.Background(), 3)
+
+	var accounts []account
+	stmt := db.WithContext(ctx).Where("name = ?", "a").Find(&accounts).Statement
+	assert.Equal(t, "SELECT * FROM `accounts` WHERE name = ? AND `accounts`.`tenant_id` = ?", stmt.SQL.String())
+	assert.Equal(t, []interface{}{"a", uint(3)}, stmt.Vars)
+
+	// 没有租户字段的模型、没有限定租户和跨租户时不过滤
+	var roles []role
+	stmt = db.WithContext(ctx).Find(&roles).Statement
+	assert.Equal(t, "SELECT * FROM `roles`", stmt.SQL.String())
+
+	stmt = db.WithContext(context.Background()).Find(&accounts).Statement
+	assert.Equal(t, "SELECT * FROM `accounts`", stmt.SQL.String())
+
+	stmt = db.WithContext(WithAllTenants(ctx)).Find(&accounts).Statement
+	assert.Equal(t, "SELECT * FROM `accounts`", stmt.SQL.String())
+
+	stmt = db.WithContext(ctx).Scopes(SkipScope).Find(&accounts).Statement
+	assert.Equal(t, "SELECT * FROM `accounts`", stmt.SQL.String())
+}
+
+func TestPlugin_UpdateDelete(t *testing.T) {
+	db := newDryRunDB(t)
+	ctx := WithID(context.Background(), 3)
+
+	stmt := db.WithContext(ctx).Model(&account{}).Where("id = ?", 1).Update("name", "b").Statement
+	assert.Equal(t, "UPDATE `accounts` SET `name`=? WHERE id = ? AND `accounts`.`tenant_id` = ?", stmt.SQL.String())
+
+	stmt = db.WithContext(ctx).Delete(&account{}, 1).Statement
+	assert.Equal(t, "DELETE FROM `accounts` WHERE `accounts`.`id` = ? AND `accounts`.`tenant_id` = ?", stmt.SQL.String())
+}
+
+func TestPlugin_Create(t *testing.T) {
+	db := newDryRunDB(t)
+	ctx := WithID(context.Background(), 3)
+
+	a := &account{Name: "a"}
+	db.WithContext(ctx).Create(a)
+	assert.Equal(t, uint(3), a.TenantID)
+
+	// 已指定租户时保留
+	b := &account{Name: "b", TenantID: 5}
+	db.WithContext(ctx).Create(b)
+	assert.Equal(t, uint(5), b.TenantID)
+
+	batch := []*account{{Name: "c"}, {Name: "d"}}
+	db.WithContext(ctx).Create(&batch)
+	assert.Equal(t, uint(3), batch[0].TenantID)
+	assert.Equal(t, uint(3), batch[1].TenantID)
+}